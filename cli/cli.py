@@ -106,7 +106,7 @@ async def async_main(client: naz.Client, logger: naz.log.SimpleLogger, dry_run:
     # 6. add signal termination handlers
     tasks = asyncio.gather(
         client.connect(),
-        client.tranceiver_bind(),
+        client.bind(),
         client.dequeue_messages(TESTING=dry_run),
         client.receive_data(TESTING=dry_run),
         client.enquire_link(TESTING=dry_run),
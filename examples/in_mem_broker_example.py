@@ -46,7 +46,7 @@ try:
     # 5. continually check the state of the SMSC
     tasks = asyncio.gather(
         cli.connect(),
-        cli.tranceiver_bind(),
+        cli.bind(),
         cli.dequeue_messages(),
         cli.receive_data(),
         cli.enquire_link(),
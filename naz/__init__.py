@@ -1,23 +1,48 @@
 from .client import Client  # noqa: F401
+from .pool import Pool  # noqa: F401
 
 from . import log  # noqa: F401
 from . import broker  # noqa: F401
 from . import codec  # noqa: F401
 from . import protocol  # noqa: F401
 from . import throttle  # noqa: F401
+from . import retry  # noqa: F401
 from . import sequence  # noqa: F401
 from . import correlater  # noqa: F401
 from . import ratelimiter  # noqa: F401
+from . import metrics  # noqa: F401
+from . import pdu  # noqa: F401
+from . import dialer  # noqa: F401
+from . import events  # noqa: F401
+from . import testing  # noqa: F401
 
 
 from .state import (  # noqa: F401
+    TLV,
     DataCoding,
+    BindMode,
+    AddressKind,
+    UnexpectedResponse,
+    InterfaceVersion,
+    ConcatMode,
+    RegisteredDelivery,
     OptionalTag,
     SmppCommand,
     CommandStatus,
     SmppDataCoding,
+    QueryResult,
+    BroadcastQueryResult,
+    SubmitResult,
+    UnsuccessSME,
+    SubmitMultiResult,
+    Stats,
+    DeliveryReceipt,
+    MessageState,
+    SmppMessageState,
     SmppSessionState,
     SmppCommandStatus,
+    status_name,
+    is_temporary,
 )
 
 from . import __version__  # noqa: F401
@@ -1,5 +1,10 @@
+import os
 import abc
+import json
+import zlib
+import typing
 import asyncio
+import collections
 
 from . import protocol
 
@@ -40,27 +45,318 @@ class BaseBroker(abc.ABC):
         """
         raise NotImplementedError("dequeue method must be implemented.")
 
+    def size(self) -> typing.Union[None, int]:
+        """
+        returns the number of items currently in the queue, or None if the broker cannot tell.
+        naz uses it, while shutting down, to wait for the queued messages to be sent.
+        It is optional to implement this method.
+        """
+        return None
+
+    async def acknowledge(self, log_id: str) -> None:
+        """
+        called by naz once SMSC has responded to a message that was dequeued from this broker, whatever the command_status of the response.
+        For a message that was split into many parts, it is called once SMSC has responded to all of them.
+        A message that SMSC does not respond to, eg a `deliver_sm_resp`, is acknowledged once it has been sent.
+        A broker that persists messages can use it to know which of them no longer need to be replayed; eg after a crash.
+        It is optional to implement this method.
+
+        Parameters:
+            log_id: the log_id of the message that SMSC responded to.
+        """
+        return None
+
 
 class SimpleBroker(BaseBroker):
     """
     This is an in-memory implementation of BaseBroker.
 
     Note: It should only be used for tests and demo purposes.
+
+    When the queue is full, :func:`enqueue <SimpleBroker.enqueue>` either waits until there is space in the queue
+    or raises `asyncio.QueueFull`, depending on the value of `block_on_full`.
     """
 
-    def __init__(self, maxsize: int = 2500) -> None:
+    def __init__(self, maxsize: int = 2500, block_on_full: bool = False) -> None:
         """
         Parameters:
             maxsize: the maximum number of items(not size) that can be put in the queue.
+            block_on_full: if True, enqueueing into a full queue waits until an item is dequeued.
+                           if False, enqueueing into a full queue raises `asyncio.QueueFull`
         """
         if not isinstance(maxsize, int):
             raise ValueError(
                 "`maxsize` should be of type:: `int` You entered: {0}".format(type(maxsize))
             )
+        if not isinstance(block_on_full, bool):
+            raise ValueError(
+                "`block_on_full` should be of type:: `bool` You entered: {0}".format(
+                    type(block_on_full)
+                )
+            )
         self.queue: asyncio.queues.Queue = asyncio.Queue(maxsize=maxsize)
+        self.block_on_full: bool = block_on_full
+
+    def size(self) -> int:
+        """
+        returns the number of items currently in the queue. It can be used for monitoring.
+        """
+        return self.queue.qsize()
 
     async def enqueue(self, message: protocol.Message) -> None:
-        self.queue.put_nowait(message)
+        if self.block_on_full:
+            # this can be cancelled by the caller, eg using `asyncio.wait_for`
+            await self.queue.put(message)
+        else:
+            self.queue.put_nowait(message)
 
     async def dequeue(self) -> protocol.Message:
         return await self.queue.get()
+
+
+class RedisBroker(BaseBroker):
+    """
+    An implementation of BaseBroker that uses a redis list as a FIFO queue.
+    Since the messages are stored in redis, queued-but-unsent messages survive restarts of your application
+    and the queue can be shared by many naz clients.
+
+    Messages are serialized using `naz.protocol.Message.to_json()`, which includes the log_id, hook_metadata and encoding of the message.
+    The redis command LPUSH is used to push messages onto the queue and BRPOP is used to pull them off.
+
+    naz does not depend on any redis library. You should pass in a non-blocking redis client, eg `aioredis <https://github.com/aio-libs/aioredis>`_,
+    that has the coroutines `lpush(key, value)` and `brpop(key, timeout=seconds)`
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+        import aioredis
+
+        redis = await aioredis.create_redis_pool(address=("localhost", 6379))
+        broker = naz.broker.RedisBroker(redis=redis, queue_name="myqueue")
+        client = naz.Client(..., broker=broker)
+    """
+
+    def __init__(self, redis: typing.Any, queue_name: str = "naz:broker", timeout: int = 8) -> None:
+        """
+        Parameters:
+            redis: a non-blocking redis client.
+            queue_name: the name of the redis list that is used as the queue.
+            timeout: the time in seconds that a dequeue blocks waiting for an item before trying again.
+        """
+        if not isinstance(queue_name, str):
+            raise ValueError(
+                "`queue_name` should be of type:: `str` You entered: {0}".format(type(queue_name))
+            )
+        if not isinstance(timeout, int):
+            raise ValueError(
+                "`timeout` should be of type:: `int` You entered: {0}".format(type(timeout))
+            )
+        self.redis = redis
+        self.queue_name: str = queue_name
+        self.timeout: int = timeout
+
+    async def enqueue(self, message: protocol.Message) -> None:
+        await self.redis.lpush(self.queue_name, message.to_json())
+
+    async def dequeue(self) -> protocol.Message:
+        while True:
+            item = await self.redis.brpop(self.queue_name, timeout=self.timeout)
+            if item:
+                dequeued_item = item[1]
+                if isinstance(dequeued_item, bytes):
+                    dequeued_item = dequeued_item.decode()
+                return protocol.json_to_Message(dequeued_item)
+
+
+class FileBroker(BaseBroker):
+    """
+    An implementation of BaseBroker that persists the queue in a segment file on local disk.
+    Since the messages are stored on disk, queued-but-unsent messages survive a crash of your application, without needing redis.
+
+    Each enqueued message, serialized using `naz.protocol.Message.to_json()`, is appended to the segment file.
+    Once SMSC responds to it(see :func:`acknowledge <BaseBroker.acknowledge>`), an acknowledgement is appended too.
+    When the broker is opened, the messages that were not acknowledged, including those that had been dequeued, are replayed in the order they were enqueued.
+    So a message may be sent twice if the application crashed after sending it but before SMSC responded.
+
+    Every record in the file has a checksum. A record that is only partially written(eg because of a crash in the middle of a write) or
+    that is corrupt, and everything after it, is discarded when the file is opened.
+    Once the file grows beyond `max_segment_size`, it is compacted so that it only holds the messages that have not been acknowledged.
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        broker = naz.broker.FileBroker(path="/var/spool/naz/queue.log")
+        client = naz.Client(..., broker=broker)
+    """
+
+    def __init__(
+        self, path: str, fsync: bool = True, max_segment_size: int = 16 * 1024 * 1024
+    ) -> None:
+        """
+        Parameters:
+            path: the path of the segment file. It is created if it does not exist.
+            fsync: if True, every record is flushed to disk, using `os.fsync`, before enqueue returns. \
+                It is slower, but a message is not lost even if the machine itself crashes. The `os.fsync` runs in the event loop's default executor.
+            max_segment_size: the size, in bytes, beyond which the segment file is compacted.
+        """
+        if not isinstance(path, str):
+            raise ValueError(
+                "`path` should be of type:: `str` You entered: {0}".format(type(path))
+            )
+        if not isinstance(fsync, bool):
+            raise ValueError(
+                "`fsync` should be of type:: `bool` You entered: {0}".format(type(fsync))
+            )
+        if not isinstance(max_segment_size, int):
+            raise ValueError(
+                "`max_segment_size` should be of type:: `int` You entered: {0}".format(
+                    type(max_segment_size)
+                )
+            )
+        if max_segment_size <= 0:
+            raise ValueError(
+                "`max_segment_size` should be greater than zero. You entered: {0}".format(
+                    max_segment_size
+                )
+            )
+        self.path = path
+        self.fsync = fsync
+        self.max_segment_size = max_segment_size
+
+        # the messages that have not been acknowledged, keyed by the id of their record, in the order they were enqueued.
+        self._unacknowledged: typing.Dict[int, str] = collections.OrderedDict()
+        # the ids of the dequeued messages that have not been acknowledged, keyed by log_id.
+        self._dequeued: typing.Dict[str, typing.Deque[int]] = {}
+        self._queue: asyncio.queues.Queue = asyncio.Queue()
+        self._next_id = 0
+        # so that the segment file is not compacted, and closed, while a record is being fsync'ed.
+        self._lock = asyncio.Lock()
+
+        self._recover()
+        for record_id in self._unacknowledged:
+            self._queue.put_nowait(record_id)
+        self._file = open(self.path, "ab")
+
+    def _recover(self) -> None:
+        """
+        reads the segment file and discards its trailing records that are partially written or corrupt.
+        """
+        if not os.path.exists(self.path):
+            return
+        with open(self.path, "rb") as f:
+            data = f.read()
+
+        offset = 0
+        while True:
+            end = data.find(b"\n", offset)
+            if end == -1:
+                # a record that was only partially written, if anything.
+                break
+            record = self._parse_record(data[offset:end])
+            if record is None:
+                break
+            if record["op"] == "enqueue":
+                self._unacknowledged[record["id"]] = record["message"]
+            else:
+                self._unacknowledged.pop(record["id"], None)
+            self._next_id = max(self._next_id, record["id"] + 1)
+            offset = end + 1
+
+        if offset != len(data):
+            with open(self.path, "r+b") as f:
+                f.truncate(offset)
+
+    @staticmethod
+    def _parse_record(line: bytes) -> typing.Union[None, typing.Dict[str, typing.Any]]:
+        """
+        returns the record in a line of the segment file, or None if the line is corrupt.
+        """
+        checksum, _, payload = line.partition(b" ")
+        try:
+            if int(checksum, 16) != zlib.crc32(payload):
+                return None
+            record = json.loads(payload.decode("utf8"))
+            if record["op"] not in ["enqueue", "acknowledge"]:
+                return None
+            if not isinstance(record["id"], int):
+                return None
+            return record
+        except (ValueError, KeyError, TypeError):
+            # UnicodeDecodeError & json.JSONDecodeError are ValueError's
+            return None
+
+    @staticmethod
+    def _record(record: typing.Dict[str, typing.Any]) -> bytes:
+        payload = json.dumps(record).encode("utf8")
+        return "{0:08x} ".format(zlib.crc32(payload)).encode("ascii") + payload + b"\n"
+
+    async def _append(self, record: typing.Dict[str, typing.Any]) -> None:
+        async with self._lock:
+            self._file.write(self._record(record))
+            self._file.flush()
+            if self.fsync:
+                # fsync blocks until the disk has the record; do not block the event loop meanwhile.
+                await asyncio.get_event_loop().run_in_executor(
+                    None, os.fsync, self._file.fileno()
+                )
+
+    def _compact(self) -> None:
+        """
+        rewrites the segment file so that it only has the messages that have not been acknowledged.
+        """
+        self._file.close()
+        compacted_path = self.path + ".compact"
+        with open(compacted_path, "wb") as f:
+            for record_id, message in self._unacknowledged.items():
+                f.write(self._record({"op": "enqueue", "id": record_id, "message": message}))
+            f.flush()
+            os.fsync(f.fileno())
+        os.replace(compacted_path, self.path)
+        self._file = open(self.path, "ab")
+
+    def size(self) -> int:
+        """
+        returns the number of messages that are waiting to be dequeued.
+        """
+        return self._queue.qsize()
+
+    async def enqueue(self, message: protocol.Message) -> None:
+        record_id = self._next_id
+        self._next_id += 1
+        serialized = message.to_json()
+        await self._append({"op": "enqueue", "id": record_id, "message": serialized})
+        self._unacknowledged[record_id] = serialized
+        self._queue.put_nowait(record_id)
+
+    async def dequeue(self) -> protocol.Message:
+        record_id = await self._queue.get()
+        message = protocol.json_to_Message(self._unacknowledged[record_id])
+        self._dequeued.setdefault(message.log_id, collections.deque()).append(record_id)
+        return message
+
+    async def acknowledge(self, log_id: str) -> None:
+        record_ids = self._dequeued.get(log_id)
+        if not record_ids:
+            # eg; a message that was not sent through this broker.
+            return
+        record_id = record_ids.popleft()
+        if not record_ids:
+            self._dequeued.pop(log_id)
+        await self._append({"op": "acknowledge", "id": record_id})
+        self._unacknowledged.pop(record_id, None)
+        async with self._lock:
+            if self._file.tell() > self.max_segment_size:
+                self._compact()
+
+    def close(self) -> None:
+        """
+        closes the segment file.
+        """
+        self._file.close()
@@ -1,11 +1,16 @@
 import os
+import copy
 import struct
 import codecs
+import ssl
+import time
 import random
 import socket
 import string
 import typing
+import datetime
 import asyncio
+import collections
 import logging
 
 
@@ -15,16 +20,32 @@ from . import hooks
 from . import protocol
 from . import sequence
 from . import throttle
+from . import retry
 from . import correlater
 from . import ratelimiter
 from . import codec as the_codec
 from . import broker as the_broker
+from . import metrics as the_metrics
+from . import pdu as the_pdu
+from . import dialer as the_dialer
+from . import events as the_events
 
 
 from .state import (
+    BindMode,
+    ConcatMode,
+    UnexpectedResponse,
     OptionalTag,
+    RegisteredDelivery,
+    TLV,
+    QueryResult,
+    BroadcastQueryResult,
+    SubmitMultiResult,
+    SubmitResult,
+    Stats,
     SmppCommand,
     CommandStatus,
+    SmppMessageState,
     SmppDataCoding,
     SmppSessionState,
     SmppCommandStatus,
@@ -32,6 +53,23 @@ from .state import (
 
 # pytype: disable=pyi-error
 
+# the errors that a bind may succeed after, if it is retried. see: `Client.bind_retries`
+_RECOVERABLE_BIND_STATUSES = [
+    SmppCommandStatus.ESME_RALYBND.value,
+    SmppCommandStatus.ESME_RBINDFAIL.value,
+    SmppCommandStatus.ESME_RTHROTTLED.value,
+]
+
+# the optional parameters of the SMPP v5.0 broadcast operations. see section 4.8.4 of smpp ver 5.0 spec document
+_BROADCAST_TAGS = dict(
+    broadcast_content_type=0x0601,
+    broadcast_rep_num=0x0604,
+    broadcast_frequency_interval=0x0605,
+    broadcast_area_identifier=0x0606,
+    broadcast_area_success=0x0608,
+    broadcast_end_time=0x0609,
+)
+
 
 class Client:
     """
@@ -62,7 +100,7 @@ class Client:
         # 5. continually check the state of the SMSC
         tasks = asyncio.gather(
             client.connect(),
-            client.tranceiver_bind(),
+            client.bind(),
             client.dequeue_messages(),
             client.receive_data(),
             client.enquire_link(),
@@ -100,7 +138,51 @@ class Client:
         correlation_handler: typing.Union[None, correlater.BaseCorrelater] = None,
         drain_duration: float = 8.00,
         socket_timeout: float = 30.0,
-        custom_codecs: typing.Union[None, typing.Dict[str, codecs.CodecInfo]] = None
+        custom_codecs: typing.Union[None, typing.Dict[str, codecs.CodecInfo]] = None,
+        split_long_messages: bool = False,
+        concat_mode: str = ConcatMode.UDH,
+        bind_mode: str = BindMode.TRANSCEIVER,
+        auto_reconnect: bool = False,
+        reconnect_initial_interval: float = 1.00,
+        reconnect_max_interval: float = 60.00,
+        reconnect_jitter: float = 0.10,
+        reconnect_fail_fast: bool = False,
+        enquire_link_response_timeout: typing.Union[None, float] = None,
+        ssl_context: typing.Union[None, ssl.SSLContext] = None,
+        metrics: typing.Union[None, the_metrics.BaseMetrics] = None,
+        window_size: typing.Union[None, int] = None,
+        window_timeout: float = 30.00,
+        registered_delivery: int = RegisteredDelivery.RECEIPT_ON_SUCCESS_OR_FAILURE,
+        max_pdu_length: int = 1024 * 1024,
+        source_addr_ton: int = 0x00000001,
+        source_addr_npi: int = 0x00000001,
+        dest_addr_ton: int = 0x00000001,
+        dest_addr_npi: int = 0x00000001,
+        redact_system_id: bool = False,
+        on_write: typing.Union[None, typing.Callable[[bytes], None]] = None,
+        on_read: typing.Union[None, typing.Callable[[bytes], None]] = None,
+        on_outbind: typing.Union[None, typing.Callable[[str, str], bool]] = None,
+        read_timeout: typing.Union[None, float] = None,
+        write_timeout: typing.Union[None, float] = None,
+        use_message_payload: bool = False,
+        on_enquire_link_latency: typing.Union[None, typing.Callable[[float], None]] = None,
+        dialer: typing.Union[None, the_dialer.BaseDialer] = None,
+        response_timeout: typing.Union[None, float] = None,
+        priority_flag: int = 0,
+        bind_timeout: typing.Union[None, float] = None,
+        reassembly_timeout: typing.Union[None, float] = None,
+        retry_policy: typing.Union[None, retry.BaseRetryPolicy] = None,
+        smsc_endpoints: typing.Union[None, typing.List[str]] = None,
+        validity_period: typing.Union[str, datetime.timedelta] = "",
+        bind_retries: int = 0,
+        message_payload_fallback: bool = True,
+        e164_addresses: typing.Union[None, typing.List[str]] = None,
+        log_pdu_contents: typing.Union[None, int] = None,
+        message_payload_threshold: int = 254,
+        dedup_window: typing.Union[None, float] = None,
+        byte_rate_limiter: typing.Union[None, ratelimiter.ByteRateLimiter] = None,
+        unexpected_responses: str = UnexpectedResponse.IGNORE,
+        window_maintenance_interval: typing.Union[None, float] = None,
         ### NON-SMPP ATTRIBUTES ###
     ) -> None:
         """
@@ -112,12 +194,18 @@ class Client:
             broker:	python class instance implementing some queueing mechanism. \
                 messages to be sent to SMSC are queued using the said mechanism before been sent
             client_id:	a unique string identifying a naz client class instance
-            system_type:	Identifies the type of ESME system requesting to bind with the SMSC.
+            system_type:	Identifies the type of ESME system requesting to bind with the SMSC; eg `VMS` or `SMPP`. Some SMSCs route traffic based on it. \
+                It is sent in the bind request and it is at most 12 ascii characters.
             addr_ton:	Type of Number of the ESME address.
             addr_npi:	Numbering Plan Indicator (NPI) for ESME address(es) served via this SMPP transceiver session
-            address_range:	A single ESME address or a range of ESME addresses served via this SMPP transceiver session.
-            interface_version:	Indicates the version of the SMPP protocol supported by the ESME.
-            enquire_link_interval:	time in seconds to wait before sending an enquire_link request to SMSC to check on its status
+            address_range:	A single ESME address or a range of ESME addresses served via this SMPP transceiver session. \
+                On a receiver or transceiver bind, SMSC only delivers the messages whose destination matches it; eg a short code like `40404`, or a regular expression like `^4040[0-9]` if SMSC supports that. \
+                It is at most 40 ascii characters.
+            interface_version:	Indicates the version of the SMPP protocol supported by the ESME. eg; :attr:`naz.InterfaceVersion.V50 <naz.state.InterfaceVersion.V50>` to bind as SMPP v5.0 \
+                The version supported by SMSC is available from :func:`smsc_interface_version <Client.smsc_interface_version>` once bound.
+            enquire_link_interval:	time in seconds to wait before sending an enquire_link request to SMSC to check on its status. \
+                If it is 0, naz does not send enquire_link's; it only responds to those of SMSC, eg on a receiver bind. \
+                In that case, if :attr:`read_timeout <Client.read_timeout>` is set, a connection that SMSC sends nothing over for that long is considered dead.
             logger: python `logger <https://docs.python.org/3/library/logging.html#logging.Logger>`_ instance to be used for logging
             rate_limiter: python class instance implementing rate limitation
             hook: python class instance implemeting functionality/hooks to be called by naz \
@@ -126,9 +214,112 @@ class Client:
             throttle_handler: python class instance implementing functionality of what todo when naz starts getting throttled responses from SMSC
             correlation_handler: A python class instance that naz uses to store relations between \
                 SMPP sequence numbers and user applications' log_id's and/or hook_metadata.
-            drain_duration: duration in seconds that `naz` will wait for after receiving a termination signal.
+            drain_duration: duration in seconds that `naz` will wait for, after receiving a termination signal, \
+                for the queued messages to be sent, for SMSC to respond to the requests that are awaiting a response and for SMSC to respond to the unbind request.
             socket_timeout: duration that `naz` will wait, for socket/connection related activities with SMSC, before timing out
             custom_codecs: a dictionary of encodings and their corresponding `codecs.CodecInfo <https://docs.python.org/3/library/codecs.html#codecs.CodecInfo>`_ that you would like to register.
+            split_long_messages: if True, `naz` will split messages that do not fit in one SMS into multiple `submit_sm` PDUs, \
+                each carrying a concatenation User Data Header(UDH). Leave it off if you do your own segmentation.
+            concat_mode: how the parts of a split message are linked together. \
+                One of :class:`naz.ConcatMode <naz.state.ConcatMode>`; either a UDH in the short_message, with an 8-bit or a 16-bit reference number, or the SAR optional parameters.
+            bind_mode: how `naz` binds to SMSC. One of :class:`naz.BindMode <naz.state.BindMode>`. \
+                A transmitter cannot receive mobile originated messages and a receiver cannot send messages.
+            auto_reconnect: if True, when the connection to SMSC is lost `naz` keeps trying to re-connect and re-bind, \
+                with an exponential backoff, until it succeeds.
+            reconnect_initial_interval: duration in seconds to wait after the first failed re-connection attempt. \
+                The wait is doubled after each failed attempt.
+            reconnect_max_interval: the maximum duration in seconds to wait between re-connection attempts.
+            reconnect_jitter: a fraction(between 0 and 1) of each wait that is randomly added or removed, \
+                so that many clients do not all re-connect at the same time.
+            reconnect_fail_fast: if True, sending a message while `naz` is re-connecting raises :class:`NazConnectionError <NazConnectionError>`. \
+                Otherwise, the send waits until `naz` has re-connected.
+            enquire_link_response_timeout: duration in seconds that `naz` will wait for SMSC to respond to an enquire_link request. \
+                If SMSC does not respond within that time, the connection is considered dead and `naz` re-connects. \
+                If it is None, `naz` does not wait for the responses.
+            ssl_context: if provided, `naz` connects to SMSC over TLS(SMPPS) using this `ssl.SSLContext <https://docs.python.org/3/library/ssl.html#ssl.SSLContext>`_ \
+                and performs the TLS handshake before binding. A context created using `ssl.create_default_context()` verifies the SMSC's certificate and hostname. \
+                For test servers with self-signed certificates, you can set `check_hostname = False` and `verify_mode = ssl.CERT_NONE` on the context.
+            metrics: python class instance that `naz` uses to record metrics about its internals. eg :class:`naz.metrics.PrometheusMetrics <naz.metrics.PrometheusMetrics>` \
+                If it is None, no metrics are recorded.
+            window_size: the maximum number of requests(eg submit_sm) that can be awaiting a response from SMSC at any one time. \
+                When that number is reached, sending of new requests waits until SMSC responds to one of the outstanding requests. \
+                If it is None, the number of outstanding requests is not limited.
+            window_timeout: duration in seconds after which a request that SMSC has not responded to stops taking up space in the window.
+            registered_delivery: the default registered_delivery for messages that do not set their own. \
+                It is a combination of :class:`naz.RegisteredDelivery <naz.state.RegisteredDelivery>` values.
+            max_pdu_length: the maximum command_length, in bytes, of a PDU that naz will read from SMSC. It defaults to 1MB. \
+                A PDU that claims to be longer than that, or shorter than a PDU header, is treated as a protocol error and the connection to SMSC is closed. \
+                This prevents a misbehaving SMSC from making naz allocate a huge amount of memory.
+            source_addr_ton: the default Type of Number of the message originator, for messages that do not set their own.
+            source_addr_npi: the default Numbering Plan Identity of the message originator, for messages that do not set their own.
+            dest_addr_ton: the default Type of Number of the destination, for messages that do not set their own.
+            dest_addr_npi: the default Numbering Plan Identity of the destination, for messages that do not set their own.
+            redact_system_id: whether to also treat `system_id` as sensitive and mask it in logs. The `password` is always masked.
+            on_write: an optional function that is called with the raw bytes of each PDU just before they are written to SMSC, eg to hex-dump them.
+            on_read: an optional function that is called with the raw bytes of each PDU just after they are read from SMSC, eg to hex-dump them.
+            on_outbind: an optional function that is called with the system_id and password of an `outbind` from SMSC. \
+                naz responds with a `bind_receiver` only if it returns True; if it is not set, outbinds are ignored. See :func:`accept_outbind <Client.accept_outbind>`
+            read_timeout: duration in seconds that `naz` will wait for SMSC to send data while it is awaiting a response, or the rest of a partially received PDU. \
+                If SMSC stays silent for that long, the connection is considered stalled and `naz` re-connects. \
+                An idle connection, with no requests awaiting a response, is not timed out. If it is None, reads do not time out.
+            write_timeout: duration in seconds that `naz` will wait for a PDU to be written to the network connection. \
+                If the write does not complete within that time, the connection is considered stalled and `naz` re-connects. If it is None, writes do not time out.
+            use_message_payload: if True, `naz` sends the whole message in the `message_payload` optional parameter of `submit_sm`, with an empty short_message. \
+                Messages whose encoded short_message is longer than `message_payload_threshold` octets are always sent that way, unless `split_long_messages` is True or `message_payload_fallback` is False. \
+                It cannot be used together with `split_long_messages`.
+            on_enquire_link_latency: an optional function that is called with the round-trip time, in seconds, of each enquire_link that SMSC responds to. \
+                See :func:`enquire_link_latency <Client.enquire_link_latency>`
+            dialer: python class instance that is used to make the network connection to SMSC. \
+                It has to implement the interface in :class:`BaseDialer <naz.dialer.BaseDialer>`, eg to connect through a proxy, over WebSocket(:class:`WebSocketDialer <naz.dialer.WebSocketDialer>`) or to use a connection that has already been made. \
+                If it is None, :class:`SimpleDialer <naz.dialer.SimpleDialer>` is used.
+            response_timeout: duration in seconds that `naz` will wait for SMSC to respond to each request that awaits a response, eg :func:`submit_message <Client.submit_message>`. \
+                Each request is timed out on its own; a request that SMSC does not respond to fails with `asyncio.TimeoutError` and frees its slot in the window, without affecting other outstanding requests. \
+                If it is None, :attr:`socket_timeout <Client.socket_timeout>` is used.
+            priority_flag: the default priority_flag, from 0(lowest) to 3(highest), for `submit_sm` messages that do not set their own.
+            bind_timeout: duration in seconds that :func:`bind <Client.bind>` will wait for SMSC to respond to the bind request. \
+                If SMSC does not respond within that time, the connection is closed and :class:`NazBindTimeoutError <NazBindTimeoutError>` is raised. \
+                It is separate from :attr:`socket_timeout <Client.socket_timeout>`, which bounds making the connection. \
+                If it is None, `bind` does not wait for the response.
+            reassembly_timeout: if it is set, the parts of a concatenated mobile originated message are buffered until all of them have arrived, \
+                and the :func:`on_deliver_sm <Client.on_deliver_sm>` handler is then called once with the whole message. \
+                The parts can arrive in any order. If some parts have not arrived within this many seconds of the first one, \
+                the handler is called with the parts that did arrive and the message's `incomplete` attribute set to True. \
+                If it is None, the handler is called with each part on its own.
+            retry_policy: python class instance implementing :class:`naz.retry.BaseRetryPolicy <naz.retry.BaseRetryPolicy>`. \
+                It decides whether, and when, a `submit_sm` or `data_sm` that was dequeued from the broker and that SMSC failed is re-submitted; \
+                eg because SMSC was throttling or its queue was full. The re-submitted message keeps its log_id and its `attempt` is incremented. \
+                Of a message that was split into many parts, only the part that SMSC failed is re-submitted. \
+                If it is None, failed messages are not re-submitted.
+            smsc_endpoints: other SMSCs to fail over to, as `host:port` strings; eg `["smsc-secondary.example.com:2775"]`. \
+                naz connects to the first of `smsc_host:smsc_port` and these endpoints, in that order, that accepts the connection. \
+                It stays on that SMSC until the connection is lost or the bind is rejected, and then fails over to the next one, round-robin. \
+                see :attr:`active_endpoint <Client.active_endpoint>`
+            validity_period: the default validity_period for `submit_sm` messages that do not set their own; eg `datetime.timedelta(hours=24)` for messages to expire after a day. \
+                Either a string in the SMPP time format, or a `datetime.timedelta` that is relative to the SMSC's current time. see :func:`naz.protocol.smpp_time <naz.protocol.smpp_time>` \
+                If it is empty, the SMSC default is used.
+            bind_retries: the number of times that :func:`bind <Client.bind>` re-connects and binds again, with an exponential backoff(see :attr:`reconnect_initial_interval <Client.reconnect_initial_interval>`), \
+                if SMSC rejects the bind with a recoverable error; `ESME_RALYBND`, `ESME_RBINDFAIL` or `ESME_RTHROTTLED`. eg; because SMSC still considers a session of a restarted naz to be bound. \
+                The other errors, eg `ESME_RINVPASWD`, fail the bind straight away. It requires :attr:`bind_timeout <Client.bind_timeout>` to be set, since that is when naz waits for the response to the bind.
+            message_payload_fallback: if True, a message whose encoded short_message is longer than :attr:`message_payload_threshold <Client.message_payload_threshold>` octets, and that is not split(see :attr:`split_long_messages <Client.split_long_messages>`), is sent in the `message_payload` optional parameter. \
+                If False, it is sent inline; and a message that is longer than 254 octets fails with :class:`NazMessageTooLongError <NazMessageTooLongError>`. eg; for an SMSC that does not support `message_payload`.
+            e164_addresses: the addresses of the messages that are normalized to E.164 using :func:`naz.protocol.e164 <naz.protocol.e164>`; any of `source_addr` and `destination_addr`. \
+                eg; `+254700111222` and `00254700111222` are both sent as `254700111222`, with a TON of international(0x01) and an NPI of ISDN/E.164(0x01). \
+                A message whose address is not a valid number fails before it is sent. An empty `source_addr` is left as it is.
+            log_pdu_contents: the number of characters of the short_message(or body) of a PDU to include in a DEBUG log of the contents of the PDUs sent to, and received from, SMSC. \
+                The `source_addr` and `destination_addr` are masked except for their last 4 digits. If it is None, the contents are not logged. \
+                The log is only emitted if the :attr:`logger <Client.logger>` is enabled for the DEBUG level.
+            message_payload_threshold: the length, in octets, of the longest encoded short_message that is sent inline in the `short_message` field of `submit_sm`. \
+                A longer message that is not split(see :attr:`split_long_messages <Client.split_long_messages>`) is sent in the `message_payload` optional parameter, if :attr:`message_payload_fallback <Client.message_payload_fallback>` is True. \
+                It is at most 254; the longest short_message that SMPP allows.
+            dedup_window: if it is set, the duration in seconds within which a message whose `log_id` is the same as that of a message that was already sent, using :func:`send_message <Client.send_message>`, is rejected with :class:`NazDuplicateMessageError <NazDuplicateMessageError>` \
+                rather than sent twice. eg; when an upstream system retries the same message.
+            byte_rate_limiter: if it is set, it limits the rate, in bytes/second, at which `submit_sm`'s and `data_sm`'s are sent to SMSC; based on the size of each encoded PDU. \
+                It is used in addition to the `rate_limiter`. See :class:`naz.ratelimiter.ByteRateLimiter <naz.ratelimiter.ByteRateLimiter>`
+            unexpected_responses: how a `submit_sm_resp`(or `data_sm_resp`) whose sequence_number does not match any request that is awaiting a response, eg one that SMSC re-transmitted late, is handled. \
+                It is one of :class:`naz.UnexpectedResponse <naz.state.UnexpectedResponse>`; the response is always counted in :func:`stats <Client.stats>`, and it is not fatal.
+            window_maintenance_interval: the interval, in seconds, at which the requests that SMSC has not responded to within :attr:`window_timeout <Client.window_timeout>` are reclaimed from the window; \
+                even when no new requests are being sent. The callers awaiting the responses to those requests fail with `asyncio.TimeoutError`. \
+                If it is None, the requests are only reclaimed when a new request needs space in the window.
 
         Raises:
             NazClientError: raised if there's an error instantiating a naz Client.
@@ -155,6 +346,50 @@ class Client:
             drain_duration=drain_duration,
             socket_timeout=socket_timeout,
             custom_codecs=custom_codecs,
+            split_long_messages=split_long_messages,
+            concat_mode=concat_mode,
+            bind_mode=bind_mode,
+            auto_reconnect=auto_reconnect,
+            reconnect_initial_interval=reconnect_initial_interval,
+            reconnect_max_interval=reconnect_max_interval,
+            reconnect_jitter=reconnect_jitter,
+            reconnect_fail_fast=reconnect_fail_fast,
+            enquire_link_response_timeout=enquire_link_response_timeout,
+            ssl_context=ssl_context,
+            metrics=metrics,
+            window_size=window_size,
+            window_timeout=window_timeout,
+            registered_delivery=registered_delivery,
+            max_pdu_length=max_pdu_length,
+            source_addr_ton=source_addr_ton,
+            source_addr_npi=source_addr_npi,
+            dest_addr_ton=dest_addr_ton,
+            dest_addr_npi=dest_addr_npi,
+            redact_system_id=redact_system_id,
+            on_write=on_write,
+            on_read=on_read,
+            on_outbind=on_outbind,
+            read_timeout=read_timeout,
+            write_timeout=write_timeout,
+            use_message_payload=use_message_payload,
+            on_enquire_link_latency=on_enquire_link_latency,
+            dialer=dialer,
+            response_timeout=response_timeout,
+            priority_flag=priority_flag,
+            bind_timeout=bind_timeout,
+            reassembly_timeout=reassembly_timeout,
+            retry_policy=retry_policy,
+            smsc_endpoints=smsc_endpoints,
+            validity_period=validity_period,
+            bind_retries=bind_retries,
+            message_payload_fallback=message_payload_fallback,
+            e164_addresses=e164_addresses,
+            log_pdu_contents=log_pdu_contents,
+            message_payload_threshold=message_payload_threshold,
+            dedup_window=dedup_window,
+            byte_rate_limiter=byte_rate_limiter,
+            unexpected_responses=unexpected_responses,
+            window_maintenance_interval=window_maintenance_interval,
         )
 
         self._PID = os.getpid()
@@ -162,6 +397,40 @@ class Client:
         self.smsc_port = smsc_port
         self.system_id = system_id
         self.password = password
+        self.redact_system_id = redact_system_id
+        self.on_write = on_write
+        self.on_read = on_read
+        self.on_outbind = on_outbind
+        self.read_timeout = read_timeout
+        self.write_timeout = write_timeout
+        self.use_message_payload = use_message_payload
+        self.on_enquire_link_latency = on_enquire_link_latency
+        if dialer is not None:
+            self.dialer = dialer
+        else:
+            self.dialer = the_dialer.SimpleDialer()
+        self.response_timeout = response_timeout
+        self.priority_flag = priority_flag
+        self.bind_timeout = bind_timeout
+        self.reassembly_timeout = reassembly_timeout
+        self.retry_policy = retry_policy
+        self.smsc_endpoints = smsc_endpoints
+        self.validity_period = validity_period
+        self.bind_retries = bind_retries
+        self.message_payload_fallback = message_payload_fallback
+        self.e164_addresses = e164_addresses
+        self.log_pdu_contents = log_pdu_contents
+        self.message_payload_threshold = message_payload_threshold
+        self.dedup_window = dedup_window
+        self.byte_rate_limiter = byte_rate_limiter
+        self.unexpected_responses = unexpected_responses
+        self.window_maintenance_interval = window_maintenance_interval
+        # the SMSCs that naz connects to, in order, and the index of the one that it is connected to. see: `Client.connect`
+        self._endpoints: typing.List[typing.Tuple[str, int]] = [(smsc_host, smsc_port)] + [
+            self._parse_endpoint(endpoint) for endpoint in smsc_endpoints or []
+        ]
+        self._endpoint_index: int = 0
+        self._bound_endpoint: typing.Union[None, int] = None
         self.broker = broker
 
         if client_id is not None:
@@ -171,6 +440,9 @@ class Client:
 
         self.system_type = system_type
         self.interface_version = interface_version
+        # see: `Client.smsc_system_id` and `Client.smsc_interface_version`
+        self._smsc_system_id: str = ""
+        self._smsc_interface_version: typing.Union[None, int] = None
         self.addr_ton = addr_ton
         self.addr_npi = addr_npi
         self.address_range = address_range
@@ -189,7 +461,7 @@ class Client:
                 "naz.client",
                 log_metadata={
                     "smsc_host": self.smsc_host,
-                    "system_id": system_id,
+                    "system_id": self._redact(system_id),
                     "client_id": self.client_id,
                     "pid": self._PID,
                 },
@@ -197,11 +469,37 @@ class Client:
         self._sanity_check_logger()
 
         self.enquire_link_interval = enquire_link_interval
+        self.enquire_link_response_timeout = enquire_link_response_timeout
+        self.ssl_context = ssl_context
+        self.metrics = metrics
+        self.window_size = window_size
+        self.window_timeout = window_timeout
+        self.registered_delivery = registered_delivery
+        self.max_pdu_length = max_pdu_length
+        self.source_addr_ton = source_addr_ton
+        self.source_addr_npi = source_addr_npi
+        self.dest_addr_ton = dest_addr_ton
+        self.dest_addr_npi = dest_addr_npi
+        # sequence_number and send time of the requests that are awaiting a response from SMSC; whether or not `window_size` is set.
+        self._window: typing.Dict[int, float] = {}
+        self._window_freed: asyncio.Event = asyncio.Event()
+        # reclaims the slots of the window every `window_maintenance_interval` seconds; while there are requests in it.
+        self._window_maintenance: typing.Union[None, asyncio.Future] = None
+        # sequence_number and send time of the latest enquire_link; used to measure its latency.
+        self._latest_enquire_link: typing.Tuple[int, float] = (-1, 0.00)
+        # when the response to `_latest_enquire_link` was received.
+        self._latest_enquire_link_resp_at: float = 0.00
+        # the round-trip times of the most recent enquire_link's
+        self._enquire_link_latencies: typing.Deque[float] = collections.deque(maxlen=10)
+        # when the oldest request that SMSC is yet to respond to was sent; 0.00 if there is none. used by `read_timeout`
+        self._unanswered_since: float = 0.00
 
         # see section 5.1.2.1 of smpp ver 3.4 spec document
         self.command_ids = {
             SmppCommand.BIND_TRANSCEIVER: 0x00000009,
             SmppCommand.BIND_TRANSCEIVER_RESP: 0x80000009,
+            SmppCommand.BIND_TRANSMITTER: 0x00000002,
+            SmppCommand.BIND_RECEIVER: 0x00000001,
             SmppCommand.UNBIND: 0x00000006,
             SmppCommand.UNBIND_RESP: 0x80000006,
             SmppCommand.SUBMIT_SM: 0x00000004,
@@ -211,22 +509,29 @@ class Client:
             SmppCommand.ENQUIRE_LINK: 0x00000015,
             SmppCommand.ENQUIRE_LINK_RESP: 0x80000015,
             SmppCommand.GENERIC_NACK: 0x80000000,
-            # naz currently does not handle the following smpp commands.
-            # open a github issue if you use naz and require support of a command in this list
-            SmppCommand.BIND_RECEIVER_RESP: 0x80000001,
-            SmppCommand.BIND_TRANSMITTER_RESP: 0x80000002,
             SmppCommand.QUERY_SM: 0x00000003,
             SmppCommand.QUERY_SM_RESP: 0x80000003,
-            SmppCommand.REPLACE_SM: 0x00000007,
-            SmppCommand.REPLACE_SM_RESP: 0x80000007,
             SmppCommand.CANCEL_SM: 0x00000008,
             SmppCommand.CANCEL_SM_RESP: 0x80000008,
+            SmppCommand.REPLACE_SM: 0x00000007,
+            SmppCommand.REPLACE_SM_RESP: 0x80000007,
+            SmppCommand.DATA_SM: 0x00000103,
+            SmppCommand.DATA_SM_RESP: 0x80000103,
+            SmppCommand.BIND_RECEIVER_RESP: 0x80000001,
+            SmppCommand.BIND_TRANSMITTER_RESP: 0x80000002,
             SmppCommand.SUBMIT_MULTI: 0x00000021,
             SmppCommand.SUBMIT_MULTI_RESP: 0x80000021,
             SmppCommand.OUTBIND: 0x0000000B,
+            # see section 4.7.5 of smpp ver 5.0 spec document
+            SmppCommand.BROADCAST_SM: 0x00000111,
+            SmppCommand.BROADCAST_SM_RESP: 0x80000111,
+            SmppCommand.QUERY_BROADCAST_SM: 0x00000112,
+            SmppCommand.QUERY_BROADCAST_SM_RESP: 0x80000112,
+            SmppCommand.CANCEL_BROADCAST_SM: 0x00000113,
+            SmppCommand.CANCEL_BROADCAST_SM_RESP: 0x80000113,
+            # naz currently does not handle the following smpp commands.
+            # open a github issue if you use naz and require support of a command in this list
             SmppCommand.ALERT_NOTIFICATION: 0x00000102,
-            SmppCommand.DATA_SM: 0x00000103,
-            SmppCommand.DATA_SM_RESP: 0x80000103,
             SmppCommand.RESERVED_A: 0x0000000A,
             SmppCommand.RESERVED_B: 0x8000000A,
             SmppCommand.RESERVED_C: 0x00000100,
@@ -276,16 +581,107 @@ class Client:
 
         self.naz_message_protocol_version = protocol.NAZ_MESSAGE_PROTOCOL_VERSION
 
-        self.current_session_state = SmppSessionState.CLOSED
+        # see: `Client.stats`
+        self._submitted: int = 0
+        self._delivered: int = 0
+        self._failed: typing.Dict[str, int] = {}
+        self._reconnects: int = 0
+        self._bytes_read: int = 0
+        self._bytes_written: int = 0
+        # see: `Client.connection_bytes`
+        self._connection_bytes_read: int = 0
+        self._connection_bytes_written: int = 0
+        self._unexpected_responses: int = 0
+        # see: `Client.events`
+        self._events: typing.Union[None, asyncio.Queue] = None
+        self._dropped_events: int = 0
+        # see: `Client.errors`
+        self._errors: typing.Union[None, asyncio.Queue] = None
+        self._dropped_errors: int = 0
+        # the log_id's of the messages that were sent within the `dedup_window`, and when they were sent; oldest first.
+        self._recent_log_ids: typing.Dict[str, float] = collections.OrderedDict()
+        # see: `Client.current_session_state`
+        self._current_session_state: str = SmppSessionState.CLOSED
         self._header_pdu_length = 16
 
         self.drain_duration = drain_duration
         self.socket_timeout = socket_timeout
         self.SHOULD_SHUT_DOWN: bool = False
+        # set at the start of `Client.shutdown`, while the queued messages are still being sent.
+        self._shutting_down: bool = False
+        # number of messages that have been dequeued from the broker but not yet sent to SMSC.
+        self._in_flight_sends: int = 0
+        # cleared while dequeuing is paused. see: `Client.pause`
+        self._resumed: asyncio.Event = asyncio.Event()
+        self._resumed.set()
+        # serializes the writes to SMSC; each PDU is written, and drained, whole before the next one. see: `Client.send_data`
         self.drain_lock: asyncio.Lock = asyncio.Lock()
 
         the_codec.register_codecs(custom_codecs)
 
+        self.split_long_messages = split_long_messages
+        self.concat_mode = concat_mode
+        self.bind_mode = bind_mode
+        # the bind request to send to SMSC and the session state that a successful bind ends up in.
+        self._bind_command, self._bound_state = {
+            BindMode.TRANSMITTER: (SmppCommand.BIND_TRANSMITTER, SmppSessionState.BOUND_TX),
+            BindMode.RECEIVER: (SmppCommand.BIND_RECEIVER, SmppSessionState.BOUND_RX),
+            BindMode.TRANSCEIVER: (SmppCommand.BIND_TRANSCEIVER, SmppSessionState.BOUND_TRX),
+        }[bind_mode]
+
+        self.auto_reconnect = auto_reconnect
+        self.reconnect_initial_interval = reconnect_initial_interval
+        self.reconnect_max_interval = reconnect_max_interval
+        self.reconnect_jitter = reconnect_jitter
+        self.reconnect_fail_fast = reconnect_fail_fast
+        # see: `Client.re_establish_conn_bind`
+        self._reconnecting: bool = False
+        self._reconnect_lock: asyncio.Lock = asyncio.Lock()
+        self._reconnected: asyncio.Event = asyncio.Event()
+        # reference number shared by all the parts of one concatenated message.
+        self._concat_reference_number: int = 0
+
+        # requests, keyed by sequence_number, whose caller is awaiting the SMSC's response.
+        # see: `Client._send_and_await_response`
+        self._pending_responses: typing.Dict[int, asyncio.Future] = {}
+        # the response command that each of `_pending_responses` expects. eg; query_sm_resp for a query_sm
+        self._expected_responses: typing.Dict[int, str] = {}
+        # requests sent by `Client.send_raw`, keyed by sequence_number, whose caller is awaiting the SMSC's response.
+        self._raw_responses: typing.Dict[int, asyncio.Future] = {}
+
+        # handler for mobile originated messages. see: `Client.on_deliver_sm`
+        self._deliver_sm_handler: typing.Union[
+            None,
+            typing.Callable[
+                [protocol.DeliverSM], typing.Awaitable[typing.Union[None, CommandStatus]]
+            ],
+        ] = None
+        # handlers for the mobile originated messages sent to particular destination addresses, as (destination_addr, prefix, handler).
+        # see: `Client.on_deliver_sm_for`
+        self._deliver_sm_routes: typing.List[
+            typing.Tuple[
+                str,
+                bool,
+                typing.Callable[
+                    [protocol.DeliverSM], typing.Awaitable[typing.Union[None, CommandStatus]]
+                ],
+            ]
+        ] = []
+        # the parts of concatenated messages that are being reassembled, keyed by (source_addr, destination_addr, reference_number)
+        # and then by part number. see: `Client._reassemble`
+        self._fragments: typing.Dict[
+            typing.Tuple[str, str, int], typing.Dict[int, typing.Tuple[protocol.DeliverSM, bytes]]
+        ] = {}
+        self._fragment_timers: typing.Dict[typing.Tuple[str, str, int], asyncio.Future] = {}
+
+        # the messages, keyed by sequence_number, that are re-submitted if SMSC fails them. see: `Client._schedule_retry`
+        # For a message that was split, it is the part that was sent with that sequence_number.
+        self._retryable: typing.Dict[int, protocol.Message] = {}
+        self._retry_tasks: typing.Set[asyncio.Future] = set()
+        # the sequence_numbers of the parts, of each message dequeued from the broker, that SMSC has not yet responded to; keyed by sequence_number.
+        # All the parts of a message share the same set. see: `Client._acknowledge`
+        self._unacknowledged_parts: typing.Dict[int, typing.Set[int]] = {}
+
         # For exceptions, we try and avoid catch-all blocks. Instead we catch only the exceptions we expect.
         # Exception hierarchy: https://docs.python.org/3/library/exceptions.html#exception-hierarchy
 
@@ -312,6 +708,50 @@ class Client:
         drain_duration: float,
         socket_timeout: float,
         custom_codecs: typing.Union[None, typing.Dict[str, codecs.CodecInfo]],
+        split_long_messages: bool,
+        concat_mode: str,
+        bind_mode: str,
+        auto_reconnect: bool,
+        reconnect_initial_interval: float,
+        reconnect_max_interval: float,
+        reconnect_jitter: float,
+        reconnect_fail_fast: bool,
+        enquire_link_response_timeout: typing.Union[None, float],
+        ssl_context: typing.Union[None, ssl.SSLContext],
+        metrics: typing.Union[None, the_metrics.BaseMetrics],
+        window_size: typing.Union[None, int],
+        window_timeout: float,
+        registered_delivery: int,
+        max_pdu_length: int,
+        source_addr_ton: int,
+        source_addr_npi: int,
+        dest_addr_ton: int,
+        dest_addr_npi: int,
+        redact_system_id: bool,
+        on_write: typing.Union[None, typing.Callable[[bytes], None]],
+        on_read: typing.Union[None, typing.Callable[[bytes], None]],
+        on_outbind: typing.Union[None, typing.Callable[[str, str], bool]],
+        read_timeout: typing.Union[None, float],
+        write_timeout: typing.Union[None, float],
+        use_message_payload: bool,
+        on_enquire_link_latency: typing.Union[None, typing.Callable[[float], None]],
+        dialer: typing.Union[None, the_dialer.BaseDialer],
+        response_timeout: typing.Union[None, float],
+        priority_flag: int,
+        bind_timeout: typing.Union[None, float],
+        reassembly_timeout: typing.Union[None, float],
+        retry_policy: typing.Union[None, retry.BaseRetryPolicy],
+        smsc_endpoints: typing.Union[None, typing.List[str]],
+        validity_period: typing.Union[str, datetime.timedelta],
+        bind_retries: int,
+        message_payload_fallback: bool,
+        e164_addresses: typing.Union[None, typing.List[str]],
+        log_pdu_contents: typing.Union[None, int],
+        message_payload_threshold: int,
+        dedup_window: typing.Union[None, float],
+        byte_rate_limiter: typing.Union[None, ratelimiter.ByteRateLimiter],
+        unexpected_responses: str,
+        window_maintenance_interval: typing.Union[None, float],
     ) -> None:
         """
         Checks that the arguments to `naz.Client` are okay.
@@ -342,6 +782,12 @@ class Client:
                     "`password` should be of type:: `str` You entered: {0}".format(type(password))
                 )
             )
+        for name, credential in [("system_id", system_id), ("password", password)]:
+            # the credentials themselves are never included in the error.
+            if isinstance(credential, str) and not credential.isascii():
+                errors.append(
+                    ValueError("`{0}` should only contain ascii characters.".format(name))
+                )
         if not isinstance(broker, the_broker.BaseBroker):
             errors.append(
                 ValueError(
@@ -366,6 +812,15 @@ class Client:
                     )
                 )
             )
+        if isinstance(system_type, str) and (not system_type.isascii() or len(system_type) > 12):
+            # a C-Octet String of at most 13 octets, including the NULL terminator. see section 5.2.3 of smpp ver 3.4 spec document
+            errors.append(
+                ValueError(
+                    "`system_type` should be at most 12 ascii characters. You entered: {0}".format(
+                        system_type
+                    )
+                )
+            )
         if not isinstance(addr_ton, int):
             errors.append(
                 ValueError(
@@ -386,6 +841,17 @@ class Client:
                     )
                 )
             )
+        if isinstance(address_range, str) and (
+            not address_range.isascii() or len(address_range) > 40
+        ):
+            # a C-Octet String of at most 41 octets, including the NULL terminator. see section 4.1.1 of smpp ver 3.4 spec document
+            errors.append(
+                ValueError(
+                    "`address_range` should be at most 40 ascii characters. You entered: {0}".format(
+                        address_range
+                    )
+                )
+            )
         if not isinstance(interface_version, int):
             errors.append(
                 ValueError(
@@ -394,6 +860,14 @@ class Client:
                     )
                 )
             )
+        if isinstance(interface_version, int) and not (0 <= interface_version <= 0xFF):
+            errors.append(
+                ValueError(
+                    "`interface_version` should be between 0 and 255. You entered: {0}".format(
+                        interface_version
+                    )
+                )
+            )
         if not isinstance(enquire_link_interval, float):
             errors.append(
                 ValueError(
@@ -402,658 +876,3893 @@ class Client:
                     )
                 )
             )
-        if not isinstance(logger, (type(None), logging.Logger)):
+        elif enquire_link_interval < 0:
             errors.append(
                 ValueError(
-                    "`logger` should be of type:: `None` or `logging.Logger` You entered: {0}".format(
-                        type(logger)
+                    "`enquire_link_interval` should not be negative. You entered: {0}".format(
+                        enquire_link_interval
                     )
                 )
             )
-
-        if not isinstance(rate_limiter, (type(None), ratelimiter.BaseRateLimiter)):
+        if not isinstance(enquire_link_response_timeout, (type(None), float)):
             errors.append(
                 ValueError(
-                    "`rate_limiter` should be of type:: `None` or `naz.ratelimiter.BaseRateLimiter` You entered: {0}".format(
-                        type(rate_limiter)
+                    "`enquire_link_response_timeout` should be of type:: `None` or `float` You entered: {0}".format(
+                        type(enquire_link_response_timeout)
                     )
                 )
             )
-        if not isinstance(hook, (type(None), hooks.BaseHook)):
+        if isinstance(enquire_link_response_timeout, float) and enquire_link_response_timeout <= 0:
             errors.append(
                 ValueError(
-                    "`hook` should be of type:: `None` or `naz.hooks.BaseHook` You entered: {0}".format(
-                        type(hook)
+                    "`enquire_link_response_timeout` should be greater than zero. You entered: {0}".format(
+                        enquire_link_response_timeout
                     )
                 )
             )
-        if not isinstance(sequence_generator, (type(None), sequence.BaseSequenceGenerator)):
+        if not isinstance(ssl_context, (type(None), ssl.SSLContext)):
             errors.append(
                 ValueError(
-                    "`sequence_generator` should be of type:: `None` or `naz.sequence.BaseSequenceGenerator` You entered: {0}".format(
-                        type(sequence_generator)
+                    "`ssl_context` should be of type:: `None` or `ssl.SSLContext` You entered: {0}".format(
+                        type(ssl_context)
                     )
                 )
             )
-        if not isinstance(throttle_handler, (type(None), throttle.BaseThrottleHandler)):
+        if not isinstance(metrics, (type(None), the_metrics.BaseMetrics)):
             errors.append(
                 ValueError(
-                    "`throttle_handler` should be of type:: `None` or `naz.throttle.BaseThrottleHandler` You entered: {0}".format(
-                        type(throttle_handler)
+                    "`metrics` should be of type:: `None` or `naz.metrics.BaseMetrics` You entered: {0}".format(
+                        type(metrics)
                     )
                 )
             )
-        if not isinstance(correlation_handler, (type(None), correlater.BaseCorrelater)):
+        if not isinstance(window_size, (type(None), int)):
             errors.append(
                 ValueError(
-                    "`correlation_handler` should be of type:: `None` or `naz.correlater.BaseCorrelater` You entered: {0}".format(
-                        type(correlation_handler)
+                    "`window_size` should be of type:: `None` or `int` You entered: {0}".format(
+                        type(window_size)
                     )
                 )
             )
-        if not isinstance(drain_duration, float):
+        if isinstance(window_size, int) and window_size < 1:
             errors.append(
                 ValueError(
-                    "`drain_duration` should be of type:: `float` You entered: {0}".format(
-                        type(drain_duration)
+                    "`window_size` should be greater than zero. You entered: {0}".format(window_size)
+                )
+            )
+        if not isinstance(window_timeout, float):
+            errors.append(
+                ValueError(
+                    "`window_timeout` should be of type:: `float` You entered: {0}".format(
+                        type(window_timeout)
                     )
                 )
             )
-        if not isinstance(socket_timeout, float):
+        if isinstance(window_timeout, float) and window_timeout <= 0:
             errors.append(
                 ValueError(
-                    "`socket_timeout` should be of type:: `float` You entered: {0}".format(
-                        type(socket_timeout)
+                    "`window_timeout` should be greater than zero. You entered: {0}".format(
+                        window_timeout
                     )
                 )
             )
-
-        if not isinstance(custom_codecs, (type(None), dict)):
+        if not isinstance(registered_delivery, int):
             errors.append(
                 ValueError(
-                    "`custom_codecs` should be of type:: `None` or `dict` You entered: {0}".format(
-                        type(custom_codecs)
+                    "`registered_delivery` should be of type:: `int` You entered: {0}".format(
+                        type(registered_delivery)
                     )
                 )
             )
-
-        if custom_codecs:
-            try:
-                if not isinstance(custom_codecs, dict):
-                    raise ValueError(
-                        "`custom_codecs` should be of type:: `None` or `dict` You entered: {0}".format(
-                            type(custom_codecs)
-                        )
+        if isinstance(registered_delivery, int) and not (0 <= registered_delivery <= 0xFF):
+            errors.append(
+                ValueError(
+                    "`registered_delivery` should fit in one octet. You entered: {0}".format(
+                        registered_delivery
                     )
-                for _encoding, _codec_info in custom_codecs.items():
-                    if not isinstance(_codec_info, codecs.CodecInfo):
-                        raise ValueError(
-                            "`custom_codecs` should be a dictionary of encoding(string) to `codecs.CodecInfo` You entered: {0}".format(
-                                type(custom_codecs)
-                            )
-                        )
-                    if _encoding != _codec_info.name:
-                        raise ValueError(
-                            "the key `{0}` should be equal to codecs.CodecInfo.name".format(
-                                _encoding
-                            )
-                        )
-
-                    # validate encoding is one allowed by SMPP
-                    _ = SmppDataCoding._find_data_coding(_encoding)
-            except ValueError as e:
-                errors.append(e)
-
-        if len(errors):
-            raise NazClientError(errors)
-
-    def _sanity_check_logger(self):
-        """
-        called when instantiating the Client just to make sure the supplied
-        logger can log.
-        """
-        try:
-            self.logger.log(logging.DEBUG, {"event": "sanity_check_logger"})
-        except Exception as e:
-            raise e
-
-    def _log(self, level, log_data):
-        # if the supplied logger is unable to log; we move on
-        try:
-            self.logger.log(level, log_data)
-        except Exception:
-            pass
-
-    def _search_by_command_id_code(self, command_id_code: int) -> typing.Union[None, str]:
-        for key, val in self.command_ids.items():
-            if isinstance(val, list):
-                __range = range(val[0], val[1] + 1)
-                if command_id_code in __range:
-                    return key
-            else:
-                if val == command_id_code:
-                    return key
-        return None
-
-    @staticmethod
-    def _search_by_command_status_value(
-        command_status_value: int,
-    ) -> typing.Union[None, CommandStatus]:
-        # TODO: find a cheaper(better) way of doing this
-        for key, val in SmppCommandStatus.__dict__.items():
-            if not key.startswith("__"):
-                if isinstance(val.value, list):
-                    __range = range(val.value[0], val.value[1] + 1)
-                    if command_status_value in __range:
-                        return val
-                if command_status_value == val.value:
-                    return val
-        return None
-
-    @staticmethod
-    def _retry_after(current_retries):
-        """
-        retries will happen in this sequence;
-        1min, 2min, 4min, 8min, 16min, 32min, 16min, 16min, 16min ...
-        """
-        # TODO:
-        # 1. give users ability to bring their own retry algorithms.
-        # 2. add jitter
-        if current_retries < 0:
-            current_retries = 0
-
-        if current_retries >= 6:
-            return 60 * 16  # 16 minutes
-        else:
-            return 60 * (1 * (2 ** current_retries))
-
-    def _msg_to_log(self, msg: bytes) -> str:
-        """
-        returns decoded string from bytes with any password removed.
-        the returned string is safe to log.
-        """
-        log_msg = "unable to decode msg"
-        try:
-            log_msg = msg.decode("ascii")
-            if self.password in log_msg:
-                # do not log password, redact it from logs.
-                log_msg = log_msg.replace(self.password, "{REDACTED}")
-        except (UnicodeDecodeError, UnicodeError) as e:
-            # in future we may want to do something custom
-            _ = e
-        except Exception as e:
-            _ = e
-        return log_msg
-
-    async def connect(self, log_id: str = "") -> None:
-        """
-        make a network connection to SMSC server.
-        """
-        log_id = (
-            log_id
-            if log_id
-            else "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
-        )
-        try:
-            self._log(
-                logging.INFO, {"event": "naz.Client.connect", "stage": "start", "log_id": log_id}
+                )
             )
-            reader, writer = await asyncio.wait_for(
-                asyncio.open_connection(self.smsc_host, self.smsc_port), timeout=self.socket_timeout
+        if not isinstance(max_pdu_length, int):
+            errors.append(
+                ValueError(
+                    "`max_pdu_length` should be of type:: `int` You entered: {0}".format(
+                        type(max_pdu_length)
+                    )
+                )
             )
-            self.reader = reader
-            self.writer = writer
-            self._log(
-                logging.INFO, {"event": "naz.Client.connect", "stage": "end", "log_id": log_id}
+        if isinstance(max_pdu_length, int) and max_pdu_length < 16:
+            errors.append(
+                ValueError(
+                    "`max_pdu_length` should be at least 16(the length of a PDU header). You entered: {0}".format(
+                        max_pdu_length
+                    )
+                )
             )
-            self.current_session_state = SmppSessionState.OPEN
-        except (
-            OSError,
-            ConnectionError,
-            TimeoutError,
-            # Note that `asyncio.TimeoutError` is raised with no msg/args.
-            # So if logged as str(e) it would appear in logs as an empty string.
-            # Instead we use repr(e) and it will appear as "TimeoutError()"
-            # https://github.com/python/cpython/blob/723f71abf7ab0a7be394f9f7b2daa9ecdf6fb1eb/Lib/asyncio/tasks.py#L490
-            asyncio.TimeoutError,
-            socket.error,
-            socket.herror,
-            socket.gaierror,
-            socket.timeout,
-        ) as e:
-            self._log(
-                logging.ERROR,
-                {"event": "naz.Client.connect", "stage": "end", "log_id": log_id, "error": repr(e)},
+        if not isinstance(source_addr_ton, int):
+            errors.append(
+                ValueError(
+                    "`source_addr_ton` should be of type:: `int` You entered: {0}".format(
+                        type(source_addr_ton)
+                    )
+                )
             )
-
-    async def tranceiver_bind(self, log_id: str = "") -> None:
-        """
-        send a BIND_TRANSCEIVER pdu to SMSC.
-        """
-        smpp_command = SmppCommand.BIND_TRANSCEIVER
-        if log_id == "":
-            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
-        self._log(
-            logging.INFO,
-            {
-                "event": "naz.Client.tranceiver_bind",
-                "stage": "start",
-                "log_id": log_id,
-                "smpp_command": smpp_command,
-            },
-        )
-        # body
-        body = b""
-        body = (
-            body
-            # system_id is a C-Octet string, which is a series of ASCII characters terminated with the NULL character.
-            # see; section 3.1 of SMPP spec
-            # Thus we need to encode C-Octet strings as ascii and also terminate them with NULL char(chr(0).encode("ascii"))
-            + self.system_id.encode("ascii")
-            + chr(0).encode("ascii")
-            + self.password.encode("ascii")
-            + chr(0).encode("ascii")
-            + self.system_type.encode("ascii")
-            + chr(0).encode("ascii")
-            + struct.pack(">B", self.interface_version)  # unsigned Int, 1octet
-            + struct.pack(">B", self.addr_ton)
-            + struct.pack(">B", self.addr_npi)
-            + self.address_range.encode("ascii")
-            + chr(0).encode("ascii")
-        )
-
-        # header
-        command_length = self._header_pdu_length + len(body)  # 16 is for headers
-        command_id = self.command_ids[smpp_command]
-        # the status for success see section 5.1.3
-        command_status = SmppCommandStatus.ESME_ROK.value
-        try:
-            sequence_number = self.sequence_generator.next_sequence()
-        except Exception as e:
-            self._log(
+        if isinstance(source_addr_ton, int) and not (0 <= source_addr_ton <= 0xFF):
+            errors.append(
+                ValueError(
+                    "`source_addr_ton` should fit in one octet. You entered: {0}".format(
+                        source_addr_ton
+                    )
+                )
+            )
+        if not isinstance(source_addr_npi, int):
+            errors.append(
+                ValueError(
+                    "`source_addr_npi` should be of type:: `int` You entered: {0}".format(
+                        type(source_addr_npi)
+                    )
+                )
+            )
+        if isinstance(source_addr_npi, int) and not (0 <= source_addr_npi <= 0xFF):
+            errors.append(
+                ValueError(
+                    "`source_addr_npi` should fit in one octet. You entered: {0}".format(
+                        source_addr_npi
+                    )
+                )
+            )
+        if not isinstance(dest_addr_ton, int):
+            errors.append(
+                ValueError(
+                    "`dest_addr_ton` should be of type:: `int` You entered: {0}".format(
+                        type(dest_addr_ton)
+                    )
+                )
+            )
+        if isinstance(dest_addr_ton, int) and not (0 <= dest_addr_ton <= 0xFF):
+            errors.append(
+                ValueError(
+                    "`dest_addr_ton` should fit in one octet. You entered: {0}".format(
+                        dest_addr_ton
+                    )
+                )
+            )
+        if not isinstance(dest_addr_npi, int):
+            errors.append(
+                ValueError(
+                    "`dest_addr_npi` should be of type:: `int` You entered: {0}".format(
+                        type(dest_addr_npi)
+                    )
+                )
+            )
+        if isinstance(dest_addr_npi, int) and not (0 <= dest_addr_npi <= 0xFF):
+            errors.append(
+                ValueError(
+                    "`dest_addr_npi` should fit in one octet. You entered: {0}".format(
+                        dest_addr_npi
+                    )
+                )
+            )
+        if not isinstance(redact_system_id, bool):
+            errors.append(
+                ValueError(
+                    "`redact_system_id` should be of type:: `bool` You entered: {0}".format(
+                        type(redact_system_id)
+                    )
+                )
+            )
+        if on_write is not None and not callable(on_write):
+            errors.append(
+                ValueError(
+                    "`on_write` should be of type:: `None` or a callable You entered: {0}".format(
+                        type(on_write)
+                    )
+                )
+            )
+        if on_read is not None and not callable(on_read):
+            errors.append(
+                ValueError(
+                    "`on_read` should be of type:: `None` or a callable You entered: {0}".format(
+                        type(on_read)
+                    )
+                )
+            )
+        if on_outbind is not None and not callable(on_outbind):
+            errors.append(
+                ValueError(
+                    "`on_outbind` should be of type:: `None` or a callable You entered: {0}".format(
+                        type(on_outbind)
+                    )
+                )
+            )
+        if not isinstance(read_timeout, (type(None), float)):
+            errors.append(
+                ValueError(
+                    "`read_timeout` should be of type:: `None` or `float` You entered: {0}".format(
+                        type(read_timeout)
+                    )
+                )
+            )
+        if isinstance(read_timeout, float) and read_timeout <= 0:
+            errors.append(
+                ValueError(
+                    "`read_timeout` should be greater than zero. You entered: {0}".format(
+                        read_timeout
+                    )
+                )
+            )
+        if not isinstance(write_timeout, (type(None), float)):
+            errors.append(
+                ValueError(
+                    "`write_timeout` should be of type:: `None` or `float` You entered: {0}".format(
+                        type(write_timeout)
+                    )
+                )
+            )
+        if isinstance(write_timeout, float) and write_timeout <= 0:
+            errors.append(
+                ValueError(
+                    "`write_timeout` should be greater than zero. You entered: {0}".format(
+                        write_timeout
+                    )
+                )
+            )
+        if not isinstance(use_message_payload, bool):
+            errors.append(
+                ValueError(
+                    "`use_message_payload` should be of type:: `bool` You entered: {0}".format(
+                        type(use_message_payload)
+                    )
+                )
+            )
+        if use_message_payload is True and split_long_messages is True:
+            errors.append(
+                ValueError(
+                    "`use_message_payload` and `split_long_messages` cannot both be True. A message is either sent whole in the `message_payload` optional parameter or split into concatenated segments."
+                )
+            )
+        if on_enquire_link_latency is not None and not callable(on_enquire_link_latency):
+            errors.append(
+                ValueError(
+                    "`on_enquire_link_latency` should be of type:: `None` or a callable You entered: {0}".format(
+                        type(on_enquire_link_latency)
+                    )
+                )
+            )
+        if not isinstance(dialer, (type(None), the_dialer.BaseDialer)):
+            errors.append(
+                ValueError(
+                    "`dialer` should be of type:: `None` or `naz.dialer.BaseDialer` You entered: {0}".format(
+                        type(dialer)
+                    )
+                )
+            )
+        if not isinstance(response_timeout, (type(None), float)):
+            errors.append(
+                ValueError(
+                    "`response_timeout` should be of type:: `None` or `float` You entered: {0}".format(
+                        type(response_timeout)
+                    )
+                )
+            )
+        if isinstance(response_timeout, float) and response_timeout <= 0:
+            errors.append(
+                ValueError(
+                    "`response_timeout` should be greater than zero. You entered: {0}".format(
+                        response_timeout
+                    )
+                )
+            )
+        if not isinstance(priority_flag, int):
+            errors.append(
+                ValueError(
+                    "`priority_flag` should be of type:: `int` You entered: {0}".format(
+                        type(priority_flag)
+                    )
+                )
+            )
+        if isinstance(priority_flag, int) and not (0 <= priority_flag <= 3):
+            errors.append(
+                ValueError(
+                    "`priority_flag` should be between 0 and 3. You entered: {0}".format(
+                        priority_flag
+                    )
+                )
+            )
+        if not isinstance(bind_timeout, (type(None), float)):
+            errors.append(
+                ValueError(
+                    "`bind_timeout` should be of type:: `None` or `float` You entered: {0}".format(
+                        type(bind_timeout)
+                    )
+                )
+            )
+        if isinstance(bind_timeout, float) and bind_timeout <= 0:
+            errors.append(
+                ValueError(
+                    "`bind_timeout` should be greater than zero. You entered: {0}".format(
+                        bind_timeout
+                    )
+                )
+            )
+        if not isinstance(reassembly_timeout, (type(None), float)):
+            errors.append(
+                ValueError(
+                    "`reassembly_timeout` should be of type:: `None` or `float` You entered: {0}".format(
+                        type(reassembly_timeout)
+                    )
+                )
+            )
+        if isinstance(reassembly_timeout, float) and reassembly_timeout <= 0:
+            errors.append(
+                ValueError(
+                    "`reassembly_timeout` should be greater than zero. You entered: {0}".format(
+                        reassembly_timeout
+                    )
+                )
+            )
+        if not isinstance(retry_policy, (type(None), retry.BaseRetryPolicy)):
+            errors.append(
+                ValueError(
+                    "`retry_policy` should be of type:: `None` or `naz.retry.BaseRetryPolicy` You entered: {0}".format(
+                        type(retry_policy)
+                    )
+                )
+            )
+        if not isinstance(smsc_endpoints, (type(None), list)):
+            errors.append(
+                ValueError(
+                    "`smsc_endpoints` should be of type:: `None` or `list` You entered: {0}".format(
+                        type(smsc_endpoints)
+                    )
+                )
+            )
+        for endpoint in smsc_endpoints if isinstance(smsc_endpoints, list) else []:
+            try:
+                Client._parse_endpoint(endpoint)
+            except ValueError as e:
+                errors.append(e)
+        if not isinstance(validity_period, (str, datetime.timedelta)):
+            errors.append(
+                ValueError(
+                    "`validity_period` should be of type:: `str` or `datetime.timedelta` You entered: {0}".format(
+                        type(validity_period)
+                    )
+                )
+            )
+        if isinstance(validity_period, datetime.timedelta):
+            try:
+                protocol.smpp_time(validity_period)
+            except ValueError as e:
+                errors.append(e)
+        if not isinstance(bind_retries, int) or isinstance(bind_retries, bool):
+            errors.append(
+                ValueError(
+                    "`bind_retries` should be of type:: `int` You entered: {0}".format(
+                        type(bind_retries)
+                    )
+                )
+            )
+        elif bind_retries < 0:
+            errors.append(
+                ValueError(
+                    "`bind_retries` should not be negative. You entered: {0}".format(bind_retries)
+                )
+            )
+        elif bind_retries > 0 and bind_timeout is None:
+            errors.append(ValueError("`bind_retries` requires `bind_timeout` to be set."))
+        if not isinstance(message_payload_fallback, bool):
+            errors.append(
+                ValueError(
+                    "`message_payload_fallback` should be of type:: `bool` You entered: {0}".format(
+                        type(message_payload_fallback)
+                    )
+                )
+            )
+        if not isinstance(e164_addresses, (type(None), list)):
+            errors.append(
+                ValueError(
+                    "`e164_addresses` should be of type:: `None` or `list` You entered: {0}".format(
+                        type(e164_addresses)
+                    )
+                )
+            )
+        elif e164_addresses is not None:
+            for address in e164_addresses:
+                if address not in ["source_addr", "destination_addr"]:
+                    errors.append(
+                        ValueError(
+                            "`e164_addresses` should only contain `source_addr` and `destination_addr` You entered: {0}".format(
+                                address
+                            )
+                        )
+                    )
+        if not isinstance(log_pdu_contents, (type(None), int)) or isinstance(
+            log_pdu_contents, bool
+        ):
+            errors.append(
+                ValueError(
+                    "`log_pdu_contents` should be of type:: `None` or `int` You entered: {0}".format(
+                        type(log_pdu_contents)
+                    )
+                )
+            )
+        elif isinstance(log_pdu_contents, int) and log_pdu_contents < 0:
+            errors.append(
+                ValueError(
+                    "`log_pdu_contents` should not be negative. You entered: {0}".format(
+                        log_pdu_contents
+                    )
+                )
+            )
+        if not isinstance(message_payload_threshold, int) or isinstance(
+            message_payload_threshold, bool
+        ):
+            errors.append(
+                ValueError(
+                    "`message_payload_threshold` should be of type:: `int` You entered: {0}".format(
+                        type(message_payload_threshold)
+                    )
+                )
+            )
+        elif not (0 <= message_payload_threshold <= 254):
+            errors.append(
+                ValueError(
+                    "`message_payload_threshold` should be between 0 and 254. You entered: {0}".format(
+                        message_payload_threshold
+                    )
+                )
+            )
+        if not isinstance(dedup_window, (type(None), float)):
+            errors.append(
+                ValueError(
+                    "`dedup_window` should be of type:: `None` or `float` You entered: {0}".format(
+                        type(dedup_window)
+                    )
+                )
+            )
+        elif isinstance(dedup_window, float) and dedup_window <= 0:
+            errors.append(
+                ValueError(
+                    "`dedup_window` should be greater than zero. You entered: {0}".format(
+                        dedup_window
+                    )
+                )
+            )
+        if not isinstance(byte_rate_limiter, (type(None), ratelimiter.ByteRateLimiter)):
+            errors.append(
+                ValueError(
+                    "`byte_rate_limiter` should be of type:: `None` or `naz.ratelimiter.ByteRateLimiter` You entered: {0}".format(
+                        type(byte_rate_limiter)
+                    )
+                )
+            )
+        if unexpected_responses not in [
+            UnexpectedResponse.IGNORE,
+            UnexpectedResponse.LOG,
+            UnexpectedResponse.HOOK,
+        ]:
+            errors.append(
+                ValueError(
+                    "`unexpected_responses` should be one of:: `{0}` You entered: {1}".format(
+                        [
+                            UnexpectedResponse.IGNORE,
+                            UnexpectedResponse.LOG,
+                            UnexpectedResponse.HOOK,
+                        ],
+                        unexpected_responses,
+                    )
+                )
+            )
+        if not isinstance(window_maintenance_interval, (type(None), float)):
+            errors.append(
+                ValueError(
+                    "`window_maintenance_interval` should be of type:: `None` or `float` You entered: {0}".format(
+                        type(window_maintenance_interval)
+                    )
+                )
+            )
+        elif isinstance(window_maintenance_interval, float) and window_maintenance_interval <= 0:
+            errors.append(
+                ValueError(
+                    "`window_maintenance_interval` should be greater than zero. You entered: {0}".format(
+                        window_maintenance_interval
+                    )
+                )
+            )
+        if not isinstance(logger, (type(None), logging.Logger)):
+            errors.append(
+                ValueError(
+                    "`logger` should be of type:: `None` or `logging.Logger` You entered: {0}".format(
+                        type(logger)
+                    )
+                )
+            )
+
+        if not isinstance(rate_limiter, (type(None), ratelimiter.BaseRateLimiter)):
+            errors.append(
+                ValueError(
+                    "`rate_limiter` should be of type:: `None` or `naz.ratelimiter.BaseRateLimiter` You entered: {0}".format(
+                        type(rate_limiter)
+                    )
+                )
+            )
+        if not isinstance(hook, (type(None), hooks.BaseHook)):
+            errors.append(
+                ValueError(
+                    "`hook` should be of type:: `None` or `naz.hooks.BaseHook` You entered: {0}".format(
+                        type(hook)
+                    )
+                )
+            )
+        if not isinstance(sequence_generator, (type(None), sequence.BaseSequenceGenerator)):
+            errors.append(
+                ValueError(
+                    "`sequence_generator` should be of type:: `None` or `naz.sequence.BaseSequenceGenerator` You entered: {0}".format(
+                        type(sequence_generator)
+                    )
+                )
+            )
+        if not isinstance(throttle_handler, (type(None), throttle.BaseThrottleHandler)):
+            errors.append(
+                ValueError(
+                    "`throttle_handler` should be of type:: `None` or `naz.throttle.BaseThrottleHandler` You entered: {0}".format(
+                        type(throttle_handler)
+                    )
+                )
+            )
+        if not isinstance(correlation_handler, (type(None), correlater.BaseCorrelater)):
+            errors.append(
+                ValueError(
+                    "`correlation_handler` should be of type:: `None` or `naz.correlater.BaseCorrelater` You entered: {0}".format(
+                        type(correlation_handler)
+                    )
+                )
+            )
+        if not isinstance(drain_duration, float):
+            errors.append(
+                ValueError(
+                    "`drain_duration` should be of type:: `float` You entered: {0}".format(
+                        type(drain_duration)
+                    )
+                )
+            )
+        if not isinstance(socket_timeout, float):
+            errors.append(
+                ValueError(
+                    "`socket_timeout` should be of type:: `float` You entered: {0}".format(
+                        type(socket_timeout)
+                    )
+                )
+            )
+
+        if not isinstance(custom_codecs, (type(None), dict)):
+            errors.append(
+                ValueError(
+                    "`custom_codecs` should be of type:: `None` or `dict` You entered: {0}".format(
+                        type(custom_codecs)
+                    )
+                )
+            )
+
+        if custom_codecs:
+            try:
+                if not isinstance(custom_codecs, dict):
+                    raise ValueError(
+                        "`custom_codecs` should be of type:: `None` or `dict` You entered: {0}".format(
+                            type(custom_codecs)
+                        )
+                    )
+                for _encoding, _codec_info in custom_codecs.items():
+                    if not isinstance(_codec_info, codecs.CodecInfo):
+                        raise ValueError(
+                            "`custom_codecs` should be a dictionary of encoding(string) to `codecs.CodecInfo` You entered: {0}".format(
+                                type(custom_codecs)
+                            )
+                        )
+                    if _encoding != _codec_info.name:
+                        raise ValueError(
+                            "the key `{0}` should be equal to codecs.CodecInfo.name".format(
+                                _encoding
+                            )
+                        )
+
+                    # validate encoding is one allowed by SMPP
+                    _ = SmppDataCoding._find_data_coding(_encoding)
+            except ValueError as e:
+                errors.append(e)
+
+        if not isinstance(split_long_messages, bool):
+            errors.append(
+                ValueError(
+                    "`split_long_messages` should be of type:: `bool` You entered: {0}".format(
+                        type(split_long_messages)
+                    )
+                )
+            )
+        if concat_mode not in [ConcatMode.UDH, ConcatMode.UDH_16BIT, ConcatMode.SAR]:
+            errors.append(
+                ValueError(
+                    "`concat_mode` should be one of:: `{0}` You entered: {1}".format(
+                        [ConcatMode.UDH, ConcatMode.UDH_16BIT, ConcatMode.SAR], concat_mode
+                    )
+                )
+            )
+        if bind_mode not in [BindMode.TRANSMITTER, BindMode.RECEIVER, BindMode.TRANSCEIVER]:
+            errors.append(
+                ValueError(
+                    "`bind_mode` should be one of:: `{0}` You entered: {1}".format(
+                        [BindMode.TRANSMITTER, BindMode.RECEIVER, BindMode.TRANSCEIVER], bind_mode
+                    )
+                )
+            )
+        if not isinstance(auto_reconnect, bool):
+            errors.append(
+                ValueError(
+                    "`auto_reconnect` should be of type:: `bool` You entered: {0}".format(
+                        type(auto_reconnect)
+                    )
+                )
+            )
+        if not isinstance(reconnect_initial_interval, float):
+            errors.append(
+                ValueError(
+                    "`reconnect_initial_interval` should be of type:: `float` You entered: {0}".format(
+                        type(reconnect_initial_interval)
+                    )
+                )
+            )
+        if not isinstance(reconnect_max_interval, float):
+            errors.append(
+                ValueError(
+                    "`reconnect_max_interval` should be of type:: `float` You entered: {0}".format(
+                        type(reconnect_max_interval)
+                    )
+                )
+            )
+        if isinstance(reconnect_initial_interval, float) and isinstance(
+            reconnect_max_interval, float
+        ):
+            if not (0 < reconnect_initial_interval <= reconnect_max_interval):
+                errors.append(
+                    ValueError(
+                        "`reconnect_initial_interval` should be greater than zero and not greater than `reconnect_max_interval`"
+                    )
+                )
+        if not isinstance(reconnect_jitter, float):
+            errors.append(
+                ValueError(
+                    "`reconnect_jitter` should be of type:: `float` You entered: {0}".format(
+                        type(reconnect_jitter)
+                    )
+                )
+            )
+        elif not (0 <= reconnect_jitter <= 1):
+            errors.append(
+                ValueError(
+                    "`reconnect_jitter` should be between 0 and 1. You entered: {0}".format(
+                        reconnect_jitter
+                    )
+                )
+            )
+        if not isinstance(reconnect_fail_fast, bool):
+            errors.append(
+                ValueError(
+                    "`reconnect_fail_fast` should be of type:: `bool` You entered: {0}".format(
+                        type(reconnect_fail_fast)
+                    )
+                )
+            )
+
+        if len(errors):
+            raise NazClientError(errors)
+
+    def _sanity_check_logger(self):
+        """
+        called when instantiating the Client just to make sure the supplied
+        logger can log.
+        """
+        try:
+            self.logger.log(logging.DEBUG, {"event": "sanity_check_logger"})
+        except Exception as e:
+            raise e
+
+    def _log(self, level, log_data):
+        # the log event is also attached to the `logging.LogRecord` as `record.log_data`
+        # so that any standard `logging.Handler` can make use of the structured fields.
+        # errors and states may embed PDUs or credentials; never log them in plaintext.
+        log_data = {k: self._redact(v) if isinstance(v, str) else v for k, v in log_data.items()}
+        extra = {"log_data": log_data, "client_id": self.client_id, "smsc_host": self.smsc_host}
+        # if the supplied logger is unable to log; we move on
+        try:
+            try:
+                self.logger.log(level, log_data, extra=extra)
+            except TypeError:
+                # custom loggers whose `log` method does not take keyword arguments.
+                self.logger.log(level, log_data)
+        except Exception:
+            pass
+
+    def _call_wire_hook(self, name: str, pdu: bytes) -> None:
+        """
+        call :attr:`on_write <Client.on_write>` or :attr:`on_read <Client.on_read>` with the raw bytes of a PDU.
+        A failing hook should not bring down naz.
+        """
+        try:
+            getattr(self, name)(pdu)
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._call_wire_hook",
+                    "stage": "end",
+                    "hook": name,
+                    "error": repr(e),
+                },
+            )
+
+    def _record_metric(self, name: str, *args: typing.Any) -> None:
+        """
+        call the method called `name` of :attr:`metrics <Client.metrics>`, if metrics are enabled.
+        A failing metrics implementation should not bring down naz.
+        """
+        if self.metrics is None:
+            return None
+        try:
+            getattr(self.metrics, name)(*args)
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._record_metric",
+                    "stage": "end",
+                    "metric": name,
+                    "error": repr(e),
+                },
+            )
+
+    def _queue_depth_metric(self) -> None:
+        if self.metrics is None:
+            return None
+        depth = self.broker.size()
+        if depth is not None:
+            self._record_metric("queue_depth", depth)
+
+    def _search_by_command_id_code(self, command_id_code: int) -> typing.Union[None, str]:
+        for key, val in self.command_ids.items():
+            if isinstance(val, list):
+                __range = range(val[0], val[1] + 1)
+                if command_id_code in __range:
+                    return key
+            else:
+                if val == command_id_code:
+                    return key
+        return None
+
+    @staticmethod
+    def _search_by_command_status_value(
+        command_status_value: int,
+    ) -> typing.Union[None, CommandStatus]:
+        return SmppCommandStatus._find_command_status(command_status_value)
+
+    @staticmethod
+    def _retry_after(current_retries):
+        """
+        retries will happen in this sequence;
+        1min, 2min, 4min, 8min, 16min, 32min, 16min, 16min, 16min ...
+        """
+        # TODO:
+        # 1. give users ability to bring their own retry algorithms.
+        # 2. add jitter
+        if current_retries < 0:
+            current_retries = 0
+
+        if current_retries >= 6:
+            return 60 * 16  # 16 minutes
+        else:
+            return 60 * (1 * (2 ** current_retries))
+
+    def _reconnect_backoff(self, attempt: int) -> float:
+        """
+        returns the duration in seconds to wait after the `attempt`'th failed re-connection attempt.
+        """
+        # the exponent is capped, since a float overflows for a large `attempt`. A cap of 32 is
+        # more than enough for the interval to have reached `reconnect_max_interval`.
+        interval = min(
+            self.reconnect_max_interval, self.reconnect_initial_interval * (2 ** min(attempt, 32))
+        )
+        jitter = interval * self.reconnect_jitter
+        return max(0.0, interval + random.uniform(-jitter, jitter))
+
+    def state(self) -> str:
+        """
+        Returns the current SMPP session state of the client. One of :class:`naz.SmppSessionState <naz.state.SmppSessionState>`
+        """
+        return self.current_session_state
+
+    async def _await_reconnection(self, operation: str) -> None:
+        """
+        If `naz` is re-connecting to SMSC, either wait for it to finish or raise
+        :class:`NazConnectionError <NazConnectionError>` depending on :attr:`reconnect_fail_fast <Client.reconnect_fail_fast>`
+        """
+        if not self._reconnecting:
+            return None
+        if self.reconnect_fail_fast:
+            raise NazConnectionError(
+                "`{0}` cannot be used while naz is re-connecting to SMSC.".format(operation)
+            )
+        await self._reconnected.wait()
+
+    def _redact(self, text: str) -> str:
+        """
+        returns the text with the password(and the system_id, if :attr:`redact_system_id <Client.redact_system_id>`) masked as `****`.
+        """
+        credentials = [self.password]
+        if self.redact_system_id:
+            credentials.append(self.system_id)
+        for credential in credentials:
+            # an empty credential would otherwise match everywhere.
+            if credential and credential in text:
+                text = text.replace(credential, "****")
+        return text
+
+    def _msg_to_log(self, msg: bytes) -> str:
+        """
+        returns decoded string from bytes with any password removed.
+        the returned string is safe to log.
+        """
+        log_msg = "unable to decode msg"
+        if msg[4:8] == struct.pack(">I", self.command_ids[SmppCommand.OUTBIND]):
+            # the body of an outbind has the password of SMSC.
+            msg = msg[: self._header_pdu_length]
+        try:
+            # do not log password, redact it from logs.
+            log_msg = self._redact(msg.decode("ascii"))
+        except (UnicodeDecodeError, UnicodeError) as e:
+            # in future we may want to do something custom
+            _ = e
+        except Exception as e:
+            _ = e
+        return log_msg
+
+    def _log_pdu_contents(
+        self,
+        event: str,
+        log_id: str,
+        smpp_command: str,
+        message: typing.Union[protocol.SubmitSM, protocol.DataSM, bytes],
+    ) -> None:
+        """
+        logs, at DEBUG level, the contents of a message that is sent to SMSC or of a PDU that is received from SMSC.
+        The short_message(or body) is truncated to :attr:`log_pdu_contents <Client.log_pdu_contents>` characters
+        and the addresses are masked except for their last 4 digits; they may be personal data.
+        """
+        limit = self.log_pdu_contents
+        if limit is None or not self.logger.isEnabledFor(logging.DEBUG):
+            return None
+
+        def truncate(text: typing.Union[str, bytes]) -> str:
+            if isinstance(text, bytes):
+                text = text.hex()
+            if len(text) <= limit:
+                return text
+            return "{0}...({1} more characters)".format(text[:limit], len(text) - limit)
+
+        def mask(address: str) -> str:
+            return "*" * (len(address) - 4) + address[-4:]
+
+        log_data = {
+            "event": event,
+            "stage": "start",
+            "log_id": log_id,
+            "smpp_command": smpp_command,
+            "state": "pdu contents",
+        }
+        try:
+            if isinstance(message, bytes) and smpp_command != SmppCommand.DELIVER_SM:
+                # the other PDUs that SMSC sends have neither addresses nor a message.
+                log_data["body"] = truncate(message[self._header_pdu_length :])
+            else:
+                decoded = (
+                    protocol.DeliverSM._from_pdu(message, log_id=log_id)
+                    if isinstance(message, bytes)
+                    else message
+                )
+                log_data["source_addr"] = mask(decoded.source_addr)
+                log_data["destination_addr"] = mask(decoded.destination_addr)
+                if isinstance(decoded, protocol.DataSM):
+                    log_data["message_payload"] = truncate(decoded.message_payload)
+                elif decoded.short_message is None:
+                    # it has no text codec, so log its bytes.
+                    log_data["short_message"] = truncate(decoded.raw_short_message)
+                else:
+                    log_data["short_message"] = truncate(decoded.short_message)
+        except Exception as e:
+            log_data["error"] = repr(e)
+        self._log(logging.DEBUG, log_data)
+
+    @staticmethod
+    def _parse_endpoint(endpoint: str) -> typing.Tuple[str, int]:
+        """
+        parses a `host:port` SMSC endpoint. An IPv6 host is enclosed in brackets, eg `[::1]:2775`
+        """
+        host, _, port = endpoint.rpartition(":") if isinstance(endpoint, str) else ("", "", "")
+        host = host[1:-1] if host.startswith("[") and host.endswith("]") else host
+        if not host or not port.isdigit() or not (0 < int(port) <= 65535):
+            raise ValueError(
+                "`smsc_endpoints` should be a list of `host:port` strings. You entered: {0}".format(
+                    endpoint
+                )
+            )
+        return host, int(port)
+
+    @property
+    def active_endpoint(self) -> typing.Tuple[str, int]:
+        """
+        the host and port of the SMSC that naz is connected to; or, if it is not connected, the one that it will try first.
+        see :attr:`smsc_endpoints <Client.smsc_endpoints>`
+        """
+        return self._endpoints[self._endpoint_index]
+
+    def _fail_over(self, log_id: str) -> None:
+        """
+        makes the next of the :attr:`smsc_endpoints <Client.smsc_endpoints>` the first that naz tries to connect to.
+        """
+        if len(self._endpoints) == 1:
+            return None
+        previous_host, previous_port = self.active_endpoint
+        self._endpoint_index = (self._endpoint_index + 1) % len(self._endpoints)
+        self._log(
+            logging.WARNING,
+            {
+                "event": "naz.Client._fail_over",
+                "stage": "end",
+                "log_id": log_id,
+                "state": "failing over from {0}:{1} to {2}:{3}".format(
+                    previous_host, previous_port, *self.active_endpoint
+                ),
+            },
+        )
+
+    async def connect(self, log_id: str = "") -> None:
+        """
+        make a network connection to SMSC server.
+        If :attr:`smsc_endpoints <Client.smsc_endpoints>` is set, the endpoints are tried in order, starting with the :attr:`active_endpoint <Client.active_endpoint>`,
+        until one of them accepts the connection.
+        It can be cancelled, or given a deadline, using `asyncio.wait_for`
+        """
+        log_id = (
+            log_id
+            if log_id
+            else "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        )
+        for attempt in range(len(self._endpoints)):
+            if attempt > 0:
+                self._fail_over(log_id=log_id)
+            if await self._connect_endpoint(*self.active_endpoint, log_id=log_id):
+                return None
+
+    async def _connect_endpoint(self, smsc_host: str, smsc_port: int, log_id: str) -> bool:
+        """
+        make a network connection to one SMSC. It returns True if the connection was made.
+        """
+        try:
+            self._log(
+                logging.INFO,
+                {
+                    "event": "naz.Client.connect",
+                    "stage": "start",
+                    "log_id": log_id,
+                    "smsc_host": smsc_host,
+                    "smsc_port": smsc_port,
+                },
+            )
+            reader, writer = await asyncio.wait_for(
+                self.dialer.dial(smsc_host, smsc_port, self.ssl_context),
+                timeout=self.socket_timeout,
+            )
+            self.reader = reader
+            self.writer = writer
+            # requests sent on a previous connection will never be responded to on this one.
+            self._unanswered_since = 0.00
+            self._connection_bytes_read, self._connection_bytes_written = 0, 0
+            self._log(
+                logging.INFO, {"event": "naz.Client.connect", "stage": "end", "log_id": log_id}
+            )
+            self.current_session_state = SmppSessionState.OPEN
+            return True
+        except (
+            OSError,
+            ConnectionError,
+            TimeoutError,
+            # Note that `asyncio.TimeoutError` is raised with no msg/args.
+            # So if logged as str(e) it would appear in logs as an empty string.
+            # Instead we use repr(e) and it will appear as "TimeoutError()"
+            # https://github.com/python/cpython/blob/723f71abf7ab0a7be394f9f7b2daa9ecdf6fb1eb/Lib/asyncio/tasks.py#L490
+            asyncio.TimeoutError,
+            socket.error,
+            socket.herror,
+            socket.gaierror,
+            socket.timeout,
+        ) as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client.connect",
+                    "stage": "end",
+                    "log_id": log_id,
+                    "smsc_host": smsc_host,
+                    "smsc_port": smsc_port,
+                    "error": repr(e),
+                },
+            )
+            return False
+
+    async def accept_outbind(
+        self, reader: asyncio.StreamReader, writer: asyncio.StreamWriter
+    ) -> None:
+        """
+        take over a network connection that SMSC has made to us in order to do an `outbind`.
+        naz waits for the `outbind` PDU and responds to it with a `bind_receiver`, if :attr:`on_outbind <Client.on_outbind>` allows it.
+        The client's :attr:`bind_mode <Client.bind_mode>` has to be :attr:`naz.BindMode.RECEIVER <naz.state.BindMode.RECEIVER>`.
+
+        It can be used as the `client_connected_cb` of `asyncio.start_server <https://docs.python.org/3/library/asyncio-stream.html#asyncio.start_server>`_
+
+        .. highlight:: python
+        .. code-block:: python
+
+            cli = naz.Client(
+                ...
+                bind_mode=naz.BindMode.RECEIVER,
+                on_outbind=lambda system_id, password: password == "smsc-password",
+            )
+            server = await asyncio.start_server(cli.accept_outbind, "0.0.0.0", 2776)
+            # once bound, read from the connection as usual.
+            await cli.receive_data()
+
+        Parameters:
+            reader: the reader of the connection that SMSC made.
+            writer: the writer of the connection that SMSC made.
+        """
+        self._validate_bind_mode("accept_outbind", [BindMode.RECEIVER])
+        log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._log(
+            logging.INFO,
+            {"event": "naz.Client.accept_outbind", "stage": "start", "log_id": log_id},
+        )
+        self.reader = reader
+        self.writer = writer
+        self._connection_bytes_read, self._connection_bytes_written = 0, 0
+        self.current_session_state = SmppSessionState.OPEN
+        try:
+            header_data = await asyncio.wait_for(
+                reader.readexactly(self._header_pdu_length), timeout=self.socket_timeout
+            )
+            command_length, command_id = struct.unpack(">II", header_data[:8])
+            if command_id != self.command_ids[SmppCommand.OUTBIND]:
+                raise ValueError("expected an `outbind` but got command_id:{0}".format(command_id))
+            if not (self._header_pdu_length <= command_length <= self.max_pdu_length):
+                raise ValueError("command_length: {0} is invalid".format(command_length))
+            body_data = await asyncio.wait_for(
+                reader.readexactly(command_length - self._header_pdu_length),
+                timeout=self.socket_timeout,
+            )
+            await self._parse_response_pdu(header_data + body_data)
+        except asyncio.CancelledError:
+            raise
+        except (
+            ValueError,
+            asyncio.IncompleteReadError,
+            ConnectionError,
+            TimeoutError,
+            asyncio.TimeoutError,
+            socket.error,
+        ) as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client.accept_outbind",
+                    "stage": "end",
+                    "log_id": log_id,
+                    "state": "unable to read an outbind from SMSC",
+                    "error": repr(e),
+                },
+            )
+
+        if self.current_session_state != self._bound_state:
+            # the outbind was not accepted.
+            writer.close()
+            self.writer = None
+            self.current_session_state = SmppSessionState.CLOSED
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.accept_outbind",
+                "stage": "end",
+                "log_id": log_id,
+                "current_session_state": self.current_session_state,
+            },
+        )
+
+    async def _handle_outbind(self, body_data: bytes, log_id: str) -> None:
+        """
+        respond to an `outbind` by binding as a receiver, if :attr:`on_outbind <Client.on_outbind>` allows it.
+        """
+        # the body of an outbind has system_id and password; both are C-Octet strings.
+        # see section 4.1.7 of smpp ver 3.4 spec document.
+        system_id, offset = protocol._read_c_octet_string(body_data, 0)
+        password, _ = protocol._read_c_octet_string(body_data, offset)
+
+        accepted = False
+        if self.on_outbind is None:
+            state = "outbind ignored since `on_outbind` is not set"
+        elif self.bind_mode != BindMode.RECEIVER:
+            state = "outbind ignored since `bind_mode` is not {0}".format(BindMode.RECEIVER)
+        else:
+            try:
+                accepted = self.on_outbind(system_id, password) is True
+                state = "outbind accepted" if accepted else "outbind rejected by `on_outbind`"
+            except Exception as e:
+                state = "`on_outbind` error: {0}".format(repr(e))
+        self._log(
+            logging.INFO if accepted else logging.WARNING,
+            {
+                "event": "naz.Client._handle_outbind",
+                "stage": "start",
+                "log_id": log_id,
+                "system_id": system_id,
+                "state": state,
+            },
+        )
+        if accepted:
+            await self.bind(log_id=log_id)
+
+    async def tranceiver_bind(self, log_id: str = "") -> None:
+        """
+        send a bind pdu to SMSC.
+        It is kept for backward compatibility, use :func:`bind <Client.bind>` instead.
+        """
+        await self.bind(log_id=log_id)
+
+    async def bind(self, log_id: str = "") -> None:
+        """
+        send a BIND_TRANSMITTER, BIND_RECEIVER or BIND_TRANSCEIVER pdu to SMSC depending on :attr:`bind_mode <Client.bind_mode>`.
+        It can be cancelled, or given a deadline, using `asyncio.wait_for`
+
+        If :attr:`bind_timeout <Client.bind_timeout>` is set, it also waits for SMSC's response to the bind request.
+        If the bind fails, the connection to SMSC is closed.
+
+        If SMSC rejects the bind with a recoverable error, eg `ESME_RALYBND`, it re-connects and binds again; upto :attr:`bind_retries <Client.bind_retries>` times.
+
+        Raises:
+            NazBindTimeoutError: raised if SMSC does not respond within :attr:`bind_timeout <Client.bind_timeout>`
+            NazCommandStatusError: raised if SMSC rejects the bind. eg; `ESME_RINVPASWD`
+            NazConnectionError: raised if naz is unable to read the response from SMSC, or to re-connect to SMSC in order to retry the bind.
+        """
+        if log_id == "":
+            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        attempt = 0
+        while True:
+            try:
+                return await self._bind(log_id=log_id)
+            except NazCommandStatusError as e:
+                if (
+                    attempt >= self.bind_retries
+                    or e.command_status_value not in _RECOVERABLE_BIND_STATUSES
+                ):
+                    raise
+                retry_after = self._reconnect_backoff(attempt)
+                attempt += 1
+                self._log(
+                    logging.WARNING,
+                    {
+                        "event": "naz.Client.bind",
+                        "stage": "start",
+                        "log_id": log_id,
+                        "state": "retrying the bind in {0:.2f} seconds".format(retry_after),
+                        "bind_attempt": attempt,
+                        "error": str(e),
+                    },
+                )
+                await asyncio.sleep(retry_after)
+                await self.connect(log_id=log_id)
+                if self.current_session_state != SmppSessionState.OPEN:
+                    raise NazConnectionError(
+                        "unable to re-connect to SMSC in order to retry the bind."
+                    ) from e
+
+    async def _bind(self, log_id: str) -> None:
+        smpp_command = self._bind_command
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.bind",
+                "stage": "start",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+        # body
+        body = b""
+        body = (
+            body
+            # system_id is a C-Octet string, which is a series of ASCII characters terminated with the NULL character.
+            # see; section 3.1 of SMPP spec
+            # Thus we need to encode C-Octet strings as ascii and also terminate them with NULL char(chr(0).encode("ascii"))
+            + self.system_id.encode("ascii")
+            + chr(0).encode("ascii")
+            + self.password.encode("ascii")
+            + chr(0).encode("ascii")
+            + self.system_type.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", self.interface_version)  # unsigned Int, 1octet
+            + struct.pack(">B", self.addr_ton)
+            + struct.pack(">B", self.addr_npi)
+            + self.address_range.encode("ascii")
+            + chr(0).encode("ascii")
+        )
+
+        # header
+        command_length = self._header_pdu_length + len(body)  # 16 is for headers
+        command_id = self.command_ids[smpp_command]
+        # the status for success see section 5.1.3
+        command_status = SmppCommandStatus.ESME_ROK.value
+        try:
+            sequence_number = self.sequence_generator.next_sequence()
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client.bind",
+                    "stage": "end",
+                    "error": repr(e),
+                    "smpp_command": smpp_command,
+                },
+            )
+
+        if sequence_number > self.max_sequence_number:
+            # prevent third party sequence_generators from ruining our party
+            raise ValueError(
+                "the sequence_number: {0} is greater than the max: {1} allowed by SMPP spec.".format(
+                    sequence_number, self.max_sequence_number
+                )
+            )
+
+        # associate sequence_number with log_id.
+        # this will enable us to also associate responses and thus enhancing traceability of all workflows
+        try:
+            await self.correlation_handler.put(
+                smpp_command=smpp_command,
+                sequence_number=sequence_number,
+                log_id=log_id,
+                hook_metadata="",
+            ),
+        except asyncio.CancelledError:
+            # in python3.7 CancelledError is a subclass of Exception; do not swallow cancellations.
+            raise
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client.bind",
+                    "stage": "end",
+                    "smpp_command": smpp_command,
+                    "log_id": log_id,
+                    "state": "correlater put error",
+                    "error": repr(e),
+                },
+            )
+
+        header = struct.pack(
+            ">IIII", command_length, command_id, command_status, sequence_number
+        )  # unsigned Int, 4octet
+        full_pdu = header + body
+        await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+        if self.bind_timeout is not None:
+            await self._await_bind_resp(
+                smpp_command=smpp_command, sequence_number=sequence_number, log_id=log_id
+            )
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.bind",
+                "stage": "end",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+
+    async def _await_bind_resp(self, smpp_command: str, sequence_number: int, log_id: str) -> None:
+        """
+        wait, for upto :attr:`bind_timeout <Client.bind_timeout>`, for SMSC to respond to the bind request that has `sequence_number`.
+        naz only reads from SMSC, in :func:`receive_data <Client.receive_data>`, once it is bound; so the response is read here.
+        """
+        error: typing.Union[None, Exception] = None
+        try:
+            command_status_value = await asyncio.wait_for(
+                self._read_bind_resp(sequence_number), timeout=self.bind_timeout
+            )
+            if self.current_session_state != self._bound_state:
+                error = NazCommandStatusError(
+                    smpp_command=smpp_command,
+                    command_status=self._search_by_command_status_value(command_status_value)
+                    or SmppCommandStatus.ESME_RBINDFAIL,
+                    command_status_value=command_status_value,
+                )
+        except asyncio.TimeoutError:
+            error = NazBindTimeoutError(
+                "bind timed out. SMSC did not respond to `{0}` within {1:.2f} seconds".format(
+                    smpp_command, self.bind_timeout
+                )
+            )
+        except (asyncio.IncompleteReadError, ValueError, ConnectionError, socket.error) as e:
+            error = NazConnectionError(
+                "unable to read the response to `{0}` from SMSC. error: {1}".format(
+                    smpp_command, repr(e)
+                )
+            )
+        if error is None:
+            return None
+
+        self._log(
+            logging.ERROR,
+            {
+                "event": "naz.Client.bind",
+                "stage": "end",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+                "state": "bind failed",
+                "error": str(error),
+            },
+        )
+        if self.writer is not None:
+            self.writer.close()
+            self.writer = None
+        self.current_session_state = SmppSessionState.CLOSED
+        # the next connection is made to another SMSC, if there are others.
+        self._fail_over(log_id=log_id)
+        raise error
+
+    async def _read_bind_resp(self, sequence_number: int) -> int:
+        """
+        read PDUs from SMSC until the response that has `sequence_number` arrives, and return its command_status.
+        """
+        if typing.TYPE_CHECKING:
+            # make mypy happy; https://github.com/python/mypy/issues/4805
+            assert isinstance(self.reader, asyncio.streams.StreamReader)
+        while True:
+            header_data = await self.reader.readexactly(self._header_pdu_length)
+            command_length, _, command_status_value, _sequence_number = struct.unpack(
+                ">IIII", header_data
+            )
+            if not (self._header_pdu_length <= command_length <= self.max_pdu_length):
+                raise ValueError("command_length: {0} is invalid".format(command_length))
+            body_data = await self.reader.readexactly(command_length - self._header_pdu_length)
+            self._unanswered_since = 0.00
+            await self._parse_response_pdu(header_data + body_data)
+            if _sequence_number == sequence_number:
+                return command_status_value
+
+    async def enquire_link(self, TESTING: bool = False) -> typing.Union[None, bytes]:
+        """
+        send an ENQUIRE_LINK pdu to SMSC.
+
+        Parameters:
+            TESTING: indicates whether this method is been called while running tests.
+        """
+        # sleep during startup so that `naz` can have had time to connect & bind
+        # we rely on `enquire_link` to kick on `re_establish_conn_bind`
+        while self.current_session_state != self._bound_state:
+            retry_after = self.socket_timeout
+            self._log(
+                logging.DEBUG,
+                {
+                    "event": "naz.Client.enquire_link",
+                    "stage": "start",
+                    "current_session_state": self.current_session_state,
+                    "state": "awaiting naz to change session state to `{0}`. sleeping for {1:.2f} seconds".format(
+                        self._bound_state, retry_after
+                    ),
+                },
+            )
+            await asyncio.sleep(retry_after)
+            if TESTING:
+                return None
+
+        smpp_command = SmppCommand.ENQUIRE_LINK
+        while True:
+            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+            self._log(
+                logging.DEBUG,
+                {
+                    "event": "naz.Client.enquire_link",
+                    "stage": "start",
+                    "log_id": log_id,
+                    "smpp_command": smpp_command,
+                },
+            )
+            if self.SHOULD_SHUT_DOWN:
+                self._log(
+                    logging.DEBUG,
+                    {
+                        "event": "naz.Client.enquire_link",
+                        "stage": "end",
+                        "state": "client is shutdown",
+                    },
+                )
+                return None
+            if self.enquire_link_interval == 0.00:
+                # naz only responds to the enquire_link's of SMSC.
+                if TESTING:
+                    return None
+                await asyncio.sleep(self.socket_timeout)
+                continue
+
+            # body
+            body = b""
+
+            # header
+            command_length = self._header_pdu_length + len(body)  # 16 is for headers
+            command_id = self.command_ids[smpp_command]
+            command_status = 0x00000000  # not used for `enquire_link`
+            try:
+                sequence_number = self.sequence_generator.next_sequence()
+            except Exception as e:
+                self._log(
+                    logging.ERROR,
+                    {
+                        "event": "naz.Client.enquire_link",
+                        "stage": "end",
+                        "error": repr(e),
+                        "log_id": log_id,
+                        "smpp_command": smpp_command,
+                    },
+                )
+            if sequence_number > self.max_sequence_number:
+                # prevent third party sequence_generators from ruining our party
+                raise ValueError(
+                    "the sequence_number: {0} is greater than the max: {1} allowed by SMPP spec.".format(
+                        sequence_number, self.max_sequence_number
+                    )
+                )
+
+            try:
+                await self.correlation_handler.put(
+                    smpp_command=smpp_command,
+                    sequence_number=sequence_number,
+                    log_id=log_id,
+                    hook_metadata="",
+                )
+            except Exception as e:
+                self._log(
+                    logging.ERROR,
+                    {
+                        "event": "naz.Client.enquire_link",
+                        "stage": "end",
+                        "smpp_command": smpp_command,
+                        "log_id": log_id,
+                        "state": "correlater put error",
+                        "error": repr(e),
+                    },
+                )
+
+            header = struct.pack(
+                ">IIII", command_length, command_id, command_status, sequence_number
+            )
+            full_pdu = header + body
+            self._latest_enquire_link = (sequence_number, time.monotonic())
+            if self.enquire_link_response_timeout is not None:
+                # the response is matched to this request in `Client.command_handlers`
+                future = asyncio.get_event_loop().create_future()
+                self._pending_responses[sequence_number] = future
+            # dont queue enquire_link in SimpleBroker since we dont want it to be behind 10k msgs etc
+            await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+            if self.enquire_link_response_timeout is not None:
+                try:
+                    await asyncio.wait_for(future, self.enquire_link_response_timeout)
+                except asyncio.TimeoutError as e:
+                    self._log(
+                        logging.ERROR,
+                        {
+                            "event": "naz.Client.enquire_link",
+                            "stage": "end",
+                            "log_id": log_id,
+                            "smpp_command": smpp_command,
+                            "state": "SMSC did not respond to enquire_link. the connection is dead",
+                            "error": repr(e),
+                        },
+                    )
+                    if self.writer is not None:
+                        self.writer.close()
+                    await self.re_establish_conn_bind(smpp_command=smpp_command, log_id=log_id)
+                finally:
+                    self._pending_responses.pop(sequence_number, None)
+            self._log(
+                logging.DEBUG,
+                {
+                    "event": "naz.Client.enquire_link",
+                    "stage": "end",
+                    "log_id": log_id,
+                    "smpp_command": smpp_command,
+                },
+            )
+            if TESTING:
+                return full_pdu
+            await asyncio.sleep(self.enquire_link_interval)
+
+    def healthy(self) -> bool:
+        """
+        whether naz is bound to SMSC and SMSC is responding to the enquire_link's that :func:`enquire_link <Client.enquire_link>` sends.
+        It is cheap to call, eg in a liveness or readiness probe, since it does not send anything to SMSC. See :func:`ping <Client.ping>` for that.
+
+        It returns False if naz is not bound, if SMSC has not responded to the latest enquire_link within
+        :attr:`enquire_link_response_timeout <Client.enquire_link_response_timeout>`(or :attr:`socket_timeout <Client.socket_timeout>` if that is not set),
+        or if no enquire_link has been sent within the expected :attr:`enquire_link_interval <Client.enquire_link_interval>`.
+        """
+        if not self._connection_is_alive():
+            return False
+        _, sent_at = self._latest_enquire_link
+        if sent_at == 0.00:
+            # no enquire_link has been sent yet; eg, naz has just bound.
+            return True
+        grace = self.enquire_link_response_timeout or self.socket_timeout
+        since_sent = time.monotonic() - sent_at
+        if self._latest_enquire_link_resp_at < sent_at and since_sent > grace:
+            # SMSC has not responded to the latest enquire_link
+            return False
+        # else, `Client.enquire_link` may have stopped sending.
+        return since_sent <= self.enquire_link_interval + grace
+
+    async def ping(self, timeout: typing.Union[None, float] = None) -> float:
+        """
+        send an ENQUIRE_LINK pdu to SMSC and wait for its response.
+
+        Parameters:
+            timeout: the duration in seconds to wait for the response. It defaults to :attr:`socket_timeout <Client.socket_timeout>`
+
+        Returns:
+            the time in seconds that SMSC took to respond.
+
+        Raises:
+            asyncio.TimeoutError: if SMSC did not respond in time.
+        """
+        if not isinstance(timeout, (type(None), float)):
+            raise ValueError(
+                "`timeout` should be of type:: `None` or `float` You entered: {0}".format(
+                    type(timeout)
+                )
+            )
+        log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        started = time.monotonic()
+        await asyncio.wait_for(
+            self._send_and_await_response(
+                smpp_command=SmppCommand.ENQUIRE_LINK, body=b"", log_id=log_id
+            ),
+            timeout=timeout if timeout is not None else self.socket_timeout,
+        )
+        latency = time.monotonic() - started
+        self._record_enquire_link_latency(latency)
+        return latency
+
+    def enquire_link_latency(
+        self
+    ) -> typing.Tuple[typing.Union[None, float], typing.Union[None, float]]:
+        """
+        the round-trip time, in seconds, of enquire_link's to SMSC. A link that is degrading will show a rising latency before it drops.
+
+        Returns:
+            the latency of the latest enquire_link, and the average latency of the last 10 enquire_link's. They are None if SMSC is yet to respond to any enquire_link.
+        """
+        if not self._enquire_link_latencies:
+            return (None, None)
+        return (
+            self._enquire_link_latencies[-1],
+            sum(self._enquire_link_latencies) / len(self._enquire_link_latencies),
+        )
+
+    def smsc_system_id(self) -> str:
+        """
+        the `system_id` that SMSC identified itself with in the latest successful bind response. It is empty if naz is yet to bind.
+        """
+        return self._smsc_system_id
+
+    def smsc_interface_version(self) -> typing.Union[None, int]:
+        """
+        the version of the SMPP protocol supported by SMSC, as sent in the `sc_interface_version` optional parameter of the latest successful bind response.
+        It is None if naz is yet to bind, or if SMSC did not send it; which means that SMSC does not support optional parameters(eg, SMPP v3.3)
+        """
+        return self._smsc_interface_version
+
+    def events(self, maxsize: int = 1000) -> asyncio.Queue:
+        """
+        a queue of the :attr:`events <naz.events.Event>` of this client; eg connected, bound, PDU sent/received, throttled, reconnecting and disconnected.
+        It is an alternative to registering the various hooks and callbacks of naz.
+
+        Events are only emitted once this method has been called, and every call returns the same queue.
+        The queue holds upto `maxsize` events; if the consumer is slower than naz, new events are dropped instead of slowing down naz. See :func:`dropped_events <Client.dropped_events>`
+
+        Parameters:
+            maxsize: the maximum number of events that the queue holds. It is only used on the first call.
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            events = client.events()
+            while True:
+                event = await events.get()
+                if isinstance(event, naz.events.Throttled):
+                    print("SMSC is throttling us")
+        """
+        if not isinstance(maxsize, int):
+            raise ValueError(
+                "`maxsize` should be of type:: `int` You entered: {0}".format(type(maxsize))
+            )
+        if maxsize <= 0:
+            raise ValueError(
+                "`maxsize` should be greater than zero. You entered: {0}".format(maxsize)
+            )
+        if self._events is None:
+            self._events = asyncio.Queue(maxsize=maxsize)
+        return self._events
+
+    def dropped_events(self) -> int:
+        """
+        the number of events that were not put in the queue returned by :func:`events <Client.events>` because it was full.
+        """
+        return self._dropped_events
+
+    def errors(self, maxsize: int = 1000) -> asyncio.Queue:
+        """
+        a queue of the non-fatal errors that naz hits while reading the PDUs from SMSC, as :class:`NazReadError <NazReadError>`'s;
+        ie a malformed PDU, a PDU whose command_id is unknown, and a response that could not be correlated with the request that it is for.
+        naz logs them and carries on; the queue lets an application alert on, or count, them.
+
+        Errors are only put in the queue once this method has been called, and every call returns the same queue.
+        The queue holds upto `maxsize` errors; if the consumer is slower than naz, new errors are dropped. See :func:`dropped_errors <Client.dropped_errors>`
+
+        Parameters:
+            maxsize: the maximum number of errors that the queue holds. It is only used on the first call.
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            errors = client.errors()
+            while True:
+                error = await errors.get()
+                if error.kind == naz.client.NazReadError.MALFORMED_PDU:
+                    alert(error)
+        """
+        if not isinstance(maxsize, int):
+            raise ValueError(
+                "`maxsize` should be of type:: `int` You entered: {0}".format(type(maxsize))
+            )
+        if maxsize <= 0:
+            raise ValueError(
+                "`maxsize` should be greater than zero. You entered: {0}".format(maxsize)
+            )
+        if self._errors is None:
+            self._errors = asyncio.Queue(maxsize=maxsize)
+        return self._errors
+
+    def dropped_errors(self) -> int:
+        """
+        the number of errors that were not put in the queue returned by :func:`errors <Client.errors>` because it was full.
+        """
+        return self._dropped_errors
+
+    def stats(self) -> Stats:
+        """
+        a snapshot of the counters of this client; eg the number of messages submitted, the number that SMSC failed and the number of re-connections.
+        It is an alternative to :attr:`metrics <Client.metrics>` that does not need a metrics implementation and can be exported however you like.
+        It is cheap to call, and safe to call at any time; the snapshot is not affected by the traffic that comes after it.
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            stats = client.stats()
+            print(stats.submitted, stats.failed.get("ESME_RMSGQFUL", 0))
+        """
+        return Stats(
+            submitted=self._submitted,
+            delivered=self._delivered,
+            failed=dict(self._failed),
+            queue_depth=self.broker.size(),
+            reconnects=self._reconnects,
+            bytes_read=self._bytes_read,
+            bytes_written=self._bytes_written,
+            unexpected_responses=self._unexpected_responses,
+        )
+
+    def connection_bytes(self) -> typing.Tuple[int, int]:
+        """
+        the number of octets of PDUs read from, and written to, SMSC on the current connection.
+        Unlike the `bytes_read` and `bytes_written` of :func:`stats <Client.stats>`, which are for the lifetime of the client, they start at zero each time that naz connects to SMSC.
+        They can be used to reconcile the traffic of a bind against the SMSC's billing.
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            bytes_read, bytes_written = client.connection_bytes()
+        """
+        return self._connection_bytes_read, self._connection_bytes_written
+
+    def outstanding(self) -> typing.Tuple[int, float]:
+        """
+        the number of requests(eg submit_sm) that are awaiting a response from SMSC, and how long, in seconds, the oldest of them has been waiting; 0.0 if there are none.
+        A count or age that keeps growing is a sign that SMSC has stopped responding, even though the connection is up.
+        A request stops being counted once SMSC responds to it, once it times out, or after :attr:`window_timeout <Client.window_timeout>`
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            count, oldest = client.outstanding()
+            if oldest > 60.0:
+                alert("SMSC has not responded to {0} requests".format(count))
+        """
+        self._reclaim_window_slots(log_id="")
+        if not self._window:
+            return 0, 0.0
+        return len(self._window), time.monotonic() - min(self._window.values())
+
+    def _emit_event(self, event: the_events.Event) -> None:
+        if self._events is None:
+            # nobody is consuming events.
+            return None
+        try:
+            self._events.put_nowait(event)
+        except asyncio.QueueFull:
+            self._dropped_events += 1
+
+    def _emit_error(
+        self, kind: str, message: str, sequence_number: typing.Union[None, int] = None
+    ) -> None:
+        if self._errors is None:
+            # nobody is consuming errors.
+            return None
+        try:
+            self._errors.put_nowait(
+                NazReadError(kind=kind, message=message, sequence_number=sequence_number)
+            )
+        except asyncio.QueueFull:
+            self._dropped_errors += 1
+
+    def _record_enquire_link_latency(self, latency: float) -> None:
+        self._enquire_link_latencies.append(latency)
+        self._record_metric("enquire_link_latency", latency)
+        if self.on_enquire_link_latency is None:
+            return None
+        try:
+            self.on_enquire_link_latency(latency)
+        except Exception as e:
+            # a failing callback should not bring down naz.
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._record_enquire_link_latency",
+                    "stage": "end",
+                    "error": repr(e),
+                },
+            )
+
+    async def enquire_link_resp(self, sequence_number: int) -> None:
+        """
+        send an ENQUIRE_LINK_RESP pdu to SMSC.
+
+        Parameters:
+            sequence_number: SMPP sequence_number
+        """
+        smpp_command = SmppCommand.ENQUIRE_LINK_RESP
+        log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._log(
+            logging.DEBUG,
+            {
+                "event": "naz.Client.enquire_link_resp",
+                "stage": "start",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+        try:
+            full_pdu = await self._build_enquire_link_resp_pdu(
+                protocol.EnquireLinkResp(
+                    version=self.naz_message_protocol_version,
+                    smpp_command=smpp_command,
+                    log_id=log_id,
+                    sequence_number=sequence_number,
+                )
+            )
+            # reply right away rather than via the broker, so that the reply does not wait behind
+            # queued messages or the throttler and is sent even if `dequeue_messages` is not running.
+            # Otherwise SMSC may think that we are dead and close the bind.
+            await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+        except asyncio.CancelledError:
+            raise
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client.enquire_link_resp",
+                    "stage": "end",
+                    "error": repr(e),
+                    "log_id": log_id,
+                    "smpp_command": smpp_command,
+                },
+            )
+        self._log(
+            logging.DEBUG,
+            {
+                "event": "naz.Client.enquire_link_resp",
+                "stage": "end",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+
+    async def unbind_resp(self, sequence_number: int) -> None:
+        """
+        send an UNBIND_RESP pdu to SMSC.
+
+        Parameters:
+            sequence_number: SMPP sequence_number
+        """
+        smpp_command = SmppCommand.UNBIND_RESP
+        log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.unbind_resp",
+                "stage": "start",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+
+        # body
+        body = b""
+
+        # header
+        command_length = self._header_pdu_length + len(body)  # 16 is for headers
+        command_id = self.command_ids[smpp_command]
+        command_status = SmppCommandStatus.ESME_ROK.value
+        sequence_number = sequence_number
+        header = struct.pack(">IIII", command_length, command_id, command_status, sequence_number)
+
+        full_pdu = header + body
+        # dont queue unbind_resp in SimpleBroker since we dont want it to be behind 10k msgs etc
+        await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.unbind_resp",
+                "stage": "end",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+
+    async def _handle_smsc_unbind(self, log_id: str) -> None:
+        """
+        SMSC has ended the session(eg during its maintenance) and we have acknowledged it.
+        close the connection; then either start re-connecting if :attr:`auto_reconnect <Client.auto_reconnect>` is set,
+        or cleanly stop the client.
+        """
+        self._log(
+            logging.WARNING,
+            {
+                "event": "naz.Client._handle_smsc_unbind",
+                "stage": "start",
+                "log_id": log_id,
+                "auto_reconnect": self.auto_reconnect,
+                "state": "SMSC unbound",
+            },
+        )
+        # SMSC is going to close the connection; that is expected and is not an error.
+        self.current_session_state = SmppSessionState.CLOSED
+        if self.writer is not None:
+            self.writer.close()
+            self.writer = None
+        # SMSC will not respond to the requests that were sent over the closed connection.
+        self._window.clear()
+        self._window_freed.set()
+        self._retryable.clear()
+        self._unacknowledged_parts.clear()
+        if not self.auto_reconnect:
+            # `receive_data`, `dequeue_messages` and `enquire_link` stop.
+            self.SHOULD_SHUT_DOWN = True
+        # else; `receive_data` re-connects since naz is no longer bound.
+        self._log(
+            logging.WARNING,
+            {
+                "event": "naz.Client._handle_smsc_unbind",
+                "stage": "end",
+                "log_id": log_id,
+                "auto_reconnect": self.auto_reconnect,
+            },
+        )
+
+    async def deliver_sm_resp(
+        self,
+        sequence_number: int,
+        command_status: int = SmppCommandStatus.ESME_ROK.value,
+        message_id: str = "",
+    ) -> None:
+        """
+        send a DELIVER_SM_RESP pdu to SMSC.
+
+        Parameters:
+            sequence_number: SMPP sequence_number
+            command_status: the command_status of the `deliver_sm_resp`
+            message_id: the SMSC message_id of the message that the `deliver_sm` is a delivery receipt for, if any.
+                        It is not sent to SMSC; it is passed on to :func:`naz.hooks.BaseHook.deliver_sm_resp_sent <naz.hooks.BaseHook.deliver_sm_resp_sent>`
+        """
+        smpp_command = SmppCommand.DELIVER_SM_RESP
+        log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.deliver_sm_resp",
+                "stage": "start",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+
+        try:
+            proto_msg = protocol.DeliverSmResp(
+                version=self.naz_message_protocol_version,
+                smpp_command=smpp_command,
+                log_id=log_id,
+                message_id=message_id,
+                sequence_number=sequence_number,
+                command_status=command_status,
+            )
+            full_pdu = await self._build_deliver_sm_pdu(proto_msg)
+            # reply right away rather than via the broker, just like `enquire_link_resp`; so that the
+            # reply is neither held back by `pause` nor queued behind messages waiting to be sent.
+            written = await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+            if written:
+                await self._call_deliver_sm_resp_hook(proto_msg)
+        except asyncio.CancelledError:
+            raise
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client.deliver_sm_resp",
+                    "stage": "end",
+                    "error": repr(e),
+                    "log_id": log_id,
+                    "smpp_command": smpp_command,
+                },
+            )
+
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.deliver_sm_resp",
+                "stage": "end",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+
+    async def _call_deliver_sm_resp_hook(self, proto_msg: protocol.DeliverSmResp) -> None:
+        try:
+            await self.hook.deliver_sm_resp_sent(
+                log_id=proto_msg.log_id,
+                hook_metadata=proto_msg.hook_metadata,
+                sequence_number=proto_msg.sequence_number,
+                command_status=proto_msg.command_status,
+                message_id=proto_msg.message_id,
+            )
+        except asyncio.CancelledError:
+            raise
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client.deliver_sm_resp",
+                    "stage": "end",
+                    "log_id": proto_msg.log_id,
+                    "state": "deliver_sm_resp_sent hook error",
+                    "error": repr(e),
+                },
+            )
+
+    def _validate_bind_mode(self, operation: str, bind_modes: typing.List[str]) -> None:
+        """
+        raises ValueError if `operation` cannot be carried out in the client's bind_mode.
+        """
+        if self.bind_mode not in bind_modes:
+            raise ValueError(
+                "`{0}` cannot be used when naz is bound as a `{1}`. It requires a bind_mode of:: `{2}`".format(
+                    operation, self.bind_mode, bind_modes
+                )
+            )
+
+    def on_deliver_sm(
+        self,
+        handler: typing.Callable[
+            [protocol.DeliverSM], typing.Awaitable[typing.Union[None, CommandStatus]]
+        ],
+    ) -> None:
+        """
+        Registers a handler for mobile originated messages/DELIVER_SM received from SMSC.
+        The handler is called with a :class:`naz.protocol.DeliverSM <naz.protocol.DeliverSM>` for each `deliver_sm` that is not a delivery receipt.
+        After the handler returns, naz sends the `deliver_sm_resp`.
+
+        The command_status of the `deliver_sm_resp` is `ESME_ROK` if the handler returns None. The handler can instead return the
+        :class:`naz.CommandStatus <naz.state.CommandStatus>` to use, eg when it is unable to process the message. The statuses that are meaningful to an SMSC are:
+
+          - :attr:`ESME_RX_T_APPN <naz.state.SmppCommandStatus.ESME_RX_T_APPN>`: a temporary error; SMSC should retry delivering the message later.
+          - :attr:`ESME_RX_P_APPN <naz.state.SmppCommandStatus.ESME_RX_P_APPN>`: a permanent error; SMSC should not retry the message.
+          - :attr:`ESME_RX_R_APPN <naz.state.SmppCommandStatus.ESME_RX_R_APPN>`: the message is rejected.
+          - :attr:`ESME_RSYSERR <naz.state.SmppCommandStatus.ESME_RSYSERR>` and :attr:`ESME_RMSGQFUL <naz.state.SmppCommandStatus.ESME_RMSGQFUL>`: most SMSCs retry the message later.
+
+        How an SMSC treats each status is SMSC specific. If the handler raises an exception, the command_status is `ESME_RX_T_APPN`.
+
+        Parameters:
+            handler: an async function that takes a :class:`naz.protocol.DeliverSM <naz.protocol.DeliverSM>` and returns None or a :class:`naz.CommandStatus <naz.state.CommandStatus>`
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            import naz
+
+            async def handle_mo(message: naz.protocol.DeliverSM):
+                try:
+                    await save_to_db(message.source_addr, message.short_message)
+                except DatabaseError:
+                    # ask SMSC to retry the message later.
+                    return naz.SmppCommandStatus.ESME_RX_T_APPN
+
+            client = naz.Client(...)
+            client.on_deliver_sm(handle_mo)
+        """
+        if not asyncio.iscoroutinefunction(handler):
+            raise ValueError(
+                "`handler` should be an async function. You entered: {0}".format(type(handler))
+            )
+        self._validate_bind_mode("on_deliver_sm", [BindMode.RECEIVER, BindMode.TRANSCEIVER])
+        self._deliver_sm_handler = handler
+
+    def on_deliver_sm_for(
+        self,
+        destination_addr: str,
+        handler: typing.Callable[
+            [protocol.DeliverSM], typing.Awaitable[typing.Union[None, CommandStatus]]
+        ],
+        prefix: bool = False,
+    ) -> None:
+        """
+        Registers a handler for the mobile originated messages/DELIVER_SM sent to a particular destination address; eg one of many short codes.
+        A message is handled by the handler registered for its exact destination address if any, otherwise by the handler of the longest matching prefix,
+        otherwise by the handler registered using :func:`on_deliver_sm <Client.on_deliver_sm>`
+        The handler is called, and its return value is treated, the same way as that of :func:`on_deliver_sm <Client.on_deliver_sm>`
+
+        Parameters:
+            destination_addr: the destination address, eg a short code, of the messages to handle.
+            handler: an async function that takes a :class:`naz.protocol.DeliverSM <naz.protocol.DeliverSM>` and returns None or a :class:`naz.CommandStatus <naz.state.CommandStatus>`
+            prefix: whether `destination_addr` is matched as a prefix of the destination address of the messages, rather than exactly.
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            client = naz.Client(...)
+            client.on_deliver_sm_for("20880", handle_votes)
+            client.on_deliver_sm_for("3030", handle_promotions, prefix=True)
+            client.on_deliver_sm(handle_everything_else)
+        """
+        if not isinstance(destination_addr, str) or not destination_addr:
+            raise ValueError(
+                "`destination_addr` should be a non-empty `str` You entered: {0}".format(
+                    destination_addr
+                )
+            )
+        if not asyncio.iscoroutinefunction(handler):
+            raise ValueError(
+                "`handler` should be an async function. You entered: {0}".format(type(handler))
+            )
+        if not isinstance(prefix, bool):
+            raise ValueError(
+                "`prefix` should be of type:: `bool` You entered: {0}".format(type(prefix))
+            )
+        self._validate_bind_mode("on_deliver_sm_for", [BindMode.RECEIVER, BindMode.TRANSCEIVER])
+        # a handler registered again, for the same destination_addr, replaces the earlier one.
+        self._deliver_sm_routes = [
+            route for route in self._deliver_sm_routes if route[:2] != (destination_addr, prefix)
+        ]
+        self._deliver_sm_routes.append((destination_addr, prefix, handler))
+
+    def _deliver_sm_handler_for(
+        self, destination_addr: str
+    ) -> typing.Union[
+        None,
+        typing.Callable[[protocol.DeliverSM], typing.Awaitable[typing.Union[None, CommandStatus]]],
+    ]:
+        """
+        the handler for the messages sent to `destination_addr`. see: `Client.on_deliver_sm_for`
+        """
+        prefix_match: typing.Union[None, typing.Tuple[str, bool, typing.Any]] = None
+        for route in self._deliver_sm_routes:
+            route_addr, prefix, handler = route
+            if not prefix and route_addr == destination_addr:
+                return handler
+            if prefix and destination_addr.startswith(route_addr):
+                if prefix_match is None or len(route_addr) > len(prefix_match[0]):
+                    prefix_match = route
+        if prefix_match is not None:
+            return prefix_match[2]
+        return self._deliver_sm_handler
+
+    async def _handle_deliver_sm(self, pdu: bytes, log_id: str, hook_metadata: str) -> int:
+        """
+        calls the user's deliver_sm handler, if any.
+        It returns the command_status to use for the `deliver_sm_resp`.
+        """
+        if self._deliver_sm_handler is None and not self._deliver_sm_routes:
+            return SmppCommandStatus.ESME_ROK.value
+        try:
+            message = protocol.DeliverSM._from_pdu(pdu, log_id=log_id, hook_metadata=hook_metadata)
+            if message.is_delivery_receipt:
+                return SmppCommandStatus.ESME_ROK.value
+            if self.reassembly_timeout is not None:
+                reassembled = self._reassemble(message)
+                if reassembled is None:
+                    # a part of a concatenated message whose other parts have not all arrived yet.
+                    return SmppCommandStatus.ESME_ROK.value
+                message = reassembled
+            return await self._call_deliver_sm_handler(message)
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._handle_deliver_sm",
+                    "stage": "end",
+                    "smpp_command": SmppCommand.DELIVER_SM,
+                    "log_id": log_id,
+                    "state": "deliver_sm handler error",
+                    "error": repr(e),
+                },
+            )
+            return SmppCommandStatus.ESME_RX_T_APPN.value
+
+    @staticmethod
+    def _receipted_message_id(pdu: bytes) -> typing.Union[None, str]:
+        """
+        the SMSC message_id of the message that the delivery receipt in the `deliver_sm` PDU is for; or None if it is not a receipt.
+        It is taken from the `receipted_message_id` optional parameter if SMSC sent it, otherwise from the `id` field of the short_message.
+
+        Raises:
+            ValueError: raised if the receipt has no message_id.
+        """
+        message = protocol.DeliverSM._from_pdu(pdu, log_id="")
+        if message.receipted_message_id is not None:
+            return message.receipted_message_id
+        if not message.is_delivery_receipt:
+            return None
+        if message.short_message is None:
+            raise ValueError("the delivery receipt has no text, and thus no message_id")
+        return protocol.parse_delivery_receipt(message.short_message).message_id
+
+    async def _call_deliver_sm_handler(self, message: protocol.DeliverSM) -> int:
+        handler = self._deliver_sm_handler_for(message.destination_addr)
+        if handler is None:
+            # no handler is registered for the destination address of the message.
+            return SmppCommandStatus.ESME_ROK.value
+        command_status = await handler(message)
+        if command_status is None:
+            return SmppCommandStatus.ESME_ROK.value
+        if not isinstance(command_status, CommandStatus):
+            raise ValueError(
+                "the deliver_sm handler should return `None` or a `naz.CommandStatus` It returned: {0}".format(
+                    type(command_status)
+                )
+            )
+        return command_status.value
+
+    def _reassemble(self, message: protocol.DeliverSM) -> typing.Union[None, protocol.DeliverSM]:
+        """
+        buffers the parts of a concatenated message.
+        It returns the whole message once all its parts have arrived, None if some are still outstanding,
+        and `message` itself if it is not a part of a concatenated message.
+        """
+        concat_info = message._concat_info()
+        if concat_info is None:
+            return message
+        reference_number, total_parts, part_number, user_data = concat_info
+        key = (message.source_addr, message.destination_addr, reference_number)
+        if key not in self._fragments:
+            self._fragments[key] = {}
+            self._fragment_timers[key] = asyncio.ensure_future(self._expire_fragments(key))
+        self._fragments[key][part_number] = (message, user_data)
+        if len(self._fragments[key]) < total_parts:
+            return None
+
+        self._fragment_timers.pop(key).cancel()
+        return self._join_fragments(self._fragments.pop(key), incomplete=False)
+
+    def _schedule_retry(
+        self,
+        sequence_number: int,
+        smpp_command: str,
+        command_status: CommandStatus,
+        command_status_value: int,
+    ) -> bool:
+        """
+        asks the :attr:`retry_policy <Client.retry_policy>` whether the message that SMSC has responded to should be re-submitted,
+        and if so, re-submits it in the background. see: `Client._retry_message`
+        It returns True if the message is being re-submitted; the broker is then acknowledged once the message has been re-enqueued.
+        Of a message that was split into many parts, only the part that failed is re-submitted. It keeps its concatenation reference number,
+        so that the recipient's phone joins it with the other parts, which are not sent again.
+        """
+        if sequence_number not in self._retryable:
+            return False
+        proto_msg = self._retryable.pop(sequence_number)
+        if command_status_value == SmppCommandStatus.ESME_ROK.value:
+            return False
+
+        try:
+            retry_after = self.retry_policy.retry_after(  # type: ignore
+                smpp_command=proto_msg.smpp_command,
+                command_status_value=command_status_value,
+                attempt=proto_msg.attempt,  # type: ignore
+            )
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._schedule_retry",
+                    "stage": "end",
+                    "smpp_command": smpp_command,
+                    "log_id": proto_msg.log_id,
+                    "state": "retry_policy error",
+                    "error": repr(e),
+                },
+            )
+            return False
+        if retry_after is None:
+            return False
+
+        task = asyncio.ensure_future(
+            self._retry_message(
+                proto_msg=proto_msg,
+                retry_after=retry_after,
+                command_status=command_status,
+                sequence_number=sequence_number,
+            )
+        )
+        self._retry_tasks.add(task)
+        task.add_done_callback(self._retry_tasks.discard)
+        return True
+
+    async def _retry_message(
+        self,
+        proto_msg: protocol.Message,
+        retry_after: float,
+        command_status: CommandStatus,
+        sequence_number: int,
+    ) -> None:
+        """
+        re-enqueues a message that SMSC failed, after waiting `retry_after` seconds.
+        """
+        proto_msg.attempt += 1  # type: ignore
+        self._log(
+            logging.WARNING,
+            {
+                "event": "naz.Client._retry_message",
+                "stage": "start",
+                "smpp_command": proto_msg.smpp_command,
+                "log_id": proto_msg.log_id,
+                "state": "re-submitting message in {0:.2f} seconds".format(retry_after),
+                "command_status": command_status.code,
+                "attempt": proto_msg.attempt,  # type: ignore
+            },
+        )
+        try:
+            await self.hook.retrying(
+                smpp_command=proto_msg.smpp_command,
+                log_id=proto_msg.log_id,
+                hook_metadata=proto_msg.hook_metadata,
+                status=command_status,
+                attempt=proto_msg.attempt,  # type: ignore
+            )
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._retry_message",
+                    "stage": "end",
+                    "log_id": proto_msg.log_id,
+                    "state": "retrying hook error",
+                    "error": repr(e),
+                },
+            )
+
+        await asyncio.sleep(retry_after)
+        try:
+            await self.broker.enqueue(proto_msg)
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._retry_message",
+                    "stage": "end",
+                    "log_id": proto_msg.log_id,
+                    "state": "broker error",
+                    "error": repr(e),
+                },
+            )
+            return
+        # the message that failed need not be replayed, now that it has been re-enqueued.
+        await self._acknowledge(log_id=proto_msg.log_id, sequence_number=sequence_number)
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client._retry_message",
+                "stage": "end",
+                "smpp_command": proto_msg.smpp_command,
+                "log_id": proto_msg.log_id,
+                "attempt": proto_msg.attempt,  # type: ignore
+            },
+        )
+
+    async def _acknowledge(
+        self, log_id: str, sequence_number: typing.Union[None, int] = None
+    ) -> None:
+        """
+        tells the :attr:`broker <Client.broker>` that the message with the given log_id need not be replayed; see :func:`naz.broker.BaseBroker.acknowledge`
+        A message that was sent as many parts is only acknowledged once SMSC has responded to the last of them.
+
+        Parameters:
+            log_id: the log_id of the message.
+            sequence_number: the sequence_number of the part of the message that SMSC responded to. \
+                It is None for a message that SMSC does not respond to, eg a `deliver_sm_resp`
+        """
+        if sequence_number is not None:
+            parts = self._unacknowledged_parts.pop(sequence_number, None)
+            if parts is None:
+                # eg; it was not dequeued from the broker, or it was reclaimed from the window.
+                return None
+            parts.discard(sequence_number)
+            if parts:
+                # SMSC is yet to respond to the other parts of the message.
+                return None
+        try:
+            await self.broker.acknowledge(log_id)
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._acknowledge",
+                    "stage": "end",
+                    "log_id": log_id,
+                    "state": "broker acknowledge error",
+                    "error": repr(e),
+                },
+            )
+
+    async def _expire_fragments(self, key: typing.Tuple[str, str, int]) -> None:
+        """
+        calls the deliver_sm handler with the parts of a concatenated message that arrived,
+        if the rest have not arrived within `reassembly_timeout`.
+        The `deliver_sm_resp` of each part has already been sent, so the handler's command_status is not used.
+        """
+        await asyncio.sleep(self.reassembly_timeout)  # type: ignore
+        self._fragment_timers.pop(key, None)
+        parts = self._fragments.pop(key, {})
+        if not parts:
+            return
+        message = self._join_fragments(parts, incomplete=True)
+        self._log(
+            logging.WARNING,
+            {
+                "event": "naz.Client._expire_fragments",
+                "stage": "start",
+                "smpp_command": SmppCommand.DELIVER_SM,
+                "log_id": message.log_id,
+                "state": "some parts of a concatenated message did not arrive within reassembly_timeout",
+                "parts_received": sorted(parts.keys()),
+            },
+        )
+        try:
+            await self._call_deliver_sm_handler(message)
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._expire_fragments",
+                    "stage": "end",
+                    "smpp_command": SmppCommand.DELIVER_SM,
+                    "log_id": message.log_id,
+                    "state": "deliver_sm handler error",
+                    "error": repr(e),
+                },
+            )
+
+    @staticmethod
+    def _join_fragments(
+        parts: typing.Dict[int, typing.Tuple[protocol.DeliverSM, bytes]], incomplete: bool
+    ) -> protocol.DeliverSM:
+        """
+        joins, in order of their part numbers, the parts of a concatenated message into one message.
+        """
+        ordered = [parts[part_number] for part_number in sorted(parts)]
+        first = ordered[0][0]
+        raw_short_message = b"".join(user_data for _, user_data in ordered)
+        short_message = None
+        if first.encoding:
+            # an empty encoding means the data_coding of the parts has no text codec.
+            short_message = the_codec._codec_info(first.encoding).decode(raw_short_message)[0]
+        return protocol.DeliverSM(
+            log_id=first.log_id,
+            sequence_number=first.sequence_number,
+            short_message=short_message,
+            source_addr=first.source_addr,
+            destination_addr=first.destination_addr,
+            service_type=first.service_type,
+            source_addr_ton=first.source_addr_ton,
+            source_addr_npi=first.source_addr_npi,
+            dest_addr_ton=first.dest_addr_ton,
+            dest_addr_npi=first.dest_addr_npi,
+            # the reassembled message no longer has a UDH.
+            esm_class=first.esm_class & 0b10111111,
+            protocol_id=first.protocol_id,
+            priority_flag=first.priority_flag,
+            registered_delivery=first.registered_delivery,
+            data_coding=first.data_coding,
+            encoding=first.encoding,
+            optional_params=[
+                tlv for tlv in first.optional_params if tlv.tag not in protocol._SAR_TAGS
+            ],
+            hook_metadata=first.hook_metadata,
+            raw_short_message=raw_short_message,
+            incomplete=incomplete,
+        )
+
+    def _deduplicate(self, log_id: str) -> None:
+        """
+        raises NazDuplicateMessageError if a message with the same log_id was sent within the `dedup_window`
+        """
+        if self.dedup_window is None:
+            return None
+        now = time.monotonic()
+        # forget the log_id's that are older than the window, so that the memory used does not grow unbounded.
+        while self._recent_log_ids:
+            oldest_log_id, sent_at = next(iter(self._recent_log_ids.items()))
+            if now - sent_at < self.dedup_window:
+                break
+            self._recent_log_ids.pop(oldest_log_id)
+        if log_id in self._recent_log_ids:
+            self._log(
+                logging.WARNING,
+                {
+                    "event": "naz.Client.send_message",
+                    "stage": "end",
+                    "log_id": log_id,
+                    "state": "duplicate message",
+                },
+            )
+            raise NazDuplicateMessageError(log_id=log_id, dedup_window=self.dedup_window)
+        self._recent_log_ids[log_id] = now
+
+    # this method just enqueues a submit_sm msg to queue
+    async def send_message(
+        self, proto_msg: typing.Union[protocol.SubmitSM, protocol.DataSM]
+    ) -> None:
+        """
+        Sends a message/SUBMIT_SM(or DATA_SM) to SMSC.
+        That message will get enqueued to :attr:`broker <Client.broker>` and later on sent to SMSC.
+        It can be cancelled, or given a deadline, using `asyncio.wait_for`; eg if the broker is full and blocks.
+
+        Parameters:
+            proto_msg: the message to send to SMSC.
+                       Has to be a class instance of :class:`naz.protocol.SubmitSM <naz.protocol.SubmitSM>`
+                       or :class:`naz.protocol.DataSM <naz.protocol.DataSM>`
+
+        Raises:
+            NazMessageTooLongError: raised, before the message is enqueued, if it is too long to send.
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            import naz
+
+            broker = naz.broker.SimpleBroker(maxsize=1000)
+            client = naz.Client(
+                    smsc_host="127.0.0.1",
+                    smsc_port=2775,
+                    system_id="smppclient1",
+                    password=os.getenv("password", "password"),
+                    broker=broker,
+                )
+            msg = naz.protocol.SubmitSM(
+                short_message="hello world",
+                source_addr="255700111222",
+                destination_addr="255799000888",
+                log_id="some-id",
+            )
+            await client.send_message(msg)
+        """
+        if not isinstance(proto_msg, (protocol.SubmitSM, protocol.DataSM)):
+            raise ValueError(
+                "`proto_msg` should be of type:: `naz.protocol.SubmitSM` or `naz.protocol.DataSM` You entered: {0}".format(
+                    type(proto_msg)
+                )
+            )
+        self._validate_bind_mode("send_message", [BindMode.TRANSMITTER, BindMode.TRANSCEIVER])
+        # fail early, rather than after the message has been dequeued, if its addresses are not valid E.164 numbers
+        # or if it is too long to send. A message that is split into parts always fits.
+        self._addresses(proto_msg)
+        if isinstance(proto_msg, protocol.SubmitSM) and not self._splittable(proto_msg):
+            self._encode_short_message(proto_msg)
+        if self._shutting_down:
+            raise NazConnectionError("unable to send_message; naz is shutting down.")
+        await self._await_reconnection("send_message")
+        self._deduplicate(proto_msg.log_id)
+        smpp_command = proto_msg.smpp_command
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.send_message",
+                "stage": "start",
+                "log_id": proto_msg.log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+        try:
+            await self.broker.enqueue(proto_msg)
+            self._queue_depth_metric()
+        except asyncio.CancelledError:
+            self._recent_log_ids.pop(proto_msg.log_id, None)
+            raise
+        except Exception as e:
+            # the message was not sent; so it can be sent again.
+            self._recent_log_ids.pop(proto_msg.log_id, None)
+            self._log(
                 logging.ERROR,
                 {
-                    "event": "naz.Client.tranceiver_bind",
+                    "event": "naz.Client.send_message",
                     "stage": "end",
                     "error": repr(e),
+                    "log_id": proto_msg.log_id,
                     "smpp_command": smpp_command,
                 },
             )
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.send_message",
+                "stage": "end",
+                "log_id": proto_msg.log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+
+    async def submit_message(
+        self, proto_msg: typing.Union[protocol.SubmitSM, protocol.DataSM]
+    ) -> typing.List[str]:
+        """
+        Sends a message/SUBMIT_SM(or DATA_SM) to SMSC and returns the message_id(s) that SMSC assigned to it.
+        Unlike :func:`send_message <Client.send_message>`, the message is not queued in the broker,
+        it is sent straight away and this method waits for the SMSC's response.
+
+        If the message is split into multiple parts(see :attr:`split_long_messages <Client.split_long_messages>`),
+        the parts are sent one after the other and the message_id of each part is returned, in the order of the parts.
+
+        Parameters:
+            proto_msg: the message to send to SMSC.
+                       Has to be a class instance of :class:`naz.protocol.SubmitSM <naz.protocol.SubmitSM>`
+                       or :class:`naz.protocol.DataSM <naz.protocol.DataSM>`
+
+        Returns:
+            the message_id's that SMSC assigned to the message. They can be used to correlate delivery receipts, or to query/cancel/replace the message.
+
+        Raises:
+            NazCommandStatusError: raised if the SMSC responds with an error. eg; `ESME_RINVDSTADR`
+            asyncio.TimeoutError: raised if the SMSC does not respond within :attr:`response_timeout <Client.response_timeout>`
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            msg = naz.protocol.SubmitSM(
+                short_message="hello world",
+                source_addr="255700111222",
+                destination_addr="255799000888",
+                log_id="some-id",
+            )
+            message_ids = await client.submit_message(msg)
+        """
+        if not isinstance(proto_msg, (protocol.SubmitSM, protocol.DataSM)):
+            raise ValueError(
+                "`proto_msg` should be of type:: `naz.protocol.SubmitSM` or `naz.protocol.DataSM` You entered: {0}".format(
+                    type(proto_msg)
+                )
+            )
+        self._validate_bind_mode("submit_message", [BindMode.TRANSMITTER, BindMode.TRANSCEIVER])
+        if self._shutting_down:
+            raise NazConnectionError("unable to submit_message; naz is shutting down.")
+        await self._await_reconnection("submit_message")
+        smpp_command = proto_msg.smpp_command
+        log_id = proto_msg.log_id
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.submit_message",
+                "stage": "start",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+
+        if isinstance(proto_msg, protocol.SubmitSM):
+            full_pdus = await self._build_submit_sm_pdus(proto_msg)
+        else:
+            full_pdus = [await self._build_data_sm_pdu(proto_msg)]
+
+        message_ids = []
+        for full_pdu in full_pdus:
+            await self._limit_bytes("naz.Client.submit_message", log_id, full_pdu)
+            commandStatus, body_data = await self._send_pdu_and_await_response(
+                smpp_command=smpp_command,
+                full_pdu=full_pdu,
+                log_id=log_id,
+                hook_metadata=proto_msg.hook_metadata,
+            )
+            if commandStatus.value != SmppCommandStatus.ESME_ROK.value:
+                raise NazCommandStatusError(smpp_command=smpp_command, command_status=commandStatus)
+            # the body of submit_sm_resp/data_sm_resp starts with the message_id
+            smsc_message_id, _ = protocol._read_c_octet_string(body_data, 0)
+            message_ids.append(smsc_message_id)
+
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.submit_message",
+                "stage": "end",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+                "message_ids": message_ids,
+            },
+        )
+        return message_ids
+
+    async def _limit_bytes(self, event: str, log_id: str, full_pdu: bytes) -> None:
+        """
+        waits, if there is a `byte_rate_limiter`, until `full_pdu` can be sent without exceeding the byte rate.
+        """
+        if self.byte_rate_limiter is None:
+            return None
+        try:
+            await self.byte_rate_limiter.limit(len(full_pdu))
+        except asyncio.CancelledError:
+            raise
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": event,
+                    "stage": "end",
+                    "log_id": log_id,
+                    "state": "byte rate limiter error",
+                    "error": repr(e),
+                },
+            )
+
+    async def submit_batch(
+        self, proto_msgs: typing.List[typing.Union[protocol.SubmitSM, protocol.DataSM]]
+    ) -> typing.List[SubmitResult]:
+        """
+        Sends many messages to SMSC and returns the result of each, in the order of the messages.
+        Each message is sent using :func:`submit_message <Client.submit_message>`, at the pace of the :attr:`rate_limiter <Client.rate_limiter>`.
+        The messages do not wait for each other's responses; the number that are awaiting a response is bounded by :attr:`window_size <Client.window_size>`, if it is set.
+
+        A message that fails does not fail the batch; its error is returned in its result.
+        If this method is cancelled, the messages that have not yet been responded to are cancelled too.
+
+        Parameters:
+            proto_msgs: the messages to send to SMSC.
+                        Each has to be a class instance of :class:`naz.protocol.SubmitSM <naz.protocol.SubmitSM>`
+                        or :class:`naz.protocol.DataSM <naz.protocol.DataSM>`
+
+        Returns:
+            a :class:`naz.SubmitResult <naz.state.SubmitResult>` for each of the messages.
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            results = await client.submit_batch(campaign_messages)
+            failed = [result for result in results if result.error is not None]
+        """
+        if not isinstance(proto_msgs, list):
+            raise ValueError(
+                "`proto_msgs` should be of type:: `list` You entered: {0}".format(type(proto_msgs))
+            )
+        # fail the whole batch, rather than each of its messages, if the client cannot send messages.
+        self._validate_bind_mode("submit_batch", [BindMode.TRANSMITTER, BindMode.TRANSCEIVER])
+        self._log(
+            logging.INFO,
+            {"event": "naz.Client.submit_batch", "stage": "start", "batch_size": len(proto_msgs)},
+        )
+        tasks: typing.List[asyncio.Future] = []
+        try:
+            for proto_msg in proto_msgs:
+                try:
+                    # rate limit ourselves
+                    await self.rate_limiter.limit()
+                except asyncio.CancelledError:
+                    raise
+                except Exception as e:
+                    self._log(
+                        logging.ERROR,
+                        {
+                            "event": "naz.Client.submit_batch",
+                            "stage": "end",
+                            "state": "rate limiter error",
+                            "error": repr(e),
+                        },
+                    )
+                tasks.append(asyncio.ensure_future(self.submit_message(proto_msg)))
+            if tasks:
+                await asyncio.wait(tasks)
+        except asyncio.CancelledError:
+            for task in tasks:
+                task.cancel()
+            self._log(
+                logging.WARNING,
+                {
+                    "event": "naz.Client.submit_batch",
+                    "stage": "end",
+                    "state": "batch was cancelled after {0} of {1} messages were sent".format(
+                        len(tasks), len(proto_msgs)
+                    ),
+                },
+            )
+            raise
+
+        results = []
+        failed = 0
+        for proto_msg, task in zip(proto_msgs, tasks):
+            log_id = getattr(proto_msg, "log_id", "")
+            error = asyncio.CancelledError() if task.cancelled() else task.exception()
+            if error is not None:
+                failed += 1
+                results.append(SubmitResult(log_id=log_id, message_ids=[], error=error))
+            else:
+                results.append(SubmitResult(log_id=log_id, message_ids=task.result(), error=None))
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.submit_batch",
+                "stage": "end",
+                "batch_size": len(proto_msgs),
+                "failed": failed,
+            },
+        )
+        return results
+
+    async def submit_multi(
+        self,
+        short_message: str,
+        source_addr: str,
+        destination_addrs: typing.List[str],
+        distribution_lists: typing.Union[None, typing.List[str]] = None,
+        service_type: str = "CMT",
+        source_addr_ton: int = 0x00000001,
+        source_addr_npi: int = 0x00000001,
+        dest_addr_ton: int = 0x00000001,
+        dest_addr_npi: int = 0x00000001,
+        esm_class: int = 0b00000011,
+        protocol_id: int = 0x00000000,
+        priority_flag: int = 0x00000000,
+        schedule_delivery_time: str = "",
+        validity_period: str = "",
+        registered_delivery: int = 0b00000001,
+        replace_if_present_flag: int = 0x00000000,
+        sm_default_msg_id: int = 0x00000000,
+        encoding: str = "gsm0338",
+        errors: str = "strict",
+        optional_params: typing.Union[None, typing.List[TLV]] = None,
+        log_id: str = "",
+    ) -> SubmitMultiResult:
+        """
+        Sends one message to many destinations, in a single `submit_multi`, and returns the message_id that SMSC assigned to it
+        together with the destinations that SMSC could not deliver it to.
+        The request is sent straight away(it is not queued in the broker) and this method waits for the SMSC's response.
+
+        Parameters:
+            short_message: the message to send.
+            source_addr: the address of the SME that originated the message.
+            destination_addrs: the addresses of the SMEs to send the message to.
+            distribution_lists: the names of the distribution lists, that are defined on the SMSC, to send the message to.
+            service_type: Indicates the SMS Application service associated with the message
+            source_addr_ton: Type of Number of the source_addr.
+            source_addr_npi: Numbering Plan Identity of the source_addr.
+            dest_addr_ton: Type of Number of each of the `destination_addrs`
+            dest_addr_npi: Numbering Plan Identity of each of the `destination_addrs`
+            esm_class: Indicates Message Mode & Message Type.
+            protocol_id: Protocol Identifier. Network specific field.
+            priority_flag: Designates the priority level of the message.
+            schedule_delivery_time: The short message is to be scheduled by the SMSC for delivery. NULL for immediate delivery.
+            validity_period: The validity period of this message. NULL to use the SMSC default.
+            registered_delivery: Indicator to signify if an SMSC delivery receipt or an SME acknowledgement is required.
+            replace_if_present_flag: Flag indicating if submitted message should replace an existing message.
+            sm_default_msg_id: SMSC index of a pre-defined(`canned`) message.
+            encoding: `encoding <https://docs.python.org/3/library/codecs.html#standard-encodings>`_ used to encode the message.
+            errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+            optional_params: the optional parameters of the `submit_multi`
+            log_id: a unique identify of this request
+
+        Returns:
+            a :class:`naz.SubmitMultiResult <naz.state.SubmitMultiResult>`
+
+        Raises:
+            ValueError: raised if there are no destinations, more than 254 of them, or the message does not fit in one SMS.
+            NazCommandStatusError: raised if the SMSC responds with an error. eg; `ESME_RINVNUMDESTS`
+            asyncio.TimeoutError: raised if the SMSC does not respond within :attr:`response_timeout <Client.response_timeout>`
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            result = await client.submit_multi(
+                short_message="hello",
+                source_addr="255700111222",
+                destination_addrs=["255799000888", "255799000999"],
+            )
+            for unsuccess_sme in result.unsuccess_smes:
+                print(unsuccess_sme.destination_addr, naz.status_name(unsuccess_sme.error_status_code))
+        """
+        self._validate_bind_mode("submit_multi", [BindMode.TRANSMITTER, BindMode.TRANSCEIVER])
+        if distribution_lists is None:
+            distribution_lists = []
+        for name, value in [
+            ("destination_addrs", destination_addrs),
+            ("distribution_lists", distribution_lists),
+        ]:
+            if not isinstance(value, list) or not all(isinstance(i, str) and i for i in value):
+                raise ValueError(
+                    "`{0}` should be a list of non-empty `str` You entered: {1}".format(name, value)
+                )
+        number_of_dests = len(destination_addrs) + len(distribution_lists)
+        if not (1 <= number_of_dests <= 254):
+            # see section 4.5.1 of smpp ver 3.4 spec document
+            raise ValueError(
+                "`destination_addrs` and `distribution_lists` should have between 1 and 254 destinations in total. You entered: {0}".format(
+                    number_of_dests
+                )
+            )
+        if the_codec.segment_count(short_message, encoding) > 1:
+            raise ValueError(
+                "`short_message` should fit in one SMS since `submit_multi` does not support concatenation. You entered a message of length: {0}".format(
+                    len(short_message)
+                )
+            )
+        if optional_params is None:
+            optional_params = []
+        for tlv in optional_params:
+            TLV._validate(tlv)
 
-        if sequence_number > self.max_sequence_number:
-            # prevent third party sequence_generators from ruining our party
+        smpp_command = SmppCommand.SUBMIT_MULTI
+        if not log_id:
+            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.submit_multi",
+                "stage": "start",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+                "number_of_dests": number_of_dests,
+            },
+        )
+        encoded_short_message, _ = the_codec._codec_info(encoding).encode(short_message, errors)
+
+        # dest_address, one for each destination
+        # dest_flag, int, 1octet; 0x01 for an SME address and 0x02 for a distribution list name
+        # for an SME address; dest_addr_ton, int, 1octet. dest_addr_npi, int, 1octet. destination_addr, c-octet str, max 21octet
+        # for a distribution list; dl_name, c-octet str, max 21octet
+        # see section 5.2.25 of smpp ver 3.4 spec document
+        dest_addresses = b"".join(
+            struct.pack(">BBB", 0x01, dest_addr_ton, dest_addr_npi)
+            + destination_addr.encode("ascii")
+            + chr(0).encode("ascii")
+            for destination_addr in destination_addrs
+        ) + b"".join(
+            struct.pack(">B", 0x02) + dl_name.encode("ascii") + chr(0).encode("ascii")
+            for dl_name in distribution_lists
+        )
+
+        # body
+        # service_type, c-octet str, max 6octet
+        # source_addr_ton, int, 1octet
+        # source_addr_npi, int, 1octet
+        # source_addr, c-octet str, max 21octet
+        # number_of_dests, int, 1octet
+        # dest_address(es), see above
+        # esm_class, int, 1octet
+        # protocol_id, int, 1octet
+        # priority_flag, int, 1octet
+        # schedule_delivery_time, c-octet str, 1 or 17 octets
+        # validity_period, c-octet str, 1 or 17 octets
+        # registered_delivery, int, 1octet
+        # replace_if_present_flag, int, 1octet
+        # data_coding, int, 1octet
+        # sm_default_msg_id, int, 1octet
+        # sm_length, int, 1octet
+        # short_message, Octet-String, 0-254 octets
+        # see section 4.5.1 of smpp ver 3.4 spec document
+        body = (
+            service_type.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", source_addr_ton)
+            + struct.pack(">B", source_addr_npi)
+            + source_addr.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", number_of_dests)
+            + dest_addresses
+            + struct.pack(">B", esm_class)
+            + struct.pack(">B", protocol_id)
+            + struct.pack(">B", priority_flag)
+            + schedule_delivery_time.encode("ascii")
+            + chr(0).encode("ascii")
+            + validity_period.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", registered_delivery)
+            + struct.pack(">B", replace_if_present_flag)
+            + struct.pack(">B", the_codec._find_data_coding(encoding).value)
+            + struct.pack(">B", sm_default_msg_id)
+            + struct.pack(">B", len(encoded_short_message))
+            + encoded_short_message
+            + b"".join(tlv.tlv for tlv in optional_params)
+        )
+        body_data = await self._send_and_await_response(
+            smpp_command=smpp_command, body=body, log_id=log_id
+        )
+
+        # submit_multi_resp body; message_id, no_unsuccess, unsuccess_sme(s)
+        # see section 4.5.2 of smpp ver 3.4 spec document
+        message_id, offset = protocol._read_c_octet_string(body_data, 0)
+        result = SubmitMultiResult(
+            message_id=message_id,
+            unsuccess_smes=protocol._read_unsuccess_smes(body_data, offset),
+        )
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.submit_multi",
+                "stage": "end",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+                "message_id": message_id,
+                "no_unsuccess": len(result.unsuccess_smes),
+            },
+        )
+        return result
+
+    async def query_message(
+        self,
+        message_id: str,
+        source_addr: str,
+        source_addr_ton: int = 0x00000001,
+        source_addr_npi: int = 0x00000001,
+        log_id: str = "",
+    ) -> QueryResult:
+        """
+        Query the SMSC about the state of a previously submitted message.
+        Unlike :func:`send_message <Client.send_message>`, the `query_sm` request is not queued in the broker,
+        it is sent straight away and this method waits for the SMSC's response.
+
+        Parameters:
+            message_id: the message ID allocated by the SMSC to the message when it was submitted.
+            source_addr: the source address that was used when the message was submitted.
+            source_addr_ton: Type of Number of the source_addr.
+            source_addr_npi: Numbering Plan Identity of the source_addr.
+            log_id: a unique identify of this request
+
+        Raises:
+            NazCommandStatusError: raised if the SMSC responds with an error.
+            asyncio.TimeoutError: raised if the SMSC does not respond within :attr:`response_timeout <Client.response_timeout>`
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            result = await client.query_message(message_id="some-smsc-id", source_addr="255700111222")
+            if result.message_state == naz.SmppMessageState.DELIVERED:
+                print("delivered at: ", result.final_date)
+        """
+        self._validate_bind_mode("query_message", [BindMode.TRANSMITTER, BindMode.TRANSCEIVER])
+        smpp_command = SmppCommand.QUERY_SM
+        if not log_id:
+            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.query_message",
+                "stage": "start",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+                "message_id": message_id,
+            },
+        )
+
+        # body
+        # message_id, c-octet str, max 65octet
+        # source_addr_ton, int, 1octet
+        # source_addr_npi, int, 1octet
+        # source_addr, c-octet str, max 21octet
+        # see section 4.8.1 of smpp ver 3.4 spec document
+        body = (
+            message_id.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", source_addr_ton)
+            + struct.pack(">B", source_addr_npi)
+            + source_addr.encode("ascii")
+            + chr(0).encode("ascii")
+        )
+        body_data = await self._send_and_await_response(
+            smpp_command=smpp_command, body=body, log_id=log_id
+        )
+
+        # query_sm_resp body; message_id, final_date, message_state, error_code
+        # see section 4.8.2 of smpp ver 3.4 spec document
+        _message_id, offset = protocol._read_c_octet_string(body_data, 0)
+        final_date, offset = protocol._read_c_octet_string(body_data, offset)
+        message_state, error_code = struct.unpack(">BB", body_data[offset : offset + 2])
+        result = QueryResult(
+            message_id=_message_id,
+            final_date=final_date,
+            message_state=SmppMessageState._find_message_state(message_state),
+            error_code=error_code,
+        )
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.query_message",
+                "stage": "end",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+                "message_id": message_id,
+                "message_state": result.message_state.code,
+            },
+        )
+        return result
+
+    async def cancel_message(
+        self,
+        message_id: str,
+        source_addr: str,
+        destination_addr: str,
+        service_type: str = "",
+        source_addr_ton: int = 0x00000001,
+        source_addr_npi: int = 0x00000001,
+        dest_addr_ton: int = 0x00000001,
+        dest_addr_npi: int = 0x00000001,
+        log_id: str = "",
+    ) -> None:
+        """
+        Cancel a previously submitted message that is still pending delivery at the SMSC.
+        The `cancel_sm` request is sent straight away(it is not queued in the broker) and this method waits for the SMSC's response.
+
+        Parameters:
+            message_id: the message ID allocated by the SMSC to the message when it was submitted.
+            source_addr: the source address that was used when the message was submitted.
+            destination_addr: the destination address that was used when the message was submitted.
+            service_type: the service_type that was used when the message was submitted.
+            source_addr_ton: Type of Number of the source_addr.
+            source_addr_npi: Numbering Plan Identity of the source_addr.
+            dest_addr_ton: Type of Number of the destination_addr.
+            dest_addr_npi: Numbering Plan Identity of the destination_addr.
+            log_id: a unique identify of this request
+
+        Raises:
+            NazCommandStatusError: raised if the SMSC responds with an error. eg; `ESME_RCANCELFAIL`
+            asyncio.TimeoutError: raised if the SMSC does not respond within :attr:`response_timeout <Client.response_timeout>`
+        """
+        self._validate_bind_mode("cancel_message", [BindMode.TRANSMITTER, BindMode.TRANSCEIVER])
+        smpp_command = SmppCommand.CANCEL_SM
+        if not log_id:
+            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.cancel_message",
+                "stage": "start",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+                "message_id": message_id,
+            },
+        )
+
+        # body
+        # service_type, c-octet str, max 6octet
+        # message_id, c-octet str, max 65octet
+        # source_addr_ton, int, 1octet
+        # source_addr_npi, int, 1octet
+        # source_addr, c-octet str, max 21octet
+        # dest_addr_ton, int, 1octet
+        # dest_addr_npi, int, 1octet
+        # destination_addr, c-octet str, max 21octet
+        # see section 4.9.1 of smpp ver 3.4 spec document
+        body = (
+            service_type.encode("ascii")
+            + chr(0).encode("ascii")
+            + message_id.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", source_addr_ton)
+            + struct.pack(">B", source_addr_npi)
+            + source_addr.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", dest_addr_ton)
+            + struct.pack(">B", dest_addr_npi)
+            + destination_addr.encode("ascii")
+            + chr(0).encode("ascii")
+        )
+        # cancel_sm_resp has no body
+        await self._send_and_await_response(smpp_command=smpp_command, body=body, log_id=log_id)
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.cancel_message",
+                "stage": "end",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+                "message_id": message_id,
+            },
+        )
+
+    async def replace_message(
+        self,
+        message_id: str,
+        source_addr: str,
+        short_message: str,
+        source_addr_ton: int = 0x00000001,
+        source_addr_npi: int = 0x00000001,
+        schedule_delivery_time: str = "",
+        validity_period: str = "",
+        registered_delivery: int = 0b00000001,
+        sm_default_msg_id: int = 0x00000000,
+        encoding: str = "gsm0338",
+        errors: str = "strict",
+        log_id: str = "",
+    ) -> None:
+        """
+        Replace a previously submitted message that is still pending delivery at the SMSC.
+        The `replace_sm` request is sent straight away(it is not queued in the broker) and this method waits for the SMSC's response.
+
+        Parameters:
+            message_id: the message ID allocated by the SMSC to the message when it was submitted.
+            source_addr: the source address that was used when the message was submitted.
+            short_message: the new message.
+            source_addr_ton: Type of Number of the source_addr.
+            source_addr_npi: Numbering Plan Identity of the source_addr.
+            schedule_delivery_time: the new scheduled delivery time. NULL to keep the original one.
+            validity_period: the new expiration time. NULL to keep the original one.
+            registered_delivery: Indicator to signify if an SMSC delivery receipt or an SME acknowledgement is required.
+            sm_default_msg_id: SMSC index of a pre-defined(`canned`) message.
+            encoding: `encoding <https://docs.python.org/3/library/codecs.html#standard-encodings>`_ used to encode the new message. \
+                It should be the same encoding that was used when the message was submitted since `replace_sm` can not change the data_coding.
+            errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+            log_id: a unique identify of this request
+
+        Raises:
+            ValueError: raised if the new message does not fit in one SMS. `replace_sm` does not support concatenation.
+            NazCommandStatusError: raised if the SMSC responds with an error. eg; `ESME_RREPLACEFAIL`
+            asyncio.TimeoutError: raised if the SMSC does not respond within :attr:`response_timeout <Client.response_timeout>`
+        """
+        self._validate_bind_mode("replace_message", [BindMode.TRANSMITTER, BindMode.TRANSCEIVER])
+        smpp_command = SmppCommand.REPLACE_SM
+        if not log_id:
+            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.replace_message",
+                "stage": "start",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+                "message_id": message_id,
+            },
+        )
+
+        if the_codec.segment_count(short_message, encoding) > 1:
             raise ValueError(
-                "the sequence_number: {0} is greater than the max: {1} allowed by SMPP spec.".format(
-                    sequence_number, self.max_sequence_number
+                "`short_message` should fit in one SMS since `replace_sm` does not support concatenation. You entered a message of length: {0}".format(
+                    len(short_message)
                 )
             )
+        encoded_short_message, _ = the_codec._codec_info(encoding).encode(short_message, errors)
 
-        # associate sequence_number with log_id.
-        # this will enable us to also associate responses and thus enhancing traceability of all workflows
-        try:
-            await self.correlation_handler.put(
-                smpp_command=smpp_command,
-                sequence_number=sequence_number,
-                log_id=log_id,
-                hook_metadata="",
-            ),
-        except Exception as e:
-            self._log(
-                logging.ERROR,
-                {
-                    "event": "naz.Client.tranceiver_bind",
-                    "stage": "end",
-                    "smpp_command": smpp_command,
-                    "log_id": log_id,
-                    "state": "correlater put error",
-                    "error": repr(e),
-                },
+        # body
+        # message_id, c-octet str, max 65octet
+        # source_addr_ton, int, 1octet
+        # source_addr_npi, int, 1octet
+        # source_addr, c-octet str, max 21octet
+        # schedule_delivery_time, c-octet str, 1 or 17 octets
+        # validity_period, c-octet str, 1 or 17 octets
+        # registered_delivery, int, 1octet
+        # sm_default_msg_id, int, 1octet
+        # sm_length, int, 1octet
+        # short_message, Octet-String, 0-254 octets
+        # see section 4.10.1 of smpp ver 3.4 spec document
+        body = (
+            message_id.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", source_addr_ton)
+            + struct.pack(">B", source_addr_npi)
+            + source_addr.encode("ascii")
+            + chr(0).encode("ascii")
+            + schedule_delivery_time.encode("ascii")
+            + chr(0).encode("ascii")
+            + validity_period.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", registered_delivery)
+            + struct.pack(">B", sm_default_msg_id)
+            + struct.pack(">B", len(encoded_short_message))
+            + encoded_short_message
+        )
+        # replace_sm_resp has no body
+        await self._send_and_await_response(smpp_command=smpp_command, body=body, log_id=log_id)
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.replace_message",
+                "stage": "end",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+                "message_id": message_id,
+            },
+        )
+
+    async def broadcast_message(
+        self,
+        message: str,
+        source_addr: str,
+        broadcast_area_identifiers: typing.List[bytes],
+        broadcast_content_type: int,
+        broadcast_rep_num: int,
+        broadcast_frequency_interval: datetime.timedelta,
+        broadcast_network_type: int = 0x01,
+        service_type: str = "",
+        source_addr_ton: int = 0x00000001,
+        source_addr_npi: int = 0x00000001,
+        priority_flag: int = 0,
+        schedule_delivery_time: str = "",
+        validity_period: str = "",
+        replace_message_id: str = "",
+        encoding: str = "gsm0338",
+        errors: str = "strict",
+        optional_params: typing.Union[None, typing.List[TLV]] = None,
+        log_id: str = "",
+    ) -> str:
+        """
+        Sends a cell broadcast message/BROADCAST_SM to SMSC and returns the message_id that SMSC assigned to it.
+        `broadcast_sm` is part of SMPP v5.0; bind with an :attr:`interface_version <Client.interface_version>` of :attr:`naz.InterfaceVersion.V50 <naz.state.InterfaceVersion.V50>` if SMSC requires it.
+        The request is sent straight away(it is not queued in the broker) and this method waits for the SMSC's response.
+
+        Parameters:
+            message: the message to broadcast. It is sent in the `message_payload` optional parameter.
+            source_addr: the address of the SME that originated the broadcast.
+            broadcast_area_identifiers: the areas to broadcast to. Each is the value of a `broadcast_area_identifier` optional parameter; \
+                its first octet is the format of the area(eg 0x00 for an area name/alias), followed by the area's details. eg; `b"\\x00Nairobi"`
+            broadcast_content_type: the type of content of the broadcast, eg 0x0001 for `Emergency Broadcasts`.
+            broadcast_rep_num: the number of times that the broadcast should be repeated.
+            broadcast_frequency_interval: the time between the repetitions of the broadcast, at most 65535 weeks. A zero interval means as frequently as possible.
+            broadcast_network_type: the network that the `broadcast_content_type` is defined for; 0x00(generic), 0x01(GSM), 0x02(TDMA) or 0x03(CDMA).
+            service_type: Indicates the SMS Application service associated with the message
+            source_addr_ton: Type of Number of the source_addr.
+            source_addr_npi: Numbering Plan Identity of the source_addr.
+            priority_flag: Designates the priority level of the message, from 0(lowest) to 3(highest).
+            schedule_delivery_time: when the broadcast should start. NULL for immediate broadcast. see :func:`naz.protocol.smpp_time <naz.protocol.smpp_time>`
+            validity_period: when the broadcast should end. NULL to use the SMSC default. see :func:`naz.protocol.smpp_time <naz.protocol.smpp_time>`
+            replace_message_id: the message_id of a previous broadcast that this one replaces; empty if it does not replace any.
+            encoding: `encoding <https://docs.python.org/3/library/codecs.html#standard-encodings>`_ used to encode the message.
+            errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+            optional_params: other optional parameters of the `broadcast_sm`, eg `broadcast_channel_indicator`.
+            log_id: a unique identify of this request
+
+        Raises:
+            ValueError: raised if any of the broadcast parameters is invalid.
+            NazCommandStatusError: raised if the SMSC responds with an error.
+            asyncio.TimeoutError: raised if the SMSC does not respond within :attr:`response_timeout <Client.response_timeout>`
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            message_id = await client.broadcast_message(
+                message="There is a flood warning in your area.",
+                source_addr="112",
+                broadcast_area_identifiers=[b"\\x00Nairobi"],
+                broadcast_content_type=0x0001,
+                broadcast_rep_num=3,
+                broadcast_frequency_interval=datetime.timedelta(minutes=10),
+            )
+        """
+        self._validate_bind_mode("broadcast_message", [BindMode.TRANSMITTER, BindMode.TRANSCEIVER])
+        if not broadcast_area_identifiers or not all(
+            isinstance(area, bytes) and area for area in broadcast_area_identifiers
+        ):
+            raise ValueError(
+                "`broadcast_area_identifiers` should be a non-empty list of non-empty `bytes` You entered: {0}".format(
+                    broadcast_area_identifiers
+                )
             )
+        for name, value, maximum in [
+            ("broadcast_content_type", broadcast_content_type, 0xFFFF),
+            ("broadcast_rep_num", broadcast_rep_num, 0xFFFF),
+            ("broadcast_network_type", broadcast_network_type, 0xFF),
+            ("priority_flag", priority_flag, 3),
+        ]:
+            if not isinstance(value, int) or not (0 <= value <= maximum):
+                raise ValueError(
+                    "`{0}` should be an int between 0 and {1}. You entered: {2}".format(
+                        name, maximum, value
+                    )
+                )
+        if optional_params is None:
+            optional_params = []
+        for tlv in optional_params:
+            TLV._validate(tlv)
 
-        header = struct.pack(
-            ">IIII", command_length, command_id, command_status, sequence_number
-        )  # unsigned Int, 4octet
-        full_pdu = header + body
-        await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+        smpp_command = SmppCommand.BROADCAST_SM
+        if not log_id:
+            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._log(
+            logging.INFO,
+            {
+                "event": "naz.Client.broadcast_message",
+                "stage": "start",
+                "log_id": log_id,
+                "smpp_command": smpp_command,
+            },
+        )
+
+        encoded_message, _ = the_codec._codec_info(encoding).encode(message, errors)
+        tlvs = [
+            TLV(tag=_BROADCAST_TAGS["broadcast_area_identifier"], value=area)
+            for area in broadcast_area_identifiers
+        ] + [
+            TLV(
+                tag=_BROADCAST_TAGS["broadcast_content_type"],
+                value=struct.pack(">BH", broadcast_network_type, broadcast_content_type),
+            ),
+            TLV(
+                tag=_BROADCAST_TAGS["broadcast_rep_num"],
+                value=struct.pack(">H", broadcast_rep_num),
+            ),
+            TLV(
+                tag=_BROADCAST_TAGS["broadcast_frequency_interval"],
+                value=self._broadcast_frequency_interval(broadcast_frequency_interval),
+            ),
+            TLV(tag=OptionalTag.NAME_to_TAG["message_payload"], value=encoded_message),
+        ]
+
+        # body
+        # service_type, c-octet str, max 6octet
+        # source_addr_ton, int, 1octet
+        # source_addr_npi, int, 1octet
+        # source_addr, c-octet str, max 21octet
+        # message_id, c-octet str, max 65octet
+        # priority_flag, int, 1octet
+        # schedule_delivery_time, c-octet str, 1 or 17 octets
+        # validity_period, c-octet str, 1 or 17 octets
+        # replace_if_present_flag, int, 1octet
+        # data_coding, int, 1octet
+        # sm_default_msg_id, int, 1octet
+        # see section 4.4.1.1 of smpp ver 5.0 spec document
+        body = (
+            service_type.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", source_addr_ton)
+            + struct.pack(">B", source_addr_npi)
+            + source_addr.encode("ascii")
+            + chr(0).encode("ascii")
+            + replace_message_id.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", priority_flag)
+            + schedule_delivery_time.encode("ascii")
+            + chr(0).encode("ascii")
+            + validity_period.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", 1 if replace_message_id else 0)
+            + struct.pack(">B", the_codec._find_data_coding(encoding).value)
+            + struct.pack(">B", 0)
+            + b"".join(tlv.tlv for tlv in tlvs + optional_params)
+        )
+        body_data = await self._send_and_await_response(
+            smpp_command=smpp_command, body=body, log_id=log_id
+        )
+        # the body of broadcast_sm_resp starts with the message_id
+        # see section 4.4.1.2 of smpp ver 5.0 spec document
+        message_id, _ = protocol._read_c_octet_string(body_data, 0)
         self._log(
             logging.INFO,
             {
-                "event": "naz.Client.tranceiver_bind",
+                "event": "naz.Client.broadcast_message",
                 "stage": "end",
                 "log_id": log_id,
                 "smpp_command": smpp_command,
+                "message_id": message_id,
             },
         )
+        return message_id
 
-    async def enquire_link(self, TESTING: bool = False) -> typing.Union[None, bytes]:
+    @staticmethod
+    def _broadcast_frequency_interval(interval: datetime.timedelta) -> bytes:
         """
-        send an ENQUIRE_LINK pdu to SMSC.
-
-        Parameters:
-            TESTING: indicates whether this method is been called while running tests.
+        the value of the broadcast_frequency_interval optional parameter; a unit of time followed by a number of those units.
+        The largest unit that the interval is a whole number of is used.
+        see section 4.8.4.12 of smpp ver 5.0 spec document
         """
-        # sleep during startup so that `naz` can have had time to connect & bind
-        # we rely on `enquire_link` to kick on `re_establish_conn_bind`
-        while self.current_session_state != SmppSessionState.BOUND_TRX:
-            retry_after = self.socket_timeout
-            self._log(
-                logging.DEBUG,
-                {
-                    "event": "naz.Client.enquire_link",
-                    "stage": "start",
-                    "current_session_state": self.current_session_state,
-                    "state": "awaiting naz to change session state to `BOUND_TRX`. sleeping for {0:.2f} seconds".format(
-                        retry_after
-                    ),
-                },
-            )
-            await asyncio.sleep(retry_after)
-            if TESTING:
-                return None
-
-        smpp_command = SmppCommand.ENQUIRE_LINK
-        while True:
-            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
-            self._log(
-                logging.DEBUG,
-                {
-                    "event": "naz.Client.enquire_link",
-                    "stage": "start",
-                    "log_id": log_id,
-                    "smpp_command": smpp_command,
-                },
-            )
-            if self.SHOULD_SHUT_DOWN:
-                self._log(
-                    logging.DEBUG,
-                    {
-                        "event": "naz.Client.enquire_link",
-                        "stage": "end",
-                        "state": "client is shutdown",
-                    },
-                )
-                return None
-
-            # body
-            body = b""
-
-            # header
-            command_length = self._header_pdu_length + len(body)  # 16 is for headers
-            command_id = self.command_ids[smpp_command]
-            command_status = 0x00000000  # not used for `enquire_link`
-            try:
-                sequence_number = self.sequence_generator.next_sequence()
-            except Exception as e:
-                self._log(
-                    logging.ERROR,
-                    {
-                        "event": "naz.Client.enquire_link",
-                        "stage": "end",
-                        "error": repr(e),
-                        "log_id": log_id,
-                        "smpp_command": smpp_command,
-                    },
-                )
-            if sequence_number > self.max_sequence_number:
-                # prevent third party sequence_generators from ruining our party
-                raise ValueError(
-                    "the sequence_number: {0} is greater than the max: {1} allowed by SMPP spec.".format(
-                        sequence_number, self.max_sequence_number
-                    )
-                )
-
-            try:
-                await self.correlation_handler.put(
-                    smpp_command=smpp_command,
-                    sequence_number=sequence_number,
-                    log_id=log_id,
-                    hook_metadata="",
-                )
-            except Exception as e:
-                self._log(
-                    logging.ERROR,
-                    {
-                        "event": "naz.Client.enquire_link",
-                        "stage": "end",
-                        "smpp_command": smpp_command,
-                        "log_id": log_id,
-                        "state": "correlater put error",
-                        "error": repr(e),
-                    },
+        if not isinstance(interval, datetime.timedelta) or interval < datetime.timedelta(0):
+            raise ValueError(
+                "`broadcast_frequency_interval` should be a positive `datetime.timedelta` You entered: {0}".format(
+                    interval
                 )
-
-            header = struct.pack(
-                ">IIII", command_length, command_id, command_status, sequence_number
             )
-            full_pdu = header + body
-            # dont queue enquire_link in SimpleBroker since we dont want it to be behind 10k msgs etc
-            await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
-            self._log(
-                logging.DEBUG,
-                {
-                    "event": "naz.Client.enquire_link",
-                    "stage": "end",
-                    "log_id": log_id,
-                    "smpp_command": smpp_command,
-                },
+        seconds = int(interval.total_seconds())
+        if seconds == 0:
+            # as frequently as possible
+            return struct.pack(">BH", 0x00, 0)
+        for unit, unit_seconds in [
+            (0x0C, 7 * 24 * 60 * 60),  # weeks
+            (0x0B, 24 * 60 * 60),  # days
+            (0x0A, 60 * 60),  # hours
+            (0x09, 60),  # minutes
+            (0x08, 1),  # seconds
+        ]:
+            if seconds % unit_seconds == 0 and seconds // unit_seconds <= 0xFFFF:
+                return struct.pack(">BH", unit, seconds // unit_seconds)
+        raise ValueError(
+            "`broadcast_frequency_interval` is too long to be represented. You entered: {0}".format(
+                interval
             )
-            if TESTING:
-                return full_pdu
-            await asyncio.sleep(self.enquire_link_interval)
+        )
 
-    async def enquire_link_resp(self, sequence_number: int) -> None:
+    async def query_broadcast_message(
+        self,
+        message_id: str,
+        source_addr: str,
+        source_addr_ton: int = 0x00000001,
+        source_addr_npi: int = 0x00000001,
+        log_id: str = "",
+    ) -> BroadcastQueryResult:
         """
-        send an ENQUIRE_LINK_RESP pdu to SMSC.
+        Query the SMSC about the state of a previously submitted broadcast, using `query_broadcast_sm`.
+        The request is sent straight away(it is not queued in the broker) and this method waits for the SMSC's response.
 
         Parameters:
-            sequence_number: SMPP sequence_number
+            message_id: the message ID allocated by the SMSC to the broadcast. see :func:`broadcast_message <Client.broadcast_message>`
+            source_addr: the source address that was used when the broadcast was submitted.
+            source_addr_ton: Type of Number of the source_addr.
+            source_addr_npi: Numbering Plan Identity of the source_addr.
+            log_id: a unique identify of this request
+
+        Raises:
+            NazCommandStatusError: raised if the SMSC responds with an error. eg; `ESME_RQUERYFAIL`
+            asyncio.TimeoutError: raised if the SMSC does not respond within :attr:`response_timeout <Client.response_timeout>`
         """
-        smpp_command = SmppCommand.ENQUIRE_LINK_RESP
-        log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._validate_bind_mode(
+            "query_broadcast_message", [BindMode.TRANSMITTER, BindMode.TRANSCEIVER]
+        )
+        smpp_command = SmppCommand.QUERY_BROADCAST_SM
+        if not log_id:
+            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
         self._log(
-            logging.DEBUG,
+            logging.INFO,
             {
-                "event": "naz.Client.enquire_link_resp",
+                "event": "naz.Client.query_broadcast_message",
                 "stage": "start",
                 "log_id": log_id,
                 "smpp_command": smpp_command,
+                "message_id": message_id,
             },
         )
-        try:
-            await self.broker.enqueue(
-                protocol.EnquireLinkResp(
-                    version=self.naz_message_protocol_version,
-                    smpp_command=smpp_command,
-                    log_id=log_id,
-                    sequence_number=sequence_number,
-                )
-            )
-        except Exception as e:
-            self._log(
-                logging.ERROR,
-                {
-                    "event": "naz.Client.enquire_link_resp",
-                    "stage": "end",
-                    "error": repr(e),
-                    "log_id": log_id,
-                    "smpp_command": smpp_command,
-                },
-            )
+
+        # body
+        # message_id, c-octet str, max 65octet
+        # source_addr_ton, int, 1octet
+        # source_addr_npi, int, 1octet
+        # source_addr, c-octet str, max 21octet
+        # see section 4.4.2.1 of smpp ver 5.0 spec document
+        body = (
+            message_id.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", source_addr_ton)
+            + struct.pack(">B", source_addr_npi)
+            + source_addr.encode("ascii")
+            + chr(0).encode("ascii")
+        )
+        body_data = await self._send_and_await_response(
+            smpp_command=smpp_command, body=body, log_id=log_id
+        )
+
+        # query_broadcast_sm_resp body; message_id followed by the message_state, broadcast_area_identifier
+        # and broadcast_area_success optional parameters. see section 4.4.2.2 of smpp ver 5.0 spec document
+        _message_id, offset = protocol._read_c_octet_string(body_data, 0)
+        tlvs = TLV.parse(body_data[offset:])
+        message_state = SmppMessageState.UNKNOWN
+        broadcast_end_time = ""
+        for tlv in tlvs:
+            if tlv.tag == OptionalTag.NAME_to_TAG["message_state"] and tlv.length == 1:
+                message_state = SmppMessageState._find_message_state(tlv.value[0])
+            elif tlv.tag == _BROADCAST_TAGS["broadcast_end_time"]:
+                broadcast_end_time = tlv.value.rstrip(b"\x00").decode("ascii")
+        result = BroadcastQueryResult(
+            message_id=_message_id,
+            message_state=message_state,
+            broadcast_area_identifiers=[
+                tlv.value for tlv in tlvs if tlv.tag == _BROADCAST_TAGS["broadcast_area_identifier"]
+            ],
+            broadcast_area_success=[
+                tlv.value[0]
+                for tlv in tlvs
+                if tlv.tag == _BROADCAST_TAGS["broadcast_area_success"] and tlv.length == 1
+            ],
+            broadcast_end_time=broadcast_end_time,
+        )
         self._log(
-            logging.DEBUG,
+            logging.INFO,
             {
-                "event": "naz.Client.enquire_link_resp",
+                "event": "naz.Client.query_broadcast_message",
                 "stage": "end",
                 "log_id": log_id,
                 "smpp_command": smpp_command,
+                "message_id": message_id,
+                "message_state": result.message_state.code,
             },
         )
+        return result
 
-    async def unbind_resp(self, sequence_number: int) -> None:
+    async def cancel_broadcast_message(
+        self,
+        message_id: str,
+        source_addr: str,
+        service_type: str = "",
+        source_addr_ton: int = 0x00000001,
+        source_addr_npi: int = 0x00000001,
+        log_id: str = "",
+    ) -> None:
         """
-        send an UNBIND_RESP pdu to SMSC.
+        Cancel a previously submitted broadcast, using `cancel_broadcast_sm`.
+        The request is sent straight away(it is not queued in the broker) and this method waits for the SMSC's response.
 
         Parameters:
-            sequence_number: SMPP sequence_number
+            message_id: the message ID allocated by the SMSC to the broadcast. see :func:`broadcast_message <Client.broadcast_message>`
+            source_addr: the source address that was used when the broadcast was submitted.
+            service_type: the service_type that was used when the broadcast was submitted.
+            source_addr_ton: Type of Number of the source_addr.
+            source_addr_npi: Numbering Plan Identity of the source_addr.
+            log_id: a unique identify of this request
+
+        Raises:
+            NazCommandStatusError: raised if the SMSC responds with an error. eg; `ESME_RCANCELFAIL`
+            asyncio.TimeoutError: raised if the SMSC does not respond within :attr:`response_timeout <Client.response_timeout>`
         """
-        smpp_command = SmppCommand.UNBIND_RESP
-        log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        self._validate_bind_mode(
+            "cancel_broadcast_message", [BindMode.TRANSMITTER, BindMode.TRANSCEIVER]
+        )
+        smpp_command = SmppCommand.CANCEL_BROADCAST_SM
+        if not log_id:
+            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
         self._log(
             logging.INFO,
             {
-                "event": "naz.Client.unbind_resp",
+                "event": "naz.Client.cancel_broadcast_message",
                 "stage": "start",
                 "log_id": log_id,
                 "smpp_command": smpp_command,
+                "message_id": message_id,
             },
         )
 
         # body
-        body = b""
-
-        # header
-        command_length = self._header_pdu_length + len(body)  # 16 is for headers
-        command_id = self.command_ids[smpp_command]
-        command_status = SmppCommandStatus.ESME_ROK.value
-        sequence_number = sequence_number
-        header = struct.pack(">IIII", command_length, command_id, command_status, sequence_number)
-
-        full_pdu = header + body
-        # dont queue unbind_resp in SimpleBroker since we dont want it to be behind 10k msgs etc
-        await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+        # service_type, c-octet str, max 6octet
+        # message_id, c-octet str, max 65octet
+        # source_addr_ton, int, 1octet
+        # source_addr_npi, int, 1octet
+        # source_addr, c-octet str, max 21octet
+        # see section 4.4.3.1 of smpp ver 5.0 spec document
+        body = (
+            service_type.encode("ascii")
+            + chr(0).encode("ascii")
+            + message_id.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", source_addr_ton)
+            + struct.pack(">B", source_addr_npi)
+            + source_addr.encode("ascii")
+            + chr(0).encode("ascii")
+        )
+        # cancel_broadcast_sm_resp has no body
+        await self._send_and_await_response(smpp_command=smpp_command, body=body, log_id=log_id)
         self._log(
             logging.INFO,
             {
-                "event": "naz.Client.unbind_resp",
+                "event": "naz.Client.cancel_broadcast_message",
                 "stage": "end",
                 "log_id": log_id,
                 "smpp_command": smpp_command,
+                "message_id": message_id,
             },
         )
 
-    async def deliver_sm_resp(self, sequence_number: int) -> None:
+    async def send_raw(
+        self, command_id: int, body: bytes = b"", log_id: str = ""
+    ) -> typing.Union[None, typing.Tuple[int, bytes]]:
         """
-        send a DELIVER_SM_RESP pdu to SMSC.
+        Sends a hand-crafted PDU to SMSC; eg for interoperability testing, or for a vendor specific operation that naz does not support.
+        naz builds the header of the PDU, with a new sequence_number, and sends it straight away(it is not queued in the broker).
+        If the PDU is a request, ie the most significant bit of its command_id is not set, this method waits for the SMSC's response.
 
         Parameters:
-            sequence_number: SMPP sequence_number
+            command_id: the command_id of the PDU. eg; 0x00000015 for `enquire_link`, or one in the reserved ranges for SMSC vendors.
+            body: the body of the PDU, as it should be sent.
+            log_id: a unique identify of this request
+
+        Returns:
+            the command_status and the body of the response, or None if the PDU is not a request.
+            The command_status is returned as it is, even if it is an error; eg 0x00000003(`ESME_RINVCMDID`) if SMSC does not support the command.
+
+        Raises:
+            asyncio.TimeoutError: raised if the SMSC does not respond within :attr:`response_timeout <Client.response_timeout>`
+
+        Usage:
+
+        .. highlight:: python
+        .. code-block:: python
+
+            command_status, body = await client.send_raw(0x00010201, body=b"vendor-request\x00")
         """
-        smpp_command = SmppCommand.DELIVER_SM_RESP
-        log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
+        if not isinstance(command_id, int) or not (0 <= command_id <= 0xFFFFFFFF):
+            raise ValueError(
+                "`command_id` should be an `int` that fits in 4 octets. You entered: {0}".format(
+                    command_id
+                )
+            )
+        if not isinstance(body, bytes):
+            raise ValueError(
+                "`body` should be of type:: `bytes` You entered: {0}".format(type(body))
+            )
+        # commands that naz does not know are named after their command_id.
+        smpp_command = self._search_by_command_id_code(command_id) or "{0:#010x}".format(
+            command_id
+        )
+        if not log_id:
+            log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
         self._log(
             logging.INFO,
             {
-                "event": "naz.Client.deliver_sm_resp",
+                "event": "naz.Client.send_raw",
                 "stage": "start",
                 "log_id": log_id,
                 "smpp_command": smpp_command,
             },
         )
-
-        try:
-            await self.broker.enqueue(
-                protocol.DeliverSmResp(
-                    version=self.naz_message_protocol_version,
-                    smpp_command=smpp_command,
-                    log_id=log_id,
-                    message_id="",
-                    sequence_number=sequence_number,
+        await self._await_reconnection("send_raw")
+        sequence_number = self.sequence_generator.next_sequence()
+        if sequence_number > self.max_sequence_number:
+            # prevent third party sequence_generators from ruining our party
+            raise ValueError(
+                "the sequence_number: {0} is greater than the max: {1} allowed by SMPP spec.".format(
+                    sequence_number, self.max_sequence_number
                 )
             )
-        except Exception as e:
-            self._log(
-                logging.ERROR,
-                {
-                    "event": "naz.Client.deliver_sm_resp",
-                    "stage": "end",
-                    "error": repr(e),
-                    "log_id": log_id,
-                    "smpp_command": smpp_command,
-                },
-            )
+        full_pdu = (
+            struct.pack(
+                ">IIII", self._header_pdu_length + len(body), command_id, 0, sequence_number
+            )
+            + body
+        )
+        if command_id & 0x80000000:
+            # a response; SMSC does not respond to it.
+            await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+            return None
 
+        response_timeout = self.response_timeout
+        if response_timeout is None:
+            response_timeout = self.socket_timeout
+        response: asyncio.Future = asyncio.get_event_loop().create_future()
+        self._raw_responses[sequence_number] = response
+        try:
+            await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+            command_status, response_body = await asyncio.wait_for(
+                response, timeout=response_timeout
+            )
+        finally:
+            self._raw_responses.pop(sequence_number, None)
         self._log(
             logging.INFO,
             {
-                "event": "naz.Client.deliver_sm_resp",
+                "event": "naz.Client.send_raw",
                 "stage": "end",
                 "log_id": log_id,
                 "smpp_command": smpp_command,
+                "command_status": command_status,
             },
         )
+        return command_status, response_body
 
-    # this method just enqueues a submit_sm msg to queue
-    async def send_message(self, proto_msg: protocol.SubmitSM) -> None:
+    async def _send_and_await_response(
+        self, smpp_command: str, body: bytes, log_id: str, hook_metadata: str = ""
+    ) -> bytes:
         """
-        Sends a message/SUBMIT_SM to SMSC.
-        That message will get enqueued to :attr:`broker <Client.broker>` and later on sent to SMSC.
+        Sends a request PDU to SMSC and waits for its corresponding response.
+        The response is matched to the request using the sequence_number.
 
         Parameters:
-            proto_msg: the message to send to SMSC.
-                       Has to be a class instance of :class:`naz.protocol.SubmitSM <naz.protocol.SubmitSM>`
-
-        Usage:
-
-        .. highlight:: python
-        .. code-block:: python
-
-            import naz
+            smpp_command: type of PDU been sent. eg query_sm
+            body: the body of the PDU
+            log_id: a unique identify of this request
+            hook_metadata: additional metadata that you would like to be passed on to hooks
 
-            broker = naz.broker.SimpleBroker(maxsize=1000)
-            client = naz.Client(
-                    smsc_host="127.0.0.1",
-                    smsc_port=2775,
-                    system_id="smppclient1",
-                    password=os.getenv("password", "password"),
-                    broker=broker,
-                )
-            msg = naz.protocol.SubmitSM(
-                short_message="hello world",
-                source_addr="255700111222",
-                destination_addr="255799000888",
-                log_id="some-id",
-            )
-            await client.send_message(msg)
+        Returns:
+            the body of the response PDU.
         """
-        if not isinstance(proto_msg, protocol.SubmitSM):
+        await self._await_reconnection(smpp_command)
+        sequence_number = self.sequence_generator.next_sequence()
+        if sequence_number > self.max_sequence_number:
+            # prevent third party sequence_generators from ruining our party
             raise ValueError(
-                "`proto_msg` should be of type:: `naz.protocol.SubmitSM` You entered: {0}".format(
-                    type(proto_msg)
+                "the sequence_number: {0} is greater than the max: {1} allowed by SMPP spec.".format(
+                    sequence_number, self.max_sequence_number
                 )
             )
-        smpp_command = proto_msg.smpp_command
-        self._log(
-            logging.INFO,
-            {
-                "event": "naz.Client.send_message",
-                "stage": "start",
-                "log_id": proto_msg.log_id,
-                "smpp_command": smpp_command,
-            },
-        )
+
         try:
-            await self.broker.enqueue(proto_msg)
+            await self.correlation_handler.put(
+                smpp_command=smpp_command,
+                sequence_number=sequence_number,
+                log_id=log_id,
+                hook_metadata=hook_metadata,
+            )
+        except asyncio.CancelledError:
+            raise
         except Exception as e:
             self._log(
                 logging.ERROR,
                 {
-                    "event": "naz.Client.send_message",
+                    "event": "naz.Client._send_and_await_response",
                     "stage": "end",
-                    "error": repr(e),
-                    "log_id": proto_msg.log_id,
                     "smpp_command": smpp_command,
-                    "short_message": proto_msg.short_message,
+                    "log_id": log_id,
+                    "state": "correlater put error",
+                    "error": repr(e),
                 },
             )
-        self._log(
-            logging.INFO,
-            {
-                "event": "naz.Client.send_message",
-                "stage": "end",
-                "log_id": proto_msg.log_id,
-                "smpp_command": smpp_command,
-            },
+
+        # header
+        command_length = self._header_pdu_length + len(body)  # 16 is for headers
+        command_id = self.command_ids[smpp_command]
+        command_status = 0x00000000  # not used for requests
+        header = struct.pack(">IIII", command_length, command_id, command_status, sequence_number)
+        full_pdu = header + body
+
+        commandStatus, body_data = await self._send_pdu_and_await_response(
+            smpp_command=smpp_command, full_pdu=full_pdu, log_id=log_id, hook_metadata=hook_metadata
         )
+        if commandStatus.value != SmppCommandStatus.ESME_ROK.value:
+            raise NazCommandStatusError(smpp_command=smpp_command, command_status=commandStatus)
+        return body_data
+
+    async def _send_pdu_and_await_response(
+        self, smpp_command: str, full_pdu: bytes, log_id: str, hook_metadata: str
+    ) -> typing.Tuple[CommandStatus, bytes]:
+        """
+        send an already built PDU to SMSC and wait for the response that has the same sequence_number.
+
+        Returns:
+            the command status and the body of the response.
+        """
+        sequence_number = struct.unpack(">I", full_pdu[12:16])[0]
+        response_timeout = self.response_timeout
+        if response_timeout is None:
+            response_timeout = self.socket_timeout
+        response: asyncio.Future = asyncio.get_event_loop().create_future()
+        self._pending_responses[sequence_number] = response
+        self._expected_responses[sequence_number] = smpp_command + "_resp"
+        try:
+            await self.send_data(
+                smpp_command=smpp_command, msg=full_pdu, log_id=log_id, hook_metadata=hook_metadata
+            )
+            return await asyncio.wait_for(response, timeout=response_timeout)
+        except asyncio.TimeoutError:
+            # SMSC may never respond; do not let this request take up space in the window.
+            self._release_window_slot(sequence_number)
+            self._retryable.pop(sequence_number, None)
+            self._log(
+                logging.WARNING,
+                {
+                    "event": "naz.Client._send_pdu_and_await_response",
+                    "stage": "end",
+                    "smpp_command": smpp_command,
+                    "log_id": log_id,
+                    "sequence_number": sequence_number,
+                    "state": "SMSC did not respond within response_timeout",
+                },
+            )
+            raise
+        finally:
+            self._pending_responses.pop(sequence_number, None)
+            self._expected_responses.pop(sequence_number, None)
+
+    def _resolve_pending_response(
+        self,
+        smpp_command: str,
+        sequence_number: int,
+        command_status: CommandStatus,
+        body_data: bytes,
+        error: typing.Union[None, Exception] = None,
+    ) -> None:
+        """
+        hand over a response PDU to the caller of :func:`_send_and_await_response <Client._send_and_await_response>`
+        If `error` is given, or the response is not of the command that the request expects, the caller fails with an error instead.
+        """
+        response = self._pending_responses.get(sequence_number)
+        if response is None or response.done():
+            # the caller may have stopped waiting; eg, because of a timeout.
+            self._log(
+                logging.WARNING,
+                {
+                    "event": "naz.Client._resolve_pending_response",
+                    "stage": "end",
+                    "sequence_number": sequence_number,
+                    "state": "no caller is awaiting this response",
+                },
+            )
+            return None
+        expected_smpp_command = self._expected_responses.get(sequence_number)
+        if (
+            error is None
+            and expected_smpp_command is not None
+            and smpp_command != expected_smpp_command
+        ):
+            error = NazResponseMismatchError(
+                expected_smpp_command=expected_smpp_command,
+                expected_command_id=self.command_ids.get(expected_smpp_command),
+                smpp_command=smpp_command,
+                command_id=self.command_ids.get(smpp_command),
+                sequence_number=sequence_number,
+                command_length=16 + len(body_data),
+            )
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._resolve_pending_response",
+                    "stage": "end",
+                    "smpp_command": smpp_command,
+                    "sequence_number": sequence_number,
+                    "state": "the response is not of the command that the request expects",
+                    "error": str(error),
+                },
+            )
+        if error is not None:
+            response.set_exception(error)
+        else:
+            response.set_result((command_status, body_data))
 
     async def _build_enquire_link_resp_pdu(self, proto_msg: protocol.EnquireLinkResp) -> bytes:
         smpp_command = SmppCommand.ENQUIRE_LINK_RESP
@@ -1114,7 +4823,7 @@ class Client:
         # header
         command_length = self._header_pdu_length + len(body)  # 16 is for headers
         command_id = self.command_ids[smpp_command]
-        command_status = SmppCommandStatus.ESME_ROK.value
+        command_status = proto_msg.command_status
         sequence_number = sequence_number
         header = struct.pack(">IIII", command_length, command_id, command_status, sequence_number)
 
@@ -1130,6 +4839,138 @@ class Client:
         )
         return full_pdu
 
+    async def _build_submit_sm_pdus(self, proto_msg: protocol.SubmitSM) -> typing.List[bytes]:
+        """
+        builds the SUBMIT_SM pdu(s) for a message; one for each of the parts from :func:`_submit_sm_parts <Client._submit_sm_parts>`
+
+        Parameters:
+            proto_msg: an instance of `naz.protocol.SubmitSM`
+        """
+        return [await self._build_submit_sm_pdu(part) for part in self._submit_sm_parts(proto_msg)]
+
+    def _splittable(self, proto_msg: protocol.SubmitSM) -> bool:
+        """
+        whether the message is split into parts, if it does not fit in one SMS. see :func:`_submit_sm_parts <Client._submit_sm_parts>`
+        """
+        return (
+            self.split_long_messages
+            and not proto_msg.sm_default_msg_id
+            and not isinstance(proto_msg.short_message, bytes)
+            and proto_msg.udh is None
+        )
+
+    def _submit_sm_parts(self, proto_msg: protocol.SubmitSM) -> typing.List[protocol.SubmitSM]:
+        """
+        If :attr:`split_long_messages <Client.split_long_messages>` is True and the message does not fit in one SMS,
+        it is split into multiple messages, one per SMS. Depending on :attr:`concat_mode <Client.concat_mode>`,
+        each part carries either a concatenation User Data Header or the SAR optional parameters.
+        Since a part has everything that is needed to send it, it can be re-submitted on its own; see `Client._schedule_retry`
+
+        Parameters:
+            proto_msg: an instance of `naz.protocol.SubmitSM`
+        """
+        if not self._splittable(proto_msg):
+            return [proto_msg]
+
+        sar = self.concat_mode == ConcatMode.SAR
+        reference_16bit = self.concat_mode == ConcatMode.UDH_16BIT
+        parts = the_codec._split_message(
+            proto_msg.short_message,
+            proto_msg.encoding,
+            reference_16bit=reference_16bit,
+            udh=not sar,
+        )
+        if len(parts) == 1:
+            return [proto_msg]
+
+        # sar_msg_ref_num is two octets
+        reference_number = self._next_concat_reference_number(
+            reference_16bit=reference_16bit or sar
+        )
+        messages = []
+        for part_number, part in enumerate(parts, start=1):
+            message = copy.copy(proto_msg)
+            message.short_message = part
+            if sar:
+                message.optional_tags_dict = dict(
+                    proto_msg.optional_tags_dict,
+                    sar_msg_ref_num=reference_number,
+                    sar_total_segments=len(parts),
+                    sar_segment_seqnum=part_number,
+                )
+            elif reference_16bit:
+                # UDHL(6), IEI(0x08: concatenated short messages, 16-bit reference number), IEDL(4),
+                # reference number, total number of parts, this part's number
+                message.udh = struct.pack(
+                    ">BBBHBB", 0x06, 0x08, 0x04, reference_number, len(parts), part_number
+                )
+            else:
+                # see section 9.2.3.24.1 of GSM 03.40 (3GPP TS 23.040)
+                # UDHL(5), IEI(0x00: concatenated short messages, 8-bit reference number), IEDL(3),
+                # reference number, total number of parts, this part's number
+                message.udh = struct.pack(
+                    ">BBBBBB", 0x05, 0x00, 0x03, reference_number, len(parts), part_number
+                )
+            messages.append(message)
+        return messages
+
+    def _next_concat_reference_number(self, reference_16bit: bool = False) -> int:
+        # the reference number is one octet, so it wraps around after 255; or after 65535 if it is two octets.
+        self._concat_reference_number = (self._concat_reference_number + 1) % (
+            65536 if reference_16bit else 256
+        )
+        return self._concat_reference_number
+
+    def _encode_short_message(self, proto_msg: protocol.SubmitSM) -> typing.Tuple[bytes, bytes]:
+        """
+        encodes the short_message of a `submit_sm`, with its User Data Header(if any) at the start.
+        It returns the short_message field and the `message_payload` optional parameter of the PDU; at most one of them is not empty.
+
+        Raises:
+            NazMessageTooLongError: raised if it is too long for the short_message field, and is not sent in the `message_payload` optional parameter.
+        """
+        short_message = proto_msg.short_message
+        udh = proto_msg.udh if proto_msg.udh is not None else b""
+        if proto_msg.sm_default_msg_id:
+            # SMSC sends its pre-defined(`canned`) message instead.
+            return b"", b""
+        if isinstance(short_message, bytes):
+            # an 8-bit binary message is sent as it is.
+            encoded_short_message = short_message
+        elif udh and proto_msg.encoding == SmppDataCoding.gsm0338_packed.code:
+            # the packed septets have to start on a septet boundary after the UDH.
+            # see section 9.2.3.24 of GSM 03.40 (3GPP TS 23.040)
+            septets, _ = the_codec.GSM7BitCodec.encode(short_message, proto_msg.errors)
+            fill_bits = (7 - (len(udh) * 8) % 7) % 7
+            encoded_short_message = the_codec.GSM7BitPackedCodec._pack(septets, fill_bits)
+        else:
+            encoder = the_codec._codec_info(proto_msg.encoding).encode
+            encoded_short_message, _ = encoder(short_message, proto_msg.errors)
+        if udh:
+            encoded_short_message = udh + encoded_short_message
+        message_payload_pdu = b""
+        if not udh and (
+            self.use_message_payload
+            or (
+                self.message_payload_fallback
+                and len(encoded_short_message) > self.message_payload_threshold
+            )
+        ):
+            # the short_message can only hold 254 octets; the message is instead sent in the `message_payload` optional parameter.
+            # In this case the `sm_length` field should be set to zero. see section 5.3.2.32 of smpp ver 3.4 spec document
+            message_payload_pdu = (
+                struct.pack(
+                    ">HH",
+                    OptionalTag.NAME_to_TAG["message_payload"],
+                    len(encoded_short_message),
+                )
+                + encoded_short_message
+            )
+            encoded_short_message = b""
+        if len(encoded_short_message) > 254:
+            raise NazMessageTooLongError(length=len(encoded_short_message), limit=254)
+        return encoded_short_message, message_payload_pdu
+
     async def _build_submit_sm_pdu(self, proto_msg: protocol.SubmitSM) -> bytes:
         """
         builds a SUBMIT_SM pdu.
@@ -1173,29 +5014,148 @@ class Client:
         log_id = proto_msg.log_id
         hook_metadata = proto_msg.hook_metadata
         short_message = proto_msg.short_message
-        source_addr = proto_msg.source_addr
-        destination_addr = proto_msg.destination_addr
+        udh = proto_msg.udh if proto_msg.udh is not None else b""
+        if proto_msg.sm_default_msg_id:
+            # SMSC sends its pre-defined(`canned`) message instead.
+            short_message = ""
+            udh = b""
         service_type = proto_msg.service_type
-        source_addr_ton = proto_msg.source_addr_ton
-        source_addr_npi = proto_msg.source_addr_npi
-        dest_addr_ton = proto_msg.dest_addr_ton
-        dest_addr_npi = proto_msg.dest_addr_npi
+        (
+            source_addr,
+            source_addr_ton,
+            source_addr_npi,
+            destination_addr,
+            dest_addr_ton,
+            dest_addr_npi,
+        ) = self._addresses(proto_msg)
         esm_class = proto_msg.esm_class
         protocol_id = proto_msg.protocol_id
         priority_flag = proto_msg.priority_flag
+        if priority_flag is None:
+            priority_flag = self.priority_flag
         schedule_delivery_time = proto_msg.schedule_delivery_time
         validity_period = proto_msg.validity_period
+        if not validity_period:
+            validity_period = self.validity_period
+            if isinstance(validity_period, datetime.timedelta):
+                validity_period = protocol.smpp_time(validity_period)
         registered_delivery = proto_msg.registered_delivery
+        if registered_delivery is None:
+            registered_delivery = self.registered_delivery
         replace_if_present_flag = proto_msg.replace_if_present_flag
         sm_default_msg_id = proto_msg.sm_default_msg_id
-        encoder = codecs.getencoder(proto_msg.encoding)
         data_coding = proto_msg.data_coding
 
         self._log(
             logging.DEBUG,
             {
                 "event": "naz.Client._build_submit_sm_pdu",
-                "stage": "start",
+                "stage": "start",
+                "log_id": log_id,
+                "short_message": short_message,
+                "source_addr": source_addr,
+                "destination_addr": destination_addr,
+                "smpp_command": smpp_command,
+            },
+        )
+        if udh:
+            # the UDHI(User Data Header Indicator) bit of esm_class. see section 5.2.12 of smpp ver 3.4 spec document
+            esm_class = esm_class | 0b01000000
+        encoded_short_message, message_payload_pdu = self._encode_short_message(proto_msg)
+        sm_length = len(encoded_short_message)
+
+        # body
+        # SUBMIT_SM
+        body = b""
+        body = (
+            body
+            + service_type.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", source_addr_ton)  # unsigned Int, 1octet
+            + struct.pack(">B", source_addr_npi)
+            + source_addr.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", dest_addr_ton)
+            + struct.pack(">B", dest_addr_npi)
+            + destination_addr.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", esm_class)
+            + struct.pack(">B", protocol_id)
+            + struct.pack(">B", priority_flag)
+            + schedule_delivery_time.encode("ascii")
+            + chr(0).encode("ascii")
+            + validity_period.encode("ascii")
+            + chr(0).encode("ascii")
+            + struct.pack(">B", registered_delivery)
+            + struct.pack(">B", replace_if_present_flag)
+            + struct.pack(">B", data_coding.value)
+            + struct.pack(">B", sm_default_msg_id)
+            + struct.pack(">B", sm_length)
+            + encoded_short_message
+            + message_payload_pdu
+        )
+
+        # check for optional SMPP parameters
+        optional_params_pdu = self._build_submit_sm_optional_params_pdu(
+            proto_msg.optional_tags_dict
+        )
+        body = body + optional_params_pdu
+        for tlv in proto_msg.optional_params:
+            body = body + tlv.tlv
+
+        # header
+        command_length = self._header_pdu_length + len(body)  # 16 is for headers
+        command_id = self.command_ids[smpp_command]
+        # the status for success see section 5.1.3
+        command_status = 0x00000000  # not used for `submit_sm`
+        try:
+            sequence_number = self.sequence_generator.next_sequence()
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._build_submit_sm_pdu",
+                    "stage": "end",
+                    "error": repr(e),
+                    "log_id": log_id,
+                    "smpp_command": smpp_command,
+                },
+            )
+        if sequence_number > self.max_sequence_number:
+            # prevent third party sequence_generators from ruining our party
+            raise ValueError(
+                "the sequence_number: {0} is greater than the max: {1} allowed by SMPP spec.".format(
+                    sequence_number, self.max_sequence_number
+                )
+            )
+
+        try:
+            await self.correlation_handler.put(
+                smpp_command=smpp_command,
+                sequence_number=sequence_number,
+                log_id=log_id,
+                hook_metadata=hook_metadata,
+            )
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._build_submit_sm_pdu",
+                    "stage": "end",
+                    "smpp_command": smpp_command,
+                    "log_id": log_id,
+                    "state": "correlater put error",
+                    "error": repr(e),
+                },
+            )
+
+        header = struct.pack(">IIII", command_length, command_id, command_status, sequence_number)
+        full_pdu = header + body
+        self._log(
+            logging.DEBUG,
+            {
+                "event": "naz.Client._build_submit_sm_pdu",
+                "stage": "end",
                 "log_id": log_id,
                 "short_message": short_message,
                 "source_addr": source_addr,
@@ -1203,17 +5163,62 @@ class Client:
                 "smpp_command": smpp_command,
             },
         )
-        encoded_short_message, _ = encoder(short_message, proto_msg.errors)
-        sm_length = len(encoded_short_message)
+        return full_pdu
+
+    async def _build_data_sm_pdu(self, proto_msg: protocol.DataSM) -> bytes:
+        """
+        builds a DATA_SM pdu.
+
+        Parameters:
+            proto_msg: an instance of `naz.protocol.DataSM`
+        """
+        # BODY::
+        # data_sm has the following pdu body. see section 4.7.1 of smpp ver 3.4 spec document
+        # service_type, c-octet str, max 6octet.
+        # source_addr_ton, int , 1octet,
+        # source_addr_npi, int, 1octet
+        # source_addr, c-octet str, max 65octet.
+        # dest_addr_ton, int, 1octet
+        # dest_addr_npi, int, 1octet
+        # destination_addr,  C-Octet String, max 65 octet.
+        # esm_class, int, 1octet
+        # registered_delivery, int, 1octet
+        # data_coding, int, 1octet.
+        # NB: data_sm has no `short_message` field, the user data is carried in the `message_payload` optional parameter.
+
+        smpp_command = SmppCommand.DATA_SM
+        log_id = proto_msg.log_id
+        hook_metadata = proto_msg.hook_metadata
+        encoder = the_codec._codec_info(proto_msg.encoding).encode
+        self._log(
+            logging.DEBUG,
+            {
+                "event": "naz.Client._build_data_sm_pdu",
+                "stage": "start",
+                "log_id": log_id,
+                "source_addr": proto_msg.source_addr,
+                "destination_addr": proto_msg.destination_addr,
+                "smpp_command": smpp_command,
+            },
+        )
+        encoded_message_payload, _ = encoder(proto_msg.message_payload, proto_msg.errors)
+        registered_delivery = proto_msg.registered_delivery
+        if registered_delivery is None:
+            registered_delivery = self.registered_delivery
+        (
+            source_addr,
+            source_addr_ton,
+            source_addr_npi,
+            destination_addr,
+            dest_addr_ton,
+            dest_addr_npi,
+        ) = self._addresses(proto_msg)
 
         # body
-        # SUBMIT_SM
-        body = b""
         body = (
-            body
-            + service_type.encode("ascii")
+            proto_msg.service_type.encode("ascii")
             + chr(0).encode("ascii")
-            + struct.pack(">B", source_addr_ton)  # unsigned Int, 1octet
+            + struct.pack(">B", source_addr_ton)
             + struct.pack(">B", source_addr_npi)
             + source_addr.encode("ascii")
             + chr(0).encode("ascii")
@@ -1221,45 +5226,27 @@ class Client:
             + struct.pack(">B", dest_addr_npi)
             + destination_addr.encode("ascii")
             + chr(0).encode("ascii")
-            + struct.pack(">B", esm_class)
-            + struct.pack(">B", protocol_id)
-            + struct.pack(">B", priority_flag)
-            + schedule_delivery_time.encode("ascii")
-            + chr(0).encode("ascii")
-            + validity_period.encode("ascii")
-            + chr(0).encode("ascii")
+            + struct.pack(">B", proto_msg.esm_class)
             + struct.pack(">B", registered_delivery)
-            + struct.pack(">B", replace_if_present_flag)
-            + struct.pack(">B", data_coding.value)
-            + struct.pack(">B", sm_default_msg_id)
-            + struct.pack(">B", sm_length)
-            + encoded_short_message
+            + struct.pack(">B", proto_msg.data_coding.value)
         )
-
-        # check for optional SMPP parameters
-        optional_params_pdu = self._build_submit_sm_optional_params_pdu(
-            proto_msg.optional_tags_dict
+        # the message_payload is an Octet String; it is encoded with the message's codec and is not NULL terminated.
+        body = (
+            body
+            + struct.pack(
+                ">HH",
+                OptionalTag.NAME_to_TAG["message_payload"],
+                len(encoded_message_payload),
+            )
+            + encoded_message_payload
         )
-        body = body + optional_params_pdu
+        body = body + self._build_submit_sm_optional_params_pdu(proto_msg.optional_tags_dict)
 
         # header
         command_length = self._header_pdu_length + len(body)  # 16 is for headers
         command_id = self.command_ids[smpp_command]
-        # the status for success see section 5.1.3
-        command_status = 0x00000000  # not used for `submit_sm`
-        try:
-            sequence_number = self.sequence_generator.next_sequence()
-        except Exception as e:
-            self._log(
-                logging.ERROR,
-                {
-                    "event": "naz.Client._build_submit_sm_pdu",
-                    "stage": "end",
-                    "error": repr(e),
-                    "log_id": log_id,
-                    "smpp_command": smpp_command,
-                },
-            )
+        command_status = 0x00000000  # not used for `data_sm`
+        sequence_number = self.sequence_generator.next_sequence()
         if sequence_number > self.max_sequence_number:
             # prevent third party sequence_generators from ruining our party
             raise ValueError(
@@ -1279,7 +5266,7 @@ class Client:
             self._log(
                 logging.ERROR,
                 {
-                    "event": "naz.Client._build_submit_sm_pdu",
+                    "event": "naz.Client._build_data_sm_pdu",
                     "stage": "end",
                     "smpp_command": smpp_command,
                     "log_id": log_id,
@@ -1293,27 +5280,73 @@ class Client:
         self._log(
             logging.DEBUG,
             {
-                "event": "naz.Client._build_submit_sm_pdu",
+                "event": "naz.Client._build_data_sm_pdu",
                 "stage": "end",
                 "log_id": log_id,
-                "short_message": short_message,
-                "source_addr": source_addr,
-                "destination_addr": destination_addr,
+                "source_addr": proto_msg.source_addr,
+                "destination_addr": proto_msg.destination_addr,
                 "smpp_command": smpp_command,
             },
         )
         return full_pdu
 
+    def _addresses_ton_npi(
+        self, proto_msg: typing.Union[protocol.SubmitSM, protocol.DataSM]
+    ) -> typing.Tuple[int, int, int, int]:
+        """
+        returns the source_addr_ton, source_addr_npi, dest_addr_ton and dest_addr_npi of a message.
+        The client's defaults are used for the ones that the message does not set.
+        """
+        values = []
+        for name in ["source_addr_ton", "source_addr_npi", "dest_addr_ton", "dest_addr_npi"]:
+            value = getattr(proto_msg, name)
+            if value is None:
+                value = getattr(self, name)
+            values.append(value)
+        return values[0], values[1], values[2], values[3]
+
+    def _addresses(
+        self, proto_msg: typing.Union[protocol.SubmitSM, protocol.DataSM]
+    ) -> typing.Tuple[str, int, int, str, int, int]:
+        """
+        returns the source_addr, source_addr_ton, source_addr_npi, destination_addr, dest_addr_ton and dest_addr_npi of a message.
+        The addresses in `e164_addresses` are normalized to E.164, with a TON of international and an NPI of ISDN/E.164
+
+        Raises:
+            ValueError: raised if an address in `e164_addresses` is not a valid E.164 number.
+        """
+        source_addr_ton, source_addr_npi, dest_addr_ton, dest_addr_npi = self._addresses_ton_npi(
+            proto_msg
+        )
+        source_addr = proto_msg.source_addr
+        destination_addr = proto_msg.destination_addr
+        e164_addresses = self.e164_addresses or []
+        if "source_addr" in e164_addresses and source_addr != "":
+            source_addr = protocol.e164(source_addr)
+            source_addr_ton, source_addr_npi = 0x01, 0x01
+        if "destination_addr" in e164_addresses:
+            destination_addr = protocol.e164(destination_addr)
+            dest_addr_ton, dest_addr_npi = 0x01, 0x01
+        return (
+            source_addr,
+            source_addr_ton,
+            source_addr_npi,
+            destination_addr,
+            dest_addr_ton,
+            dest_addr_npi,
+        )
+
     @staticmethod
     def _build_submit_sm_optional_params_pdu(optional_tags_dict):
         # optional params may be included in ANY ORDER within
         # the `Optional Parameters` section of the SMPP PDU.
         opt_pdu = b""
         for opt_name in optional_tags_dict.keys():
-            if optional_tags_dict.get(opt_name):
-                opt_pdu = (
-                    opt_pdu + OptionalTag(name=opt_name, value=optional_tags_dict[opt_name]).tlv
-                )
+            # a value of 0 is still sent, eg a sar_msg_ref_num that has wrapped around.
+            # `alert_on_message_delivery` has no value part, False means that it is not set.
+            value = optional_tags_dict.get(opt_name)
+            if value is not None and value is not False:
+                opt_pdu = opt_pdu + OptionalTag(name=opt_name, value=value).tlv
         return opt_pdu
 
     async def re_establish_conn_bind(
@@ -1351,12 +5384,23 @@ class Client:
             )
             return None
 
-        # 1. re-connect
-        # 2. re-bind
-        await self.connect(log_id=log_id)
-        if self.current_session_state == SmppSessionState.OPEN:
-            # state can only be open if `client.connect` succeded
-            await self.tranceiver_bind(log_id=log_id)
+        self._reconnects += 1
+        self._record_metric("reconnect")
+        self._emit_event(the_events.Reconnecting(log_id=log_id))
+        # SMSC will not respond to the requests that were sent over the lost connection.
+        self._window.clear()
+        self._window_freed.set()
+        self._retryable.clear()
+        self._unacknowledged_parts.clear()
+        if self.auto_reconnect:
+            await self._reconnect_until_bound(log_id=log_id)
+        else:
+            # 1. re-connect
+            # 2. re-bind
+            await self.connect(log_id=log_id)
+            if self.current_session_state == SmppSessionState.OPEN:
+                # state can only be open if `client.connect` succeded
+                await self._rebind(log_id=log_id)
         self._log(
             logging.INFO,
             {
@@ -1370,19 +5414,205 @@ class Client:
             # offer escape hatch for tests to come out of endless loop
             return None
 
+    async def _rebind(self, log_id: str) -> None:
+        """
+        bind after a re-connection. A failed bind leaves the connection closed, so that it is retried.
+        """
+        try:
+            await self.bind(log_id=log_id)
+        except (NazConnectionError, NazCommandStatusError) as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._rebind",
+                    "stage": "end",
+                    "log_id": log_id,
+                    "state": "unable to re-bind to SMSC",
+                    "error": str(e),
+                },
+            )
+
+    async def _reconnect_until_bound(self, log_id: str) -> None:
+        """
+        keeps trying to re-connect & re-bind to SMSC, with an exponential backoff, until it succeeds.
+        """
+        async with self._reconnect_lock:
+            if self._connection_is_alive():
+                # another caller has already re-connected while we were waiting for the lock.
+                return None
+            self.current_session_state = SmppSessionState.CLOSED
+            self._reconnecting = True
+            self._reconnected.clear()
+            if self._bound_endpoint == self._endpoint_index:
+                # the connection to the SMSC that naz was bound to has been lost.
+                self._bound_endpoint = None
+                self._fail_over(log_id=log_id)
+            try:
+                attempt = 0
+                while not self.SHOULD_SHUT_DOWN:
+                    # 1. re-connect
+                    # 2. re-bind
+                    await self.connect(log_id=log_id)
+                    if self.current_session_state == SmppSessionState.OPEN:
+                        # state can only be open if `client.connect` succeded
+                        await self._rebind(log_id=log_id)
+                    if self._connection_is_alive():
+                        break
+
+                    retry_after = self._reconnect_backoff(attempt)
+                    attempt += 1
+                    self._log(
+                        logging.WARNING,
+                        {
+                            "event": "naz.Client._reconnect_until_bound",
+                            "stage": "start",
+                            "log_id": log_id,
+                            "current_session_state": self.current_session_state,
+                            "state": "unable to re-connect to SMSC. sleeping for {0:.2f} seconds".format(
+                                retry_after
+                            ),
+                            "retry_count": attempt,
+                        },
+                    )
+                    await asyncio.sleep(retry_after)
+            finally:
+                self._reconnecting = False
+                self._reconnected.set()
+
+    @property
+    def current_session_state(self) -> str:
+        """
+        the state of the SMPP session with SMSC. eg; :attr:`naz.SmppSessionState.BOUND_TRX <naz.state.SmppSessionState.BOUND_TRX>`
+        """
+        return self._current_session_state
+
+    @current_session_state.setter
+    def current_session_state(self, session_state: str) -> None:
+        previous_state = self._current_session_state
+        self._current_session_state = session_state
+        if session_state == previous_state:
+            return None
+        if session_state == SmppSessionState.OPEN:
+            self._emit_event(
+                the_events.Connected(
+                    smsc_host=self.active_endpoint[0], smsc_port=self.active_endpoint[1]
+                )
+            )
+        elif session_state == self._bound_state:
+            self._bound_endpoint = self._endpoint_index
+            self._emit_event(the_events.Bound(session_state=session_state))
+        elif session_state == SmppSessionState.CLOSED:
+            self._emit_event(the_events.Disconnected())
+
+    def _connection_is_alive(self) -> bool:
+        return (
+            self.current_session_state == self._bound_state
+            and self.writer is not None
+            and not self.writer.transport.is_closing()
+        )
+
+    async def _acquire_window_slot(self, sequence_number: int, log_id: str) -> None:
+        """
+        wait until there is space in the window for one more outstanding request.
+        Requests older than :attr:`window_timeout <Client.window_timeout>` are reclaimed.
+        """
+        while True:
+            now = self._reclaim_window_slots(log_id)
+            if len(self._window) < self.window_size:
+                break
+
+            self._window_freed.clear()
+            oldest = min(self._window.values())
+            try:
+                await asyncio.wait_for(
+                    self._window_freed.wait(), max(oldest + self.window_timeout - now, 0.0)
+                )
+            except asyncio.TimeoutError:
+                pass
+        self._window[sequence_number] = time.monotonic()
+
+    def _reclaim_window_slots(self, log_id: str) -> float:
+        """
+        stop tracking the requests that SMSC has not responded to within :attr:`window_timeout <Client.window_timeout>`. It returns the current time.
+        """
+        now = time.monotonic()
+        for _sequence_number, sent_at in list(self._window.items()):
+            if now - sent_at > self.window_timeout:
+                self._log(
+                    logging.WARNING,
+                    {
+                        "event": "naz.Client._acquire_window_slot",
+                        "stage": "start",
+                        "log_id": log_id,
+                        "sequence_number": _sequence_number,
+                        "state": "SMSC did not respond within window_timeout. reclaiming slot",
+                    },
+                )
+                self._window.pop(_sequence_number, None)
+                self._unacknowledged_parts.pop(_sequence_number, None)
+                # SMSC is not going to respond, so the request is not going to be re-submitted.
+                self._retryable.pop(_sequence_number, None)
+        return now
+
+    def _start_window_maintenance(self) -> None:
+        if self.window_maintenance_interval is None:
+            return None
+        if self._window_maintenance is None or self._window_maintenance.done():
+            self._window_maintenance = asyncio.ensure_future(self._maintain_window())
+
+    async def _maintain_window(self) -> None:
+        """
+        reclaim the requests that SMSC has not responded to within :attr:`window_timeout <Client.window_timeout>`, every :attr:`window_maintenance_interval <Client.window_maintenance_interval>` seconds;
+        so that the window frees up even if no new requests are sent. The callers awaiting the responses to those requests fail with asyncio.TimeoutError.
+        It returns once there are no requests in the window; the next request starts it again.
+        """
+        while self._window:
+            await asyncio.sleep(self.window_maintenance_interval)  # type: ignore
+            expired = [
+                sequence_number
+                for sequence_number, sent_at in self._window.items()
+                if time.monotonic() - sent_at > self.window_timeout
+            ]
+            self._reclaim_window_slots(log_id="")
+            if not expired:
+                continue
+            self._window_freed.set()
+            for sequence_number in expired:
+                response = self._pending_responses.get(sequence_number)
+                if response is not None and not response.done():
+                    response.set_exception(
+                        asyncio.TimeoutError(
+                            "SMSC did not respond to the request with sequence_number:{0} within the window_timeout of {1} seconds".format(
+                                sequence_number, self.window_timeout
+                            )
+                        )
+                    )
+
+    def _release_window_slot(self, sequence_number: int) -> None:
+        if self._window.pop(sequence_number, None) is not None:
+            self._window_freed.set()
+
     async def send_data(
         self, smpp_command: str, msg: bytes, log_id: str, hook_metadata: str = ""
-    ) -> None:
+    ) -> bool:
         """
         Sends PDU's to SMSC over a network connection.
         This method does not block; it buffers the data and arranges for it to be sent out asynchronously.
         It also accts as a flow control method that interacts with the IO write buffer.
 
+        It is safe to call concurrently from many tasks. The PDUs are written one at a time, so the bytes of one PDU are never interleaved with another's,
+        and :attr:`on_write <Client.on_write>` sees them in the order they go out on the wire.
+        A request's sequence_number is registered with the :attr:`correlation_handler <Client.correlation_handler>` before it is written,
+        so its response is correlated correctly regardless of the order in which the concurrent requests are written.
+
         Parameters:
             smpp_command: type of PDU been sent. eg bind_transceiver
             msg: PDU to be sent to SMSC over the network connection.
             log_id: a unique identify of this request
             hook_metadata: additional metadata that you would like to be passed on to hooks
+
+        Returns:
+            True if the PDU was written to SMSC, otherwise False.
         """
         # todo: look at `set_write_buffer_limits` and `get_write_buffer_limits` methods
         # print("get_write_buffer_limits:", writer.transport.get_write_buffer_limits())
@@ -1418,7 +5648,7 @@ class Client:
                 },
             )
             await self.re_establish_conn_bind(smpp_command=smpp_command, log_id=log_id)
-            return None
+            return False
         elif (
             self.current_session_state == SmppSessionState.OPEN
             and smpp_command == SmppCommand.ENQUIRE_LINK
@@ -1467,7 +5697,28 @@ class Client:
                 },
             )
             # do not raise, we do not want naz-cli to exit
-            return None
+            return False
+
+        if len(msg) >= self._header_pdu_length and smpp_command in [
+            SmppCommand.SUBMIT_SM,
+            SmppCommand.DATA_SM,
+            SmppCommand.SUBMIT_MULTI,
+            SmppCommand.QUERY_SM,
+            SmppCommand.CANCEL_SM,
+            SmppCommand.REPLACE_SM,
+            SmppCommand.BROADCAST_SM,
+            SmppCommand.QUERY_BROADCAST_SM,
+            SmppCommand.CANCEL_BROADCAST_SM,
+        ]:
+            # the sequence_number is the last 4 octets of the header
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            if self.window_size is not None:
+                await self._acquire_window_slot(sequence_number, log_id)
+            else:
+                # there is no window to wait for; the request is only tracked. see: `Client.outstanding`
+                self._reclaim_window_slots(log_id)
+                self._window[sequence_number] = time.monotonic()
+            self._start_window_maintenance()
 
         if (self.writer is None) or self.writer.transport.is_closing():
             await self.re_establish_conn_bind(smpp_command=smpp_command, log_id=log_id)
@@ -1477,6 +5728,8 @@ class Client:
             await self.hook.to_smsc(
                 smpp_command=smpp_command, log_id=log_id, hook_metadata=hook_metadata, pdu=msg
             )
+        except asyncio.CancelledError:
+            raise
         except Exception as e:
             self._log(
                 logging.ERROR,
@@ -1490,6 +5743,7 @@ class Client:
                 },
             )
 
+        written = False
         try:
             if typing.TYPE_CHECKING:
                 # make mypy happy; https://github.com/python/mypy/issues/4805
@@ -1500,17 +5754,34 @@ class Client:
             # drain blocks until the size of the buffer is drained down to the low watermark and writing can be resumed.
             # When there is nothing to wait for, the drain() returns immediately.
             # ref: https://docs.python.org/3/library/asyncio-stream.html#asyncio.StreamWriter.drain
-            self.writer.write(msg)
             async with self.drain_lock:
                 # see: https://github.com/komuw/naz/issues/114
-                await self.writer.drain()
-            if smpp_command == SmppCommand.BIND_TRANSCEIVER:
-                # if we have successfully sent a bind_transceiver request, we can set session state to `BOUND_TRX`
+                if self.on_write is not None:
+                    self._call_wire_hook("on_write", msg)
+                await asyncio.wait_for(self._write_pdu(msg), timeout=self.write_timeout)
+            written = True
+            self._bytes_written += len(msg)
+            self._connection_bytes_written += len(msg)
+            if smpp_command in [SmppCommand.SUBMIT_SM, SmppCommand.DATA_SM]:
+                self._submitted += 1
+            self._record_metric("pdu_sent", smpp_command)
+            self._emit_event(
+                the_events.PDUSent(
+                    smpp_command=smpp_command,
+                    # the sequence_number is the last 4 octets of the header
+                    sequence_number=struct.unpack(">I", msg[12:16])[0] if len(msg) >= 16 else 0,
+                    log_id=log_id,
+                )
+            )
+            if self._unanswered_since == 0.00 and not smpp_command.endswith("_resp"):
+                self._unanswered_since = time.monotonic()
+            if smpp_command == self._bind_command and self.bind_timeout is None:
+                # if we have successfully sent a bind request, we can set session state to eg `BOUND_TRX`
                 # Ideally, you should only set state to `BOUND_TRX` once SMSC sends back a successful `BIND_TRANSCEIVER_RESP`
                 # However, an SMSC may fail to do so. This is especially true when sending `re_establish_conn_bind`
                 # hack!! bad!!
                 # TODO: fix this
-                self.current_session_state = SmppSessionState.BOUND_TRX
+                self.current_session_state = self._bound_state
         except (
             ConnectionError,
             TimeoutError,
@@ -1532,6 +5803,9 @@ class Client:
                     "error": repr(e),
                 },
             )
+            # the write did not complete within `write_timeout`, or failed midway.
+            # Either way, a part of the PDU may have been written; and SMSC would read the PDUs that follow as a continuation of it.
+            self._abort_connection()
 
         self._log(
             logging.INFO,
@@ -1543,6 +5817,53 @@ class Client:
                 "msg": log_msg,
             },
         )
+        return written
+
+    async def _write_pdu(self, msg: bytes) -> None:
+        """
+        writes the whole of `msg` to SMSC.
+        An asyncio StreamWriter buffers all that it is given, but the writer returned by a :attr:`dialer <Client.dialer>` may instead
+        return the number of octets that it wrote; in which case the rest is written after draining.
+        """
+        if typing.TYPE_CHECKING:
+            # make mypy happy; https://github.com/python/mypy/issues/4805
+            assert isinstance(self.writer, asyncio.streams.StreamWriter)
+        written = 0
+        while written < len(msg):
+            count = self.writer.write(msg[written:])  # type: ignore
+            if not isinstance(count, int):
+                # the whole of it was buffered.
+                count = len(msg) - written
+            written = written + count
+            await self.writer.drain()
+
+    @property
+    def paused(self) -> bool:
+        """
+        whether dequeuing of messages is paused. see :func:`pause <Client.pause>`
+        """
+        return not self._resumed.is_set()
+
+    def pause(self) -> None:
+        """
+        Stops :func:`dequeue_messages <Client.dequeue_messages>` from dequeuing, and sending, messages; eg during an SMSC maintenance window.
+        The bind is kept alive, since :func:`enquire_link <Client.enquire_link>` carries on sending enquire_link requests,
+        and :func:`send_message <Client.send_message>` carries on queuing messages in the broker. They are sent once :func:`resume <Client.resume>` is called.
+        A message that had already been dequeued when it is called is still sent.
+        It does not affect the messages sent straight away, eg using :func:`submit_message <Client.submit_message>`,
+        nor the replies to SMSC's requests, eg :func:`deliver_sm_resp <Client.deliver_sm_resp>`
+        """
+        self._log(logging.INFO, {"event": "naz.Client.pause", "stage": "start"})
+        self._resumed.clear()
+        self._log(logging.INFO, {"event": "naz.Client.pause", "stage": "end"})
+
+    def resume(self) -> None:
+        """
+        Resumes the dequeuing, and sending, of messages that was paused by :func:`pause <Client.pause>`
+        """
+        self._log(logging.INFO, {"event": "naz.Client.resume", "stage": "start"})
+        self._resumed.set()
+        self._log(logging.INFO, {"event": "naz.Client.resume", "stage": "end"})
 
     async def dequeue_messages(
         self, TESTING: bool = False
@@ -1567,7 +5888,7 @@ class Client:
                 )
                 return {"shutdown": "shutdown"}
 
-            while self.current_session_state != SmppSessionState.BOUND_TRX:
+            while self.current_session_state != self._bound_state:
                 # If the connection to SMSC is broken, there's no need to try and send messages
                 # sleep and wait for `Client.re_establish_conn_bind` to do its thing.
                 # this same thing cannot be done for `enquire_link` since we rely on it to kick on `re_establish_conn_bind`
@@ -1578,20 +5899,44 @@ class Client:
                         "event": "naz.Client.dequeue_messages",
                         "stage": "start",
                         "current_session_state": self.current_session_state,
-                        "state": "awaiting naz to change session state to `BOUND_TRX`. sleeping for {0:.2f} seconds".format(
-                            retry_after
+                        "state": "awaiting naz to change session state to `{0}`. sleeping for {1:.2f} seconds".format(
+                            self._bound_state, retry_after
                         ),
                     },
                 )
                 await asyncio.sleep(retry_after)
                 if TESTING:
-                    return {"state": "awaiting naz to change session state to `BOUND_TRX`"}
+                    return {
+                        "state": "awaiting naz to change session state to `{0}`".format(
+                            self._bound_state
+                        )
+                    }
+
+            if self.paused:
+                self._log(
+                    logging.INFO,
+                    {
+                        "event": "naz.Client.dequeue_messages",
+                        "stage": "start",
+                        "state": "dequeuing is paused. awaiting `Client.resume`",
+                    },
+                )
+                try:
+                    # wake up every so often to check whether naz is shutting down.
+                    await asyncio.wait_for(self._resumed.wait(), timeout=self.socket_timeout)
+                except asyncio.TimeoutError:
+                    pass
+                if TESTING:
+                    return {"state": "dequeuing is paused"}
+                continue
 
             # TODO: there are so many try-except classes in this func.
             # do something about that.
             try:
                 # check with throttle handler
                 send_request = await self.throttle_handler.allow_request()
+            except asyncio.CancelledError:
+                raise
             except Exception as e:
                 self._log(
                     logging.ERROR,
@@ -1607,6 +5952,8 @@ class Client:
                 try:
                     # rate limit ourselves
                     await self.rate_limiter.limit()
+                except asyncio.CancelledError:
+                    raise
                 except Exception as e:
                     self._log(
                         logging.ERROR,
@@ -1620,6 +5967,8 @@ class Client:
 
                 try:
                     proto_msg = await self.broker.dequeue()
+                except asyncio.CancelledError:
+                    raise
                 except Exception as e:
                     dequeue_retry_count += 1
                     poll_queue_interval = self._retry_after(dequeue_retry_count)
@@ -1645,17 +5994,25 @@ class Client:
 
                 # we didn't fail to dequeue a message
                 dequeue_retry_count = 0
+                self._queue_depth_metric()
+                self._in_flight_sends += 1
                 try:
                     log_id = proto_msg.log_id
                     proto_msg.version  # version is a required field
                     smpp_command = proto_msg.smpp_command
                     hook_metadata = proto_msg.hook_metadata
+                    # the message that each pdu is for; a part of `proto_msg` if it was split.
+                    messages: typing.List[protocol.Message] = [proto_msg]
                     if isinstance(proto_msg, protocol.SubmitSM):
-                        full_pdu = await self._build_submit_sm_pdu(proto_msg)
+                        submit_sms = self._submit_sm_parts(proto_msg)
+                        messages = list(submit_sms)
+                        full_pdus = [await self._build_submit_sm_pdu(part) for part in submit_sms]
+                    elif isinstance(proto_msg, protocol.DataSM):
+                        full_pdus = [await self._build_data_sm_pdu(proto_msg)]
                     elif isinstance(proto_msg, protocol.DeliverSmResp):
-                        full_pdu = await self._build_deliver_sm_pdu(proto_msg)
+                        full_pdus = [await self._build_deliver_sm_pdu(proto_msg)]
                     elif isinstance(proto_msg, protocol.EnquireLinkResp):
-                        full_pdu = await self._build_enquire_link_resp_pdu(proto_msg)
+                        full_pdus = [await self._build_enquire_link_resp_pdu(proto_msg)]
                     else:
                         raise ValueError(
                             "The protocol message `{0}` is not recognised by naz.".format(
@@ -1663,6 +6020,7 @@ class Client:
                             )
                         )
                 except Exception as e:
+                    self._in_flight_sends -= 1
                     self._log(
                         logging.ERROR,
                         {
@@ -1674,12 +6032,42 @@ class Client:
                     )
                     continue
 
-                await self.send_data(
-                    smpp_command=smpp_command,
-                    msg=full_pdu,
-                    log_id=log_id,
-                    hook_metadata=hook_metadata,
-                )
+                if isinstance(proto_msg, (protocol.SubmitSM, protocol.DataSM)):
+                    self._log_pdu_contents(
+                        event="naz.Client.dequeue_messages",
+                        log_id=log_id,
+                        smpp_command=smpp_command,
+                        message=proto_msg,
+                    )
+                try:
+                    parts: typing.Set[int] = set()
+                    for message, full_pdu in zip(messages, full_pdus):
+                        # the sequence_number is the last 4 octets of the header
+                        sequence_number = struct.unpack(">I", full_pdu[12:16])[0]
+                        if isinstance(proto_msg, (protocol.SubmitSM, protocol.DataSM)):
+                            if self.retry_policy is not None:
+                                self._retryable[sequence_number] = message
+                            parts.add(sequence_number)
+                            self._unacknowledged_parts[sequence_number] = parts
+                            await self._limit_bytes("naz.Client.dequeue_messages", log_id, full_pdu)
+                        written = await self.send_data(
+                            smpp_command=smpp_command,
+                            msg=full_pdu,
+                            log_id=log_id,
+                            hook_metadata=hook_metadata,
+                        )
+                        if not written:
+                            # SMSC will not respond to a part that was not sent.
+                            self._unacknowledged_parts.pop(sequence_number, None)
+                        elif isinstance(
+                            proto_msg, (protocol.DeliverSmResp, protocol.EnquireLinkResp)
+                        ):
+                            if isinstance(proto_msg, protocol.DeliverSmResp):
+                                await self._call_deliver_sm_resp_hook(proto_msg)
+                            # SMSC does not respond to a response; it need not be replayed.
+                            await self._acknowledge(log_id=log_id)
+                finally:
+                    self._in_flight_sends -= 1
                 self._log(
                     logging.INFO,
                     {
@@ -1705,6 +6093,8 @@ class Client:
                 )
                 try:
                     await asyncio.sleep(await self.throttle_handler.throttle_delay())
+                except asyncio.CancelledError:
+                    raise
                 except Exception as e:
                     self._log(
                         logging.ERROR,
@@ -1741,7 +6131,7 @@ class Client:
                     },
                 )
                 return None
-            if self.current_session_state != SmppSessionState.BOUND_TRX:
+            if self.current_session_state != self._bound_state:
                 retry_after = self.socket_timeout
                 self._log(
                     logging.INFO,
@@ -1765,7 +6155,8 @@ class Client:
 
                 # `client.reader` and `client.writer` should not have timeouts since they are non-blocking
                 # https://github.com/komuw/naz/issues/116
-                header_data = await self.reader.readexactly(self._header_pdu_length)
+                # The exception is `read_timeout`, which only applies while we are awaiting a response from SMSC.
+                header_data = await self._read_header()
             except asyncio.IncompleteReadError as e:
                 # see: https://github.com/komuw/naz/issues/135
                 self._log(
@@ -1828,60 +6219,79 @@ class Client:
 
             # first 4bytes of header are the command_length
             total_pdu_length = struct.unpack(">I", header_data[:4])[0]
-            MSGLEN = total_pdu_length - self._header_pdu_length
-            chunks = []
-            bytes_recd = 0
-            while bytes_recd < MSGLEN:
-                chunk = b""
-                try:
-                    if typing.TYPE_CHECKING:
-                        # make mypy happy; https://github.com/python/mypy/issues/4805
-                        assert isinstance(self.reader, asyncio.streams.StreamReader)
-
-                    chunk = await self.reader.read(min(MSGLEN - bytes_recd, 2048))
-                    if chunk == b"":
-                        # TODO: maybe we also need todo; `self.writer=None`
-                        # so that the `re_establish_conn_bind` mechanism can kick in.
-                        raise ConnectionError("socket connection broken")
-                except (
-                    ConnectionError,
-                    TimeoutError,
-                    asyncio.TimeoutError,
-                    socket.error,
-                    socket.herror,
-                    socket.gaierror,
-                    socket.timeout,
-                ) as e:
-                    self._log(
-                        logging.ERROR,
-                        {
-                            "event": "naz.Client.receive_data",
-                            "stage": "end",
-                            "state": "unable to read from SMSC",
-                            "error": repr(e),
-                        },
+            if total_pdu_length < self._header_pdu_length or total_pdu_length > self.max_pdu_length:
+                # we cannot tell where this PDU ends and the next one starts; so start over.
+                if total_pdu_length < self._header_pdu_length:
+                    error_msg = "protocol error. command_length: {0} is less than the length of a PDU header: {1}".format(
+                        total_pdu_length, self._header_pdu_length
                     )
-                    if self.SHOULD_SHUT_DOWN:
-                        return None
+                else:
+                    error_msg = "protocol error. command_length: {0} is greater than the max: {1}".format(
+                        total_pdu_length, self.max_pdu_length
+                    )
+                self._log(
+                    logging.ERROR,
+                    {
+                        "event": "naz.Client.receive_data",
+                        "stage": "end",
+                        "state": error_msg,
+                        "header": self._msg_to_log(msg=header_data),
+                    },
+                )
+                self._emit_error(NazReadError.MALFORMED_PDU, error_msg)
+                # close connection. it will be automatically reconnected later
+                await self._unbind_and_disconnect()
+                if TESTING:
+                    # offer escape hatch for tests to come out of endless loop
+                    return header_data
+                continue
 
-                    _read_smsc_interval = 62.00
-                    self._log(
-                        logging.DEBUG,
-                        {
-                            "event": "naz.Client.receive_data",
-                            "stage": "end",
-                            "state": "unable to read from SMSC. sleeping for {0:.2f} seconds".format(
-                                _read_smsc_interval
-                            ),
-                            "error": repr(e),
-                        },
+            body_data = b""
+            MSGLEN = total_pdu_length - self._header_pdu_length
+            try:
+                if typing.TYPE_CHECKING:
+                    # make mypy happy; https://github.com/python/mypy/issues/4805
+                    assert isinstance(self.reader, asyncio.streams.StreamReader)
+
+                # readexactly keeps reading until the whole body has arrived, even if it arrives in fragments.
+                if MSGLEN > 0:
+                    body_data = await asyncio.wait_for(
+                        self.reader.readexactly(MSGLEN), timeout=self.read_timeout
                     )
-                    await asyncio.sleep(_read_smsc_interval)
-                    continue  # important so that we do not hit the bug: issues/135
-                chunks.append(chunk)
-                bytes_recd = bytes_recd + len(chunk)
+            except (
+                asyncio.IncompleteReadError,
+                ConnectionError,
+                TimeoutError,
+                asyncio.TimeoutError,
+                socket.error,
+                socket.herror,
+                socket.gaierror,
+                socket.timeout,
+            ) as e:
+                # the connection broke in the middle of a PDU; see: https://github.com/komuw/naz/issues/135
+                self._log(
+                    logging.ERROR,
+                    {
+                        "event": "naz.Client.receive_data",
+                        "stage": "end",
+                        "state": "unable to read exactly {0}bytes of smpp body.".format(MSGLEN),
+                        "error": repr(e),
+                    },
+                )
+                if self.SHOULD_SHUT_DOWN:
+                    return None
+                # close connection. it will be automatically reconnected later
+                await self._unbind_and_disconnect()
+                if TESTING:
+                    # offer escape hatch for tests to come out of endless loop
+                    return header_data
+                continue
 
-            full_pdu_data = header_data + b"".join(chunks)
+            full_pdu_data = header_data + body_data
+            # SMSC is responsive.
+            self._unanswered_since = 0.00
+            if self.on_read is not None:
+                self._call_wire_hook("on_read", full_pdu_data)
             self._log(
                 logging.DEBUG,
                 {
@@ -1896,6 +6306,64 @@ class Client:
                 # offer escape hatch for tests to come out of endless loop
                 return full_pdu_data
 
+    async def _read_header(self) -> bytes:
+        """
+        read the header of the next PDU from SMSC.
+        If :attr:`read_timeout <Client.read_timeout>` is set, it raises asyncio.TimeoutError when a request has been awaiting a response for longer than that.
+        An idle connection is waited on indefinitely; unless :attr:`enquire_link_interval <Client.enquire_link_interval>` is 0,
+        in which case the enquire_link's of SMSC are what keep it alive.
+        """
+        if typing.TYPE_CHECKING:
+            # make mypy happy; https://github.com/python/mypy/issues/4805
+            assert isinstance(self.reader, asyncio.streams.StreamReader)
+
+        if self.read_timeout is None:
+            return await self.reader.readexactly(self._header_pdu_length)
+        while True:
+            if self._unanswered_since == 0.00:
+                wait = self.read_timeout
+            else:
+                wait = self._unanswered_since + self.read_timeout - time.monotonic()
+                if wait <= 0:
+                    self._abort_connection()
+                    raise asyncio.TimeoutError(
+                        "SMSC did not send any data within the read_timeout of {0} seconds".format(
+                            self.read_timeout
+                        )
+                    )
+            try:
+                # a cancelled readexactly does not consume any data; so it is safe to retry.
+                return await asyncio.wait_for(
+                    self.reader.readexactly(self._header_pdu_length), timeout=wait
+                )
+            except asyncio.TimeoutError:
+                if self._unanswered_since == 0.00 and self.enquire_link_interval == 0.00:
+                    self._abort_connection()
+                    raise asyncio.TimeoutError(
+                        "SMSC did not send any data, not even an enquire_link, within the read_timeout of {0} seconds".format(
+                            self.read_timeout
+                        )
+                    )
+                # re-check whether we are awaiting a response, or the connection is just idle.
+                continue
+
+    def _abort_connection(self) -> None:
+        """
+        drop a stalled connection to SMSC without unbinding, since SMSC is not reading. It will be re-established later.
+        """
+        self._log(
+            logging.WARNING,
+            {
+                "event": "naz.Client._abort_connection",
+                "stage": "start",
+                "state": "connection stalled",
+            },
+        )
+        if self.writer is not None:
+            self.writer.transport.abort()
+        self.current_session_state = SmppSessionState.CLOSED
+        self._unanswered_since = 0.00
+
     async def _parse_response_pdu(self, pdu: bytes) -> None:
         """
         Take the bytes that have been read from network and parse them into their corresponding PDU.
@@ -1910,6 +6378,8 @@ class Client:
             {"event": "naz.Client._parse_response_pdu", "stage": "start", "pdu": log_pdu},
         )
 
+        self._bytes_read += len(pdu)
+        self._connection_bytes_read += len(pdu)
         header_data = pdu[: self._header_pdu_length]
         body_data = pdu[self._header_pdu_length :]
         command_id_header_data = header_data[4:8]
@@ -1932,10 +6402,16 @@ class Client:
                     "pdu": log_pdu,
                 },
             )
+            self._emit_error(NazReadError.MALFORMED_PDU, "unable to parse PDU: {0!r}".format(e))
             # close connection
             await self._unbind_and_disconnect()
             return None
 
+        raw_response = self._raw_responses.get(sequence_number)
+        if raw_response is not None and command_id & 0x80000000 and not raw_response.done():
+            # the response to a request sent by `Client.send_raw`; whether or not naz knows its command_id.
+            raw_response.set_result((command_status, body_data))
+
         smpp_command = self._search_by_command_id_code(command_id)
         if not smpp_command:
             err = ValueError("command_id:{0} is unknown.".format(command_id))
@@ -1948,6 +6424,9 @@ class Client:
                     "error": str(err),
                 },
             )
+            self._emit_error(NazReadError.UNKNOWN_COMMAND_ID, str(err), sequence_number)
+            # the hook still gets to see it; as a `naz.pdu.RawPDU`
+            await self._call_decoded_hook(pdu=pdu, log_id="", hook_metadata="")
             return None
 
         # get associated user supplied log_id if any
@@ -1967,7 +6446,44 @@ class Client:
                     "error": repr(e),
                 },
             )
+            self._emit_error(
+                NazReadError.CORRELATION_MISS,
+                "unable to correlate {0}(command_id:{1:#010x}) with sequence_number:{2}: {3!r}".format(
+                    smpp_command, command_id, sequence_number, e
+                ),
+                sequence_number,
+            )
+        else:
+            if not log_id and smpp_command in [
+                SmppCommand.SUBMIT_SM_RESP,
+                SmppCommand.DATA_SM_RESP,
+            ]:
+                # every submit_sm and data_sm that naz sends is correlated; so the request is unknown.
+                self._emit_error(
+                    NazReadError.CORRELATION_MISS,
+                    "{0}(command_id:{1:#010x}) with sequence_number:{2} does not correlate with any request.".format(
+                        smpp_command, command_id, sequence_number
+                    ),
+                    sequence_number,
+                )
+
+        if (
+            smpp_command in [SmppCommand.SUBMIT_SM_RESP, SmppCommand.DATA_SM_RESP]
+            and sequence_number not in self._window
+        ):
+            await self._unexpected_response(
+                smpp_command=smpp_command,
+                sequence_number=sequence_number,
+                command_status_value=command_status,
+                pdu=pdu,
+            )
 
+        self._log_pdu_contents(
+            event="naz.Client._parse_response_pdu",
+            log_id=log_id,
+            smpp_command=smpp_command,
+            message=pdu,
+        )
         await self.command_handlers(
             pdu=pdu,
             body_data=body_data,
@@ -1977,6 +6493,7 @@ class Client:
             log_id=log_id,
             hook_metadata=hook_metadata,
         )
+        await self._call_decoded_hook(pdu=pdu, log_id=log_id, hook_metadata=hook_metadata)
         self._log(
             logging.DEBUG,
             {
@@ -1984,10 +6501,86 @@ class Client:
                 "stage": "end",
                 "smpp_command": smpp_command,
                 "log_id": log_id,
+                "sequence_number": sequence_number,
                 "command_status": command_status,
             },
         )
 
+    async def _call_decoded_hook(self, pdu: bytes, log_id: str, hook_metadata: str) -> None:
+        """
+        call :func:`decoded_from_smsc <naz.hooks.BaseHook.decoded_from_smsc>` of the :attr:`hook <Client.hook>` with the decoded PDU.
+        PDUs are only decoded if the hook implements that method.
+        """
+        if type(self.hook).decoded_from_smsc is hooks.BaseHook.decoded_from_smsc:
+            return None
+        try:
+            decoded = the_pdu.decode(pdu=pdu, log_id=log_id, hook_metadata=hook_metadata)
+            await self.hook.decoded_from_smsc(
+                log_id=log_id, hook_metadata=hook_metadata, decoded=decoded
+            )
+        except asyncio.CancelledError:
+            raise
+        except Exception as e:
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Client._call_decoded_hook",
+                    "stage": "end",
+                    "log_id": log_id,
+                    "state": "decoded_from_smsc hook error",
+                    "error": repr(e),
+                },
+            )
+
+    async def _unexpected_response(
+        self, smpp_command: str, sequence_number: int, command_status_value: int, pdu: bytes
+    ) -> None:
+        """
+        handle a response whose sequence_number does not match any request that is awaiting a response. see `Client.unexpected_responses`
+        """
+        self._unexpected_responses += 1
+        commandStatus = self._search_by_command_status_value(command_status_value)
+        if commandStatus is None:
+            commandStatus = CommandStatus(
+                code="UNKNOWN",
+                value=command_status_value,
+                description="command_status is not known to naz.",
+            )
+        if self.unexpected_responses == UnexpectedResponse.LOG:
+            self._log(
+                logging.WARNING,
+                {
+                    "event": "naz.Client._parse_response_pdu",
+                    "stage": "end",
+                    "smpp_command": smpp_command,
+                    "sequence_number": sequence_number,
+                    "command_status": commandStatus.code,
+                    "state": "the response does not match any request that is awaiting a response",
+                },
+            )
+        elif self.unexpected_responses == UnexpectedResponse.HOOK:
+            try:
+                await self.hook.unexpected_response(
+                    smpp_command=smpp_command,
+                    sequence_number=sequence_number,
+                    status=commandStatus,
+                    pdu=pdu,
+                )
+            except asyncio.CancelledError:
+                raise
+            except Exception as e:
+                self._log(
+                    logging.ERROR,
+                    {
+                        "event": "naz.Client._parse_response_pdu",
+                        "stage": "end",
+                        "smpp_command": smpp_command,
+                        "sequence_number": sequence_number,
+                        "state": "unexpected_response hook error",
+                        "error": repr(e),
+                    },
+                )
+
     async def command_handlers(
         self,
         pdu: bytes,
@@ -2010,9 +6603,21 @@ class Client:
             log_id: a unique identify of this request
             hook_metadata: additional metadata that you would like to be passed on to hooks
         """
+        if smpp_command.endswith("_resp") or smpp_command == SmppCommand.GENERIC_NACK:
+            # SMSC has responded to one of our requests
+            self._release_window_slot(sequence_number)
+
         commandStatus = self._search_by_command_status_value(
             command_status_value=command_status_value
         )
+        self._emit_event(
+            the_events.PDUReceived(
+                smpp_command=smpp_command,
+                sequence_number=sequence_number,
+                command_status=commandStatus.code if commandStatus else "",
+                log_id=log_id,
+            )
+        )
         if not commandStatus:
             self._log(
                 logging.ERROR,
@@ -2021,6 +6626,7 @@ class Client:
                     "stage": "start",
                     "smpp_command": smpp_command,
                     "log_id": log_id,
+                    "sequence_number": sequence_number,
                     "error": "command_status: `{0}` is unknown.".format(command_status_value),
                 },
             )
@@ -2034,8 +6640,16 @@ class Client:
                     "stage": "start",
                     "smpp_command": smpp_command,
                     "log_id": log_id,
+                    "sequence_number": sequence_number,
                     "command_status": commandStatus.value,
                     "state": commandStatus.description,
+                    "error": str(
+                        NazCommandStatusError(
+                            smpp_command=smpp_command,
+                            command_status=commandStatus,
+                            command_status_value=command_status_value,
+                        )
+                    ),
                 },
             )
         else:
@@ -2046,6 +6660,7 @@ class Client:
                     "stage": "start",
                     "smpp_command": smpp_command,
                     "log_id": log_id,
+                    "sequence_number": sequence_number,
                     "command_status": commandStatus.value,
                     "state": commandStatus.description,
                 },
@@ -2057,6 +6672,12 @@ class Client:
                 SmppCommandStatus.ESME_RTHROTTLED.value,
                 SmppCommandStatus.ESME_RMSGQFUL.value,
             ]:
+                self._record_metric("throttled")
+                self._emit_event(
+                    the_events.Throttled(
+                        smpp_command=smpp_command, sequence_number=sequence_number, log_id=log_id
+                    )
+                )
                 await self.throttle_handler.throttled()
             else:
                 await self.throttle_handler.not_throttled()
@@ -2069,39 +6690,80 @@ class Client:
                     "error": repr(e),
                     "smpp_command": smpp_command,
                     "log_id": log_id,
+                    "sequence_number": sequence_number,
                     "state": commandStatus.description,
                 },
             )
 
         if smpp_command in [
             SmppCommand.BIND_TRANSCEIVER,
-            SmppCommand.UNBIND_RESP,
             SmppCommand.SUBMIT_SM,  # We dont expect SMSC to send `submit_sm` to us.
             SmppCommand.DELIVER_SM_RESP,
             # we will never send a deliver_sm request to SMSC, which means we never
             # have to handle deliver_sm_resp
-            SmppCommand.ENQUIRE_LINK_RESP,
-            SmppCommand.GENERIC_NACK,  # we can ignore this
         ]:
             # we never have to handle this
             pass
-        elif smpp_command == SmppCommand.BIND_TRANSCEIVER_RESP:
-            # the body of `bind_transceiver_resp` only has `system_id` which is a
-            # C-Octet String of variable length upto 16 octets
+        elif smpp_command in [
+            SmppCommand.BIND_TRANSCEIVER_RESP,
+            SmppCommand.BIND_TRANSMITTER_RESP,
+            SmppCommand.BIND_RECEIVER_RESP,
+        ]:
+            # the body of a bind response has `system_id` which is a
+            # C-Octet String of variable length upto 16 octets, optionally followed by `sc_interface_version`
             if commandStatus.value == SmppCommandStatus.ESME_ROK.value:
-                self.current_session_state = SmppSessionState.BOUND_TRX
+                self.current_session_state = self._bound_state
+                bind_resp = the_pdu.decode(pdu)
+                if isinstance(bind_resp, the_pdu.BindResp):
+                    self._smsc_system_id = bind_resp.system_id
+                    self._smsc_interface_version = bind_resp.sc_interface_version
+                else:
+                    # a malformed body
+                    self._smsc_system_id, self._smsc_interface_version = "", None
+                self._log(
+                    logging.INFO,
+                    {
+                        "event": "naz.Client.command_handlers",
+                        "stage": "end",
+                        "smpp_command": smpp_command,
+                        "log_id": log_id,
+                        "smsc_system_id": self._smsc_system_id,
+                        "smsc_interface_version": self._smsc_interface_version,
+                    },
+                )
+        elif smpp_command == SmppCommand.OUTBIND:
+            # SMSC wants us to bind to it, as a receiver.
+            await self._handle_outbind(body_data=body_data, log_id=log_id)
+        elif smpp_command == SmppCommand.GENERIC_NACK:
+            # SMSC was unable to make sense of one of our requests, eg because it was malformed.
+            # It has no body. Its sequence_number is that of the request, so the caller waiting for
+            # the response of that request(if any) fails right away rather than waiting for a timeout.
+            self._resolve_pending_response(
+                smpp_command=smpp_command,
+                sequence_number=sequence_number,
+                command_status=commandStatus,
+                body_data=body_data,
+                error=NazCommandStatusError(
+                    smpp_command=smpp_command,
+                    command_status=commandStatus,
+                    command_status_value=command_status_value,
+                ),
+            )
         elif smpp_command == SmppCommand.UNBIND:
             # we need to handle this since we need to send unbind_resp
             # it has no body
             await self.unbind_resp(sequence_number=sequence_number)
-        elif smpp_command == SmppCommand.SUBMIT_SM_RESP:
+            await self._handle_smsc_unbind(log_id=log_id)
+        elif smpp_command in [SmppCommand.SUBMIT_SM_RESP, SmppCommand.DATA_SM_RESP]:
+            if command_status_value != SmppCommandStatus.ESME_ROK.value:
+                self._failed[commandStatus.code] = self._failed.get(commandStatus.code, 0) + 1
             try:
                 # the body of this only has `message_id` which is a C-Octet String of variable length upto 65 octets.
                 # This field contains the SMSC message_id of the submitted message.
                 # It may be used at a later stage to query the status of a message, cancel
                 # or replace the message.
-                _message_id = body_data.replace(chr(0).encode("ascii"), b"")
-                smsc_message_id = _message_id.decode("ascii")
+                # `data_sm_resp` may also have optional parameters after the message_id.
+                smsc_message_id, _ = protocol._read_c_octet_string(body_data, 0)
                 await self.correlation_handler.put(
                     smpp_command=smpp_command,
                     sequence_number=sequence_number,
@@ -2121,6 +6783,23 @@ class Client:
                         "error": repr(e),
                     },
                 )
+            # `Client.submit_message` waits for this, `Client.send_message` does not.
+            if sequence_number in self._pending_responses:
+                self._resolve_pending_response(
+                    smpp_command=smpp_command,
+                    sequence_number=sequence_number,
+                    command_status=commandStatus,
+                    body_data=body_data,
+                )
+            retrying = self._schedule_retry(
+                sequence_number=sequence_number,
+                smpp_command=smpp_command,
+                command_status=commandStatus,
+                command_status_value=command_status_value,
+            )
+            if log_id and not retrying:
+                # a broker that persists messages need not replay this one.
+                await self._acknowledge(log_id=log_id, sequence_number=sequence_number)
         elif smpp_command == SmppCommand.DELIVER_SM:
             # HEADER::
             # command_length, int, 4octet
@@ -2151,34 +6830,36 @@ class Client:
             # sm_length, Int, 1 octet.It is length of short message user data in octets.
             # short_message, C-Octet String, 0-254 octet
 
-            await self.deliver_sm_resp(sequence_number=sequence_number)
+            self._delivered += 1
+            command_status = await self._handle_deliver_sm(
+                pdu=pdu, log_id=log_id, hook_metadata=hook_metadata
+            )
+            try:
+                smsc_message_id = self._receipted_message_id(pdu)
+            except Exception as e:
+                smsc_message_id = None
+                self._log(
+                    logging.ERROR,
+                    {
+                        "event": "naz.Client.command_handlers",
+                        "stage": "start",
+                        "log_id": log_id,
+                        "state": "receipted_message_id error",
+                        "error": repr(e),
+                    },
+                )
+            await self.deliver_sm_resp(
+                sequence_number=sequence_number,
+                command_status=command_status,
+                message_id=smsc_message_id or "",
+            )
             try:
                 # get associated user supplied log_id if any
-                target_tag = struct.pack(
-                    ">H", OptionalTag.NAME_to_TAG["receipted_message_id"]
-                )  # unsigned Int, 2octet
-                if target_tag in body_data:
-                    # the PDU contains a `receipted_message_id` TLV optional tag
-                    position_of_target_tag = body_data.find(target_tag)
-                    # since a tag is 2 integer in size lets skip one more.
-                    end_of_target_tag = position_of_target_tag + 1
-                    # since after a tag, comes a tag_length which is 2 integer in size
-                    # lets also skip that
-                    end_of_target_tag_length = end_of_target_tag + 2
-                    end_of_target_tag_length = (
-                        end_of_target_tag_length + 1
-                    )  # because of c-octet string null termination
-                    # tag_value is of size 1 - 65
-                    end_of_tag_value = end_of_target_tag_length + 65
-                    tag_value = body_data[end_of_target_tag_length:end_of_tag_value]
-                    _tag_value = tag_value.replace(
-                        chr(0).encode("ascii"), b""
-                    )  # change variable names to make mypy happy
-                    t_value = _tag_value.decode("ascii")
+                if smsc_message_id is not None:
                     log_id, hook_metadata = await self.correlation_handler.get(
                         smpp_command=smpp_command,
                         sequence_number=sequence_number,
-                        smsc_message_id=t_value,
+                        smsc_message_id=smsc_message_id,
                     )
             except Exception as e:
                 log_id, hook_metadata = "", ""
@@ -2192,10 +6873,63 @@ class Client:
                         "error": repr(e),
                     },
                 )
+        elif smpp_command == SmppCommand.ENQUIRE_LINK_RESP:
+            if body_data:
+                # it has no body; but some SMSC's send one anyway. that is no reason to drop the connection.
+                self._log(
+                    logging.WARNING,
+                    {
+                        "event": "naz.Client.command_handlers",
+                        "stage": "end",
+                        "smpp_command": smpp_command,
+                        "log_id": log_id,
+                        "sequence_number": sequence_number,
+                        "state": "ignoring the {0} octets body of enquire_link_resp; it should have no body".format(
+                            len(body_data)
+                        ),
+                    },
+                )
+            enquire_link_sequence_number, sent_at = self._latest_enquire_link
+            if sequence_number == enquire_link_sequence_number:
+                self._latest_enquire_link_resp_at = time.monotonic()
+                self._record_enquire_link_latency(self._latest_enquire_link_resp_at - sent_at)
+            # `Client.enquire_link` only waits for this if `enquire_link_response_timeout` is set
+            if sequence_number in self._pending_responses:
+                self._resolve_pending_response(
+                    smpp_command=smpp_command,
+                    sequence_number=sequence_number,
+                    command_status=commandStatus,
+                    body_data=body_data,
+                )
+        elif smpp_command == SmppCommand.UNBIND_RESP:
+            # `Client.unbind` only waits for this if it was called with a timeout
+            if sequence_number in self._pending_responses:
+                self._resolve_pending_response(
+                    smpp_command=smpp_command,
+                    sequence_number=sequence_number,
+                    command_status=commandStatus,
+                    body_data=body_data,
+                )
         elif smpp_command == SmppCommand.ENQUIRE_LINK:
             # we have to handle this. we have to return enquire_link_resp
             # it has no body
             await self.enquire_link_resp(sequence_number=sequence_number)
+        elif smpp_command in [
+            SmppCommand.SUBMIT_MULTI_RESP,
+            SmppCommand.QUERY_SM_RESP,
+            SmppCommand.CANCEL_SM_RESP,
+            SmppCommand.REPLACE_SM_RESP,
+            SmppCommand.BROADCAST_SM_RESP,
+            SmppCommand.QUERY_BROADCAST_SM_RESP,
+            SmppCommand.CANCEL_BROADCAST_SM_RESP,
+        ]:
+            # the caller that sent the request is waiting for this response
+            self._resolve_pending_response(
+                smpp_command=smpp_command,
+                sequence_number=sequence_number,
+                command_status=commandStatus,
+                body_data=body_data,
+            )
         else:
             self._log(
                 logging.ERROR,
@@ -2204,6 +6938,7 @@ class Client:
                     "stage": "end",
                     "smpp_command": smpp_command,
                     "log_id": log_id,
+                    "sequence_number": sequence_number,
                     "command_status": commandStatus.code,
                     "state": commandStatus.description,
                     "error": "the smpp_command: `{0}` has not been implemented in naz. please create a github issue".format(
@@ -2211,7 +6946,13 @@ class Client:
                     ),
                 },
             )
+            self._emit_error(
+                NazReadError.UNKNOWN_COMMAND_ID,
+                "the smpp_command: `{0}` has not been implemented in naz.".format(smpp_command),
+                sequence_number,
+            )
 
+        self._record_metric("pdu_received", smpp_command, commandStatus)
         try:
             # call user's hook for responses
             # this has to be done last
@@ -2230,14 +6971,18 @@ class Client:
                     "stage": "end",
                     "smpp_command": smpp_command,
                     "log_id": log_id,
+                    "sequence_number": sequence_number,
                     "state": "from_smsc hook error",
                     "error": repr(e),
                 },
             )
 
-    async def unbind(self) -> None:
+    async def unbind(self, timeout: typing.Union[None, float] = None) -> None:
         """
         send an UNBIND pdu to SMSC.
+
+        Parameters:
+            timeout: if provided, the duration in seconds to wait for SMSC to respond with an UNBIND_RESP.
         """
         smpp_command = SmppCommand.UNBIND
         log_id = "".join(random.choices(string.ascii_lowercase + string.digits, k=17))
@@ -2300,8 +7045,29 @@ class Client:
 
         header = struct.pack(">IIII", command_length, command_id, command_status, sequence_number)
         full_pdu = header + body
-        # dont queue unbind in SimpleBroker since we dont want it to be behind 10k msgs etc
-        await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+        if timeout is not None:
+            response: asyncio.Future = asyncio.get_event_loop().create_future()
+            self._pending_responses[sequence_number] = response
+        try:
+            # dont queue unbind in SimpleBroker since we dont want it to be behind 10k msgs etc
+            await self.send_data(smpp_command=smpp_command, msg=full_pdu, log_id=log_id)
+            if timeout is not None:
+                await asyncio.wait_for(response, timeout)
+        except asyncio.TimeoutError:
+            self._log(
+                logging.WARNING,
+                {
+                    "event": "naz.Client.unbind",
+                    "stage": "end",
+                    "log_id": log_id,
+                    "smpp_command": smpp_command,
+                    "state": "SMSC did not respond to unbind within {0:.2f} seconds".format(
+                        timeout
+                    ),
+                },
+            )
+        finally:
+            self._pending_responses.pop(sequence_number, None)
         self._log(
             logging.INFO,
             {
@@ -2312,27 +7078,97 @@ class Client:
             },
         )
 
-    async def shutdown(self) -> None:
+    async def shutdown(self) -> int:
         """
-        Cleanly shutdown this client.
+        Cleanly shutdown this client. In order, it:
+          - stops accepting new messages; :func:`send_message <Client.send_message>` raises :class:`NazConnectionError <NazConnectionError>`
+          - waits for the messages queued in the :attr:`broker <Client.broker>`, and those already dequeued, to be sent to SMSC
+          - waits for SMSC to respond to the requests that are awaiting a response(see :func:`outstanding <Client.outstanding>`);
+            the callers, eg of :func:`submit_message <Client.submit_message>`, that are still waiting then are failed with :class:`NazConnectionError <NazConnectionError>`
+          - stops consuming from the queue and sending `enquire_link` requests
+          - sends an unbind request to SMSC and waits for the unbind_resp
+          - closes the network connection
+
+        All that is done within :attr:`drain_duration <Client.drain_duration>` seconds.
+        If that duration elapses first, the connection is closed anyway.
+        The queue can only be waited upon if the broker implements :func:`size <naz.broker.BaseBroker.size>`
+
+        Returns:
+            the number of messages that had not been sent to SMSC.
         """
         self._log(
             logging.INFO,
             {"event": "naz.Client.shutdown", "stage": "start", "state": "intiating shutdown"},
         )
+        deadline = time.monotonic() + self.drain_duration
+        self._shutting_down = True
+
+        remaining = await self._drain_messages(deadline)
+        await self._drain_responses(deadline)
         self.SHOULD_SHUT_DOWN = True
+        if self._window_maintenance is not None:
+            self._window_maintenance.cancel()
+        await self._unbind_and_disconnect(unbind_timeout=max(deadline - time.monotonic(), 0.0))
 
-        await self._unbind_and_disconnect()
+        self._log(
+            logging.INFO,
+            {"event": "naz.Client.shutdown", "stage": "end", "remaining_messages": remaining},
+        )
+        return remaining
+
+    def _unsent_messages(self) -> int:
+        return (self.broker.size() or 0) + self._in_flight_sends
 
-        # sleep so that client can:
-        # - stop consuming from queue
-        # - finish sending any SMSes it may have already picked from queue
-        # - stop sending `enquire_link` requests
-        # - send unbind to SMSC
-        await asyncio.sleep(self.drain_duration)  # asyncio.sleep so that we do not block eventloop
-        self._log(logging.DEBUG, {"event": "naz.Client.shutdown", "stage": "end"})
+    async def _drain_messages(self, deadline: float) -> int:
+        """
+        wait, until the deadline, for the queued and in-flight messages to be sent to SMSC.
+
+        Returns:
+            the number of messages that are yet to be sent.
+        """
+        while self._unsent_messages() > 0:
+            now = time.monotonic()
+            if now >= deadline or self.current_session_state != self._bound_state or self.paused:
+                # we cannot send messages if we are not bound, or if dequeuing is paused.
+                break
+            await asyncio.sleep(min(0.05, deadline - now))
+        return self._unsent_messages()
+
+    async def _drain_responses(self, deadline: float) -> None:
+        """
+        wait, until the deadline, for SMSC to respond to the requests that are awaiting a response.
+        The callers that are still waiting for a response after that are released with an error, rather than left blocked.
+        """
+        while self._window or self._pending_responses or self._raw_responses:
+            now = self._reclaim_window_slots(log_id="")
+            if now >= deadline or self.current_session_state != self._bound_state:
+                # SMSC cannot respond if we are not bound.
+                break
+            await asyncio.sleep(min(0.05, deadline - now))
+
+        for sequence_number, response in list(self._pending_responses.items()) + list(
+            self._raw_responses.items()
+        ):
+            if response.done():
+                continue
+            self._log(
+                logging.WARNING,
+                {
+                    "event": "naz.Client.shutdown",
+                    "stage": "end",
+                    "sequence_number": sequence_number,
+                    "state": "SMSC did not respond before shutdown",
+                },
+            )
+            response.set_exception(
+                NazConnectionError(
+                    "SMSC did not respond to the request with sequence_number:{0}; naz is shutting down.".format(
+                        sequence_number
+                    )
+                )
+            )
 
-    async def _unbind_and_disconnect(self):
+    async def _unbind_and_disconnect(self, unbind_timeout: typing.Union[None, float] = None):
         """
         unbind from SMSC and close network connection.
         This is usually done in two situations;
@@ -2340,6 +7176,10 @@ class Client:
           - if we got into an unrecoverable state and need to start over; issues/135
         """
         self._log(logging.DEBUG, {"event": "naz.Client._unbind_and_disconnect", "stage": "start"})
+        if self.writer is None:
+            # already disconnected; eg because SMSC unbound.
+            self.current_session_state = SmppSessionState.CLOSED
+            return None
 
         if typing.TYPE_CHECKING:
             # make mypy happy; https://github.com/python/mypy/issues/4805
@@ -2356,7 +7196,7 @@ class Client:
             # see: https://github.com/komuw/naz/issues/117
             self.writer.transport.set_write_buffer_limits(0)  # pytype: disable=attribute-error
             # https://github.com/google/pytype/issues/350
-            await self.unbind()
+            await self.unbind(timeout=unbind_timeout)
             async with self.drain_lock:
                 await self.writer.drain()
             self.writer.write_eof()
@@ -2389,3 +7229,186 @@ class NazClientError(Exception):
     """
 
     pass
+
+
+class NazConnectionError(Exception):
+    """
+    Error raised when an operation cannot be carried out because naz is not connected to SMSC, or is shutting down.
+    """
+
+    pass
+
+
+class NazBindTimeoutError(NazConnectionError):
+    """
+    Error raised when SMSC does not respond to a bind request within :attr:`bind_timeout <Client.bind_timeout>`
+    """
+
+    pass
+
+
+class NazMessageTooLongError(Exception):
+    """
+    Error raised when the encoded short_message of a `submit_sm` is longer than what the short_message field can hold,
+    and it is neither split(see :attr:`split_long_messages <Client.split_long_messages>`) nor sent in the `message_payload` optional parameter(see :attr:`message_payload_fallback <Client.message_payload_fallback>`).
+    """
+
+    def __init__(self, length: int, limit: int) -> None:
+        """
+        Parameters:
+            length: the length, in octets, of the encoded short_message.
+            limit: the maximum length, in octets, of a short_message.
+        """
+        self.length = length
+        self.limit = limit
+        super(NazMessageTooLongError, self).__init__(
+            "the encoded short_message is {0} octets long, which is longer than the limit of {1} octets. "
+            "Use `split_long_messages` or `message_payload_fallback` to send it.".format(
+                length, limit
+            )
+        )
+
+
+class NazDuplicateMessageError(Exception):
+    """
+    Error raised when a message is sent, using :func:`send_message <Client.send_message>`, whose `log_id` is the same as that of a message that was sent within the :attr:`dedup_window <Client.dedup_window>`
+    """
+
+    def __init__(self, log_id: str, dedup_window: float) -> None:
+        """
+        Parameters:
+            log_id: the log_id of the message.
+            dedup_window: the duration in seconds within which messages with the same log_id are rejected.
+        """
+        self.log_id = log_id
+        self.dedup_window = dedup_window
+        super(NazDuplicateMessageError, self).__init__(
+            "a message with log_id:{0} was already sent within the last {1} seconds.".format(
+                log_id, dedup_window
+            )
+        )
+
+
+class NazResponseMismatchError(Exception):
+    """
+    Error raised when SMSC responds to a request with a response of a command other than the one that the request expects.
+    eg; it responds to a `query_sm` with a `cancel_sm_resp` that has the same sequence_number.
+    """
+
+    def __init__(
+        self,
+        expected_smpp_command: str,
+        expected_command_id: typing.Union[None, int],
+        smpp_command: str,
+        command_id: typing.Union[None, int],
+        sequence_number: int,
+        command_length: int,
+    ) -> None:
+        """
+        Parameters:
+            expected_smpp_command: the response command that the request expects. eg; query_sm_resp
+            expected_command_id: the command_id of `expected_smpp_command`
+            smpp_command: the response command that SMSC responded with. eg; cancel_sm_resp
+            command_id: the command_id of the response that SMSC responded with.
+            sequence_number: the sequence_number of the response that SMSC responded with.
+            command_length: the command_length of the response that SMSC responded with.
+        """
+        self.expected_smpp_command = expected_smpp_command
+        self.expected_command_id = expected_command_id
+        self.smpp_command = smpp_command
+        self.command_id = command_id
+        self.sequence_number = sequence_number
+        self.command_length = command_length
+        super(NazResponseMismatchError, self).__init__(
+            "expected {0}(command_id:{1:#010x}) for the request with sequence_number:{2}, "
+            "but SMSC responded with {3}(command_id:{4:#010x}, command_length:{5}).".format(
+                expected_smpp_command,
+                expected_command_id or 0,
+                sequence_number,
+                smpp_command,
+                command_id or 0,
+                command_length,
+            )
+        )
+
+
+class NazReadError(Exception):
+    """
+    A non-fatal error that naz hit while reading the PDUs from SMSC. It is not raised; it is put in the queue returned by :func:`Client.errors <Client.errors>`
+    """
+
+    # the kinds of errors
+    MALFORMED_PDU = "malformed_pdu"
+    UNKNOWN_COMMAND_ID = "unknown_command_id"
+    CORRELATION_MISS = "correlation_miss"
+
+    def __init__(
+        self, kind: str, message: str, sequence_number: typing.Union[None, int] = None
+    ) -> None:
+        """
+        Parameters:
+            kind: the kind of error; one of `malformed_pdu`, `unknown_command_id` and `correlation_miss`
+            message: the description of the error.
+            sequence_number: the sequence_number of the PDU, if it could be read.
+        """
+        self.kind = kind
+        self.sequence_number = sequence_number
+        super(NazReadError, self).__init__(message)
+
+
+class NazCommandStatusError(Exception):
+    """
+    Error raised when SMSC responds to a request with a command_status other than `ESME_ROK`.
+
+    Use :func:`is_status <NazCommandStatusError.is_status>` to check for a particular command status;
+
+    .. highlight:: python
+    .. code-block:: python
+
+        try:
+            await client.query_message(...)
+        except naz.client.NazCommandStatusError as e:
+            if e.is_status(naz.SmppCommandStatus.ESME_RINVMSGID):
+                ...
+    """
+
+    def __init__(
+        self,
+        smpp_command: str,
+        command_status: CommandStatus,
+        command_status_value: typing.Union[None, int] = None,
+    ) -> None:
+        """
+        Parameters:
+            smpp_command: the SMPP command that failed. eg submit_sm_resp
+            command_status: the command status that SMSC responded with.
+            command_status_value: the numeric command_status that SMSC responded with.
+                                  It defaults to the value of `command_status`, but is needed for the reserved command statuses which cover a range of values.
+        """
+        if command_status_value is None and isinstance(command_status.value, int):
+            command_status_value = command_status.value
+        self.smpp_command = smpp_command
+        self.command_status = command_status
+        self.command_status_value = command_status_value
+
+        value = "unknown"
+        if command_status_value is not None:
+            value = "0x{0:08X}".format(command_status_value)
+        super(NazCommandStatusError, self).__init__(
+            "smpp_command `{0}` failed with command_status `{1}`({2}): {3}".format(
+                smpp_command, command_status.code, value, command_status.description
+            )
+        )
+
+    def is_status(self, command_status: CommandStatus) -> bool:
+        """
+        returns True if this error was caused by the given command status.
+
+        Parameters:
+            command_status: any one of the :class:`SmppCommandStatus <naz.state.SmppCommandStatus>` attributes.
+        """
+        if isinstance(command_status.value, list):
+            if self.command_status_value is None:
+                return command_status == self.command_status
+            return command_status.value[0] <= self.command_status_value <= command_status.value[1]
+        return command_status.value == self.command_status_value
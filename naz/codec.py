@@ -35,6 +35,8 @@
 import codecs
 import typing
 
+from . import state
+
 
 # An alternative to using this codec module is to use: https://github.com/dsch/gsm0338
 # however, I'm guessing that vumi has been in use longer and we should thus go with it.
@@ -44,6 +46,49 @@ class NazCodecException(Exception):
     pass
 
 
+class CodecError(UnicodeEncodeError):
+    """
+    Error raised when a message cannot be encoded because one of its characters is not representable in the encoding.
+    It is a `UnicodeEncodeError`, that also carries the character and its position; so that a precise message can be shown to the sender.
+
+    Example Usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        try:
+            naz.codec.GSM7BitCodec.encode("hello 😀")
+        except naz.codec.CodecError as e:
+            print(e)  # character '😀' at position 6 is not representable in gsm0338
+    """
+
+    def __init__(self, codec: str, char: str, index: int, input: str) -> None:
+        """
+        Parameters:
+            codec: the name of the encoding. eg; gsm0338
+            char: the character that is not representable in the encoding.
+            index: the position of `char` in `input`
+            input: the string that was being encoded.
+        """
+        self.codec = codec
+        self.char = char
+        self.index = index
+        super(CodecError, self).__init__(
+            codec,
+            input,
+            index,
+            index + 1,
+            "character {0!r} at position {1} is not representable in {2}".format(
+                char, index, codec
+            ),
+        )
+
+    def __str__(self) -> str:
+        return self.reason
+
+
 class GSM7BitCodec(codecs.Codec):
     """
     SMPP uses a 7-bit GSM character set.
@@ -61,20 +106,26 @@ class GSM7BitCodec(codecs.Codec):
         codec.encode("foo €")
     """
 
+    # see section 6.2.1 of GSM 03.38 (3GPP TS 23.038)
     gsm_basic_charset = (
         "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞ\x1bÆæßÉ !\"#¤%&'()*+,-./0123456789:;"
-        "<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ`¿abcdefghijklmnopqrstuvwxyzäö"
+        "<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäö"
         "ñüà"
     )
 
     gsm_basic_charset_map = dict((l, i) for i, l in enumerate(gsm_basic_charset))
 
+    # The extension table is reached by prefixing a character with the escape character(0x1B).
+    # Positions that have no character assigned are marked with a backtick(which is not part of the GSM alphabet).
+    # see section 6.2.1.1 of GSM 03.38 (3GPP TS 23.038)
+    gsm_extension_placeholder = "`"
     gsm_extension = (
-        "````````````````````^```````````````````{}`````\\````````````[~]`"
+        "``````````\x0c`````````^```````````````````{}`````\\````````````[~]`"
         "|````````````````````````````````````€``````````````````````````"
     )
 
-    gsm_extension_map = dict((l, i) for i, l in enumerate(gsm_extension))
+    # note: class attributes are not accessible inside a generator expression, hence the literal.
+    gsm_extension_map = dict((l, i) for i, l in enumerate(gsm_extension) if l != "`")
 
     # All the methods have to be staticmethods because they are passed to `codecs.CodecInfo`
     @staticmethod
@@ -122,8 +173,17 @@ class GSM7BitCodec(codecs.Codec):
         for position, c in enumerate(res):
             try:
                 if c == 27:
-                    c = next(res)
-                    result.append(GSM7BitCodec.gsm_extension[c])
+                    _next = next(res, None)
+                    if _next is None:
+                        # the escape character was the last octet; there's nothing to extend.
+                        raise IndexError("escape character at the end of input")
+                    c = _next
+                    _char = GSM7BitCodec.gsm_extension[c]
+                    if _char == GSM7BitCodec.gsm_extension_placeholder:
+                        # GSM 03.38 says that a receiving entity should display the character
+                        # from the basic charset if the extension table has no entry for it.
+                        _char = GSM7BitCodec.gsm_basic_charset[c]
+                    result.append(_char)
                 else:
                     result.append(GSM7BitCodec.gsm_basic_charset[c])
             except IndexError as indexErrorException:
@@ -152,7 +212,7 @@ class GSM7BitCodec(codecs.Codec):
 
     @staticmethod
     def _handle_encode_strict_error(char, position, obj):
-        raise UnicodeEncodeError("gsm0338", char, position, position + 1, repr(obj))
+        raise CodecError(codec="gsm0338", char=char, index=position, input=obj)
 
     @staticmethod
     def _handle_encode_ignore_error(char, position, obj):
@@ -192,12 +252,108 @@ class GSM7BitCodec(codecs.Codec):
         return "?"
 
 
+class GSM7BitPackedCodec(codecs.Codec):
+    """
+    This class implements the packed form of the 7-bit GSM character set, where 8 characters fit into 7 octets.
+    Some SMSCs expect messages with data_coding 0(SMSC Default Alphabet) to be packed.
+    Users should never have to use this directly, instead; use `naz.protocol.SubmitSM(encoding="gsm0338_packed")`
+
+    Example Usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        codec = naz.codec.GSM7BitPackedCodec()
+        codec.encode("hellohello")
+    """
+
+    # see section 6.1.2.1.1 of GSM 03.38 (3GPP TS 23.038)
+    # When a message is 8n-1 characters long, the last octet has 7 spare bits at the top which a receiver
+    # would interpret as an `@`(0x00). In that case, the spare bits are filled with a carriage return instead.
+    _CR: int = 0x0D
+
+    # All the methods have to be staticmethods because they are passed to `codecs.CodecInfo`
+    @staticmethod
+    def encode(input: str, errors: str = "strict") -> typing.Tuple[bytes, int]:
+        """
+        return an encoded(and packed) version of the string as a bytes object and its length.
+
+        Parameters:
+            input: the string to encode
+            errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+        """
+        try:
+            septets, _ = GSM7BitCodec.encode(input, errors)
+        except CodecError as e:
+            raise CodecError(
+                codec="gsm0338_packed", char=e.char, index=e.index, input=input
+            ) from None
+        if len(septets) % 8 == 7:
+            septets = septets + bytes([GSM7BitPackedCodec._CR])
+        elif len(septets) % 8 == 0 and septets.endswith(bytes([GSM7BitPackedCodec._CR])):
+            # a wanted CR that ends on an octet boundary has to be followed by another CR,
+            # otherwise the receiver will take it to be padding and strip it.
+            septets = septets + bytes([GSM7BitPackedCodec._CR])
+
+        packed = GSM7BitPackedCodec._pack(septets)
+        return (packed, len(packed))
+
+    @staticmethod
+    def decode(input: bytes, errors: str = "strict") -> typing.Tuple[str, int]:
+        """
+        return a string decoded from the given(packed) bytes and its length.
+
+        Parameters:
+            input: the bytes to decode
+            errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+        """
+        septets = GSM7BitPackedCodec._unpack(input)
+        if len(input) % 7 == 0 and septets.endswith(bytes([GSM7BitPackedCodec._CR])):
+            # the last septet is padding
+            septets = septets[:-1]
+        return GSM7BitCodec.decode(septets, errors)
+
+    @staticmethod
+    def _pack(septets: bytes, fill_bits: int = 0) -> bytes:
+        # `fill_bits` zero bits are placed before the first septet.
+        result = bytearray()
+        carry = 0
+        carry_bits = fill_bits
+        for septet in septets:
+            carry = carry | ((septet & 0x7F) << carry_bits)
+            carry_bits += 7
+            while carry_bits >= 8:
+                result.append(carry & 0xFF)
+                carry = carry >> 8
+                carry_bits -= 8
+        if carry_bits > 0:
+            result.append(carry & 0xFF)
+        return bytes(result)
+
+    @staticmethod
+    def _unpack(octets: bytes) -> bytes:
+        result = bytearray()
+        carry = 0
+        carry_bits = 0
+        for octet in octets:
+            carry = carry | (octet << carry_bits)
+            carry_bits += 8
+            while carry_bits >= 7:
+                result.append(carry & 0x7F)
+                carry = carry >> 7
+                carry_bits -= 7
+        return bytes(result)
+
+
 class UCS2Codec(codecs.Codec):
     """
     This class implements the UCS2 encoding/decoding scheme.
     Users should never have to use this directly, instead; use `naz.protocol.SubmitSM(encoding="ucs2")`
 
     UCS2 is for all intents & purposes assumed to be the same as big endian UTF16.
+    Characters outside the Basic Multilingual Plane, eg emoji, are encoded as a surrogate pair of two 16-bit code units.
     """
 
     # All the methods have to be staticmethods because they are passed to `codecs.CodecInfo`
@@ -211,7 +367,8 @@ class UCS2Codec(codecs.Codec):
             errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
         """
         # https://github.com/google/pytype/issues/348
-        return codecs.utf_16_be_encode(input, errors)
+        encoded, _ = codecs.utf_16_be_encode(_join_surrogates(input), errors)
+        return (encoded, len(input))
 
     @staticmethod
     def decode(input: bytes, errors: str = "strict") -> typing.Tuple[str, int]:
@@ -222,7 +379,91 @@ class UCS2Codec(codecs.Codec):
             input: the bytes to decode
             errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
         """
-        return codecs.utf_16_be_decode(input, errors)
+        # final=True so that a truncated surrogate pair at the end is an error, rather than silently dropped.
+        return codecs.utf_16_be_decode(input, errors, True)
+
+
+class UCS2BOMCodec(codecs.Codec):
+    """
+    This class implements the UCS2 encoding/decoding scheme, with a leading UTF-16 byte order mark(BOM); 0xFEFF.
+    It is for the SMSCs that expect UCS2 messages to start with a BOM. Users should never have to use this directly,
+    instead; use `naz.protocol.SubmitSM(encoding="ucs2_bom")`
+
+    The BOM takes up the space of one character; so a message that is sent in one SMS is at most 69 characters long,
+    and each part of a concatenated message starts with its own BOM.
+    """
+
+    BOM: bytes = codecs.BOM_UTF16_BE
+
+    # All the methods have to be staticmethods because they are passed to `codecs.CodecInfo`
+    @staticmethod
+    def encode(input: str, errors: str = "strict") -> typing.Tuple[bytes, int]:
+        """
+        return an encoded version of the string, starting with the BOM, as a bytes object and its length.
+
+        Parameters:
+            input: the string to encode
+            errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+        """
+        encoded, length = UCS2Codec.encode(input, errors)
+        return (UCS2BOMCodec.BOM + encoded, length)
+
+    @staticmethod
+    def decode(input: bytes, errors: str = "strict") -> typing.Tuple[str, int]:
+        """
+        return a string decoded from the given bytes and its length. The BOM is stripped, if the bytes start with it.
+
+        Parameters:
+            input: the bytes to decode
+            errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+        """
+        if not input.startswith(UCS2BOMCodec.BOM):
+            return UCS2Codec.decode(input, errors)
+        decoded, length = UCS2Codec.decode(input[len(UCS2BOMCodec.BOM) :], errors)
+        return (decoded, length + len(UCS2BOMCodec.BOM))
+
+
+class Latin1Codec(codecs.Codec):
+    """
+    This class implements the Latin-1(ISO-8859-1) encoding/decoding scheme; SMPP data_coding 3.
+    Each of the unicode code points U+0000 to U+00FF is encoded as the single octet of the same value,
+    and it is an error to encode any character outside that range, eg "€".
+
+    It is the codec that naz uses for the `latin_1` encoding, eg `naz.protocol.SubmitSM(encoding="latin_1")`, and for deliver_sm PDUs with data_coding 3.
+    """
+
+    # All the methods have to be staticmethods because they are passed to `codecs.CodecInfo`
+    @staticmethod
+    def encode(input: str, errors: str = "strict") -> typing.Tuple[bytes, int]:
+        """
+        return an encoded version of the string as a bytes object and its length.
+
+        Parameters:
+            input: the string to encode
+            errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+        """
+        return codecs.latin_1_encode(input, errors)
+
+    @staticmethod
+    def decode(input: bytes, errors: str = "strict") -> typing.Tuple[str, int]:
+        """
+        return a string decoded from the given bytes and its length.
+
+        Parameters:
+            input: the bytes to decode
+            errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+        """
+        return codecs.latin_1_decode(input, errors)
+
+
+def _join_surrogates(text: str) -> str:
+    """
+    A python string can hold a surrogate pair as two separate characters, eg "\\ud83d\\ude00" instead of "😀".
+    Join each such pair into the one character that it represents, so that it can be encoded. Lone surrogates are left as they are.
+    """
+    if not any(0xD800 <= ord(char) <= 0xDFFF for char in text):
+        return text
+    return text.encode("utf_16_be", "surrogatepass").decode("utf_16_be", "surrogatepass")
 
 
 _INBUILT_CODECS: typing.Dict[str, codecs.CodecInfo] = {
@@ -232,11 +473,26 @@ _INBUILT_CODECS: typing.Dict[str, codecs.CodecInfo] = {
         encode=UCS2Codec.encode,
         decode=UCS2Codec.decode,  # pytype: disable=wrong-arg-types
     ),
+    "ucs2_bom": codecs.CodecInfo(
+        name="ucs2_bom",
+        encode=UCS2BOMCodec.encode,
+        decode=UCS2BOMCodec.decode,  # pytype: disable=wrong-arg-types
+    ),
     "gsm0338": codecs.CodecInfo(
         name="gsm0338",
         encode=GSM7BitCodec.encode,
         decode=GSM7BitCodec.decode,  # pytype: disable=wrong-arg-types
     ),
+    "gsm0338_packed": codecs.CodecInfo(
+        name="gsm0338_packed",
+        encode=GSM7BitPackedCodec.encode,
+        decode=GSM7BitPackedCodec.decode,  # pytype: disable=wrong-arg-types
+    ),
+    "latin_1": codecs.CodecInfo(
+        name="latin_1",
+        encode=Latin1Codec.encode,
+        decode=Latin1Codec.decode,  # pytype: disable=wrong-arg-types
+    ),
 }
 
 
@@ -277,3 +533,227 @@ def register_codecs(custom_codecs: typing.Union[None, typing.Dict[str, codecs.Co
             return _INBUILT_CODECS.get(_encoding)
 
     codecs.register(_codec_search_function)
+
+
+# the codecs that have been registered, using `register`, for SMPP data_coding values.
+_DATA_CODING_CODECS: typing.Dict[int, codecs.CodecInfo] = {}
+
+
+def register(data_coding: int, codec_info: codecs.CodecInfo) -> None:
+    """
+    Register a codec for an SMPP data_coding value, eg for a proprietary encoding that an SMSC uses on a custom data_coding.
+    The codec is used to encode messages whose encoding is `codec_info.name`; those are sent with the given data_coding.
+    It is also used to decode the short_message of `deliver_sm` PDUs that have the given data_coding.
+
+    naz's inbuilt codecs are used for the data_coding values in :class:`naz.SmppDataCoding <naz.state.SmppDataCoding>`.
+    Registering a codec for any of those values overrides them.
+
+    Example Usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        naz.codec.register(
+            0xF5, codecs.CodecInfo(name="vendor_x", encode=vendor_encode, decode=vendor_decode)
+        )
+        msg = naz.protocol.SubmitSM(short_message="hello", encoding="vendor_x", ...)
+
+    Parameters:
+        data_coding: the data_coding value. It should be between 0 and 255.
+        codec_info: the codec to use for that data_coding.
+    """
+    if not isinstance(data_coding, int):
+        raise ValueError(
+            "`data_coding` should be of type:: `int` You entered: {0}".format(type(data_coding))
+        )
+    if data_coding < 0 or data_coding > 255:
+        raise ValueError(
+            "`data_coding` should be between 0 and 255. You entered: {0}".format(data_coding)
+        )
+    if not isinstance(codec_info, codecs.CodecInfo):
+        raise ValueError(
+            "`codec_info` should be of type:: `codecs.CodecInfo` You entered: {0}".format(
+                type(codec_info)
+            )
+        )
+    _DATA_CODING_CODECS[data_coding] = codec_info
+
+
+def lookup(data_coding: int) -> codecs.CodecInfo:
+    """
+    returns the codec that is used for an SMPP data_coding value; either one registered using :func:`register <register>` or an inbuilt one.
+
+    Parameters:
+        data_coding: the data_coding value.
+
+    Raises:
+        ValueError: raised if there is no codec for that data_coding.
+    """
+    return _codec_info(_find_encoding(data_coding))
+
+
+def _find_encoding(data_coding: int) -> str:
+    """
+    returns the encoding to use for a data_coding value as found in a PDU.
+    """
+    if data_coding in _DATA_CODING_CODECS:
+        return _DATA_CODING_CODECS[data_coding].name
+    return state.SmppDataCoding._find_encoding(data_coding)
+
+
+def _find_data_coding(encoding: str) -> state.DataCoding:
+    """
+    returns the data_coding to send a message that uses the given encoding with.
+    """
+    for data_coding, codec_info in _DATA_CODING_CODECS.items():
+        if codec_info.name == encoding:
+            return state.DataCoding(
+                code=encoding, value=data_coding, description="registered codec"
+            )
+    return state.SmppDataCoding._find_data_coding(encoding)
+
+
+def _codec_info(encoding: str) -> codecs.CodecInfo:
+    """
+    returns the codec for the encoding. Codecs registered using :func:`register <register>` take precedence.
+    """
+    for codec_info in _DATA_CODING_CODECS.values():
+        if codec_info.name == encoding:
+            return codec_info
+    codec_info = codecs.lookup(encoding)
+    if codec_info.name != encoding and encoding in _INBUILT_CODECS:
+        # python's own codec(eg `latin_1`) shadows the naz inbuilt one in `codecs.lookup`
+        return _INBUILT_CODECS[encoding]
+    return codec_info
+
+
+def detect_encoding(message: str) -> typing.Tuple[str, int]:
+    """
+    Find the most compact encoding that can represent the given message.
+    It returns the name of the encoding and its matching SMPP `data_coding` value.
+    Messages that only have characters from the GSM alphabet(including the extension table) use `gsm0338`,
+    everything else falls back to `ucs2`.
+
+    Example Usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        encoding, data_coding = naz.codec.detect_encoding("foo €")
+        msg = naz.protocol.SubmitSM(short_message="foo €", encoding=encoding, ...)
+
+    Parameters:
+        message: the message to inspect
+    """
+    for char in message:
+        if (
+            char not in GSM7BitCodec.gsm_basic_charset_map
+            and char not in GSM7BitCodec.gsm_extension_map
+        ):
+            return (state.SmppDataCoding.ucs2.code, state.SmppDataCoding.ucs2.value)
+    return (state.SmppDataCoding.gsm0338.code, state.SmppDataCoding.gsm0338.value)
+
+
+# The maximum number of characters(or octets) that fit in one SMS, and in one part of a concatenated SMS.
+# The limits for concatenated SMS are lower because part of each segment is used up by the
+# concatenation User Data Header(UDH). see section 9.2.3.24.1 of GSM 03.40 (3GPP TS 23.040)
+_GSM_SEGMENT_LIMITS: typing.Tuple[int, int] = (160, 153)  # septets
+_UCS2_SEGMENT_LIMITS: typing.Tuple[int, int] = (70, 67)  # 16-bit code units
+# the byte order mark of `ucs2_bom` takes up one code unit of each segment.
+_UCS2_BOM_SEGMENT_LIMITS: typing.Tuple[int, int] = (69, 66)
+_OCTET_SEGMENT_LIMITS: typing.Tuple[int, int] = (140, 134)  # octets
+# the UDH with a 16-bit reference number is one octet longer, so each part holds a little less.
+# see section 9.2.3.24.8 of GSM 03.40 (3GPP TS 23.040)
+_GSM_SEGMENT_LIMITS_16BIT: typing.Tuple[int, int] = (160, 152)
+_UCS2_SEGMENT_LIMITS_16BIT: typing.Tuple[int, int] = (70, 66)
+_UCS2_BOM_SEGMENT_LIMITS_16BIT: typing.Tuple[int, int] = (69, 65)
+_OCTET_SEGMENT_LIMITS_16BIT: typing.Tuple[int, int] = (140, 133)
+
+
+def _segment_limits(encoding: str, reference_16bit: bool = False) -> typing.Tuple[int, int]:
+    if encoding in (state.SmppDataCoding.gsm0338.code, state.SmppDataCoding.gsm0338_packed.code):
+        return _GSM_SEGMENT_LIMITS_16BIT if reference_16bit else _GSM_SEGMENT_LIMITS
+    elif encoding in (state.SmppDataCoding.ucs2.code, state.SmppDataCoding.utf_16_be.code):
+        return _UCS2_SEGMENT_LIMITS_16BIT if reference_16bit else _UCS2_SEGMENT_LIMITS
+    elif encoding == state.SmppDataCoding.ucs2_bom.code:
+        return _UCS2_BOM_SEGMENT_LIMITS_16BIT if reference_16bit else _UCS2_BOM_SEGMENT_LIMITS
+    return _OCTET_SEGMENT_LIMITS_16BIT if reference_16bit else _OCTET_SEGMENT_LIMITS
+
+
+def _char_size(char: str, encoding: str) -> int:
+    """
+    the number of units(septets, code units or octets) that the character takes up in the given encoding.
+    """
+    if encoding in (state.SmppDataCoding.gsm0338.code, state.SmppDataCoding.gsm0338_packed.code):
+        # extension table characters are preceded by an escape character.
+        return 2 if char in GSM7BitCodec.gsm_extension_map else 1
+    elif encoding in (
+        state.SmppDataCoding.ucs2.code,
+        state.SmppDataCoding.utf_16_be.code,
+        state.SmppDataCoding.ucs2_bom.code,
+    ):
+        # characters outside the Basic Multilingual Plane are encoded as a surrogate pair.
+        return 2 if ord(char) > 0xFFFF else 1
+    return len(_codec_info(encoding).encode(char)[0])
+
+
+def _split_message(
+    message: str, encoding: str, reference_16bit: bool = False, udh: bool = True
+) -> typing.List[str]:
+    """
+    split the message into the parts that each fit in one SMS segment.
+    The split is done on characters so that escape sequences and surrogate pairs are never broken up.
+    If `reference_16bit` is True, the parts leave room for a concatenation UDH with a 16-bit reference number.
+    If `udh` is False, the parts are not sent with a concatenation UDH(eg; they use the SAR optional parameters), so each part takes up a whole segment.
+    """
+    single_limit, multi_limit = _segment_limits(encoding, reference_16bit)
+    if not udh:
+        multi_limit = single_limit
+    if encoding in (
+        state.SmppDataCoding.ucs2.code,
+        state.SmppDataCoding.utf_16_be.code,
+        state.SmppDataCoding.ucs2_bom.code,
+    ):
+        message = _join_surrogates(message)
+    sizes = [_char_size(char, encoding) for char in message]
+    if sum(sizes) <= single_limit:
+        return [message]
+
+    parts = []
+    start = 0
+    part_size = 0
+    for index, size in enumerate(sizes):
+        if part_size + size > multi_limit:
+            parts.append(message[start:index])
+            start = index
+            part_size = 0
+        part_size += size
+    parts.append(message[start:])
+    return parts
+
+
+def segment_count(message: str, encoding: str = "gsm0338") -> int:
+    """
+    Calculate the number of SMS segments that the message will be split into when sent.
+    A message that fits within one SMS(160 gsm0338 characters, 70 ucs2 characters or 140 octets for other encodings)
+    is one segment. Longer messages are split into segments of 153, 67 or 134 respectively,
+    to make room for the concatenation header.
+
+    Example Usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        naz.codec.segment_count("hello " * 30, encoding="gsm0338") # 2
+
+    Parameters:
+        message: the message to inspect
+        encoding: the encoding that will be used to send the message
+    """
+    return len(_split_message(message, encoding))
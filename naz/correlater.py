@@ -1,5 +1,8 @@
 import abc
+import json
 import time
+import random
+import string
 import typing
 
 
@@ -66,6 +69,8 @@ class SimpleCorrelater(BaseCorrelater):
     It stores the correlation/relation between a given SMPP sequence_number(and/or smsc_message_id) and a user supplied log_id and/or hook_metadata.
 
     SimpleCorrelater also features an auto-expiration of dictionary keys(and their values) based on time.
+    It also holds at most :attr:`max_size <SimpleCorrelater.max_size>` items; when that size is exceeded,
+    the least recently used items are evicted. A lookup of an evicted item is treated just like a lookup of an item that was never stored.
 
     The storage is done in memory using a python dictionary. The storage looks like:
 
@@ -92,18 +97,35 @@ class SimpleCorrelater(BaseCorrelater):
         }
     """
 
-    def __init__(self, max_ttl: float = 15.00) -> None:
+    def __init__(self, max_ttl: float = 15.00, max_size: int = 100_000) -> None:
         """
         Parameters:
             max_ttl: The time in seconds that an item is going to be stored.
                     After the expiration of max_ttl seconds, that item will be deleted.
+            max_size: The maximum number of items that are going to be stored.
+                    When it is exceeded, the least recently used items are deleted.
         """
         if not isinstance(max_ttl, float):
             raise ValueError(
                 "`max_ttl` should be of type:: `float` You entered: {0}".format(type(max_ttl))
             )
+        if not isinstance(max_size, int):
+            raise ValueError(
+                "`max_size` should be of type:: `int` You entered: {0}".format(type(max_size))
+            )
+        if max_size < 1:
+            raise ValueError(
+                "`max_size` should be greater than zero. You entered: {0}".format(max_size)
+            )
         self.store: dict = {}
         self.max_ttl: float = max_ttl
+        self.max_size: int = max_size
+
+    def size(self) -> int:
+        """
+        returns the number of items currently stored. It can be used for monitoring.
+        """
+        return len(self.store)
 
     async def put(
         self,
@@ -114,23 +136,18 @@ class SimpleCorrelater(BaseCorrelater):
         smsc_message_id: typing.Union[None, str] = None,
     ) -> None:
         stored_at = time.monotonic()
+        key: typing.Union[None, int, str] = sequence_number
         if smpp_command == "submit_sm_resp":
             # TODO: dict with smsc_message_id should replace dict with corresponding sequence_number
             # currently we are not deduping data; we should
-            self.store[smsc_message_id] = {
-                "log_id": log_id,
-                "hook_metadata": hook_metadata,
-                "stored_at": stored_at,
-            }
-        else:
-            self.store[sequence_number] = {
-                "log_id": log_id,
-                "hook_metadata": hook_metadata,
-                "stored_at": stored_at,
-            }
+            key = smsc_message_id
+        # pop first so that a re-stored item becomes the most recently used.
+        self.store.pop(key, None)
+        self.store[key] = {"log_id": log_id, "hook_metadata": hook_metadata, "stored_at": stored_at}
 
         # garbage collect
         await self._delete_after_ttl()
+        self._evict_least_recently_used()
 
     async def get(
         self,
@@ -138,11 +155,14 @@ class SimpleCorrelater(BaseCorrelater):
         sequence_number: int,
         smsc_message_id: typing.Union[None, str] = None,
     ) -> typing.Tuple[str, str]:
+        key: typing.Union[None, int, str] = sequence_number
         if smpp_command == "deliver_sm":
-            item = self.store.get(smsc_message_id)
+            key = smsc_message_id
+        item = self.store.pop(key, None)
+        if item:
+            # re-insert so that the item becomes the most recently used.
+            self.store[key] = item
         else:
-            item = self.store.get(sequence_number)
-        if not item:
             # garbage collect
             await self._delete_after_ttl()
             return "", ""
@@ -162,3 +182,118 @@ class SimpleCorrelater(BaseCorrelater):
             time_diff = now - stored_at
             if time_diff > self.max_ttl:
                 del self.store[key]
+
+    def _evict_least_recently_used(self) -> None:
+        """
+        delete the least recently used items until the store has at most self.max_size items.
+        python dicts preserve insertion order, and items are re-inserted on access;
+        so the first keys are the least recently used ones.
+        """
+        while len(self.store) > self.max_size:
+            del self.store[next(iter(self.store))]
+
+
+class RedisCorrelater(BaseCorrelater):
+    """
+    An implementation of BaseCorrelater that stores the correlations in redis.
+    It can be used when running many naz clients, so that a delivery notification that is received by one client
+    can be correlated with a `submit_sm` that was sent by a different client.
+
+    Each correlation is stored as a redis key that expires after :attr:`max_ttl <RedisCorrelater.max_ttl>` seconds.
+    A SMPP sequence_number is only unique within one client, so the keys of the correlations made using it are scoped by :attr:`client_id <RedisCorrelater.client_id>`;
+    each naz client should thus be given its own RedisCorrelater. The keys of the correlations made using the smsc_message_id are shared by all the clients.
+
+    naz does not depend on any redis library. You should pass in a non-blocking redis client, eg `aioredis <https://github.com/aio-libs/aioredis>`_,
+    that has the coroutines `set(key, value, pexpire=milliseconds)` and `get(key)`
+
+    If redis is unavailable, the errors raised by the redis client are propagated; naz logs them and carries on.
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+        import aioredis
+
+        redis = await aioredis.create_redis_pool(address=("localhost", 6379))
+        correlater = naz.correlater.RedisCorrelater(redis=redis, max_ttl=3600.00, client_id="client-1")
+        client = naz.Client(..., client_id="client-1", correlation_handler=correlater)
+    """
+
+    def __init__(
+        self,
+        redis: typing.Any,
+        max_ttl: float = 15.00,
+        key_prefix: str = "naz:correlater:",
+        client_id: typing.Union[None, str] = None,
+    ) -> None:
+        """
+        Parameters:
+            redis: a non-blocking redis client.
+            max_ttl: The time in seconds that an item is going to be stored.
+                    After the expiration of max_ttl seconds, that item will be deleted by redis.
+            key_prefix: a prefix that is added to all the keys stored in redis.
+            client_id: an identifier of the naz client that uses this correlater, eg its `client_id`. If it is None, a random one is generated.
+        """
+        if not isinstance(max_ttl, float):
+            raise ValueError(
+                "`max_ttl` should be of type:: `float` You entered: {0}".format(type(max_ttl))
+            )
+        if not isinstance(key_prefix, str):
+            raise ValueError(
+                "`key_prefix` should be of type:: `str` You entered: {0}".format(type(key_prefix))
+            )
+        if not isinstance(client_id, (type(None), str)):
+            raise ValueError(
+                "`client_id` should be of type:: `None` or `str` You entered: {0}".format(
+                    type(client_id)
+                )
+            )
+        if client_id is None:
+            client_id = "".join(random.choices(string.ascii_uppercase + string.digits, k=17))
+        self.redis = redis
+        self.max_ttl: float = max_ttl
+        self.key_prefix: str = key_prefix
+        self.client_id: str = client_id
+
+    def _key(self, sequence_number: int, smsc_message_id: typing.Union[None, str]) -> str:
+        if smsc_message_id is not None:
+            return "{0}smsc_message_id:{1}".format(self.key_prefix, smsc_message_id)
+        return "{0}{1}:sequence_number:{2}".format(
+            self.key_prefix, self.client_id, sequence_number
+        )
+
+    async def put(
+        self,
+        smpp_command: str,
+        sequence_number: int,
+        log_id: str,
+        hook_metadata: str,
+        smsc_message_id: typing.Union[None, str] = None,
+    ) -> None:
+        if smpp_command != "submit_sm_resp":
+            # only `submit_sm_resp` is correlated using the smsc_message_id; see `SimpleCorrelater`
+            smsc_message_id = None
+        await self.redis.set(
+            self._key(sequence_number, smsc_message_id),
+            json.dumps({"log_id": log_id, "hook_metadata": hook_metadata}),
+            pexpire=int(self.max_ttl * 1000),
+        )
+
+    async def get(
+        self,
+        smpp_command: str,
+        sequence_number: int,
+        smsc_message_id: typing.Union[None, str] = None,
+    ) -> typing.Tuple[str, str]:
+        if smpp_command != "deliver_sm":
+            # only `deliver_sm` is looked up using the smsc_message_id; see `SimpleCorrelater`
+            smsc_message_id = None
+        item = await self.redis.get(self._key(sequence_number, smsc_message_id))
+        if not item:
+            return "", ""
+        if isinstance(item, bytes):
+            item = item.decode()
+        item = json.loads(item)
+        return item["log_id"], item["hook_metadata"]
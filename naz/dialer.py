@@ -0,0 +1,417 @@
+import os
+import abc
+import ssl
+import base64
+import socket
+import struct
+import typing
+import asyncio
+import hashlib
+
+
+class BaseDialer(abc.ABC):
+    """
+    Interface that must be implemented to satisfy naz's dialer.
+    User implementations should inherit this class and
+    implement the :func:`dial <BaseDialer.dial>` method with the type signature shown.
+
+    A dialer is what naz uses to make the network connection to SMSC; eg directly, through a SOCKS proxy or over a unix socket.
+    naz calls it every time that it connects to SMSC, including when it re-connects.
+    """
+
+    @abc.abstractmethod
+    async def dial(
+        self, host: str, port: int, ssl_context: typing.Union[None, ssl.SSLContext]
+    ) -> typing.Tuple[asyncio.StreamReader, asyncio.StreamWriter]:
+        """
+        called by naz to make a network connection to SMSC.
+
+        Parameters:
+            host: the :attr:`smsc_host <naz.Client.smsc_host>`
+            port: the :attr:`smsc_port <naz.Client.smsc_port>`
+            ssl_context: the :attr:`ssl_context <naz.Client.ssl_context>`. If it is not None, the connection should be made over TLS using it.
+
+        Returns:
+            the reader and writer of the connection.
+        """
+        raise NotImplementedError("dial method must be implemented.")
+
+
+class SimpleDialer(BaseDialer):
+    """
+    This is an implementation of BaseDialer.
+    It makes a TCP connection to SMSC using `asyncio.open_connection <https://docs.python.org/3/library/asyncio-stream.html#asyncio.open_connection>`_
+    """
+
+    async def dial(
+        self, host: str, port: int, ssl_context: typing.Union[None, ssl.SSLContext]
+    ) -> typing.Tuple[asyncio.StreamReader, asyncio.StreamWriter]:
+        return await asyncio.open_connection(host, port, ssl=ssl_context)
+
+
+class HappyEyeballsDialer(BaseDialer):
+    """
+    This is an implementation of BaseDialer for an SMSC whose host resolves to both IPv6 and IPv4 addresses.
+    It connects using `Happy Eyeballs <https://tools.ietf.org/html/rfc8305>`_; the addresses are tried in turn, alternating between IPv6 and IPv4,
+    and a new attempt is started every `delay` seconds, or as soon as the previous one fails, without waiting for it to time out.
+    The first connection to be made is used and the other attempts are cancelled; so an unreachable address family does not hold up the connection.
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        cli = naz.Client(
+            smsc_host="smpp.example.com",
+            ...
+            dialer=naz.dialer.HappyEyeballsDialer(delay=0.25),
+        )
+    """
+
+    def __init__(self, delay: float = 0.25, prefer_ipv6: bool = True) -> None:
+        """
+        Parameters:
+            delay: the duration in seconds to wait for an attempt to connect before the next address is also tried.
+            prefer_ipv6: whether the first address to be tried is an IPv6 one, rather than an IPv4 one.
+        """
+        if not isinstance(delay, float) or delay < 0:
+            raise ValueError(
+                "`delay` should be a `float` that is not negative. You entered: {0}".format(delay)
+            )
+        if not isinstance(prefer_ipv6, bool):
+            raise ValueError(
+                "`prefer_ipv6` should be of type:: `bool` You entered: {0}".format(
+                    type(prefer_ipv6)
+                )
+            )
+        self.delay = delay
+        self.prefer_ipv6 = prefer_ipv6
+
+    def _order(
+        self, addresses: typing.List[typing.Tuple[int, str, int]]
+    ) -> typing.List[typing.Tuple[int, str, int]]:
+        """
+        interleave the addresses of the two families, starting with the preferred one. see section 4 of rfc8305
+        """
+        first_family = socket.AF_INET6 if self.prefer_ipv6 else socket.AF_INET
+        first = [address for address in addresses if address[0] == first_family]
+        second = [address for address in addresses if address[0] != first_family]
+        ordered = []
+        for index in range(0, max(len(first), len(second))):
+            ordered.extend(first[index : index + 1] + second[index : index + 1])
+        return ordered
+
+    async def dial(
+        self, host: str, port: int, ssl_context: typing.Union[None, ssl.SSLContext]
+    ) -> typing.Tuple[asyncio.StreamReader, asyncio.StreamWriter]:
+        infos = await asyncio.get_event_loop().getaddrinfo(host, port, type=socket.SOCK_STREAM)
+        addresses: typing.List[typing.Tuple[int, str, int]] = []
+        for family, _, _, _, sockaddr in infos:
+            if (family, sockaddr[0], sockaddr[1]) not in addresses:
+                addresses.append((family, sockaddr[0], sockaddr[1]))
+        addresses = self._order(addresses)
+
+        attempts: typing.Set[asyncio.Future] = set()
+        errors: typing.List[BaseException] = []
+        connection = None
+        try:
+            while connection is None and (addresses or attempts):
+                timeout = None
+                if addresses:
+                    _, address, address_port = addresses.pop(0)
+                    attempts.add(
+                        asyncio.ensure_future(
+                            asyncio.open_connection(
+                                address,
+                                address_port,
+                                ssl=ssl_context,
+                                server_hostname=host if ssl_context is not None else None,
+                            )
+                        )
+                    )
+                    if addresses:
+                        timeout = self.delay
+                done, attempts = await asyncio.wait(
+                    attempts, timeout=timeout, return_when=asyncio.FIRST_COMPLETED
+                )
+                for attempt in done:
+                    if attempt.exception() is not None:
+                        errors.append(attempt.exception())  # type: ignore
+                    elif connection is None:
+                        connection = attempt.result()
+                    else:
+                        # more than one attempt connected at the same time.
+                        attempt.result()[1].close()
+        finally:
+            for attempt in attempts:
+                attempt.cancel()
+            if attempts:
+                await asyncio.wait(attempts)
+            for attempt in attempts:
+                if not attempt.cancelled() and attempt.exception() is None:
+                    attempt.result()[1].close()
+
+        if connection is None:
+            raise ConnectionError(
+                "unable to connect to any of the addresses of {0}:{1}. errors: {2}".format(
+                    host, port, errors
+                )
+            )
+        return connection
+
+
+class ConnectionDialer(BaseDialer):
+    """
+    This is an implementation of BaseDialer that hands over a connection that has already been made, eg one end of a `socket.socketpair` in tests.
+    The connection is used as it is; so the `host`, `port` and `ssl_context` are ignored.
+    It can only be used once, since naz is unable to re-connect over a connection that has been closed.
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        reader, writer = await asyncio.open_connection(sock=my_socket)
+        cli = naz.Client(
+            ...
+            dialer=naz.dialer.ConnectionDialer(reader, writer),
+        )
+    """
+
+    def __init__(self, reader: asyncio.StreamReader, writer: asyncio.StreamWriter) -> None:
+        """
+        Parameters:
+            reader: the reader of the connection to SMSC.
+            writer: the writer of the connection to SMSC.
+        """
+        self.reader = reader
+        self.writer = writer
+        self._used: bool = False
+
+    async def dial(
+        self, host: str, port: int, ssl_context: typing.Union[None, ssl.SSLContext]
+    ) -> typing.Tuple[asyncio.StreamReader, asyncio.StreamWriter]:
+        if self._used:
+            raise ConnectionError("the connection of ConnectionDialer has already been used.")
+        self._used = True
+        return self.reader, self.writer
+
+
+# see: https://tools.ietf.org/html/rfc6455
+_WEBSOCKET_GUID = b"258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+_OPCODE_CONTINUATION = 0x0
+_OPCODE_TEXT = 0x1
+_OPCODE_BINARY = 0x2
+_OPCODE_CLOSE = 0x8
+_OPCODE_PING = 0x9
+_OPCODE_PONG = 0xA
+
+
+def _websocket_frame(opcode: int, payload: bytes) -> bytes:
+    """
+    a single, final, websocket frame. Frames sent by a client have to be masked. see section 5.2 of rfc6455
+    """
+    header = struct.pack(">B", 0b10000000 | opcode)
+    if len(payload) < 126:
+        header = header + struct.pack(">B", 0b10000000 | len(payload))
+    elif len(payload) < 2 ** 16:
+        header = header + struct.pack(">BH", 0b10000000 | 126, len(payload))
+    else:
+        header = header + struct.pack(">BQ", 0b10000000 | 127, len(payload))
+    mask = os.urandom(4)
+    return header + mask + bytes(octet ^ mask[i % 4] for i, octet in enumerate(payload))
+
+
+async def _read_websocket_frame(reader: asyncio.StreamReader) -> typing.Tuple[bool, int, bytes]:
+    """
+    reads one websocket frame and returns its (fin, opcode, payload).
+    """
+    first, second = await reader.readexactly(2)
+    fin, opcode = bool(first & 0b10000000), first & 0b00001111
+    length = second & 0b01111111
+    if length == 126:
+        length = struct.unpack(">H", await reader.readexactly(2))[0]
+    elif length == 127:
+        length = struct.unpack(">Q", await reader.readexactly(8))[0]
+    mask = await reader.readexactly(4) if second & 0b10000000 else b""
+    payload = await reader.readexactly(length)
+    if mask:
+        payload = bytes(octet ^ mask[i % 4] for i, octet in enumerate(payload))
+    return fin, opcode, payload
+
+
+class _WebSocketWriter(asyncio.StreamWriter):
+    """
+    A StreamWriter that sends each write as one binary websocket message.
+    naz writes each PDU with a single call to `write`, so each PDU is sent in its own message.
+    """
+
+    def __init__(self, *args, **kwargs) -> None:
+        super(_WebSocketWriter, self).__init__(*args, **kwargs)
+        self._pump: typing.Union[None, asyncio.Future] = None
+
+    def write(self, data: bytes) -> None:
+        super(_WebSocketWriter, self).write(_websocket_frame(_OPCODE_BINARY, data))
+
+    def _write_control(self, opcode: int, payload: bytes) -> None:
+        if not self.transport.is_closing():
+            super(_WebSocketWriter, self).write(_websocket_frame(opcode, payload))
+
+    def write_eof(self) -> None:
+        # the close handshake takes the place of half-closing the connection. see section 7 of rfc6455
+        self._write_control(_OPCODE_CLOSE, struct.pack(">H", 1000))
+
+    def close(self) -> None:
+        self._write_control(_OPCODE_CLOSE, struct.pack(">H", 1000))
+        if self._pump is not None:
+            self._pump.cancel()
+        super(_WebSocketWriter, self).close()
+
+
+class WebSocketDialer(BaseDialer):
+    """
+    This is an implementation of BaseDialer that carries SMPP over a WebSocket connection; eg to an SMSC gateway that is only exposed over WebSocket.
+    Each PDU is sent to SMSC as a binary websocket message, and the binary messages received from SMSC are read as a stream of PDUs.
+    The connection is made to the `smsc_host` and `smsc_port` of :class:`naz.Client <naz.Client>`; and if its `ssl_context` is set, it is made over TLS(`wss://`).
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        cli = naz.Client(
+            smsc_host="smpp.example.com",
+            smsc_port=443,
+            ssl_context=ssl.create_default_context(),
+            ...
+            dialer=naz.dialer.WebSocketDialer(path="/smpp"),
+        )
+    """
+
+    def __init__(
+        self,
+        path: str = "/",
+        headers: typing.Union[None, typing.Dict[str, str]] = None,
+        subprotocols: typing.Union[None, typing.List[str]] = None,
+    ) -> None:
+        """
+        Parameters:
+            path: the path, and query string if any, of the websocket endpoint. eg; `/smpp?account=1`
+            headers: additional HTTP headers to send with the opening handshake; eg an `Authorization` header.
+            subprotocols: the websocket subprotocols to ask SMSC for, in order of preference.
+        """
+        if not isinstance(path, str) or not path.startswith("/"):
+            raise ValueError(
+                "`path` should be a `str` that starts with `/` You entered: {0}".format(path)
+            )
+        if not isinstance(headers, (type(None), dict)):
+            raise ValueError(
+                "`headers` should be of type:: `None` or `dict` You entered: {0}".format(
+                    type(headers)
+                )
+            )
+        if not isinstance(subprotocols, (type(None), list)):
+            raise ValueError(
+                "`subprotocols` should be of type:: `None` or `list` You entered: {0}".format(
+                    type(subprotocols)
+                )
+            )
+        self.path = path
+        self.headers = headers or {}
+        self.subprotocols = subprotocols or []
+
+    async def dial(
+        self, host: str, port: int, ssl_context: typing.Union[None, ssl.SSLContext]
+    ) -> typing.Tuple[asyncio.StreamReader, asyncio.StreamWriter]:
+        loop = asyncio.get_event_loop()
+        raw_reader = asyncio.StreamReader()
+        protocol = asyncio.StreamReaderProtocol(raw_reader)
+        transport, _ = await loop.create_connection(lambda: protocol, host, port, ssl=ssl_context)
+        writer = _WebSocketWriter(transport, protocol, raw_reader, loop)
+        try:
+            await self._handshake(host, port, raw_reader, transport)
+        except BaseException:
+            transport.close()
+            raise
+
+        reader = asyncio.StreamReader()
+        writer._pump = asyncio.ensure_future(self._pump(raw_reader, reader, writer))
+        return reader, writer
+
+    async def _handshake(
+        self,
+        host: str,
+        port: int,
+        raw_reader: asyncio.StreamReader,
+        transport: asyncio.BaseTransport,
+    ) -> None:
+        """
+        the opening handshake. see section 4 of rfc6455
+
+        Raises:
+            ConnectionError: raised if SMSC does not accept the websocket connection.
+        """
+        key = base64.b64encode(os.urandom(16))
+        request_headers = {
+            "Host": "{0}:{1}".format(host, port),
+            "Upgrade": "websocket",
+            "Connection": "Upgrade",
+            "Sec-WebSocket-Key": key.decode("ascii"),
+            "Sec-WebSocket-Version": "13",
+        }
+        if self.subprotocols:
+            request_headers["Sec-WebSocket-Protocol"] = ", ".join(self.subprotocols)
+        request_headers.update(self.headers)
+        request = "GET {0} HTTP/1.1\r\n".format(self.path) + "".join(
+            "{0}: {1}\r\n".format(name, value) for name, value in request_headers.items()
+        )
+        transport.write((request + "\r\n").encode("latin_1"))  # type: ignore
+
+        response = (await raw_reader.readuntil(b"\r\n\r\n")).decode("latin_1")
+        status_line, *header_lines = response.split("\r\n")
+        if status_line.split(" ")[1:2] != ["101"]:
+            raise ConnectionError(
+                "SMSC did not accept the websocket connection. It responded with: {0}".format(
+                    status_line
+                )
+            )
+        response_headers = {}
+        for line in header_lines:
+            name, _, value = line.partition(":")
+            response_headers[name.strip().lower()] = value.strip()
+        accept = base64.b64encode(hashlib.sha1(key + _WEBSOCKET_GUID).digest()).decode("ascii")
+        if response_headers.get("sec-websocket-accept") != accept:
+            raise ConnectionError("SMSC responded with an invalid `Sec-WebSocket-Accept` header.")
+
+    @staticmethod
+    async def _pump(
+        raw_reader: asyncio.StreamReader, reader: asyncio.StreamReader, writer: _WebSocketWriter
+    ) -> None:
+        """
+        reads the websocket messages from SMSC and feeds their payload to the reader that naz reads PDUs from.
+        """
+        try:
+            while True:
+                _, opcode, payload = await _read_websocket_frame(raw_reader)
+                if opcode in [_OPCODE_BINARY, _OPCODE_TEXT, _OPCODE_CONTINUATION]:
+                    # a message may be fragmented into many frames; the PDUs are a stream of octets either way.
+                    reader.feed_data(payload)
+                elif opcode == _OPCODE_PING:
+                    writer._write_control(_OPCODE_PONG, payload)
+                elif opcode == _OPCODE_CLOSE:
+                    writer._write_control(_OPCODE_CLOSE, payload[:2])
+                    reader.feed_eof()
+                    return
+        except asyncio.IncompleteReadError:
+            reader.feed_eof()
+        except asyncio.CancelledError:
+            reader.feed_eof()
+            raise
+        except Exception as e:
+            reader.set_exception(e)
@@ -0,0 +1,82 @@
+import typing
+
+
+# see: `naz.Client.events`
+
+
+class Connected(typing.NamedTuple):
+    """
+    The network connection to SMSC has been made.
+    """
+
+    smsc_host: str
+    smsc_port: int
+    kind: str = "connected"
+
+
+class Bound(typing.NamedTuple):
+    """
+    naz is bound to SMSC.
+    """
+
+    # the session state that naz is in. eg; `BOUND_TRX`
+    session_state: str
+    kind: str = "bound"
+
+
+class PDUSent(typing.NamedTuple):
+    """
+    A PDU has been written to the network connection.
+    """
+
+    smpp_command: str
+    sequence_number: int
+    log_id: str
+    kind: str = "pdu_sent"
+
+
+class PDUReceived(typing.NamedTuple):
+    """
+    A PDU has been received from SMSC.
+    """
+
+    smpp_command: str
+    sequence_number: int
+    # the code of the `command_status` in the header of the PDU. eg; `ESME_ROK`
+    command_status: str
+    log_id: str
+    kind: str = "pdu_received"
+
+
+class Throttled(typing.NamedTuple):
+    """
+    SMSC responded to a request with `ESME_RTHROTTLED`
+    """
+
+    smpp_command: str
+    sequence_number: int
+    log_id: str
+    kind: str = "throttled"
+
+
+class Reconnecting(typing.NamedTuple):
+    """
+    The connection to SMSC has been lost and naz is re-connecting.
+    """
+
+    log_id: str
+    kind: str = "reconnecting"
+
+
+class Disconnected(typing.NamedTuple):
+    """
+    The connection to SMSC has been closed.
+    """
+
+    kind: str = "disconnected"
+
+
+Event = typing.Union[Connected, Bound, PDUSent, PDUReceived, Throttled, Reconnecting, Disconnected]
+"""
+Any one of the events that are emitted on :func:`naz.Client.events <naz.Client.events>`
+"""
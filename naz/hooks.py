@@ -5,6 +5,7 @@ import typing
 from . import log
 
 if typing.TYPE_CHECKING:
+    from . import pdu  # noqa: F401
     from . import state  # noqa: F401
 
 
@@ -51,6 +52,78 @@ class BaseHook(abc.ABC):
         """
         raise NotImplementedError("from_smsc method must be implemented.")
 
+    async def decoded_from_smsc(self, log_id: str, hook_metadata: str, decoded: "pdu.PDU") -> None:
+        """
+        called after receiving data from SMSC, with the PDU decoded into the type of its command_id. See :func:`naz.pdu.decode <naz.pdu.decode>`
+        Unlike :func:`from_smsc <BaseHook.from_smsc>`, it is also called for PDUs whose command_id is unknown; as a :class:`naz.pdu.RawPDU <naz.pdu.RawPDU>`.
+        Implementing it is optional; naz only decodes the PDUs if it is implemented.
+
+        Parameters:
+            log_id: an ID that a user's application had previously supplied to naz to track/correlate different messages.
+            hook_metadata: a string that a user's application had previously supplied to naz that it may want to be correlated with the log_id.
+            decoded: the decoded PDU
+        """
+        return None
+
+    async def retrying(
+        self,
+        smpp_command: str,
+        log_id: str,
+        hook_metadata: str,
+        status: "state.CommandStatus",
+        attempt: int,
+    ) -> None:
+        """
+        called when a message that SMSC failed is going to be re-submitted. See :attr:`naz.Client.retry_policy <naz.Client.retry_policy>`
+        It is optional to implement this method.
+
+        Parameters:
+            smpp_command: the command that is re-submitted. eg submit_sm
+            log_id: an ID that a user's application had previously supplied to naz to track/correlate different messages.
+            hook_metadata: a string that a user's application had previously supplied to naz that it may want to be correlated with the log_id.
+            status: the command status that SMSC failed the message with.
+            attempt: the number of the upcoming submission; 2 for the first re-submission.
+        """
+        return None
+
+    async def deliver_sm_resp_sent(
+        self,
+        log_id: str,
+        hook_metadata: str,
+        sequence_number: int,
+        command_status: int,
+        message_id: str,
+    ) -> None:
+        """
+        called right after a `deliver_sm_resp` has been written to SMSC; both for the `ESME_ROK` ones and for those with the
+        error command_status returned by a deliver_sm handler. See :func:`naz.Client.on_deliver_sm <naz.Client.on_deliver_sm>`
+        It is optional to implement this method.
+
+        Parameters:
+            log_id: the log_id of the `deliver_sm_resp`
+            hook_metadata: the hook_metadata of the `deliver_sm_resp`
+            sequence_number: the sequence_number of the `deliver_sm_resp`; it is the same as that of the `deliver_sm` it acknowledges.
+            command_status: the numeric command_status of the `deliver_sm_resp`. eg; 0x00000000(`ESME_ROK`)
+            message_id: the SMSC message_id of the message that the `deliver_sm` is a delivery receipt for. It is empty if the `deliver_sm` is not a delivery receipt.
+        """
+        return None
+
+    async def unexpected_response(
+        self, smpp_command: str, sequence_number: int, status: "state.CommandStatus", pdu: bytes
+    ) -> None:
+        """
+        called when SMSC sends a `submit_sm_resp`(or `data_sm_resp`) whose sequence_number does not match any request that is awaiting a response;
+        if the :attr:`naz.Client.unexpected_responses <naz.Client.unexpected_responses>` is `naz.UnexpectedResponse.HOOK`
+        It is optional to implement this method.
+
+        Parameters:
+            smpp_command: the command of the response. eg submit_sm_resp
+            sequence_number: the sequence_number of the response.
+            status: the command status of the response.
+            pdu: the full PDU as received from SMSC
+        """
+        return None
+
 
 class SimpleHook(BaseHook):
     """
@@ -103,3 +176,59 @@ class SimpleHook(BaseHook):
                 "status": status.description,
             },
         )
+
+    async def retrying(
+        self,
+        smpp_command: str,
+        log_id: str,
+        hook_metadata: str,
+        status: "state.CommandStatus",
+        attempt: int,
+    ) -> None:
+        self.logger.log(
+            logging.NOTSET,
+            {
+                "event": "naz.SimpleHook.retrying",
+                "stage": "start",
+                "smpp_command": smpp_command,
+                "log_id": log_id,
+                "hook_metadata": hook_metadata,
+                "status": status.description,
+                "attempt": attempt,
+            },
+        )
+
+    async def deliver_sm_resp_sent(
+        self,
+        log_id: str,
+        hook_metadata: str,
+        sequence_number: int,
+        command_status: int,
+        message_id: str,
+    ) -> None:
+        self.logger.log(
+            logging.NOTSET,
+            {
+                "event": "naz.SimpleHook.deliver_sm_resp_sent",
+                "stage": "start",
+                "log_id": log_id,
+                "hook_metadata": hook_metadata,
+                "sequence_number": sequence_number,
+                "command_status": command_status,
+                "message_id": message_id,
+            },
+        )
+
+    async def unexpected_response(
+        self, smpp_command: str, sequence_number: int, status: "state.CommandStatus", pdu: bytes
+    ) -> None:
+        self.logger.log(
+            logging.NOTSET,
+            {
+                "event": "naz.SimpleHook.unexpected_response",
+                "stage": "start",
+                "smpp_command": smpp_command,
+                "sequence_number": sequence_number,
+                "status": status.description,
+            },
+        )
@@ -0,0 +1,183 @@
+import abc
+import typing
+
+if typing.TYPE_CHECKING:
+    from . import state  # noqa: F401
+
+
+class BaseMetrics(abc.ABC):
+    """
+    Interface that must be implemented to satisfy naz's metrics.
+    User implementations should inherit this class and
+    implement all the methods with the type signatures shown.
+
+    naz calls the methods of this class to record metrics about its internals.
+    The methods are called from within naz's event loop, so they should be fast and should not block.
+    """
+
+    @abc.abstractmethod
+    def pdu_sent(self, smpp_command: str) -> None:
+        """
+        called after a PDU has been sent to SMSC.
+
+        Parameters:
+            smpp_command: any one of the SMSC commands eg submit_sm
+        """
+        raise NotImplementedError("pdu_sent method must be implemented.")
+
+    @abc.abstractmethod
+    def pdu_received(self, smpp_command: str, status: "state.CommandStatus") -> None:
+        """
+        called after a PDU has been received from SMSC.
+
+        Parameters:
+            smpp_command: any one of the SMSC commands eg submit_sm_resp
+            status: the command_status of the PDU.
+        """
+        raise NotImplementedError("pdu_received method must be implemented.")
+
+    @abc.abstractmethod
+    def queue_depth(self, depth: int) -> None:
+        """
+        called with the number of items in the broker, each time an item is enqueued or dequeued.
+        It is only called if the broker implements :func:`size <naz.broker.BaseBroker.size>`
+
+        Parameters:
+            depth: the number of items in the broker.
+        """
+        raise NotImplementedError("queue_depth method must be implemented.")
+
+    @abc.abstractmethod
+    def enquire_link_latency(self, latency: float) -> None:
+        """
+        called after an enquire_link_resp has been received from SMSC.
+
+        Parameters:
+            latency: the duration in seconds between sending the enquire_link and receiving its response.
+        """
+        raise NotImplementedError("enquire_link_latency method must be implemented.")
+
+    @abc.abstractmethod
+    def reconnect(self) -> None:
+        """
+        called each time naz tries to re-establish a lost connection to SMSC.
+        """
+        raise NotImplementedError("reconnect method must be implemented.")
+
+    @abc.abstractmethod
+    def throttled(self) -> None:
+        """
+        called each time SMSC responds with a throttling error; eg ESME_RTHROTTLED
+        """
+        raise NotImplementedError("throttled method must be implemented.")
+
+
+class PrometheusMetrics(BaseMetrics):
+    """
+    An implementation of BaseMetrics that keeps the metrics in memory and exposes them as a
+    `prometheus <https://prometheus.io/>`_ custom collector.
+
+    naz does not depend on `prometheus_client <https://github.com/prometheus/client_python>`_;
+    you need to install it and register this collector against your own registry.
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+        import prometheus_client
+
+        metrics = naz.metrics.PrometheusMetrics()
+        prometheus_client.REGISTRY.register(metrics)
+        client = naz.Client(..., metrics=metrics)
+    """
+
+    def __init__(self, namespace: str = "naz") -> None:
+        """
+        Parameters:
+            namespace: a prefix that is added to the names of all the metrics.
+        """
+        if not isinstance(namespace, str):
+            raise ValueError(
+                "`namespace` should be of type:: `str` You entered: {0}".format(type(namespace))
+            )
+        self.namespace: str = namespace
+        self.pdus_sent: typing.Dict[str, int] = {}
+        # keyed by (smpp_command, command_status code)
+        self.pdus_received: typing.Dict[typing.Tuple[str, str], int] = {}
+        self.current_queue_depth: int = 0
+        self.enquire_link_latency_count: int = 0
+        self.enquire_link_latency_sum: float = 0.00
+        self.reconnects: int = 0
+        self.throttles: int = 0
+
+    def pdu_sent(self, smpp_command: str) -> None:
+        self.pdus_sent[smpp_command] = self.pdus_sent.get(smpp_command, 0) + 1
+
+    def pdu_received(self, smpp_command: str, status: "state.CommandStatus") -> None:
+        key = (smpp_command, status.code)
+        self.pdus_received[key] = self.pdus_received.get(key, 0) + 1
+
+    def queue_depth(self, depth: int) -> None:
+        self.current_queue_depth = depth
+
+    def enquire_link_latency(self, latency: float) -> None:
+        self.enquire_link_latency_count += 1
+        self.enquire_link_latency_sum += latency
+
+    def reconnect(self) -> None:
+        self.reconnects += 1
+
+    def throttled(self) -> None:
+        self.throttles += 1
+
+    def collect(self) -> typing.Iterator[typing.Any]:
+        """
+        called by prometheus_client when the registry that this collector is registered with is scraped.
+        """
+        from prometheus_client.core import (
+            CounterMetricFamily,
+            GaugeMetricFamily,
+            SummaryMetricFamily,
+        )
+
+        sent = CounterMetricFamily(
+            "{0}_pdus_sent".format(self.namespace),
+            "Number of PDUs sent to SMSC.",
+            labels=["smpp_command"],
+        )
+        for smpp_command, count in self.pdus_sent.items():
+            sent.add_metric([smpp_command], count)
+        yield sent
+
+        received = CounterMetricFamily(
+            "{0}_pdus_received".format(self.namespace),
+            "Number of PDUs received from SMSC.",
+            labels=["smpp_command", "command_status"],
+        )
+        for (smpp_command, command_status), count in self.pdus_received.items():
+            received.add_metric([smpp_command, command_status], count)
+        yield received
+
+        yield GaugeMetricFamily(
+            "{0}_queue_depth".format(self.namespace),
+            "Number of messages in the broker.",
+            value=self.current_queue_depth,
+        )
+        yield SummaryMetricFamily(
+            "{0}_enquire_link_latency_seconds".format(self.namespace),
+            "Round trip time of enquire_link requests.",
+            count_value=self.enquire_link_latency_count,
+            sum_value=self.enquire_link_latency_sum,
+        )
+        yield CounterMetricFamily(
+            "{0}_reconnects".format(self.namespace),
+            "Number of times naz has tried to re-establish the connection to SMSC.",
+            value=self.reconnects,
+        )
+        yield CounterMetricFamily(
+            "{0}_throttles".format(self.namespace),
+            "Number of throttling responses received from SMSC.",
+            value=self.throttles,
+        )
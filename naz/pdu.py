@@ -0,0 +1,318 @@
+import struct
+import typing
+
+from . import state
+from . import protocol
+
+
+# see section 4 of smpp ver 3.4 spec document for the bodies of the PDUs.
+
+
+class RawPDU(typing.NamedTuple):
+    """
+    A PDU whose command_id naz does not decode. It carries the undecoded header and body.
+    """
+
+    command_id: int
+    command_status: int
+    sequence_number: int
+    header: bytes
+    body: bytes
+    smpp_command: str = ""
+
+
+class GenericNack(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    smpp_command: str = state.SmppCommand.GENERIC_NACK
+
+
+class EnquireLink(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    smpp_command: str = state.SmppCommand.ENQUIRE_LINK
+
+
+class EnquireLinkResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    smpp_command: str = state.SmppCommand.ENQUIRE_LINK_RESP
+
+
+class Unbind(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    smpp_command: str = state.SmppCommand.UNBIND
+
+
+class UnbindResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    smpp_command: str = state.SmppCommand.UNBIND_RESP
+
+
+class BindResp(typing.NamedTuple):
+    """
+    A `bind_transceiver_resp`, `bind_receiver_resp` or `bind_transmitter_resp`.
+    """
+
+    command_id: int
+    command_status: int
+    sequence_number: int
+    smpp_command: str
+    # the id of the SMSC. It is empty if SMSC did not send a body, eg because the bind failed.
+    system_id: str
+    # the version of the SMPP protocol supported by SMSC. It is None if SMSC did not send the `sc_interface_version` optional parameter.
+    sc_interface_version: typing.Union[None, int] = None
+
+
+class Outbind(typing.NamedTuple):
+    """
+    A request by SMSC for us to bind as a receiver.
+    """
+
+    command_id: int
+    command_status: int
+    sequence_number: int
+    # the id and password that SMSC identifies itself with.
+    system_id: str
+    password: str
+    smpp_command: str = state.SmppCommand.OUTBIND
+
+
+class SubmitSMResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    # the id that SMSC gave the message. It is empty if SMSC did not send a body, eg because the submit_sm failed.
+    message_id: str
+    smpp_command: str = state.SmppCommand.SUBMIT_SM_RESP
+
+
+class SubmitMultiResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    # the id that SMSC gave the message. It is empty if SMSC did not send a body, eg because the submit_multi failed.
+    message_id: str
+    # the destinations that SMSC could not deliver the message to.
+    unsuccess_smes: typing.List[state.UnsuccessSME]
+    smpp_command: str = state.SmppCommand.SUBMIT_MULTI_RESP
+
+
+class DataSMResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    message_id: str
+    optional_params: typing.List[state.TLV]
+    smpp_command: str = state.SmppCommand.DATA_SM_RESP
+
+
+class QuerySMResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    message_id: str
+    final_date: str
+    message_state: int
+    error_code: int
+    smpp_command: str = state.SmppCommand.QUERY_SM_RESP
+
+
+class CancelSMResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    smpp_command: str = state.SmppCommand.CANCEL_SM_RESP
+
+
+class ReplaceSMResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    smpp_command: str = state.SmppCommand.REPLACE_SM_RESP
+
+
+class BroadcastSMResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    # the id that SMSC gave the broadcast. It is empty if SMSC did not send a body.
+    message_id: str
+    optional_params: typing.List[state.TLV]
+    smpp_command: str = state.SmppCommand.BROADCAST_SM_RESP
+
+
+class QueryBroadcastSMResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    message_id: str
+    # message_state, broadcast_area_identifier, broadcast_area_success and the other optional parameters.
+    optional_params: typing.List[state.TLV]
+    smpp_command: str = state.SmppCommand.QUERY_BROADCAST_SM_RESP
+
+
+class CancelBroadcastSMResp(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    smpp_command: str = state.SmppCommand.CANCEL_BROADCAST_SM_RESP
+
+
+class DeliverSM(typing.NamedTuple):
+    command_id: int
+    command_status: int
+    sequence_number: int
+    # the decoded `deliver_sm`
+    message: protocol.DeliverSM
+    smpp_command: str = state.SmppCommand.DELIVER_SM
+
+
+PDU = typing.Union[
+    RawPDU,
+    GenericNack,
+    EnquireLink,
+    EnquireLinkResp,
+    Unbind,
+    UnbindResp,
+    BindResp,
+    Outbind,
+    SubmitSMResp,
+    SubmitMultiResp,
+    DataSMResp,
+    QuerySMResp,
+    CancelSMResp,
+    ReplaceSMResp,
+    BroadcastSMResp,
+    QueryBroadcastSMResp,
+    CancelBroadcastSMResp,
+    DeliverSM,
+]
+"""
+Any one of the PDUs that can be returned by :func:`decode <decode>`
+"""
+
+_BIND_RESPS = {
+    0x80000009: state.SmppCommand.BIND_TRANSCEIVER_RESP,
+    0x80000001: state.SmppCommand.BIND_RECEIVER_RESP,
+    0x80000002: state.SmppCommand.BIND_TRANSMITTER_RESP,
+}
+
+# the PDUs that have no body.
+_HEADER_ONLY = {
+    0x80000000: GenericNack,
+    0x00000015: EnquireLink,
+    0x80000015: EnquireLinkResp,
+    0x00000006: Unbind,
+    0x80000006: UnbindResp,
+    0x80000008: CancelSMResp,
+    0x80000007: ReplaceSMResp,
+    0x80000113: CancelBroadcastSMResp,
+}
+
+# the responses whose body is a message_id followed by optional parameters.
+_MESSAGE_ID_AND_TLVS = {
+    0x80000103: DataSMResp,
+    0x80000111: BroadcastSMResp,
+    0x80000112: QueryBroadcastSMResp,
+}
+
+
+def _read_sc_interface_version(body: bytes) -> typing.Union[None, int]:
+    """
+    read the `sc_interface_version` optional parameter, that follows the system_id, in the body of a bind response.
+
+    Raises:
+        ValueError: raised if the body is malformed.
+    """
+    _, offset = protocol._read_c_octet_string(body, 0)
+    for tlv in state.TLV.parse(body[offset:]):
+        if tlv.tag == state.OptionalTag.NAME_to_TAG["sc_interface_version"] and tlv.length == 1:
+            return tlv.value[0]
+    return None
+
+
+def decode(pdu: bytes, log_id: str = "", hook_metadata: str = "") -> PDU:
+    """
+    Parses the bytes of a PDU received from SMSC into the type that corresponds to its command_id.
+    A PDU whose command_id is not decoded by naz, or whose body is malformed, is returned as a :class:`RawPDU <RawPDU>`
+    The short_message of a `deliver_sm` is decoded using the codecs registered by :func:`naz.codec.register_codecs <naz.codec.register_codecs>` or :func:`naz.codec.register <naz.codec.register>`
+
+    Parameters:
+        pdu: the full PDU(header and body) as received from SMSC.
+        log_id: the log_id to set on a decoded `deliver_sm`
+        hook_metadata: the hook_metadata to set on a decoded `deliver_sm`
+
+    Raises:
+        ValueError: raised if `pdu` is shorter than a PDU header.
+    """
+    if len(pdu) < 16:
+        raise ValueError(
+            "a PDU should be at least 16 octets long. You entered: {0} octets".format(len(pdu))
+        )
+    _, command_id, command_status, sequence_number = struct.unpack(">IIII", pdu[:16])
+    body = pdu[16:]
+    header = (command_id, command_status, sequence_number)
+    try:
+        if command_id in _HEADER_ONLY:
+            return _HEADER_ONLY[command_id](*header)
+        elif command_id in _BIND_RESPS:
+            system_id, _ = protocol._read_c_octet_string(body, 0)
+            return BindResp(
+                *header,
+                smpp_command=_BIND_RESPS[command_id],
+                system_id=system_id,
+                sc_interface_version=_read_sc_interface_version(body),
+            )
+        elif command_id == 0x0000000B:
+            system_id, offset = protocol._read_c_octet_string(body, 0)
+            password, _ = protocol._read_c_octet_string(body, offset)
+            return Outbind(*header, system_id=system_id, password=password)
+        elif command_id == 0x80000004:
+            message_id, _ = protocol._read_c_octet_string(body, 0)
+            return SubmitSMResp(*header, message_id=message_id)
+        elif command_id == 0x80000021:
+            message_id, offset = protocol._read_c_octet_string(body, 0)
+            return SubmitMultiResp(
+                *header,
+                message_id=message_id,
+                unsuccess_smes=protocol._read_unsuccess_smes(body, offset) if body else [],
+            )
+        elif command_id in _MESSAGE_ID_AND_TLVS:
+            message_id, offset = protocol._read_c_octet_string(body, 0)
+            return _MESSAGE_ID_AND_TLVS[command_id](
+                *header,
+                message_id=message_id,
+                optional_params=state.TLV.parse(body[offset:]) if body else [],
+            )
+        elif command_id == 0x80000003:
+            if not body:
+                return QuerySMResp(
+                    *header, message_id="", final_date="", message_state=0, error_code=0
+                )
+            message_id, offset = protocol._read_c_octet_string(body, 0)
+            final_date, offset = protocol._read_c_octet_string(body, offset)
+            message_state, error_code = struct.unpack(">BB", body[offset : offset + 2])
+            return QuerySMResp(
+                *header,
+                message_id=message_id,
+                final_date=final_date,
+                message_state=message_state,
+                error_code=error_code,
+            )
+        elif command_id == 0x00000005:
+            message = protocol.DeliverSM._from_pdu(
+                pdu=pdu, log_id=log_id, hook_metadata=hook_metadata
+            )
+            return DeliverSM(*header, message=message)
+    except (IndexError, ValueError, LookupError, struct.error):
+        # UnicodeDecodeError is a ValueError
+        pass
+    return RawPDU(*header, header=pdu[:16], body=body)
@@ -0,0 +1,227 @@
+import asyncio
+import logging
+import typing
+
+from . import log
+from . import protocol
+from . import sequence
+from . import correlater
+from .client import Client
+
+
+class Pool:
+    """
+    A Pool manages many :class:`naz.Client <naz.client.Client>` binds to the same SMSC, and spreads messages across them.
+    It can be used when a single bind is unable to attain the required throughput.
+
+    Messages are sent using the bind, amongst those that are alive, that has the least number of requests awaiting a response from SMSC.
+    Ties are broken in a round-robin manner.
+
+    All the clients share one :attr:`correlation_handler <naz.client.Client.correlation_handler>`,
+    so that a delivery notification that arrives on any of the binds can be correlated with the message that was sent on another bind.
+    They also share one :attr:`sequence_generator <naz.client.Client.sequence_generator>`, so that the requests of different binds
+    do not have the same sequence_number and thus do not overwrite each other's entries in the correlation_handler.
+    If the `broker` that is passed in is also shared, then a message that is queued with :func:`send_message <Pool.send_message>`
+    is sent by whichever bind dequeues it first.
+
+    Every :attr:`health_check_interval <Pool.health_check_interval>` seconds, the pool checks the binds and
+    replaces any bind that is no longer running, or has lost its connection to SMSC and is not re-connecting.
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        pool = naz.Pool(
+            size=3,
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=naz.broker.SimpleBroker(maxsize=1000),
+        )
+        await pool.start()
+        message_ids = await pool.submit_message(msg)
+        await pool.shutdown()
+    """
+
+    def __init__(
+        self, size: int = 2, health_check_interval: float = 30.00, **client_args: typing.Any
+    ) -> None:
+        """
+        Parameters:
+            size: the number of binds to SMSC.
+            health_check_interval: the time in seconds between checks of the health of the binds.
+            client_args: the arguments that are used to create each :class:`naz.Client <naz.client.Client>`
+        """
+        if not isinstance(size, int):
+            raise ValueError("`size` should be of type:: `int` You entered: {0}".format(type(size)))
+        if size < 1:
+            raise ValueError("`size` should be greater than zero. You entered: {0}".format(size))
+        if not isinstance(health_check_interval, float):
+            raise ValueError(
+                "`health_check_interval` should be of type:: `float` You entered: {0}".format(
+                    type(health_check_interval)
+                )
+            )
+
+        self.size: int = size
+        self.health_check_interval: float = health_check_interval
+        if client_args.get("correlation_handler") is None:
+            client_args["correlation_handler"] = correlater.SimpleCorrelater()
+        self.correlation_handler: correlater.BaseCorrelater = client_args["correlation_handler"]
+        if client_args.get("sequence_generator") is None:
+            client_args["sequence_generator"] = sequence.SimpleSequenceGenerator()
+        self.sequence_generator: sequence.BaseSequenceGenerator = client_args["sequence_generator"]
+        self.client_args: typing.Dict[str, typing.Any] = client_args
+
+        self.logger: logging.Logger
+        if client_args.get("logger") is not None:
+            self.logger = client_args["logger"]
+        else:
+            self.logger = log.SimpleLogger("naz.Pool")
+
+        self.clients: typing.List[Client] = [Client(**client_args) for _ in range(0, size)]
+        self._tasks: typing.List[typing.List[asyncio.Future]] = [[] for _ in range(0, size)]
+        self._round_robin: int = 0
+        self._health_check_task: typing.Union[None, asyncio.Future] = None
+        self._shutting_down: bool = False
+
+    def _log(self, level, log_data):
+        # if the supplied logger is unable to log; we move on
+        try:
+            self.logger.log(level, log_data)
+        except Exception:
+            pass
+
+    async def start(self) -> None:
+        """
+        connect and bind all the clients to SMSC, and start their background tasks; ie
+        :func:`dequeue_messages <naz.client.Client.dequeue_messages>`, :func:`receive_data <naz.client.Client.receive_data>`
+        and :func:`enquire_link <naz.client.Client.enquire_link>`.
+        It also starts the periodic health check of the binds.
+        """
+        self._log(logging.INFO, {"event": "naz.Pool.start", "stage": "start", "size": self.size})
+        for index in range(0, self.size):
+            await self._start_client(index)
+        self._health_check_task = asyncio.ensure_future(self._health_check())
+        self._log(logging.INFO, {"event": "naz.Pool.start", "stage": "end", "size": self.size})
+
+    async def _start_client(self, index: int) -> None:
+        client = self.clients[index]
+        await client.connect()
+        await client.bind()
+        self._tasks[index] = [
+            asyncio.ensure_future(client.dequeue_messages()),
+            asyncio.ensure_future(client.receive_data()),
+            asyncio.ensure_future(client.enquire_link()),
+        ]
+
+    @staticmethod
+    def _outstanding(client: Client) -> int:
+        count, _ = client.outstanding()
+        return count
+
+    def _pick_client(self) -> Client:
+        """
+        returns the bind that has the least number of requests awaiting a response from SMSC.
+        """
+        ordered = [self.clients[(self._round_robin + i) % self.size] for i in range(0, self.size)]
+        self._round_robin = (self._round_robin + 1) % self.size
+        alive = [client for client in ordered if client._connection_is_alive()]
+        # if no bind is alive, use any; it will wait for(or fail fast on) re-connection.
+        return min(alive or ordered, key=self._outstanding)
+
+    async def send_message(
+        self, proto_msg: typing.Union[protocol.SubmitSM, protocol.DataSM]
+    ) -> None:
+        """
+        enqueue a message using one of the binds. See :func:`naz.Client.send_message <naz.client.Client.send_message>`
+        """
+        await self._pick_client().send_message(proto_msg)
+
+    async def submit_message(
+        self, proto_msg: typing.Union[protocol.SubmitSM, protocol.DataSM]
+    ) -> typing.List[str]:
+        """
+        send a message using one of the binds. See :func:`naz.Client.submit_message <naz.client.Client.submit_message>`
+        """
+        return await self._pick_client().submit_message(proto_msg)
+
+    def _is_dead(self, index: int) -> bool:
+        client = self.clients[index]
+        if any(task.done() for task in self._tasks[index]):
+            return True
+        return not client._connection_is_alive() and not client._reconnecting
+
+    async def _health_check(self) -> None:
+        while not self._shutting_down:
+            await asyncio.sleep(self.health_check_interval)
+            for index in range(0, self.size):
+                if self._shutting_down or not self._is_dead(index):
+                    continue
+                self._log(
+                    logging.WARNING,
+                    {
+                        "event": "naz.Pool._health_check",
+                        "stage": "start",
+                        "index": index,
+                        "current_session_state": self.clients[index].current_session_state,
+                        "state": "replacing dead bind",
+                    },
+                )
+                await self._replace_client(index)
+
+    async def _replace_client(self, index: int) -> None:
+        old_client = self.clients[index]
+        old_client.SHOULD_SHUT_DOWN = True
+        for task in self._tasks[index]:
+            task.cancel()
+        if old_client.writer is not None:
+            old_client.writer.close()
+
+        self.clients[index] = Client(**self.client_args)
+        try:
+            await self._start_client(index)
+        except asyncio.CancelledError:
+            raise
+        except Exception as e:
+            # the next health check will try again.
+            self._log(
+                logging.ERROR,
+                {
+                    "event": "naz.Pool._replace_client",
+                    "stage": "end",
+                    "index": index,
+                    "error": repr(e),
+                },
+            )
+
+    async def shutdown(self) -> int:
+        """
+        Cleanly shutdown all the clients. See :func:`naz.Client.shutdown <naz.client.Client.shutdown>`
+
+        Returns:
+            the number of messages that had not been sent to SMSC.
+        """
+        self._log(logging.INFO, {"event": "naz.Pool.shutdown", "stage": "start"})
+        self._shutting_down = True
+        if self._health_check_task is not None:
+            self._health_check_task.cancel()
+        await asyncio.gather(*[client.shutdown() for client in self.clients])
+        for tasks in self._tasks:
+            for task in tasks:
+                task.cancel()
+
+        # the clients may share one broker; count its messages only once.
+        brokers = {id(client.broker): client.broker for client in self.clients}
+        remaining = sum(broker.size() or 0 for broker in brokers.values()) + sum(
+            client._in_flight_sends for client in self.clients
+        )
+        self._log(
+            logging.INFO,
+            {"event": "naz.Pool.shutdown", "stage": "end", "remaining_messages": remaining},
+        )
+        return remaining
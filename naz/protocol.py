@@ -1,7 +1,11 @@
+import re
 import abc
 import json
+import struct
 import typing
+import datetime
 
+from . import codec
 from . import state
 
 
@@ -14,6 +18,12 @@ eg a future version of naz could add/remove the number of required items in a me
 This is a bit similar to: http://docs.celeryproject.org/en/latest/internals/protocol.html
 """
 
+# the 8-bit binary encodings; a message in either of them is sent as it is, without a codec. see section 5.2.19 of smpp ver 3.4 spec document
+_BINARY_ENCODINGS = [
+    state.SmppDataCoding.octet_unspecified_I.code,
+    state.SmppDataCoding.octet_unspecified_II.code,
+]
+
 
 class Message(abc.ABC):
     """
@@ -120,30 +130,31 @@ class SubmitSM(Message):
     def __init__(
         self,
         #### MANDATORY SMPP PARAMETERS ###
-        short_message: str,
+        short_message: typing.Union[str, bytes],
         source_addr: str,
         destination_addr: str,
         log_id: str,
         service_type: str = "CMT",  # section 5.2.11
-        source_addr_ton: int = 0x00000001,  # section 5.2.5
-        source_addr_npi: int = 0x00000001,
-        dest_addr_ton: int = 0x00000001,
-        dest_addr_npi: int = 0x00000001,
+        source_addr_ton: typing.Union[None, int] = None,  # section 5.2.5
+        source_addr_npi: typing.Union[None, int] = None,
+        dest_addr_ton: typing.Union[None, int] = None,
+        dest_addr_npi: typing.Union[None, int] = None,
         # xxxxxx00 store-and-forward
         # xx0010xx Short Message contains ESME Delivery Acknowledgement
         # 00xxxxxx No specific features selected
         esm_class: int = 0b00000011,  # section 5.2.12
         protocol_id: int = 0x00000000,
-        priority_flag: int = 0x00000000,
-        schedule_delivery_time: str = "",
-        validity_period: str = "",
+        priority_flag: typing.Union[None, int] = None,
+        schedule_delivery_time: typing.Union[str, datetime.datetime, datetime.timedelta] = "",
+        validity_period: typing.Union[str, datetime.datetime, datetime.timedelta] = "",
         # xxxxxx01 SMSC Delivery Receipt requested where final delivery outcome is delivery success or failure
         # xxxx01xx SME Delivery Acknowledgement requested
         # xxx0xxxx No Intermediate notification requested
         # all other values reserved
-        registered_delivery: int = 0b00000001,  # see section 5.2.17
+        registered_delivery: typing.Union[None, int] = None,  # see section 5.2.17
         replace_if_present_flag: int = 0x00000000,
         sm_default_msg_id: int = 0x00000000,
+        data_coding: typing.Union[None, int] = None,  # section 5.2.19
         #### MANDATORY SMPP PARAMETERS ###
         ###
         ### NON-SMPP ATTRIBUTES ###
@@ -152,6 +163,10 @@ class SubmitSM(Message):
         hook_metadata: str = "",
         encoding: str = "gsm0338",
         errors: str = "strict",
+        attempt: int = 1,
+        udh: typing.Union[None, bytes] = None,
+        source_addr_kind: typing.Union[None, str] = None,
+        dest_addr_kind: typing.Union[None, str] = None,
         ### NON-SMPP ATTRIBUTES ###
         ###
         #### OPTIONAL SMPP PARAMETERS ###
@@ -184,35 +199,59 @@ class SubmitSM(Message):
         its_reply_type: typing.Union[None, int] = None,
         its_session_info: typing.Union[None, str] = None,
         ussd_service_op: typing.Union[None, str] = None,
+        optional_params: typing.Union[None, typing.List[state.TLV]] = None,
         #### OPTIONAL SMPP PARAMETERS ###
     ) -> None:
         """
         Parameters:
-            short_message: message to send to SMSC
+            short_message: message to send to SMSC.
+                                    It is `bytes`, eg an OTA or WAP push payload, if the `encoding` is `octet_unspecified_II`(data_coding 4) or `octet_unspecified_I`(data_coding 2);
+                                    in which case it is sent as it is, in the short_message or, if it is longer than 254 octets, in the `message_payload` optional parameter.
             source_addr: the identifier(eg msisdn) of the message sender
             destination_addr: the identifier(eg msisdn) of the message recipient
             log_id: a unique identify of this request
             version: This indicates the current version of the naz message protocol.
                      This version will enable naz to be able to evolve in future; a future version of `naz` may ship with a different message protocol.
             hook_metadata: a string that to will later on be passed to `naz.Client.hook`. Your application can use it for correlation.
-            service_type:	Indicates the SMS Application service associated with the message
+            service_type:	Indicates the SMS Application service associated with the message, eg "CMT" or an SMSC specific value. It is at most 5 characters.
             source_addr_ton:	Type of Number of message originator.
+                                    If it is None, the :py:attr:`naz.Client.source_addr_ton <naz.Client.source_addr_ton>` is used.
             source_addr_npi:	Numbering Plan Identity of message originator.
+                                    If it is None, the :py:attr:`naz.Client.source_addr_npi <naz.Client.source_addr_npi>` is used.
             dest_addr_ton:	Type of Number for destination.
+                                    If it is None, the :py:attr:`naz.Client.dest_addr_ton <naz.Client.dest_addr_ton>` is used.
             dest_addr_npi:	Numbering Plan Identity of destination
+                                    If it is None, the :py:attr:`naz.Client.dest_addr_npi <naz.Client.dest_addr_npi>` is used.
             esm_class:	Indicates Message Mode & Message Type.
             protocol_id:	Protocol Identifier. Network specific field.
-            priority_flag:	Designates the priority level of the message.
-            schedule_delivery_time:	The short message is to be scheduled by the SMSC for delivery.
-            validity_period:	The validity period of this message.
+            priority_flag:	Designates the priority level of the message; from 0(lowest) to 3(highest). eg; a higher priority for OTPs than for marketing messages.
+                                    If it is None, the :py:attr:`naz.Client.priority_flag <naz.Client.priority_flag>` is used.
+            schedule_delivery_time:	The short message is to be scheduled by the SMSC for delivery. Empty for immediate delivery.
+                                    Either a string in the SMPP time format, or a `datetime.datetime`/`datetime.timedelta` which is formatted using :func:`smpp_time <smpp_time>`
+            validity_period:	The validity period of this message. Empty for the :py:attr:`naz.Client.validity_period <naz.Client.validity_period>`, which is itself empty for the SMSC default.
+                                    Either a string in the SMPP time format, or a `datetime.datetime`/`datetime.timedelta` which is formatted using :func:`smpp_time <smpp_time>`
             registered_delivery:	Indicator to signify if an SMSC delivery receipt or an SME acknowledgement is required.
+                                    It is a combination of :class:`naz.RegisteredDelivery <naz.state.RegisteredDelivery>` values.
+                                    If it is None, the :py:attr:`naz.Client.registered_delivery <naz.Client.registered_delivery>` is used.
             replace_if_present_flag:	Flag indicating if submitted message should replace an existing message.
-            sm_default_msg_id:	Indicates the short message to send from a list of predefined ('canned') short messages stored on the SMSC
+            sm_default_msg_id:	Indicates the short message to send from a list of predefined ('canned') short messages stored on the SMSC; from 1 to 255, or 0 for none.
+                                    If it is set, the `short_message` is not sent.
+            data_coding:	The `data_coding` octet to send the message with. If it is None, it is the one that matches the `encoding`.
+                                    It is sent as it is, eg to satisfy an SMSC that expects a different value; the `short_message` is still encoded using the `encoding`.
             smpp_command: any one of the SMSC commands eg submit_sm
             encoding: `encoding <https://docs.python.org/3/library/codecs.html#standard-encodings>`_ used to encode messages been sent to SMSC.
                       The encoding should be one of the encodings recognised by the SMPP specification. See section 5.2.19 of SMPP spec.
                       If you want to use your own custom codec implementation for an encoding, make sure to pass it to :py:attr:`naz.Client.custom_codecs <naz.Client.custom_codecs>`
             errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+            attempt: the number of times that the message has been submitted to SMSC, counting this one. \
+                     naz increments it each time that it re-submits the message. see :py:attr:`naz.Client.retry_policy <naz.Client.retry_policy>`
+            udh: a User Data Header, including its length octet(UDHL), that was built by the caller; eg for a WAP push. \
+                     It is placed before the encoded `short_message` and the UDHI bit of the `esm_class` is set. \
+                     naz does not split the message nor add a UDH of its own, so the message has to fit in one SMS.
+            source_addr_kind: the :class:`naz.AddressKind <naz.state.AddressKind>` of the `source_addr`. eg; `naz.AddressKind.SHORT_CODE` \
+                     It sets the `source_addr_ton` and `source_addr_npi` that have not been set.
+            dest_addr_kind: the :class:`naz.AddressKind <naz.state.AddressKind>` of the `destination_addr`. eg; `naz.AddressKind.INTERNATIONAL` \
+                     It sets the `dest_addr_ton` and `dest_addr_npi` that have not been set.
             # Optional SMPP parameters.
             user_message_reference: ESME assigned message reference number.
             source_port: It is used to indicate the application port number associated with the source address of the message
@@ -248,7 +287,13 @@ class SubmitSM(Message):
             its_session_info: It contains control information for the interactive session between an MS and an ESME.
                               It is a required parameter for the CDMA Interactive Teleservice as defined by the Korean PCS carriers [KORITS].
             ussd_service_op: It is required to define the USSD service operation when SMPP is being used as an interface to a (GSM) USSD system.
+            optional_params: a list of :class:`naz.TLV <naz.state.TLV>` for any other optional parameters, eg vendor specific ones.
+                             They are sent after the optional parameters above.
         """
+        if isinstance(schedule_delivery_time, (datetime.datetime, datetime.timedelta)):
+            schedule_delivery_time = smpp_time(schedule_delivery_time)
+        if isinstance(validity_period, (datetime.datetime, datetime.timedelta)):
+            validity_period = smpp_time(validity_period)
 
         self._validate_msg_type_args(
             short_message=short_message,
@@ -268,11 +313,23 @@ class SubmitSM(Message):
             registered_delivery=registered_delivery,
             replace_if_present_flag=replace_if_present_flag,
             sm_default_msg_id=sm_default_msg_id,
+            data_coding=data_coding,
             smpp_command=smpp_command,
             version=version,
             hook_metadata=hook_metadata,
             encoding=encoding,
             errors=errors,
+            attempt=attempt,
+            udh=udh,
+        )
+        source_addr_ton, source_addr_npi = _address_kind(
+            "source_addr_kind", source_addr_kind, source_addr_ton, source_addr_npi
+        )
+        dest_addr_ton, dest_addr_npi = _address_kind(
+            "dest_addr_kind", dest_addr_kind, dest_addr_ton, dest_addr_npi
+        )
+        source_addr_ton, source_addr_npi = _alphanumeric_sender(
+            source_addr, source_addr_ton, source_addr_npi
         )
 
         self.smpp_command: str = state.SmppCommand.SUBMIT_SM
@@ -298,7 +355,14 @@ class SubmitSM(Message):
         self.sm_default_msg_id = sm_default_msg_id
         self.encoding = encoding
         self.errors = errors
-        self.data_coding = state.SmppDataCoding._find_data_coding(self.encoding)
+        self.attempt = attempt
+        self.udh = udh
+        self.data_coding = codec._find_data_coding(self.encoding)
+        self._data_coding = data_coding
+        if data_coding is not None:
+            self.data_coding = state.DataCoding(
+                code=self.encoding, value=data_coding, description="overridden data_coding"
+            )
 
         self.optional_tags_dict = self._create_opt_tags(
             user_message_reference=user_message_reference,
@@ -330,10 +394,21 @@ class SubmitSM(Message):
             its_session_info=its_session_info,
             ussd_service_op=ussd_service_op,
         )
+        if optional_params is None:
+            optional_params = []
+        if not isinstance(optional_params, list):
+            raise ValueError(
+                "`optional_params` should be of type:: `None` or `list` You entered: {0}".format(
+                    type(optional_params)
+                )
+            )
+        for tlv in optional_params:
+            state.TLV._validate(tlv)
+        self.optional_params = optional_params
 
     @staticmethod
     def _validate_msg_type_args(
-        short_message: str,
+        short_message: typing.Union[str, bytes],
         source_addr: str,
         destination_addr: str,
         log_id: str,
@@ -341,20 +416,23 @@ class SubmitSM(Message):
         version: int,
         hook_metadata: str,
         service_type: str,
-        source_addr_ton: int,
-        source_addr_npi: int,
-        dest_addr_ton: int,
-        dest_addr_npi: int,
+        source_addr_ton: typing.Union[None, int],
+        source_addr_npi: typing.Union[None, int],
+        dest_addr_ton: typing.Union[None, int],
+        dest_addr_npi: typing.Union[None, int],
         esm_class: int,
         protocol_id: int,
-        priority_flag: int,
+        priority_flag: typing.Union[None, int],
         schedule_delivery_time: str,
         validity_period: str,
-        registered_delivery: int,
+        registered_delivery: typing.Union[None, int],
         replace_if_present_flag: int,
         sm_default_msg_id: int,
+        data_coding: typing.Union[None, int],
         encoding: str,
         errors: str,
+        attempt: int,
+        udh: typing.Union[None, bytes] = None,
     ) -> None:
         if not isinstance(version, int):
             raise ValueError(
@@ -366,7 +444,14 @@ class SubmitSM(Message):
                     NAZ_MESSAGE_PROTOCOL_VERSION
                 )
             )
-        if not isinstance(short_message, str):
+        if encoding in _BINARY_ENCODINGS:
+            if not isinstance(short_message, bytes):
+                raise ValueError(
+                    "`short_message` should be of type:: `bytes` if `encoding` is `{0}` You entered: {1}".format(
+                        encoding, type(short_message)
+                    )
+                )
+        elif not isinstance(short_message, str):
             raise ValueError(
                 "`short_message` should be of type:: `str` You entered: {0}".format(
                     type(short_message)
@@ -410,30 +495,61 @@ class SubmitSM(Message):
                     type(service_type)
                 )
             )
-        if not isinstance(source_addr_ton, int):
+        if len(service_type) > 5 or not service_type.isascii():
+            # it is a C-Octet String of at most 6 octets, including the NULL terminator. section 5.2.11
+            raise ValueError(
+                "`service_type` should be at most 5 ascii characters. You entered: {0}".format(
+                    service_type
+                )
+            )
+        if not isinstance(source_addr_ton, (type(None), int)):
             raise ValueError(
-                "`source_addr_ton` should be of type:: `int` You entered: {0}".format(
+                "`source_addr_ton` should be of type:: `None` or `int` You entered: {0}".format(
                     type(source_addr_ton)
                 )
             )
-        if not isinstance(source_addr_npi, int):
+        if isinstance(source_addr_ton, int) and not (0 <= source_addr_ton <= 0xFF):
             raise ValueError(
-                "`source_addr_npi` should be of type:: `int` You entered: {0}".format(
+                "`source_addr_ton` should fit in one octet. You entered: {0}".format(
+                    source_addr_ton
+                )
+            )
+        if not isinstance(source_addr_npi, (type(None), int)):
+            raise ValueError(
+                "`source_addr_npi` should be of type:: `None` or `int` You entered: {0}".format(
                     type(source_addr_npi)
                 )
             )
-        if not isinstance(dest_addr_ton, int):
+        if isinstance(source_addr_npi, int) and not (0 <= source_addr_npi <= 0xFF):
+            raise ValueError(
+                "`source_addr_npi` should fit in one octet. You entered: {0}".format(
+                    source_addr_npi
+                )
+            )
+        if not isinstance(dest_addr_ton, (type(None), int)):
             raise ValueError(
-                "`dest_addr_ton` should be of type:: `int` You entered: {0}".format(
+                "`dest_addr_ton` should be of type:: `None` or `int` You entered: {0}".format(
                     type(dest_addr_ton)
                 )
             )
-        if not isinstance(dest_addr_npi, int):
+        if isinstance(dest_addr_ton, int) and not (0 <= dest_addr_ton <= 0xFF):
+            raise ValueError(
+                "`dest_addr_ton` should fit in one octet. You entered: {0}".format(
+                    dest_addr_ton
+                )
+            )
+        if not isinstance(dest_addr_npi, (type(None), int)):
             raise ValueError(
-                "`dest_addr_npi` should be of type:: `int` You entered: {0}".format(
+                "`dest_addr_npi` should be of type:: `None` or `int` You entered: {0}".format(
                     type(dest_addr_npi)
                 )
             )
+        if isinstance(dest_addr_npi, int) and not (0 <= dest_addr_npi <= 0xFF):
+            raise ValueError(
+                "`dest_addr_npi` should fit in one octet. You entered: {0}".format(
+                    dest_addr_npi
+                )
+            )
         if not isinstance(esm_class, int):
             raise ValueError(
                 "`esm_class` should be of type:: `int` You entered: {0}".format(type(esm_class))
@@ -442,12 +558,16 @@ class SubmitSM(Message):
             raise ValueError(
                 "`protocol_id` should be of type:: `int` You entered: {0}".format(type(protocol_id))
             )
-        if not isinstance(priority_flag, int):
+        if not isinstance(priority_flag, (type(None), int)):
             raise ValueError(
-                "`priority_flag` should be of type:: `int` You entered: {0}".format(
+                "`priority_flag` should be of type:: `None` or `int` You entered: {0}".format(
                     type(priority_flag)
                 )
             )
+        if isinstance(priority_flag, int) and not (0 <= priority_flag <= 3):
+            raise ValueError(
+                "`priority_flag` should be between 0 and 3. You entered: {0}".format(priority_flag)
+            )
         if not isinstance(schedule_delivery_time, str):
             raise ValueError(
                 "`schedule_delivery_time` should be of type:: `str` You entered: {0}".format(
@@ -460,12 +580,20 @@ class SubmitSM(Message):
                     type(validity_period)
                 )
             )
-        if not isinstance(registered_delivery, int):
+        _validate_smpp_time("schedule_delivery_time", schedule_delivery_time)
+        _validate_smpp_time("validity_period", validity_period)
+        if not isinstance(registered_delivery, (type(None), int)):
             raise ValueError(
-                "`registered_delivery` should be of type:: `int` You entered: {0}".format(
+                "`registered_delivery` should be of type:: `None` or `int` You entered: {0}".format(
                     type(registered_delivery)
                 )
             )
+        if isinstance(registered_delivery, int) and not (0 <= registered_delivery <= 0xFF):
+            raise ValueError(
+                "`registered_delivery` should fit in one octet. You entered: {0}".format(
+                    registered_delivery
+                )
+            )
         if not isinstance(replace_if_present_flag, int):
             raise ValueError(
                 "`replace_if_present_flag` should be of type:: `int` You entered: {0}".format(
@@ -478,6 +606,22 @@ class SubmitSM(Message):
                     type(sm_default_msg_id)
                 )
             )
+        if not (0 <= sm_default_msg_id <= 255):
+            raise ValueError(
+                "`sm_default_msg_id` should be between 0 and 255. You entered: {0}".format(
+                    sm_default_msg_id
+                )
+            )
+        if not isinstance(data_coding, (type(None), int)) or isinstance(data_coding, bool):
+            raise ValueError(
+                "`data_coding` should be of type:: `None` or `int` You entered: {0}".format(
+                    type(data_coding)
+                )
+            )
+        if isinstance(data_coding, int) and not (0 <= data_coding <= 0xFF):
+            raise ValueError(
+                "`data_coding` should fit in one octet. You entered: {0}".format(data_coding)
+            )
         if not isinstance(encoding, str):
             raise ValueError(
                 "`encoding` should be of type:: `str` You entered: {0}".format(type(encoding))
@@ -486,6 +630,25 @@ class SubmitSM(Message):
             raise ValueError(
                 "`errors` should be of type:: `str` You entered: {0}".format(type(errors))
             )
+        if not isinstance(attempt, int):
+            raise ValueError(
+                "`attempt` should be of type:: `int` You entered: {0}".format(type(attempt))
+            )
+        if attempt <= 0:
+            raise ValueError(
+                "`attempt` should be greater than zero. You entered: {0}".format(attempt)
+            )
+        if not isinstance(udh, (type(None), bytes)):
+            raise ValueError(
+                "`udh` should be of type:: `None` or `bytes` You entered: {0}".format(type(udh))
+            )
+        if udh is not None and (len(udh) < 2 or udh[0] != len(udh) - 1):
+            # the first octet of a UDH(UDHL) is the length of the information elements that follow it.
+            raise ValueError(
+                "the first octet of `udh` should be the number of octets that follow it. You entered: {0}".format(
+                    udh.hex()
+                )
+            )
 
         # note: optional smpp parameters get validated on their own in `_create_opt_tags`
 
@@ -506,7 +669,9 @@ class SubmitSM(Message):
         _item = dict(
             smpp_command=self.smpp_command,
             version=self.version,
-            short_message=self.short_message,
+            short_message=self.short_message.hex()
+            if isinstance(self.short_message, bytes)
+            else self.short_message,
             source_addr=self.source_addr,
             destination_addr=self.destination_addr,
             log_id=self.log_id,
@@ -524,46 +689,217 @@ class SubmitSM(Message):
             registered_delivery=self.registered_delivery,
             replace_if_present_flag=self.replace_if_present_flag,
             sm_default_msg_id=self.sm_default_msg_id,
+            data_coding=self._data_coding,
             encoding=self.encoding,
             errors=self.errors,
+            attempt=self.attempt,
+            udh=self.udh.hex() if self.udh is not None else None,
         )
         _item.update(**self.optional_tags_dict)
+        if self.optional_params:
+            _item.update(
+                optional_params=[
+                    {"tag": tlv.tag, "value": tlv.value.hex()} for tlv in self.optional_params
+                ]
+            )
         return json.dumps(_item)
 
     @staticmethod
     def from_json(json_message: str) -> "SubmitSM":
         _in_dict = json.loads(json_message)
+        if _in_dict.get("encoding") in _BINARY_ENCODINGS:
+            _in_dict["short_message"] = bytes.fromhex(_in_dict["short_message"])
+        if _in_dict.get("udh") is not None:
+            _in_dict["udh"] = bytes.fromhex(_in_dict["udh"])
+        if _in_dict.get("optional_params"):
+            _in_dict["optional_params"] = [
+                state.TLV(tag=tlv["tag"], value=bytes.fromhex(tlv["value"]))
+                for tlv in _in_dict["optional_params"]
+            ]
         return SubmitSM(**_in_dict)
 
 
-class EnquireLinkResp(Message):
+class DataSM(Message):
+    """
+    The code representation of the `data_sm` pdu that will get queued into a broker.
+    It is an alternative to :class:`SubmitSM <SubmitSM>` that some SMSCs require, eg for WAP or binary payloads.
+    The message is carried in the `message_payload` optional parameter rather than in a `short_message` field.
+
+    Usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        msg = naz.protocol.DataSM(
+            message_payload="hello world",
+            source_addr="255700111222",
+            destination_addr="255799000888",
+            log_id="some-id",
+        )
+        await client.send_message(msg)
+    """
+
     def __init__(
         self,
+        #### MANDATORY SMPP PARAMETERS ###
+        message_payload: str,
+        source_addr: str,
+        destination_addr: str,
         log_id: str,
-        sequence_number: int,
-        smpp_command: str = state.SmppCommand.ENQUIRE_LINK_RESP,
+        service_type: str = "CMT",  # section 5.2.11
+        source_addr_ton: typing.Union[None, int] = None,  # section 5.2.5
+        source_addr_npi: typing.Union[None, int] = None,
+        dest_addr_ton: typing.Union[None, int] = None,
+        dest_addr_npi: typing.Union[None, int] = None,
+        esm_class: int = 0b00000011,  # section 5.2.12
+        registered_delivery: typing.Union[None, int] = None,  # see section 5.2.17
+        #### MANDATORY SMPP PARAMETERS ###
+        ###
+        ### NON-SMPP ATTRIBUTES ###
+        smpp_command: str = state.SmppCommand.DATA_SM,
         version: int = 1,
         hook_metadata: str = "",
+        encoding: str = "gsm0338",
+        errors: str = "strict",
+        attempt: int = 1,
+        source_addr_kind: typing.Union[None, str] = None,
+        dest_addr_kind: typing.Union[None, str] = None,
+        ### NON-SMPP ATTRIBUTES ###
+        ###
+        #### OPTIONAL SMPP PARAMETERS ###
+        # section 4.7.1 of smpp documentation
+        user_message_reference: typing.Union[None, int] = None,
+        source_port: typing.Union[None, int] = None,
+        destination_port: typing.Union[None, int] = None,
+        payload_type: typing.Union[None, int] = None,
+        more_messages_to_send: typing.Union[None, int] = None,
+        qos_time_to_live: typing.Union[None, int] = None,
+        set_dpf: typing.Union[None, int] = None,
+        language_indicator: typing.Union[None, int] = None,
+        #### OPTIONAL SMPP PARAMETERS ###
     ) -> None:
         """
         Parameters:
+            message_payload: message to send to SMSC
+            source_addr: the identifier(eg msisdn) of the message sender
+            destination_addr: the identifier(eg msisdn) of the message recipient
             log_id: a unique identify of this request
-            smpp_command: any one of the SMSC commands eg enquire_link_resp
             version: This indicates the current version of the naz message protocol.
                      This version will enable naz to be able to evolve in future; a future version of `naz` may ship with a different message protocol.
             hook_metadata: a string that to will later on be passed to `naz.Client.hook`. Your application can use it for correlation.
-            sequence_number: SMPP sequence_number
+            service_type:	Indicates the SMS Application service associated with the message, eg "CMT" or an SMSC specific value. It is at most 5 characters.
+            source_addr_ton:	Type of Number of message originator.
+                                    If it is None, the :py:attr:`naz.Client.source_addr_ton <naz.Client.source_addr_ton>` is used.
+            source_addr_npi:	Numbering Plan Identity of message originator.
+                                    If it is None, the :py:attr:`naz.Client.source_addr_npi <naz.Client.source_addr_npi>` is used.
+            dest_addr_ton:	Type of Number for destination.
+                                    If it is None, the :py:attr:`naz.Client.dest_addr_ton <naz.Client.dest_addr_ton>` is used.
+            dest_addr_npi:	Numbering Plan Identity of destination
+                                    If it is None, the :py:attr:`naz.Client.dest_addr_npi <naz.Client.dest_addr_npi>` is used.
+            esm_class:	Indicates Message Mode & Message Type.
+            registered_delivery:	Indicator to signify if an SMSC delivery receipt or an SME acknowledgement is required.
+                                    It is a combination of :class:`naz.RegisteredDelivery <naz.state.RegisteredDelivery>` values.
+                                    If it is None, the :py:attr:`naz.Client.registered_delivery <naz.Client.registered_delivery>` is used.
+            smpp_command: any one of the SMSC commands eg data_sm
+            encoding: `encoding <https://docs.python.org/3/library/codecs.html#standard-encodings>`_ used to encode messages been sent to SMSC.
+                      The encoding should be one of the encodings recognised by the SMPP specification. See section 5.2.19 of SMPP spec.
+            errors:	same meaning as the errors argument to pythons' `encode <https://docs.python.org/3/library/codecs.html#codecs.encode>`_ method
+            attempt: the number of times that the message has been submitted to SMSC, counting this one. \
+                     naz increments it each time that it re-submits the message. see :py:attr:`naz.Client.retry_policy <naz.Client.retry_policy>`
+            source_addr_kind: the :class:`naz.AddressKind <naz.state.AddressKind>` of the `source_addr`. eg; `naz.AddressKind.SHORT_CODE` \
+                     It sets the `source_addr_ton` and `source_addr_npi` that have not been set.
+            dest_addr_kind: the :class:`naz.AddressKind <naz.state.AddressKind>` of the `destination_addr`. eg; `naz.AddressKind.INTERNATIONAL` \
+                     It sets the `dest_addr_ton` and `dest_addr_npi` that have not been set.
+            # Optional SMPP parameters.
+            user_message_reference: ESME assigned message reference number.
+            source_port: It is used to indicate the application port number associated with the source address of the message
+            destination_port: It is used to indicate the application port number associated with the destination address of the message.
+            payload_type: It defines the higher layer PDU type contained in the message payload.
+            more_messages_to_send: It is used to indicate to the SMSC that there are further messages for the same destination SME.
+            qos_time_to_live: It defines the number of seconds which the sender requests the SMSC to keep the message if undelivered.
+            set_dpf: It is used to request the setting of a delivery pending flag (DPF) for certain delivery failure scenarios
+            language_indicator: It is used to indicate the language of the short message.
         """
-        if not isinstance(log_id, str):
-            raise ValueError(
-                "`log_id` should be of type:: `str` You entered: {0}".format(type(log_id))
-            )
-        if not isinstance(sequence_number, int):
-            raise ValueError(
-                "`sequence_number` should be of type:: `int` You entered: {0}".format(
-                    type(sequence_number)
-                )
-            )
+        self._validate_msg_type_args(
+            message_payload=message_payload,
+            source_addr=source_addr,
+            destination_addr=destination_addr,
+            log_id=log_id,
+            service_type=service_type,
+            source_addr_ton=source_addr_ton,
+            source_addr_npi=source_addr_npi,
+            dest_addr_ton=dest_addr_ton,
+            dest_addr_npi=dest_addr_npi,
+            esm_class=esm_class,
+            registered_delivery=registered_delivery,
+            smpp_command=smpp_command,
+            version=version,
+            hook_metadata=hook_metadata,
+            encoding=encoding,
+            errors=errors,
+            attempt=attempt,
+        )
+        source_addr_ton, source_addr_npi = _address_kind(
+            "source_addr_kind", source_addr_kind, source_addr_ton, source_addr_npi
+        )
+        dest_addr_ton, dest_addr_npi = _address_kind(
+            "dest_addr_kind", dest_addr_kind, dest_addr_ton, dest_addr_npi
+        )
+        source_addr_ton, source_addr_npi = _alphanumeric_sender(
+            source_addr, source_addr_ton, source_addr_npi
+        )
+
+        self.smpp_command: str = state.SmppCommand.DATA_SM
+        self.version = version
+
+        self.message_payload = message_payload
+        self.source_addr = source_addr
+        self.destination_addr = destination_addr
+        self.log_id = log_id
+        self.hook_metadata = hook_metadata
+        self.service_type = service_type
+        self.source_addr_ton = source_addr_ton
+        self.source_addr_npi = source_addr_npi
+        self.dest_addr_ton = dest_addr_ton
+        self.dest_addr_npi = dest_addr_npi
+        self.esm_class = esm_class
+        self.registered_delivery = registered_delivery
+        self.encoding = encoding
+        self.errors = errors
+        self.attempt = attempt
+        self.data_coding = codec._find_data_coding(self.encoding)
+
+        self.optional_tags_dict = SubmitSM._create_opt_tags(
+            user_message_reference=user_message_reference,
+            source_port=source_port,
+            destination_port=destination_port,
+            payload_type=payload_type,
+            more_messages_to_send=more_messages_to_send,
+            qos_time_to_live=qos_time_to_live,
+            set_dpf=set_dpf,
+            language_indicator=language_indicator,
+        )
+
+    @staticmethod
+    def _validate_msg_type_args(
+        message_payload: str,
+        source_addr: str,
+        destination_addr: str,
+        log_id: str,
+        smpp_command: str,
+        version: int,
+        hook_metadata: str,
+        service_type: str,
+        source_addr_ton: typing.Union[None, int],
+        source_addr_npi: typing.Union[None, int],
+        dest_addr_ton: typing.Union[None, int],
+        dest_addr_npi: typing.Union[None, int],
+        esm_class: int,
+        registered_delivery: typing.Union[None, int],
+        encoding: str,
+        errors: str,
+        attempt: int,
+    ) -> None:
         if not isinstance(version, int):
             raise ValueError(
                 "`version` should be of type:: `int` You entered: {0}".format(type(version))
@@ -574,76 +910,36 @@ class EnquireLinkResp(Message):
                     NAZ_MESSAGE_PROTOCOL_VERSION
                 )
             )
-        if not isinstance(hook_metadata, str):
+        if not isinstance(message_payload, str):
             raise ValueError(
-                "`hook_metadata` should be of type:: `str` You entered: {0}".format(
-                    type(hook_metadata)
+                "`message_payload` should be of type:: `str` You entered: {0}".format(
+                    type(message_payload)
                 )
             )
-        self.log_id = log_id
-        self.sequence_number = sequence_number
-        self.version = version
-        self.smpp_command = state.SmppCommand.ENQUIRE_LINK_RESP
-        self.hook_metadata = hook_metadata
-
-    def to_json(self) -> str:
-        _item = dict(
-            smpp_command=self.smpp_command,
-            version=self.version,
-            log_id=self.log_id,
-            sequence_number=self.sequence_number,
-            hook_metadata=self.hook_metadata,
-        )
-        return json.dumps(_item)
-
-    @staticmethod
-    def from_json(json_message: str) -> "EnquireLinkResp":
-        _in_dict = json.loads(json_message)
-        return EnquireLinkResp(**_in_dict)
-
-
-class DeliverSmResp(Message):
-    def __init__(
-        self,
-        log_id: str,
-        message_id: str,
-        sequence_number: int,
-        smpp_command: str = state.SmppCommand.DELIVER_SM_RESP,
-        version: int = 1,
-        hook_metadata: str = "",
-    ) -> None:
-        """
-        Parameters:
-            log_id: a unique identify of this request
-            smpp_command: any one of the SMSC commands eg deliver_sm_resp
-            version: This indicates the current version of the naz message protocol.
-                     This version will enable naz to be able to evolve in future; a future version of `naz` may ship with a different message protocol.
-            hook_metadata: a string that to will later on be passed to `naz.Client.hook`. Your application can use it for correlation.
-            message_id: id of this message
-            sequence_number: SMPP sequence_number
-        """
-        if not isinstance(log_id, str):
+        if not isinstance(source_addr, str):
             raise ValueError(
-                "`log_id` should be of type:: `str` You entered: {0}".format(type(log_id))
+                "`source_addr` should be of type:: `str` You entered: {0}".format(type(source_addr))
             )
-        if not isinstance(message_id, str):
+        if not isinstance(destination_addr, str):
             raise ValueError(
-                "`message_id` should be of type:: `str` You entered: {0}".format(type(message_id))
+                "`destination_addr` should be of type:: `str` You entered: {0}".format(
+                    type(destination_addr)
+                )
             )
-        if not isinstance(sequence_number, int):
+        if not isinstance(log_id, str):
             raise ValueError(
-                "`sequence_number` should be of type:: `int` You entered: {0}".format(
-                    type(sequence_number)
-                )
+                "`log_id` should be of type:: `str` You entered: {0}".format(type(log_id))
             )
-        if not isinstance(version, int):
+        if not isinstance(smpp_command, str):
             raise ValueError(
-                "`version` should be of type:: `int` You entered: {0}".format(type(version))
+                "`smpp_command` should be of type:: `str` You entered: {0}".format(
+                    type(smpp_command)
+                )
             )
-        if version != NAZ_MESSAGE_PROTOCOL_VERSION:
+        if smpp_command != state.SmppCommand.DATA_SM:
             raise ValueError(
-                "`naz` currently only supports naz protocol version {0}".format(
-                    NAZ_MESSAGE_PROTOCOL_VERSION
+                "`smpp_command` should be:: `naz.state.SmppCommand.DATA_SM` You entered: {0}".format(
+                    smpp_command
                 )
             )
         if not isinstance(hook_metadata, str):
@@ -652,21 +948,272 @@ class DeliverSmResp(Message):
                     type(hook_metadata)
                 )
             )
-        self.log_id = log_id
-        self.version = version
-        self.smpp_command = state.SmppCommand.DELIVER_SM_RESP
-        self.message_id = message_id
-        self.sequence_number = sequence_number
-        self.hook_metadata = hook_metadata
-
-    def to_json(self) -> str:
-        _item = dict(
-            smpp_command=self.smpp_command,
-            version=self.version,
-            log_id=self.log_id,
+        if not isinstance(service_type, str):
+            raise ValueError(
+                "`service_type` should be of type:: `str` You entered: {0}".format(
+                    type(service_type)
+                )
+            )
+        if len(service_type) > 5 or not service_type.isascii():
+            # it is a C-Octet String of at most 6 octets, including the NULL terminator. section 5.2.11
+            raise ValueError(
+                "`service_type` should be at most 5 ascii characters. You entered: {0}".format(
+                    service_type
+                )
+            )
+        if not isinstance(source_addr_ton, (type(None), int)):
+            raise ValueError(
+                "`source_addr_ton` should be of type:: `None` or `int` You entered: {0}".format(
+                    type(source_addr_ton)
+                )
+            )
+        if isinstance(source_addr_ton, int) and not (0 <= source_addr_ton <= 0xFF):
+            raise ValueError(
+                "`source_addr_ton` should fit in one octet. You entered: {0}".format(
+                    source_addr_ton
+                )
+            )
+        if not isinstance(source_addr_npi, (type(None), int)):
+            raise ValueError(
+                "`source_addr_npi` should be of type:: `None` or `int` You entered: {0}".format(
+                    type(source_addr_npi)
+                )
+            )
+        if isinstance(source_addr_npi, int) and not (0 <= source_addr_npi <= 0xFF):
+            raise ValueError(
+                "`source_addr_npi` should fit in one octet. You entered: {0}".format(
+                    source_addr_npi
+                )
+            )
+        if not isinstance(dest_addr_ton, (type(None), int)):
+            raise ValueError(
+                "`dest_addr_ton` should be of type:: `None` or `int` You entered: {0}".format(
+                    type(dest_addr_ton)
+                )
+            )
+        if isinstance(dest_addr_ton, int) and not (0 <= dest_addr_ton <= 0xFF):
+            raise ValueError(
+                "`dest_addr_ton` should fit in one octet. You entered: {0}".format(
+                    dest_addr_ton
+                )
+            )
+        if not isinstance(dest_addr_npi, (type(None), int)):
+            raise ValueError(
+                "`dest_addr_npi` should be of type:: `None` or `int` You entered: {0}".format(
+                    type(dest_addr_npi)
+                )
+            )
+        if isinstance(dest_addr_npi, int) and not (0 <= dest_addr_npi <= 0xFF):
+            raise ValueError(
+                "`dest_addr_npi` should fit in one octet. You entered: {0}".format(
+                    dest_addr_npi
+                )
+            )
+        if not isinstance(esm_class, int):
+            raise ValueError(
+                "`esm_class` should be of type:: `int` You entered: {0}".format(type(esm_class))
+            )
+        if not isinstance(registered_delivery, (type(None), int)):
+            raise ValueError(
+                "`registered_delivery` should be of type:: `None` or `int` You entered: {0}".format(
+                    type(registered_delivery)
+                )
+            )
+        if isinstance(registered_delivery, int) and not (0 <= registered_delivery <= 0xFF):
+            raise ValueError(
+                "`registered_delivery` should fit in one octet. You entered: {0}".format(
+                    registered_delivery
+                )
+            )
+        if not isinstance(encoding, str):
+            raise ValueError(
+                "`encoding` should be of type:: `str` You entered: {0}".format(type(encoding))
+            )
+        if not isinstance(errors, str):
+            raise ValueError(
+                "`errors` should be of type:: `str` You entered: {0}".format(type(errors))
+            )
+        if not isinstance(attempt, int):
+            raise ValueError(
+                "`attempt` should be of type:: `int` You entered: {0}".format(type(attempt))
+            )
+        if attempt <= 0:
+            raise ValueError(
+                "`attempt` should be greater than zero. You entered: {0}".format(attempt)
+            )
+
+        # note: optional smpp parameters get validated on their own in `SubmitSM._create_opt_tags`
+
+    def to_json(self) -> str:
+        _item = dict(
+            smpp_command=self.smpp_command,
+            version=self.version,
+            message_payload=self.message_payload,
+            source_addr=self.source_addr,
+            destination_addr=self.destination_addr,
+            log_id=self.log_id,
+            hook_metadata=self.hook_metadata,
+            service_type=self.service_type,
+            source_addr_ton=self.source_addr_ton,
+            source_addr_npi=self.source_addr_npi,
+            dest_addr_ton=self.dest_addr_ton,
+            dest_addr_npi=self.dest_addr_npi,
+            esm_class=self.esm_class,
+            registered_delivery=self.registered_delivery,
+            encoding=self.encoding,
+            errors=self.errors,
+            attempt=self.attempt,
+        )
+        _item.update(**self.optional_tags_dict)
+        return json.dumps(_item)
+
+    @staticmethod
+    def from_json(json_message: str) -> "DataSM":
+        _in_dict = json.loads(json_message)
+        return DataSM(**_in_dict)
+
+
+class EnquireLinkResp(Message):
+    def __init__(
+        self,
+        log_id: str,
+        sequence_number: int,
+        smpp_command: str = state.SmppCommand.ENQUIRE_LINK_RESP,
+        version: int = 1,
+        hook_metadata: str = "",
+    ) -> None:
+        """
+        Parameters:
+            log_id: a unique identify of this request
+            smpp_command: any one of the SMSC commands eg enquire_link_resp
+            version: This indicates the current version of the naz message protocol.
+                     This version will enable naz to be able to evolve in future; a future version of `naz` may ship with a different message protocol.
+            hook_metadata: a string that to will later on be passed to `naz.Client.hook`. Your application can use it for correlation.
+            sequence_number: SMPP sequence_number
+        """
+        if not isinstance(log_id, str):
+            raise ValueError(
+                "`log_id` should be of type:: `str` You entered: {0}".format(type(log_id))
+            )
+        if not isinstance(sequence_number, int):
+            raise ValueError(
+                "`sequence_number` should be of type:: `int` You entered: {0}".format(
+                    type(sequence_number)
+                )
+            )
+        if not isinstance(version, int):
+            raise ValueError(
+                "`version` should be of type:: `int` You entered: {0}".format(type(version))
+            )
+        if version != NAZ_MESSAGE_PROTOCOL_VERSION:
+            raise ValueError(
+                "`naz` currently only supports naz protocol version {0}".format(
+                    NAZ_MESSAGE_PROTOCOL_VERSION
+                )
+            )
+        if not isinstance(hook_metadata, str):
+            raise ValueError(
+                "`hook_metadata` should be of type:: `str` You entered: {0}".format(
+                    type(hook_metadata)
+                )
+            )
+        self.log_id = log_id
+        self.sequence_number = sequence_number
+        self.version = version
+        self.smpp_command = state.SmppCommand.ENQUIRE_LINK_RESP
+        self.hook_metadata = hook_metadata
+
+    def to_json(self) -> str:
+        _item = dict(
+            smpp_command=self.smpp_command,
+            version=self.version,
+            log_id=self.log_id,
+            sequence_number=self.sequence_number,
+            hook_metadata=self.hook_metadata,
+        )
+        return json.dumps(_item)
+
+    @staticmethod
+    def from_json(json_message: str) -> "EnquireLinkResp":
+        _in_dict = json.loads(json_message)
+        return EnquireLinkResp(**_in_dict)
+
+
+class DeliverSmResp(Message):
+    def __init__(
+        self,
+        log_id: str,
+        message_id: str,
+        sequence_number: int,
+        smpp_command: str = state.SmppCommand.DELIVER_SM_RESP,
+        version: int = 1,
+        hook_metadata: str = "",
+        command_status: int = state.SmppCommandStatus.ESME_ROK.value,
+    ) -> None:
+        """
+        Parameters:
+            log_id: a unique identify of this request
+            smpp_command: any one of the SMSC commands eg deliver_sm_resp
+            version: This indicates the current version of the naz message protocol.
+                     This version will enable naz to be able to evolve in future; a future version of `naz` may ship with a different message protocol.
+            hook_metadata: a string that to will later on be passed to `naz.Client.hook`. Your application can use it for correlation.
+            message_id: id of this message
+            sequence_number: SMPP sequence_number
+            command_status: the command_status of the `deliver_sm_resp`. It is `0`(ESME_ROK) if the `deliver_sm` was accepted.
+        """
+        if not isinstance(log_id, str):
+            raise ValueError(
+                "`log_id` should be of type:: `str` You entered: {0}".format(type(log_id))
+            )
+        if not isinstance(message_id, str):
+            raise ValueError(
+                "`message_id` should be of type:: `str` You entered: {0}".format(type(message_id))
+            )
+        if not isinstance(sequence_number, int):
+            raise ValueError(
+                "`sequence_number` should be of type:: `int` You entered: {0}".format(
+                    type(sequence_number)
+                )
+            )
+        if not isinstance(version, int):
+            raise ValueError(
+                "`version` should be of type:: `int` You entered: {0}".format(type(version))
+            )
+        if version != NAZ_MESSAGE_PROTOCOL_VERSION:
+            raise ValueError(
+                "`naz` currently only supports naz protocol version {0}".format(
+                    NAZ_MESSAGE_PROTOCOL_VERSION
+                )
+            )
+        if not isinstance(hook_metadata, str):
+            raise ValueError(
+                "`hook_metadata` should be of type:: `str` You entered: {0}".format(
+                    type(hook_metadata)
+                )
+            )
+        self.log_id = log_id
+        self.version = version
+        if not isinstance(command_status, int):
+            raise ValueError(
+                "`command_status` should be of type:: `int` You entered: {0}".format(
+                    type(command_status)
+                )
+            )
+        self.smpp_command = state.SmppCommand.DELIVER_SM_RESP
+        self.message_id = message_id
+        self.sequence_number = sequence_number
+        self.hook_metadata = hook_metadata
+        self.command_status = command_status
+
+    def to_json(self) -> str:
+        _item = dict(
+            smpp_command=self.smpp_command,
+            version=self.version,
+            log_id=self.log_id,
             message_id=self.message_id,
             sequence_number=self.sequence_number,
             hook_metadata=self.hook_metadata,
+            command_status=self.command_status,
         )
         return json.dumps(_item)
 
@@ -676,6 +1223,379 @@ class DeliverSmResp(Message):
         return DeliverSmResp(**_in_dict)
 
 
+# the optional parameters that link together the parts of a concatenated message. see: `DeliverSM._concat_info`
+_SAR_TAGS = {
+    state.OptionalTag.NAME_to_TAG[name]: name
+    for name in ["sar_msg_ref_num", "sar_total_segments", "sar_segment_seqnum"]
+}
+
+
+class DeliverSM(Message):
+    """
+    A message/DELIVER_SM received from SMSC, eg a mobile originated message.
+    The short_message has already been decoded using the codec of the PDU's data_coding; eg `gsm0338` for 0, `latin_1` for 3 and `ucs2` for 8.
+    The undecoded bytes are available as `raw_short_message`.
+    If the data_coding has no text codec, eg binary data(data_coding 4), the short_message is None.
+    """
+
+    def __init__(
+        self,
+        log_id: str,
+        sequence_number: int,
+        short_message: typing.Union[None, str],
+        source_addr: str,
+        destination_addr: str,
+        service_type: str = "",
+        source_addr_ton: int = 0,
+        source_addr_npi: int = 0,
+        dest_addr_ton: int = 0,
+        dest_addr_npi: int = 0,
+        esm_class: int = 0,
+        protocol_id: int = 0,
+        priority_flag: int = 0,
+        registered_delivery: int = 0,
+        data_coding: int = 0,
+        encoding: str = "gsm0338",
+        optional_params: typing.Union[None, typing.List[state.TLV]] = None,
+        smpp_command: str = state.SmppCommand.DELIVER_SM,
+        version: int = 1,
+        hook_metadata: str = "",
+        raw_short_message: bytes = b"",
+        incomplete: bool = False,
+    ) -> None:
+        """
+        Parameters:
+            log_id: a unique identify of this request
+            sequence_number: SMPP sequence_number of the `deliver_sm`
+            short_message: the decoded message; or None if the data_coding has no text codec.
+            source_addr: the address of the SME which originated this message.
+            destination_addr: the destination address of this message.
+            service_type: Indicates the SMS Application service associated with the message
+            source_addr_ton: Type of Number for source address.
+            source_addr_npi: Numbering Plan Indicator for source address.
+            dest_addr_ton: Type of Number for destination.
+            dest_addr_npi: Numbering Plan Indicator for destination.
+            esm_class: Indicates the message type.
+            protocol_id: Protocol Identifier. Network specific field.
+            priority_flag: Designates the priority level of the message.
+            registered_delivery: Indicator to signify if an SMSC delivery receipt or an SME acknowledgement is required.
+            data_coding: the data_coding of the PDU.
+            encoding: the encoding that was used to decode the short_message; or an empty string if it was not decoded.
+            optional_params: the optional parameters of the PDU.
+            smpp_command: any one of the SMSC commands eg deliver_sm
+            version: This indicates the current version of the naz message protocol.
+                     This version will enable naz to be able to evolve in future; a future version of `naz` may ship with a different message protocol.
+            hook_metadata: a string that to will later on be passed to `naz.Client.hook`. Your application can use it for correlation.
+            raw_short_message: the message as it was received from SMSC, before it was decoded. \
+                It is taken from the `message_payload` optional parameter if the short_message of the PDU is empty.
+            incomplete: True if this message was reassembled from only some of the parts of a concatenated message, because the rest did not arrive in time. \
+                See the `reassembly_timeout` argument of :class:`naz.Client <naz.Client>`
+        """
+        if not isinstance(short_message, (type(None), str)):
+            raise ValueError(
+                "`short_message` should be of type:: `None` or `str` You entered: {0}".format(
+                    type(short_message)
+                )
+            )
+        for name, value in [
+            ("log_id", log_id),
+            ("source_addr", source_addr),
+            ("destination_addr", destination_addr),
+            ("service_type", service_type),
+            ("encoding", encoding),
+            ("hook_metadata", hook_metadata),
+        ]:
+            if not isinstance(value, str):
+                raise ValueError(
+                    "`{0}` should be of type:: `str` You entered: {1}".format(name, type(value))
+                )
+        for name, value in [
+            ("sequence_number", sequence_number),
+            ("source_addr_ton", source_addr_ton),
+            ("source_addr_npi", source_addr_npi),
+            ("dest_addr_ton", dest_addr_ton),
+            ("dest_addr_npi", dest_addr_npi),
+            ("esm_class", esm_class),
+            ("protocol_id", protocol_id),
+            ("priority_flag", priority_flag),
+            ("registered_delivery", registered_delivery),
+            ("data_coding", data_coding),
+            ("version", version),
+        ]:
+            if not isinstance(value, int):
+                raise ValueError(
+                    "`{0}` should be of type:: `int` You entered: {1}".format(name, type(value))
+                )
+        if version != NAZ_MESSAGE_PROTOCOL_VERSION:
+            raise ValueError(
+                "`naz` currently only supports naz protocol version {0}".format(
+                    NAZ_MESSAGE_PROTOCOL_VERSION
+                )
+            )
+        if not isinstance(raw_short_message, bytes):
+            raise ValueError(
+                "`raw_short_message` should be of type:: `bytes` You entered: {0}".format(
+                    type(raw_short_message)
+                )
+            )
+        if not isinstance(incomplete, bool):
+            raise ValueError(
+                "`incomplete` should be of type:: `bool` You entered: {0}".format(type(incomplete))
+            )
+        if optional_params is None:
+            optional_params = []
+        for tlv in optional_params:
+            state.TLV._validate(tlv)
+
+        self.log_id = log_id
+        self.version = version
+        self.smpp_command = state.SmppCommand.DELIVER_SM
+        self.sequence_number = sequence_number
+        self.short_message = short_message
+        self.raw_short_message = raw_short_message
+        self.incomplete = incomplete
+        self.source_addr = source_addr
+        self.destination_addr = destination_addr
+        self.service_type = service_type
+        self.source_addr_ton = source_addr_ton
+        self.source_addr_npi = source_addr_npi
+        self.dest_addr_ton = dest_addr_ton
+        self.dest_addr_npi = dest_addr_npi
+        self.esm_class = esm_class
+        self.protocol_id = protocol_id
+        self.priority_flag = priority_flag
+        self.registered_delivery = registered_delivery
+        self.data_coding = data_coding
+        self.encoding = encoding
+        self.optional_params = optional_params
+        self.hook_metadata = hook_metadata
+
+    @property
+    def is_delivery_receipt(self) -> bool:
+        """
+        Whether this `deliver_sm` is a delivery receipt or an SME acknowledgement rather than a mobile originated message.
+        """
+        # bits 5-2 of esm_class are the message type. see section 5.2.12 of smpp ver 3.4 spec document
+        return (self.esm_class & 0b00111100) != 0
+
+    # the esm_class of a `deliver_sm`. see section 5.2.12 of smpp ver 3.4 spec document
+    # bits 7-6 are the GSM network specific features, and bits 5-2 are the message type.
+
+    @property
+    def udh_indicator(self) -> bool:
+        """
+        Whether the short_message, or message_payload, starts with a User Data Header(UDHI); eg because it is a part of a concatenated message.
+        """
+        return bool(self.esm_class & 0b01000000)
+
+    @property
+    def reply_path(self) -> bool:
+        """
+        Whether the sender asked for a reply to be sent through the same SMSC(Reply Path).
+        """
+        return bool(self.esm_class & 0b10000000)
+
+    @property
+    def message_type(self) -> int:
+        """
+        The message type bits(5-2) of esm_class. eg; 0b00000100 for an SMSC delivery receipt.
+        The `is_*` properties tell the message types apart without having to compare bits.
+        """
+        return self.esm_class & 0b00111100
+
+    @property
+    def is_mobile_originated(self) -> bool:
+        """
+        Whether this is a normal message, eg one sent by a mobile user, rather than a receipt, acknowledgement or notification.
+        """
+        return self.message_type == 0b00000000
+
+    @property
+    def is_smsc_delivery_receipt(self) -> bool:
+        """
+        Whether this is a delivery receipt of a message that was submitted. see :func:`parse_delivery_receipt <parse_delivery_receipt>`
+        """
+        return self.message_type == 0b00000100
+
+    @property
+    def is_sme_delivery_acknowledgement(self) -> bool:
+        """
+        Whether this is an acknowledgement, by the recipient's SME, that it has received a message.
+        """
+        return self.message_type == 0b00001000
+
+    @property
+    def is_sme_manual_acknowledgement(self) -> bool:
+        """
+        Whether this is an acknowledgement that the recipient has read or replied to a message; a manual or user acknowledgement.
+        """
+        return self.message_type == 0b00010000
+
+    @property
+    def is_conversation_abort(self) -> bool:
+        """
+        Whether this is a Conversation Abort; used in Korean CDMA networks.
+        """
+        return self.message_type == 0b00011000
+
+    @property
+    def is_intermediate_notification(self) -> bool:
+        """
+        Whether this is an intermediate delivery notification; ie the message has not reached its final state yet.
+        """
+        return self.message_type == 0b00100000
+
+    @property
+    def receipted_message_id(self) -> typing.Union[None, str]:
+        """
+        The SMSC message_id of the message that this delivery receipt is for, as carried in the `receipted_message_id` optional parameter; or None if SMSC did not send it.
+        Some SMSCs only send the message_id that way, rather than in the `id` field of the short_message. see :func:`parse_delivery_receipt <parse_delivery_receipt>`
+        """
+        for tlv in self.optional_params:
+            if tlv.tag == state.OptionalTag.NAME_to_TAG["receipted_message_id"]:
+                return tlv.value.rstrip(chr(0).encode("ascii")).decode("ascii")
+        return None
+
+    def _concat_info(self) -> typing.Union[None, typing.Tuple[int, int, int, bytes]]:
+        """
+        If this `deliver_sm` is one part of a concatenated message, it returns the
+        (reference_number, total_parts, part_number, user_data) of the part; otherwise None.
+        The part is identified by either a concatenation UDH or the SAR optional parameters.
+        """
+        if self.udh_indicator:
+            # the UDHI(User Data Header Indicator) bit is set. see section 5.2.12 of smpp ver 3.4 spec document
+            udh_length = self.raw_short_message[0]
+            udh = self.raw_short_message[1 : 1 + udh_length]
+            offset = 0
+            while offset + 2 <= len(udh):
+                iei, iedl = udh[offset], udh[offset + 1]
+                ie = udh[offset + 2 : offset + 2 + iedl]
+                if iei == 0x00 and iedl == 3:
+                    # concatenated short messages, 8-bit reference number
+                    return ie[0], ie[1], ie[2], self.raw_short_message[1 + udh_length :]
+                if iei == 0x08 and iedl == 4:
+                    # concatenated short messages, 16-bit reference number
+                    reference_number = struct.unpack(">H", ie[:2])[0]
+                    return reference_number, ie[2], ie[3], self.raw_short_message[1 + udh_length :]
+                offset = offset + 2 + iedl
+            return None
+
+        sar = {}
+        for tlv in self.optional_params:
+            if tlv.tag in _SAR_TAGS:
+                sar[_SAR_TAGS[tlv.tag]] = int.from_bytes(tlv.value, "big")
+        if len(sar) == len(_SAR_TAGS):
+            return (
+                sar["sar_msg_ref_num"],
+                sar["sar_total_segments"],
+                sar["sar_segment_seqnum"],
+                self.raw_short_message,
+            )
+        return None
+
+    @staticmethod
+    def _from_pdu(pdu: bytes, log_id: str, hook_metadata: str = "") -> "DeliverSM":
+        """
+        creates a DeliverSM from a `deliver_sm` PDU as received from SMSC.
+        see section 4.6.1 of smpp ver 3.4 spec document
+        """
+        sequence_number = struct.unpack(">I", pdu[12:16])[0]
+        body = pdu[16:]
+        service_type, offset = _read_c_octet_string(body, 0)
+        source_addr_ton, source_addr_npi = body[offset], body[offset + 1]
+        source_addr, offset = _read_c_octet_string(body, offset + 2)
+        dest_addr_ton, dest_addr_npi = body[offset], body[offset + 1]
+        destination_addr, offset = _read_c_octet_string(body, offset + 2)
+        esm_class, protocol_id, priority_flag = body[offset], body[offset + 1], body[offset + 2]
+        _, offset = _read_c_octet_string(body, offset + 3)  # schedule_delivery_time
+        _, offset = _read_c_octet_string(body, offset)  # validity_period
+        registered_delivery = body[offset]
+        data_coding = body[offset + 2]
+        sm_length = body[offset + 4]
+        short_message = body[offset + 5 : offset + 5 + sm_length]
+        optional_params = state.TLV.parse(body[offset + 5 + sm_length :])
+
+        if not short_message:
+            # the message may instead be in the message_payload optional parameter
+            for tlv in optional_params:
+                if tlv.tag == state.OptionalTag.NAME_to_TAG["message_payload"]:
+                    short_message = tlv.value
+        user_data = short_message
+        if esm_class & 0b01000000 and short_message:
+            # the short_message starts with a UDH(whose first octet is its length) that is not text.
+            user_data = short_message[1 + short_message[0] :]
+        decoded: typing.Union[None, str] = None
+        try:
+            encoding = codec._find_encoding(data_coding)
+            codec_info = codec._codec_info(encoding)
+        except (ValueError, LookupError):
+            # the data_coding has no text codec, eg binary data. It is left to the handler to
+            # interpret the raw_short_message.
+            encoding = ""
+        else:
+            decoded = codec_info.decode(user_data)[0]
+        return DeliverSM(
+            log_id=log_id,
+            sequence_number=sequence_number,
+            short_message=decoded,
+            source_addr=source_addr,
+            destination_addr=destination_addr,
+            service_type=service_type,
+            source_addr_ton=source_addr_ton,
+            source_addr_npi=source_addr_npi,
+            dest_addr_ton=dest_addr_ton,
+            dest_addr_npi=dest_addr_npi,
+            esm_class=esm_class,
+            protocol_id=protocol_id,
+            priority_flag=priority_flag,
+            registered_delivery=registered_delivery,
+            data_coding=data_coding,
+            encoding=encoding,
+            optional_params=optional_params,
+            hook_metadata=hook_metadata,
+            raw_short_message=short_message,
+        )
+
+    def to_json(self) -> str:
+        _item = dict(
+            smpp_command=self.smpp_command,
+            version=self.version,
+            log_id=self.log_id,
+            sequence_number=self.sequence_number,
+            short_message=self.short_message,
+            raw_short_message=self.raw_short_message.hex(),
+            incomplete=self.incomplete,
+            source_addr=self.source_addr,
+            destination_addr=self.destination_addr,
+            service_type=self.service_type,
+            source_addr_ton=self.source_addr_ton,
+            source_addr_npi=self.source_addr_npi,
+            dest_addr_ton=self.dest_addr_ton,
+            dest_addr_npi=self.dest_addr_npi,
+            esm_class=self.esm_class,
+            protocol_id=self.protocol_id,
+            priority_flag=self.priority_flag,
+            registered_delivery=self.registered_delivery,
+            data_coding=self.data_coding,
+            encoding=self.encoding,
+            optional_params=[
+                {"tag": tlv.tag, "value": tlv.value.hex()} for tlv in self.optional_params
+            ],
+            hook_metadata=self.hook_metadata,
+        )
+        return json.dumps(_item)
+
+    @staticmethod
+    def from_json(json_message: str) -> "DeliverSM":
+        _in_dict = json.loads(json_message)
+        _in_dict["optional_params"] = [
+            state.TLV(tag=tlv["tag"], value=bytes.fromhex(tlv["value"]))
+            for tlv in _in_dict.get("optional_params", [])
+        ]
+        _in_dict["raw_short_message"] = bytes.fromhex(_in_dict.get("raw_short_message", ""))
+        return DeliverSM(**_in_dict)
+
+
 def json_to_Message(json_message: str) -> Message:
     """
     Utility function to deserialize the message protocol from json.
@@ -688,13 +1608,371 @@ def json_to_Message(json_message: str) -> Message:
     smpp_command = _item["smpp_command"]
     if smpp_command == state.SmppCommand.SUBMIT_SM:
         return SubmitSM.from_json(json_message=json_message)
+    elif smpp_command == state.SmppCommand.DATA_SM:
+        return DataSM.from_json(json_message=json_message)
     elif smpp_command == state.SmppCommand.ENQUIRE_LINK_RESP:
         return EnquireLinkResp.from_json(json_message=json_message)
     elif smpp_command == state.SmppCommand.DELIVER_SM_RESP:
         return DeliverSmResp.from_json(json_message=json_message)
+    elif smpp_command == state.SmppCommand.DELIVER_SM:
+        return DeliverSM.from_json(json_message=json_message)
     else:
         raise NotImplementedError(
             "The `from_json` method for smpp_command: `{0}` has not been implemented.".format(
                 smpp_command
             )
         )
+
+
+def _read_c_octet_string(data: bytes, offset: int) -> typing.Tuple[str, int]:
+    """
+    read a C-Octet String that starts at `offset`.
+    It returns the string and the offset of the first octet after the string's NULL terminator.
+    """
+    end = data.find(chr(0).encode("ascii"), offset)
+    if end == -1:
+        end = len(data)
+    return data[offset:end].decode("ascii"), end + 1
+
+
+def _read_unsuccess_smes(data: bytes, offset: int) -> typing.List[state.UnsuccessSME]:
+    """
+    read the no_unsuccess field, that starts at `offset`, of a `submit_multi_resp` and the unsuccess_sme's that follow it.
+    see section 4.5.2 of smpp ver 3.4 spec document
+
+    Raises:
+        struct.error: raised if the unsuccess_sme's are malformed.
+    """
+    if offset >= len(data):
+        # SMSC may leave out no_unsuccess if the message was accepted for all the destinations.
+        return []
+    no_unsuccess = struct.unpack(">B", data[offset : offset + 1])[0]
+    offset = offset + 1
+    unsuccess_smes = []
+    for _ in range(0, no_unsuccess):
+        dest_addr_ton, dest_addr_npi = struct.unpack(">BB", data[offset : offset + 2])
+        destination_addr, offset = _read_c_octet_string(data, offset + 2)
+        error_status_code = struct.unpack(">I", data[offset : offset + 4])[0]
+        offset = offset + 4
+        unsuccess_smes.append(
+            state.UnsuccessSME(
+                dest_addr_ton=dest_addr_ton,
+                dest_addr_npi=dest_addr_npi,
+                destination_addr=destination_addr,
+                error_status_code=error_status_code,
+            )
+        )
+    return unsuccess_smes
+
+
+def read_optional_params(pdu: bytes) -> typing.List[state.TLV]:
+    """
+    Utility function to parse the optional parameters(TLVs) of an SMPP PDU.
+    It can be used, for example, inside a :class:`naz.hooks.BaseHook <naz.hooks.BaseHook>`
+    to read the optional parameters of PDUs received from SMSC.
+
+    Parameters:
+        pdu: the full PDU(header and body)
+
+    Raises:
+        ValueError: raised if the optional parameters are malformed.
+    """
+    command_id = struct.unpack(">I", pdu[4:8])[0]
+    body = pdu[16:]
+
+    offset = 0
+    if command_id in (0x00000004, 0x00000005):
+        # submit_sm & deliver_sm. see section 4.4.1 & 4.6.1 of smpp ver 3.4 spec document
+        _, offset = _read_c_octet_string(body, offset)  # service_type
+        offset = offset + 2  # source_addr_ton, source_addr_npi
+        _, offset = _read_c_octet_string(body, offset)  # source_addr
+        offset = offset + 2  # dest_addr_ton, dest_addr_npi
+        _, offset = _read_c_octet_string(body, offset)  # destination_addr
+        offset = offset + 3  # esm_class, protocol_id, priority_flag
+        _, offset = _read_c_octet_string(body, offset)  # schedule_delivery_time
+        _, offset = _read_c_octet_string(body, offset)  # validity_period
+        offset = offset + 4  # registered_delivery, replace_if_present_flag, data_coding, sm_default_msg_id
+        sm_length = body[offset]
+        offset = offset + 1 + sm_length
+    elif command_id == 0x00000103:
+        # data_sm. see section 4.7.1 of smpp ver 3.4 spec document
+        _, offset = _read_c_octet_string(body, offset)  # service_type
+        offset = offset + 2  # source_addr_ton, source_addr_npi
+        _, offset = _read_c_octet_string(body, offset)  # source_addr
+        offset = offset + 2  # dest_addr_ton, dest_addr_npi
+        _, offset = _read_c_octet_string(body, offset)  # destination_addr
+        offset = offset + 3  # esm_class, registered_delivery, data_coding
+    elif command_id in (
+        0x80000001,  # bind_receiver_resp
+        0x80000002,  # bind_transmitter_resp
+        0x80000009,  # bind_transceiver_resp
+        0x80000004,  # submit_sm_resp
+        0x80000103,  # data_sm_resp
+    ):
+        # the only mandatory parameter is a system_id or message_id
+        if body:
+            _, offset = _read_c_octet_string(body, offset)
+    else:
+        # the other PDUs do not carry optional parameters
+        return []
+    return state.TLV.parse(body[offset:])
+
+
+_DELIVERY_RECEIPT_FIELDS = re.compile(r"\b(id|sub|dlvrd|submit date|done date|stat|err|text):", re.I)
+
+# the abbreviated message states used in the `stat` field of delivery receipts.
+_DELIVERY_RECEIPT_STATS = {
+    "ENROUTE": state.SmppMessageState.ENROUTE,
+    "DELIVRD": state.SmppMessageState.DELIVERED,
+    "EXPIRED": state.SmppMessageState.EXPIRED,
+    "DELETED": state.SmppMessageState.DELETED,
+    "UNDELIV": state.SmppMessageState.UNDELIVERABLE,
+    "ACCEPTD": state.SmppMessageState.ACCEPTED,
+    "UNKNOWN": state.SmppMessageState.UNKNOWN,
+    "REJECTD": state.SmppMessageState.REJECTED,
+}
+
+
+def _parse_delivery_receipt_date(
+    value: typing.Union[None, str]
+) -> typing.Union[None, datetime.datetime]:
+    if not value:
+        return None
+    # the format is YYMMDDhhmm, some SMSCs also add seconds.
+    if len(value) == 12:
+        return datetime.datetime.strptime(value, "%y%m%d%H%M%S")
+    return datetime.datetime.strptime(value, "%y%m%d%H%M")
+
+
+def parse_delivery_receipt(
+    short_message: str, optional_params: typing.Union[None, typing.List[state.TLV]] = None
+) -> state.DeliveryReceipt:
+    """
+    Utility function to parse the delivery receipt carried in the short_message of a `deliver_sm` PDU.
+    The receipt is expected to be in the format described in Appendix B of smpp ver 3.4 spec document, ie;
+    `id:IIIIIIIIII sub:SSS dlvrd:DDD submit date:YYMMDDhhmm done date:YYMMDDhhmm stat:DDDDDDD err:E text:...`
+
+    If the `receipted_message_id` or `message_state` optional parameters are present, they take precedence over
+    the `id` and `stat` fields of the short_message.
+
+    Parameters:
+        short_message: the decoded short_message of the `deliver_sm`
+        optional_params: the optional parameters of the `deliver_sm`, eg as returned by :func:`read_optional_params <read_optional_params>`
+
+    Raises:
+        ValueError: raised if the receipt does not have a message id or a stat, or if a field is malformed.
+    """
+    fields: typing.Dict[str, str] = {}
+    matches = list(_DELIVERY_RECEIPT_FIELDS.finditer(short_message))
+    for index, match in enumerate(matches):
+        key = match.group(1).lower()
+        if key == "text":
+            # text is the last field and may itself contain spaces or colons
+            fields[key] = short_message[match.end() :]
+            break
+        end = matches[index + 1].start() if index + 1 < len(matches) else len(short_message)
+        fields[key] = short_message[match.end() : end].strip()
+
+    message_state = None
+    for tlv in optional_params or []:
+        if tlv.tag == state.OptionalTag.NAME_to_TAG["receipted_message_id"]:
+            fields["id"] = tlv.value.rstrip(chr(0).encode("ascii")).decode("ascii")
+        elif tlv.tag == state.OptionalTag.NAME_to_TAG["message_state"]:
+            message_state = state.SmppMessageState._find_message_state(tlv.value[0])
+
+    if not fields.get("id"):
+        raise ValueError(
+            "the delivery receipt does not have a message id: {0}".format(short_message)
+        )
+    stat = fields.get("stat", "")
+    if message_state is None:
+        if not stat:
+            raise ValueError("the delivery receipt does not have a stat: {0}".format(short_message))
+        message_state = _DELIVERY_RECEIPT_STATS.get(stat.upper())
+
+    return state.DeliveryReceipt(
+        message_id=fields["id"],
+        stat=stat,
+        message_state=message_state,
+        submitted=int(fields["sub"]) if fields.get("sub") else None,
+        delivered=int(fields["dlvrd"]) if fields.get("dlvrd") else None,
+        submit_date=_parse_delivery_receipt_date(fields.get("submit date")),
+        done_date=_parse_delivery_receipt_date(fields.get("done date")),
+        err=fields.get("err") or None,
+        text=fields.get("text"),
+    )
+
+
+# YYMMDDhhmmss, tenths of a second, quarter hours from UTC and `+`/`-` for absolute or `R` for relative times.
+_SMPP_TIME = re.compile(r"^\d{12}(\d)(\d{2})([+\-R])$")
+
+
+def smpp_time(value: typing.Union[datetime.datetime, datetime.timedelta]) -> str:
+    """
+    Utility function to format a time in the `YYMMDDhhmmsstnnp` format used by the `schedule_delivery_time` and `validity_period` fields.
+    see section 7.1.1 of smpp ver 3.4 spec document.
+
+    A `datetime.datetime` is formatted as an absolute time; if it is naive, it is taken to be in UTC.
+    A `datetime.timedelta` is formatted as a time relative to the SMSC's current time, eg `datetime.timedelta(hours=24)`
+
+    Parameters:
+        value: the time to format.
+
+    Raises:
+        ValueError: raised if the time cannot be represented in the SMPP time format.
+    """
+    if isinstance(value, datetime.datetime):
+        offset = value.utcoffset() or datetime.timedelta(0)
+        quarter_hours, remainder = divmod(abs(offset).total_seconds(), 15 * 60)
+        if remainder:
+            raise ValueError(
+                "the UTC offset of `value` should be a multiple of 15 minutes. You entered: {0}".format(
+                    offset
+                )
+            )
+        return "{0}{1}{2:02d}{3}".format(
+            value.strftime("%y%m%d%H%M%S"),
+            value.microsecond // 100_000,
+            int(quarter_hours),
+            "-" if offset < datetime.timedelta(0) else "+",
+        )
+    elif isinstance(value, datetime.timedelta):
+        if value < datetime.timedelta(0):
+            raise ValueError("`value` should not be negative. You entered: {0}".format(value))
+        years, days = divmod(value.days, 365)
+        months, days = divmod(days, 30)
+        if years > 99:
+            raise ValueError(
+                "`value` should be less than 100 years. You entered: {0}".format(value)
+            )
+        hours, seconds = divmod(value.seconds, 3600)
+        minutes, seconds = divmod(seconds, 60)
+        return "{0:02d}{1:02d}{2:02d}{3:02d}{4:02d}{5:02d}000R".format(
+            years, months, days, hours, minutes, seconds
+        )
+    raise ValueError(
+        "`value` should be of type:: `datetime.datetime` or `datetime.timedelta` You entered: {0}".format(
+            type(value)
+        )
+    )
+
+
+def e164(number: str) -> str:
+    """
+    Utility function to normalize a phone number to the E.164 format that SMSCs expect in the `source_addr` and `destination_addr` fields;
+    ie, the country code and subscriber number without a leading `+` or `00`. eg; both `+254700111222` and `00254700111222` are normalized to `254700111222`
+
+    Parameters:
+        number: the phone number to normalize.
+
+    Raises:
+        ValueError: raised if the number is not a valid E.164 number.
+    """
+    if not isinstance(number, str):
+        raise ValueError("`number` should be of type:: `str` You entered: {0}".format(type(number)))
+    digits = number.strip()
+    if digits.startswith("+"):
+        digits = digits[1:]
+    elif digits.startswith("00"):
+        digits = digits[2:]
+    # an E.164 number has at most 15 digits and a country code never starts with zero.
+    if (
+        not digits.isdigit()
+        or not digits.isascii()
+        or digits.startswith("0")
+        or not (7 <= len(digits) <= 15)
+    ):
+        raise ValueError("`number` should be a valid E.164 number. You entered: {0}".format(number))
+    return digits
+
+
+def _validate_smpp_time(name: str, value: str) -> None:
+    """
+    an empty string is allowed; it means immediate delivery or the SMSC default validity period.
+    """
+    if value == "":
+        return None
+    match = _SMPP_TIME.match(value)
+    if not match or int(match.group(2)) > 48:
+        raise ValueError(
+            "`{0}` should be in the `YYMMDDhhmmsstnnp` format. You entered: {1}".format(name, value)
+        )
+    if match.group(3) != "R":
+        try:
+            datetime.datetime.strptime(value[:12], "%y%m%d%H%M%S")
+        except ValueError:
+            raise ValueError(
+                "`{0}` should be in the `YYMMDDhhmmsstnnp` format. You entered: {1}".format(
+                    name, value
+                )
+            )
+
+
+# the TON and NPI of each `naz.AddressKind`; see section 5.2.5 & 5.2.6 of smpp ver 3.4 spec document
+_ADDRESS_KINDS: typing.Dict[str, typing.Tuple[int, int]] = {
+    state.AddressKind.INTERNATIONAL: (0x01, 0x01),
+    state.AddressKind.NATIONAL: (0x02, 0x01),
+    state.AddressKind.SHORT_CODE: (0x03, 0x00),
+    state.AddressKind.ALPHANUMERIC: (0x05, 0x00),
+}
+
+
+def _address_kind(
+    name: str,
+    kind: typing.Union[None, str],
+    ton: typing.Union[None, int],
+    npi: typing.Union[None, int],
+) -> typing.Tuple[typing.Union[None, int], typing.Union[None, int]]:
+    """
+    Set the TON and NPI of an address from its `naz.AddressKind`; unless they have been set already.
+    """
+    if kind is None:
+        return ton, npi
+    if kind not in _ADDRESS_KINDS:
+        raise ValueError(
+            "`{0}` should be one of; {1}. You entered: {2}".format(
+                name, list(_ADDRESS_KINDS.keys()), kind
+            )
+        )
+    kind_ton, kind_npi = _ADDRESS_KINDS[kind]
+    if ton is None:
+        ton = kind_ton
+    if npi is None:
+        npi = kind_npi
+    return ton, npi
+
+
+def _alphanumeric_sender(
+    source_addr: str,
+    source_addr_ton: typing.Union[None, int],
+    source_addr_npi: typing.Union[None, int],
+) -> typing.Tuple[typing.Union[None, int], typing.Union[None, int]]:
+    """
+    If the source_addr is alphanumeric(eg a brand name), check that SMSC can accept it and
+    set its TON to alphanumeric(0x05) and NPI to unknown(0x00); unless they have been set already.
+    Only a source_addr that has letters is alphanumeric; a formatted number like "+254 700 111-222" is not.
+    see section 5.2.5 & 5.2.6 of smpp ver 3.4 spec document
+    """
+    if not any(char.isalpha() for char in source_addr):
+        return source_addr_ton, source_addr_npi
+    if len(source_addr) > 11:
+        raise ValueError(
+            "an alphanumeric `source_addr` should be at most 11 characters long. You entered: {0}".format(
+                source_addr
+            )
+        )
+    for char in source_addr:
+        if (
+            char not in codec.GSM7BitCodec.gsm_basic_charset_map
+            and char not in codec.GSM7BitCodec.gsm_extension_map
+        ):
+            raise ValueError(
+                "an alphanumeric `source_addr` should only have characters from the GSM alphabet. You entered: {0}".format(
+                    source_addr
+                )
+            )
+    if source_addr_ton is None:
+        source_addr_ton = 0x05
+    if source_addr_npi is None:
+        source_addr_npi = 0x00
+    return source_addr_ton, source_addr_npi
@@ -72,6 +72,24 @@ class SimpleRateLimiter(BaseRateLimiter):
         else:
             self.logger = log.SimpleLogger("naz.SimpleRateLimiter")
 
+    def set_send_rate(self, send_rate: float) -> None:
+        """
+        changes the maximum rate, in messages/second, at which naz can send messages to SMSC.
+        It is used, for example, by :class:`naz.throttle.AdaptiveThrottleHandler <naz.throttle.AdaptiveThrottleHandler>`
+        to slow down when SMSC starts throttling naz.
+
+        Parameters:
+            send_rate: the new send rate.
+        """
+        if not isinstance(send_rate, float):
+            raise ValueError(
+                "`send_rate` should be of type:: `float` You entered: {0}".format(type(send_rate))
+            )
+        self.send_rate = send_rate
+        # we should always be able to accumulate at least one token.
+        self.max_tokens = max(self.send_rate, 1.0)
+        self.tokens = min(self.tokens, self.max_tokens)
+
     async def limit(self) -> None:
         self.logger.log(logging.DEBUG, {"event": "naz.SimpleRateLimiter.limit", "stage": "start"})
         while self.tokens < 1:
@@ -102,3 +120,209 @@ class SimpleRateLimiter(BaseRateLimiter):
             self.tokens = min(self.tokens + new_tokens, self.max_tokens)
             self.updated_at = now
             self.messages_delivered = 0
+
+
+class TokenBucketRateLimiter(BaseRateLimiter):
+    """
+    This is an implementation of BaseRateLimiter.
+
+    It does rate limiting using a `token bucket rate limiting algorithm <https://en.wikipedia.org/wiki/Token_bucket>`_
+    that allows short bursts above the sustained send rate. \
+    Tokens are added at :attr:`send_rate <TokenBucketRateLimiter.send_rate>` tokens per second, \
+    upto a maximum of :attr:`burst <TokenBucketRateLimiter.burst>` tokens. Each message sent uses up one token.
+
+    :func:`limit <TokenBucketRateLimiter.limit>` only sleeps as long as is needed for the next token to be available.
+    Since it uses `asyncio.sleep`, cancelling the task that is waiting on it(eg when shutting down) returns immediately.
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        # a sustained rate of 10 messages/second, with bursts of upto 50 messages.
+        rate_limiter = TokenBucketRateLimiter(send_rate=10.00, burst=50.00)
+        await rate_limiter.limit()
+        send_messsages()
+    """
+
+    def __init__(
+        self,
+        send_rate: float = 100_000.00,
+        burst: typing.Union[None, float] = None,
+        logger: typing.Union[None, logging.Logger] = None,
+    ) -> None:
+        """
+        Parameters:
+            send_rate: the sustained rate, in messages/second, at which naz can send messages to SMSC.
+            burst: the maximum number of messages that can be sent at once. Defaults to `send_rate`
+        """
+        if not isinstance(send_rate, float) or send_rate <= 0:
+            raise ValueError(
+                "`send_rate` should be a `float` greater than zero. You entered: {0}".format(
+                    send_rate
+                )
+            )
+        if burst is None:
+            burst = max(send_rate, 1.0)
+        if not isinstance(burst, float) or burst < 1:
+            raise ValueError(
+                "`burst` should be of type:: `None` or a `float` not less than 1. You entered: {0}".format(
+                    burst
+                )
+            )
+        if not isinstance(logger, (type(None), logging.Logger)):
+            raise ValueError(
+                "`logger` should be of type:: `None` or `logging.Logger` You entered: {0}".format(
+                    type(logger)
+                )
+            )
+
+        self.send_rate: float = send_rate
+        self.burst: float = burst
+        self.tokens: float = self.burst
+        self.updated_at: float = time.monotonic()
+        if logger is not None:
+            self.logger = logger
+        else:
+            self.logger = log.SimpleLogger("naz.TokenBucketRateLimiter")
+
+    def set_send_rate(self, send_rate: float) -> None:
+        """
+        changes the sustained rate, in messages/second, at which naz can send messages to SMSC.
+
+        Parameters:
+            send_rate: the new send rate.
+        """
+        if not isinstance(send_rate, float) or send_rate <= 0:
+            raise ValueError(
+                "`send_rate` should be a `float` greater than zero. You entered: {0}".format(
+                    send_rate
+                )
+            )
+        self._add_new_tokens()
+        self.send_rate = send_rate
+
+    async def limit(self) -> None:
+        self.logger.log(
+            logging.DEBUG, {"event": "naz.TokenBucketRateLimiter.limit", "stage": "start"}
+        )
+        self._add_new_tokens()
+        while self.tokens < 1:
+            delay = (1 - self.tokens) / self.send_rate
+            self.logger.log(
+                logging.DEBUG,
+                {
+                    "event": "naz.TokenBucketRateLimiter.limit",
+                    "stage": "end",
+                    "state": "limiting rate",
+                    "send_rate": self.send_rate,
+                    "burst": self.burst,
+                    "delay": delay,
+                },
+            )
+            await asyncio.sleep(delay)
+            self._add_new_tokens()
+        self.tokens -= 1
+
+    def _add_new_tokens(self) -> None:
+        now = time.monotonic()
+        self.tokens = min(self.burst, self.tokens + ((now - self.updated_at) * self.send_rate))
+        self.updated_at = now
+
+
+class ByteRateLimiter:
+    """
+    Limits the rate, in bytes/second, at which naz sends messages to SMSC; based on the size of each encoded PDU.
+    It is useful on a link whose bandwidth is limited, since the size of messages varies widely.
+    It is used in addition to the :attr:`rate_limiter <naz.Client.rate_limiter>`; see :attr:`byte_rate_limiter <naz.Client.byte_rate_limiter>`
+
+    It uses a `token bucket rate limiting algorithm <https://en.wikipedia.org/wiki/Token_bucket>`_ in which a token is a byte. \
+    Tokens are added at :attr:`byte_rate <ByteRateLimiter.byte_rate>` tokens per second, \
+    upto a maximum of :attr:`burst <ByteRateLimiter.burst>` tokens. A PDU that is larger than the burst is sent once the bucket is full, \
+    and the bytes that it overdraws are paid back before the next PDU is sent.
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        # a sustained rate of 2KB/second, with bursts of upto 8KB.
+        byte_rate_limiter = ByteRateLimiter(byte_rate=2_048.00, burst=8_192.00)
+        await byte_rate_limiter.limit(len(pdu))
+        send_pdu(pdu)
+    """
+
+    def __init__(
+        self,
+        byte_rate: float,
+        burst: typing.Union[None, float] = None,
+        logger: typing.Union[None, logging.Logger] = None,
+    ) -> None:
+        """
+        Parameters:
+            byte_rate: the sustained rate, in bytes/second, at which naz can send messages to SMSC.
+            burst: the maximum number of bytes that can be sent at once. Defaults to `byte_rate`
+        """
+        if not isinstance(byte_rate, float) or byte_rate <= 0:
+            raise ValueError(
+                "`byte_rate` should be a `float` greater than zero. You entered: {0}".format(
+                    byte_rate
+                )
+            )
+        if burst is None:
+            burst = max(byte_rate, 1.0)
+        if not isinstance(burst, float) or burst < 1:
+            raise ValueError(
+                "`burst` should be of type:: `None` or a `float` not less than 1. You entered: {0}".format(
+                    burst
+                )
+            )
+        if not isinstance(logger, (type(None), logging.Logger)):
+            raise ValueError(
+                "`logger` should be of type:: `None` or `logging.Logger` You entered: {0}".format(
+                    type(logger)
+                )
+            )
+
+        self.byte_rate: float = byte_rate
+        self.burst: float = burst
+        self.tokens: float = self.burst
+        self.updated_at: float = time.monotonic()
+        if logger is not None:
+            self.logger = logger
+        else:
+            self.logger = log.SimpleLogger("naz.ByteRateLimiter")
+
+    async def limit(self, size: int) -> None:
+        """
+        waits until a PDU of `size` bytes can be sent to SMSC without exceeding the byte rate.
+
+        Parameters:
+            size: the size, in bytes, of the encoded PDU.
+        """
+        self.logger.log(logging.DEBUG, {"event": "naz.ByteRateLimiter.limit", "stage": "start"})
+        self._add_new_tokens()
+        # a PDU larger than the burst could otherwise never be sent.
+        needed = min(float(size), self.burst)
+        while self.tokens < needed:
+            delay = (needed - self.tokens) / self.byte_rate
+            self.logger.log(
+                logging.DEBUG,
+                {
+                    "event": "naz.ByteRateLimiter.limit",
+                    "stage": "end",
+                    "state": "limiting rate",
+                    "byte_rate": self.byte_rate,
+                    "burst": self.burst,
+                    "size": size,
+                    "delay": delay,
+                },
+            )
+            await asyncio.sleep(delay)
+            self._add_new_tokens()
+        self.tokens -= size
+
+    def _add_new_tokens(self) -> None:
+        now = time.monotonic()
+        self.tokens = min(self.burst, self.tokens + ((now - self.updated_at) * self.byte_rate))
+        self.updated_at = now
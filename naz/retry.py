@@ -0,0 +1,140 @@
+import abc
+import random
+import typing
+
+from . import state
+
+
+class BaseRetryPolicy(abc.ABC):
+    """
+    Interface that must be implemented to satisfy naz's retry policy.
+    User implementations should inherit this class and
+    implement the :func:`retry_after <BaseRetryPolicy.retry_after>` method with the type signature shown.
+
+    A retry policy decides whether a message that was dequeued from the broker, and that SMSC failed, should be re-submitted; and when.
+    """
+
+    @abc.abstractmethod
+    def retry_after(
+        self, smpp_command: str, command_status_value: int, attempt: int
+    ) -> typing.Union[None, float]:
+        """
+        called by naz when SMSC responds to a `submit_sm` or `data_sm` with an error.
+
+        Parameters:
+            smpp_command: the command that failed. eg; submit_sm
+            command_status_value: the numeric command_status that SMSC responded with. eg; 0x00000014(`ESME_RMSGQFUL`)
+            attempt: the number of times that the message has been submitted, including this failed one. It is 1 for the first submission.
+
+        Returns:
+            the duration in seconds to wait before re-submitting the message, or None if it should not be re-submitted.
+        """
+        raise NotImplementedError("retry_after method must be implemented.")
+
+
+class SimpleRetryPolicy(BaseRetryPolicy):
+    """
+    This is an implementation of BaseRetryPolicy.
+    It re-submits the messages that fail with a temporary command status(see :func:`naz.is_temporary <naz.state.is_temporary>`),
+    waiting exponentially longer(with jitter) before each attempt; upto `max_attempts` submissions in total.
+    Messages that fail with any other command status, eg `ESME_RINVDSTADR`, are not re-submitted.
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        cli = naz.Client(
+            ...
+            retry_policy=naz.retry.SimpleRetryPolicy(max_attempts=5),
+        )
+    """
+
+    def __init__(
+        self,
+        max_attempts: int = 3,
+        initial_interval: float = 1.00,
+        max_interval: float = 60.00,
+        jitter: float = 0.10,
+        retryable_statuses: typing.Union[None, typing.List[state.CommandStatus]] = None,
+    ) -> None:
+        """
+        Parameters:
+            max_attempts: the maximum number of times that a message is submitted, including the first submission.
+            initial_interval: the duration in seconds to wait before the first re-submission. It doubles with each re-submission.
+            max_interval: the maximum duration in seconds to wait before a re-submission.
+            jitter: the fraction, between 0 and 1, of the wait that is randomised; so that many failed messages are not all re-submitted at the same time.
+            retryable_statuses: the command statuses that a message is re-submitted for. If it is None, the temporary ones are.
+        """
+        if not isinstance(max_attempts, int) or isinstance(max_attempts, bool):
+            raise ValueError(
+                "`max_attempts` should be of type:: `int` You entered: {0}".format(
+                    type(max_attempts)
+                )
+            )
+        if max_attempts <= 0:
+            raise ValueError(
+                "`max_attempts` should be greater than zero. You entered: {0}".format(max_attempts)
+            )
+        if not isinstance(initial_interval, float):
+            raise ValueError(
+                "`initial_interval` should be of type:: `float` You entered: {0}".format(
+                    type(initial_interval)
+                )
+            )
+        if not isinstance(max_interval, float):
+            raise ValueError(
+                "`max_interval` should be of type:: `float` You entered: {0}".format(
+                    type(max_interval)
+                )
+            )
+        if initial_interval < 0 or max_interval < initial_interval:
+            raise ValueError(
+                "`initial_interval` should be between zero and `max_interval`. You entered: {0}, {1}".format(
+                    initial_interval, max_interval
+                )
+            )
+        if not isinstance(jitter, float) or not (0 <= jitter <= 1):
+            raise ValueError(
+                "`jitter` should be a float between 0 and 1. You entered: {0}".format(jitter)
+            )
+        if not isinstance(retryable_statuses, (type(None), list)):
+            raise ValueError(
+                "`retryable_statuses` should be of type:: `None` or `list` You entered: {0}".format(
+                    type(retryable_statuses)
+                )
+            )
+        for command_status in retryable_statuses or []:
+            if not isinstance(command_status, state.CommandStatus):
+                raise ValueError(
+                    "`retryable_statuses` should be a list of `naz.CommandStatus` You entered: {0}".format(
+                        command_status
+                    )
+                )
+
+        self.max_attempts = max_attempts
+        self.initial_interval = initial_interval
+        self.max_interval = max_interval
+        self.jitter = jitter
+        self.retryable_statuses = retryable_statuses
+
+    def _is_retryable(self, command_status_value: int) -> bool:
+        if self.retryable_statuses is None:
+            return state.is_temporary(command_status_value)
+        for command_status in self.retryable_statuses:
+            if isinstance(command_status.value, list):
+                if command_status.value[0] <= command_status_value <= command_status.value[1]:
+                    return True
+            elif command_status.value == command_status_value:
+                return True
+        return False
+
+    def retry_after(
+        self, smpp_command: str, command_status_value: int, attempt: int
+    ) -> typing.Union[None, float]:
+        if attempt >= self.max_attempts or not self._is_retryable(command_status_value):
+            return None
+        interval = min(self.max_interval, self.initial_interval * (2 ** (attempt - 1)))
+        return interval - (interval * self.jitter * random.random())
@@ -1,4 +1,5 @@
 import abc
+import threading
 
 
 class BaseSequenceGenerator(abc.ABC):
@@ -24,6 +25,9 @@ class BaseSequenceGenerator(abc.ABC):
 class SimpleSequenceGenerator(BaseSequenceGenerator):
     """
     This is an implementation of BaseSequenceGenerator.
+
+    It wraps around to `1`, and never returns `0`, once it reaches :attr:`max_sequence_number <SimpleSequenceGenerator.max_sequence_number>`.
+    It is safe to share an instance of it amongst many naz clients, even if they run in different threads.
     """
 
     min_sequence_number: int = 0x00000001
@@ -31,11 +35,13 @@ class SimpleSequenceGenerator(BaseSequenceGenerator):
 
     def __init__(self) -> None:
         self.sequence_number: int = self.min_sequence_number
+        self._lock = threading.Lock()
 
     def next_sequence(self) -> int:
-        if self.sequence_number == self.max_sequence_number:
-            # wrap around
-            self.sequence_number = self.min_sequence_number
-        else:
-            self.sequence_number += 1
-        return self.sequence_number
+        with self._lock:
+            if self.sequence_number >= self.max_sequence_number or self.sequence_number < 0:
+                # wrap around
+                self.sequence_number = self.min_sequence_number
+            else:
+                self.sequence_number += 1
+            return self.sequence_number
@@ -1,5 +1,6 @@
 import typing
 import struct
+import datetime
 
 # TODO: try and turn these classes to enum
 
@@ -10,10 +11,15 @@ class SmppSessionState:
     """
 
     # see section 2.2 of SMPP spec document v3.4
-    # we are ignoring the other states since we are only concerning ourselves with an ESME in Transceiver mode.
 
     # An ESME has established a network connection to the SMSC but has not yet issued a Bind request.
     OPEN: str = "OPEN"
+    # A connected ESME has requested to bind as an ESME Transmitter (by issuing a bind_transmitter PDU)
+    # and has received a response from the SMSC authorising its Bind request.
+    BOUND_TX: str = "BOUND_TX"
+    # A connected ESME has requested to bind as an ESME Receiver (by issuing a bind_receiver PDU)
+    # and has received a response from the SMSC authorising its Bind request.
+    BOUND_RX: str = "BOUND_RX"
     # A connected ESME has requested to bind as an ESME Transceiver (by issuing a bind_transceiver PDU)
     # and has received a response from the SMSC authorising its Bind request.
     BOUND_TRX: str = "BOUND_TRX"
@@ -40,6 +46,16 @@ class SmppCommand:
     ENQUIRE_LINK: str = "enquire_link"
     ENQUIRE_LINK_RESP: str = "enquire_link_resp"
     GENERIC_NACK: str = "generic_nack"
+    SUBMIT_MULTI: str = "submit_multi"
+    SUBMIT_MULTI_RESP: str = "submit_multi_resp"
+    OUTBIND: str = "outbind"
+    # see section 4.4 of SMPP spec document v5.0
+    BROADCAST_SM: str = "broadcast_sm"
+    BROADCAST_SM_RESP: str = "broadcast_sm_resp"
+    QUERY_BROADCAST_SM: str = "query_broadcast_sm"
+    QUERY_BROADCAST_SM_RESP: str = "query_broadcast_sm_resp"
+    CANCEL_BROADCAST_SM: str = "cancel_broadcast_sm"
+    CANCEL_BROADCAST_SM_RESP: str = "cancel_broadcast_sm_resp"
 
     # naz currently does not handle the following smpp commands.
     # open a github issue if you use naz and require support of a command in this list
@@ -51,9 +67,6 @@ class SmppCommand:
     REPLACE_SM_RESP: str = "replace_sm_resp"
     CANCEL_SM: str = "cancel_sm"
     CANCEL_SM_RESP: str = "cancel_sm_resp"
-    SUBMIT_MULTI: str = "submit_multi"
-    SUBMIT_MULTI_RESP: str = "submit_multi_resp"
-    OUTBIND: str = "outbind"
     ALERT_NOTIFICATION: str = "alert_notification"
     DATA_SM: str = "data_sm"
     DATA_SM_RESP: str = "data_sm_resp"
@@ -79,6 +92,100 @@ class SmppCommand:
     RESERVED_FOR_SMSC_VENDOR_B: str = "reserved_for_smsc_vendor_b"
 
 
+class ConcatMode:
+    """
+    Represensts the ways in which the parts of a long(concatenated) message can be linked together.
+    """
+
+    # a concatenation User Data Header(UDH) is placed at the start of each part's short_message.
+    # see section 9.2.3.24.1 of GSM 03.40 (3GPP TS 23.040)
+    UDH: str = "UDH"
+    # like UDH, but with a 16-bit reference number rather than an 8-bit one; so that fewer messages share a reference number.
+    # see section 9.2.3.24.8 of GSM 03.40 (3GPP TS 23.040)
+    UDH_16BIT: str = "UDH_16BIT"
+    # the sar_msg_ref_num, sar_total_segments & sar_segment_seqnum optional parameters are sent with each part.
+    # since there is no UDH, each part uses the whole segment(eg 160 gsm0338 characters) and the reference number is 16-bit.
+    # see section 5.3.2.22 - 5.3.2.24 of SMPP spec document v3.4
+    SAR: str = "SAR"
+
+
+class BindMode:
+    """
+    Represensts the modes in which an ESME can bind to SMSC.
+    """
+
+    # see section 2.2 of SMPP spec document v3.4
+    # the ESME can only send messages to SMSC, using bind_transmitter
+    TRANSMITTER: str = "TRANSMITTER"
+    # the ESME can only receive messages from SMSC, using bind_receiver
+    RECEIVER: str = "RECEIVER"
+    # the ESME can both send and receive messages, using bind_transceiver
+    TRANSCEIVER: str = "TRANSCEIVER"
+
+
+class AddressKind:
+    """
+    Represensts the common kinds of addresses; each of which has a TON(Type of Number) and NPI(Numbering Plan Indicator) pair.
+    They can be used as the `source_addr_kind` and `dest_addr_kind` of a :class:`naz.protocol.SubmitSM <naz.protocol.SubmitSM>`
+    instead of its numeric TON/NPI parameters. see section 5.2.5 & 5.2.6 of SMPP spec document v3.4
+    """
+
+    # a number in the international format, eg 254722111111; TON international(0x01), NPI ISDN/E.164(0x01)
+    INTERNATIONAL: str = "INTERNATIONAL"
+    # a number in the national format, eg 0722111111; TON national(0x02), NPI ISDN/E.164(0x01)
+    NATIONAL: str = "NATIONAL"
+    # a short code, eg 40404; TON network specific(0x03), NPI unknown(0x00)
+    SHORT_CODE: str = "SHORT_CODE"
+    # an alphanumeric sender, eg a brand name; TON alphanumeric(0x05), NPI unknown(0x00)
+    ALPHANUMERIC: str = "ALPHANUMERIC"
+
+
+class UnexpectedResponse:
+    """
+    Represensts the ways in which naz can handle a `submit_sm_resp`(or `data_sm_resp`) whose sequence_number does not match any
+    request that is awaiting a response; eg one that SMSC re-transmitted late. Whichever is used, the response is counted in
+    :attr:`naz.Stats.unexpected_responses <naz.state.Stats.unexpected_responses>`; and it is then handled like any other response.
+    """
+
+    # the response is only counted.
+    IGNORE: str = "IGNORE"
+    # the response is counted and logged.
+    LOG: str = "LOG"
+    # the response is counted and passed to :func:`naz.hooks.BaseHook.unexpected_response <naz.hooks.BaseHook.unexpected_response>`
+    HOOK: str = "HOOK"
+
+
+class InterfaceVersion:
+    """
+    Represensts the versions of the SMPP protocol that can be used as the `interface_version` of a bind.
+    see section 5.2.4 of SMPP spec document v3.4
+    """
+
+    # SMPP v3.4
+    V34: int = 0x34
+    # SMPP v5.0
+    V50: int = 0x50
+
+
+class RegisteredDelivery:
+    """
+    Represensts the values of the registered_delivery parameter; which request delivery receipts and acknowledgements for a message.
+    The values can be combined using bitwise OR. eg; `RegisteredDelivery.RECEIPT_ON_FAILURE | RegisteredDelivery.SME_DELIVERY_ACK`
+    see section 5.2.17 of SMPP spec document v3.4
+    """
+
+    # bits 1 and 0; SMSC delivery receipt
+    NO_RECEIPT: int = 0b00000000
+    RECEIPT_ON_SUCCESS_OR_FAILURE: int = 0b00000001
+    RECEIPT_ON_FAILURE: int = 0b00000010
+    # bits 3 and 2; SME originated acknowledgement
+    SME_DELIVERY_ACK: int = 0b00000100
+    SME_MANUAL_ACK: int = 0b00001000
+    SME_DELIVERY_AND_MANUAL_ACK: int = 0b00001100
+    # bit 4; intermediate notification
+    INTERMEDIATE_NOTIFICATION: int = 0b00010000
+
+
 class CommandStatus(typing.NamedTuple):
     """
     An SMPP command status
@@ -159,7 +266,7 @@ class SmppCommandStatus:
         code="ESME_RINVNUMDESTS", value=0x00000033, description="Invalid number of destinations"
     )
     ESME_RINVDLNAME: CommandStatus = CommandStatus(
-        code="ESME_RINVNUMDESTS", value=0x00000034, description="Invalid Distribution List name"
+        code="ESME_RINVDLNAME", value=0x00000034, description="Invalid Distribution List name"
     )
     ESME_RINVDESTFLAG: CommandStatus = CommandStatus(
         code="ESME_RINVDESTFLAG",
@@ -314,6 +421,247 @@ class SmppCommandStatus:
     RESERVED_LIST_J: CommandStatus = CommandStatus(
         code="Reserved", value=[0x00000500, 0xFFFFFFFF], description="Reserved"
     )
+    RESERVED_LIST_K: CommandStatus = CommandStatus(
+        code="Reserved", value=[0x0000004A, 0x0000004F], description="Reserved"
+    )
+
+    @staticmethod
+    def _find_command_status(value: int) -> typing.Union[None, CommandStatus]:
+        for key, val in SmppCommandStatus.__dict__.items():
+            if not isinstance(val, CommandStatus):
+                continue
+            if isinstance(val.value, list):
+                if val.value[0] <= value <= val.value[1]:
+                    return val
+            elif val.value == value:
+                return val
+        return None
+
+
+# the command statuses with which a request may succeed if it is sent again later, unchanged.
+_TEMPORARY_COMMAND_STATUSES = frozenset(
+    [
+        SmppCommandStatus.ESME_RSYSERR.value,
+        SmppCommandStatus.ESME_RMSGQFUL.value,
+        SmppCommandStatus.ESME_RTHROTTLED.value,
+        SmppCommandStatus.ESME_RX_T_APPN.value,
+    ]
+)
+
+
+def status_name(command_status: int) -> str:
+    """
+    Returns the name of an SMPP command_status, eg `ESME_RTHROTTLED` for 0x00000058.
+    The command statuses that the SMPP spec document reserves are named `Reserved`.
+    see :class:`SmppCommandStatus <SmppCommandStatus>` for the full table of command statuses and their meanings.
+
+    Parameters:
+        command_status: the numeric command_status, as found in the header of a PDU.
+
+    Raises:
+        ValueError: raised if `command_status` is not an unsigned 32-bit integer.
+    """
+    found = None
+    if isinstance(command_status, int):
+        found = SmppCommandStatus._find_command_status(command_status)
+    if found is None:
+        raise ValueError(
+            "`command_status` should be an int between 0 and 0xFFFFFFFF. You entered: {0}".format(
+                command_status
+            )
+        )
+    return found.code
+
+
+def is_temporary(command_status: int) -> bool:
+    """
+    Returns True if `command_status` is a temporary failure; ie the request may succeed if it is sent again later.
+    These are :attr:`ESME_RSYSERR <SmppCommandStatus.ESME_RSYSERR>`, :attr:`ESME_RMSGQFUL <SmppCommandStatus.ESME_RMSGQFUL>`,
+    :attr:`ESME_RTHROTTLED <SmppCommandStatus.ESME_RTHROTTLED>` and :attr:`ESME_RX_T_APPN <SmppCommandStatus.ESME_RX_T_APPN>`.
+    Any other failure, eg :attr:`ESME_RINVDSTADR <SmppCommandStatus.ESME_RINVDSTADR>`, is permanent; sending the same request again will fail again.
+    `ESME_ROK` is not a failure, so it is not temporary.
+
+    Parameters:
+        command_status: the numeric command_status, as found in the header of a PDU.
+
+    Usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        try:
+            await cli.query_message(message_id="some-id", source_addr="2547000000")
+        except naz.client.NazCommandStatusError as e:
+            if naz.is_temporary(e.command_status_value):
+                ... # try again later
+    """
+    return command_status in _TEMPORARY_COMMAND_STATUSES
+
+
+class MessageState(typing.NamedTuple):
+    """
+    The state of a message at the SMSC.
+    """
+
+    code: str
+    value: int
+    description: str
+
+
+class SmppMessageState:
+    """
+    Represensts the various states that a short message can be in at the SMSC.
+    """
+
+    # see section 5.2.28 of smpp ver 3.4 spec document
+    ENROUTE: MessageState = MessageState(
+        code="ENROUTE", value=1, description="The message is in enroute state."
+    )
+    DELIVERED: MessageState = MessageState(
+        code="DELIVERED", value=2, description="Message is delivered to destination"
+    )
+    EXPIRED: MessageState = MessageState(
+        code="EXPIRED", value=3, description="Message validity period has expired."
+    )
+    DELETED: MessageState = MessageState(
+        code="DELETED", value=4, description="Message has been deleted."
+    )
+    UNDELIVERABLE: MessageState = MessageState(
+        code="UNDELIVERABLE", value=5, description="Message is undeliverable"
+    )
+    ACCEPTED: MessageState = MessageState(
+        code="ACCEPTED",
+        value=6,
+        description="Message is in accepted state(i.e. has been manually read on behalf of the subscriber by customer service)",
+    )
+    UNKNOWN: MessageState = MessageState(
+        code="UNKNOWN", value=7, description="Message is in invalid state"
+    )
+    REJECTED: MessageState = MessageState(
+        code="REJECTED", value=8, description="Message is in a rejected state"
+    )
+
+    @staticmethod
+    def _find_message_state(value: int) -> MessageState:
+        for _, v in SmppMessageState.__dict__.items():
+            if isinstance(v, MessageState) and v.value == value:
+                return v
+        # SMSCs may send values that are not in the spec; keep the raw value rather than failing.
+        return MessageState(
+            code="UNKNOWN",
+            value=value,
+            description="That message_state: `{0}` is not a recognised SMPP state.".format(value),
+        )
+
+
+class QueryResult(typing.NamedTuple):
+    """
+    The result of querying the SMSC about the state of a previously submitted message.
+    """
+
+    message_id: str
+    # date and time when the message reached its final state. empty if it is not yet in a final state.
+    final_date: str
+    message_state: MessageState
+    # network specific error code defining the reason for failure of message delivery.
+    error_code: int
+
+
+class BroadcastQueryResult(typing.NamedTuple):
+    """
+    The result of querying the SMSC about the state of a previously submitted broadcast. See :func:`naz.Client.query_broadcast_message <naz.Client.query_broadcast_message>`
+    """
+
+    message_id: str
+    message_state: MessageState
+    # the value of each of the broadcast_area_identifier optional parameters; the areas that the broadcast is sent to.
+    broadcast_area_identifiers: typing.List[bytes]
+    # for each of the broadcast areas, in the same order, the percentage(0-100) of the area that the broadcast has reached, or 255 if SMSC does not know.
+    broadcast_area_success: typing.List[int]
+    # when the broadcast ended or will end. empty if SMSC did not say.
+    broadcast_end_time: str
+
+
+class UnsuccessSME(typing.NamedTuple):
+    """
+    A destination of a `submit_multi` that SMSC could not deliver the message to. See :class:`SubmitMultiResult <SubmitMultiResult>`
+    """
+
+    dest_addr_ton: int
+    dest_addr_npi: int
+    destination_addr: str
+    # the command_status that the message to this destination failed with. eg; 0x0000000B(`ESME_RINVDSTADR`)
+    error_status_code: int
+
+
+class SubmitMultiResult(typing.NamedTuple):
+    """
+    The result of submitting a message to multiple destinations. See :func:`naz.Client.submit_multi <naz.Client.submit_multi>`
+    """
+
+    message_id: str
+    # the destinations that the message could not be delivered to; empty if it was accepted for all of them.
+    unsuccess_smes: typing.List[UnsuccessSME]
+
+
+class SubmitResult(typing.NamedTuple):
+    """
+    The result of submitting one of the messages of a batch. See :func:`naz.Client.submit_batch <naz.Client.submit_batch>`
+    """
+
+    # the log_id of the message.
+    log_id: str
+    # the message_id's that SMSC assigned to the message; one for each part of the message. empty if the submission failed.
+    message_ids: typing.List[str]
+    # the error that the submission failed with, eg; `NazCommandStatusError`. None if it succeeded.
+    error: typing.Union[None, Exception]
+
+
+class Stats(typing.NamedTuple):
+    """
+    A snapshot of the counters of a client. See :func:`naz.Client.stats <naz.Client.stats>`
+    The counters start at zero when the client is created and carry on across re-connections.
+    """
+
+    # the number of submit_sm and data_sm PDUs sent to SMSC; each part of a message that is split into many parts counts once.
+    submitted: int
+    # the number of deliver_sm PDUs received from SMSC; both mobile originated messages and delivery receipts.
+    delivered: int
+    # the number of submit_sm_resp and data_sm_resp PDUs that SMSC failed, keyed by command_status code. eg; {"ESME_RMSGQFUL": 2}
+    failed: typing.Dict[str, int]
+    # the number of messages in the broker. It is None if the broker does not implement `size`
+    queue_depth: typing.Union[None, int]
+    # the number of times that naz has tried to re-establish a lost connection to SMSC.
+    reconnects: int
+    # the number of octets of PDUs read from, and written to, SMSC.
+    bytes_read: int
+    bytes_written: int
+    # the number of submit_sm_resp and data_sm_resp PDUs that did not match any request that was awaiting a response.
+    unexpected_responses: int
+
+
+class DeliveryReceipt(typing.NamedTuple):
+    """
+    The fields of a delivery receipt sent by the SMSC in a `deliver_sm` PDU.
+    see Appendix B of smpp ver 3.4 spec document.
+    Fields that are missing from the receipt are set to None.
+    """
+
+    # the SMSC message_id of the message that this receipt is for.
+    message_id: str
+    # the stat field as it appears in the receipt, eg DELIVRD
+    stat: str
+    message_state: typing.Union[None, MessageState]
+    # number of short messages originally submitted.
+    submitted: typing.Union[None, int]
+    # number of short messages delivered.
+    delivered: typing.Union[None, int]
+    submit_date: typing.Union[None, datetime.datetime]
+    done_date: typing.Union[None, datetime.datetime]
+    # network specific error code.
+    err: typing.Union[None, str]
+    # the first 20 characters of the short message.
+    text: typing.Union[None, str]
 
 
 class DataCoding(typing.NamedTuple):
@@ -347,6 +695,9 @@ class SmppDataCoding:
     gsm0338: DataCoding = DataCoding(
         code="gsm0338", value=0b00000000, description="SMSC Default Alphabet"
     )
+    gsm0338_packed: DataCoding = DataCoding(
+        code="gsm0338_packed", value=0b00000000, description="SMSC Default Alphabet(7-bit packed)"
+    )
     ascii: DataCoding = DataCoding(
         code="ascii", value=0b00000001, description="IA5(CCITT T.50) / ASCII(ANSI X3.4)"
     )
@@ -381,6 +732,12 @@ class SmppDataCoding:
     ucs2: DataCoding = DataCoding(
         code="ucs2", value=0b00001000, description="UCS2(ISO / IEC - 10646)"
     )
+    # ucs2, with a leading byte order mark; for the SMSCs that expect one.
+    ucs2_bom: DataCoding = DataCoding(
+        code="ucs2_bom",
+        value=0b00001000,
+        description="UCS2(ISO / IEC - 10646) with a byte order mark",
+    )
     shift_jis: DataCoding = DataCoding(
         code="shift_jis", value=0b00001001, description="Pictogram Encoding"
     )
@@ -400,6 +757,18 @@ class SmppDataCoding:
     # 0b1110xxxx reserved
     # 0b1111xxxx GSM message class control - see [GSM 03.38]
 
+    @staticmethod
+    def _find_encoding(value: int) -> str:
+        """
+        returns the encoding to use for a data_coding value as found in a PDU.
+        """
+        for _, v in SmppDataCoding.__dict__.items():
+            if isinstance(v, DataCoding) and v.value == value:
+                return v.code
+        raise ValueError(
+            "That data_coding: `{0}` is not a recognised SMPP data_coding.".format(value)
+        )
+
     @staticmethod
     def _find_data_coding(encoding):
         # NB:
@@ -793,3 +1162,93 @@ class OptionalTag:
                     self.name
                 )
             )
+
+
+class TLV(typing.NamedTuple):
+    """
+    A generic SMPP optional parameter(Tag, Length, Value).
+    It can be used for any optional parameter, including vendor specific ones that are not known to :class:`OptionalTag <OptionalTag>`
+    The Length field is computed from the value.
+
+    Usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        # a vendor specific optional parameter
+        tlv = naz.TLV(tag=0x1400, value=b"some-value")
+    """
+
+    # Tag, Integer, 2octets
+    tag: int
+    # Value, Octet String
+    value: bytes
+
+    @property
+    def length(self) -> int:
+        """
+        Returns the Length field; the length of the Value field in octets.
+        """
+        return len(self.value)
+
+    @property
+    def tlv(self) -> bytes:
+        """
+        Returns the bytes representation of the optional parameter.
+        """
+        return struct.pack(">HH", self.tag, self.length) + self.value
+
+    @staticmethod
+    def _validate(tlv: "TLV") -> None:
+        if not isinstance(tlv, TLV):
+            raise ValueError(
+                "`tlv` should be of type:: `naz.TLV` You entered: {0}".format(type(tlv))
+            )
+        if not isinstance(tlv.tag, int) or not (0 <= tlv.tag <= 0xFFFF):
+            raise ValueError(
+                "The tag of a TLV should be an int between 0 and 65535. You entered: {0}".format(
+                    tlv.tag
+                )
+            )
+        if not isinstance(tlv.value, bytes):
+            raise ValueError(
+                "The value of a TLV should be of type:: `bytes` You entered: {0}".format(
+                    type(tlv.value)
+                )
+            )
+        if tlv.length > 0xFFFF:
+            raise ValueError(
+                "The value of a TLV should be at most 65535 octets. You entered: {0}".format(
+                    tlv.length
+                )
+            )
+
+    @staticmethod
+    def parse(data: bytes) -> typing.List["TLV"]:
+        """
+        Parses the `Optional Parameters` section of an SMPP PDU into a list of TLVs.
+
+        Parameters:
+            data: the bytes that follow the mandatory parameters of a PDU.
+
+        Raises:
+            ValueError: raised if the optional parameters are truncated.
+        """
+        tlvs = []
+        offset = 0
+        while offset < len(data):
+            if offset + 4 > len(data):
+                raise ValueError("truncated TLV header at offset {0}".format(offset))
+            tag, length = struct.unpack(">HH", data[offset : offset + 4])
+            value = data[offset + 4 : offset + 4 + length]
+            if len(value) != length:
+                raise ValueError(
+                    "the TLV with tag `{0}` should have {1} octets but only {2} are present".format(
+                        tag, length, len(value)
+                    )
+                )
+            tlvs.append(TLV(tag=tag, value=value))
+            offset = offset + 4 + length
+        return tlvs
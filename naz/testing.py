@@ -0,0 +1,341 @@
+import struct
+import typing
+import asyncio
+import collections
+
+from . import state
+
+
+# the requests that MockSMSC understands; see section 5.1.2.1 of smpp ver 3.4 spec document.
+_REQUESTS = {
+    0x00000009: state.SmppCommand.BIND_TRANSCEIVER,
+    0x00000002: state.SmppCommand.BIND_TRANSMITTER,
+    0x00000001: state.SmppCommand.BIND_RECEIVER,
+    0x00000006: state.SmppCommand.UNBIND,
+    0x00000004: state.SmppCommand.SUBMIT_SM,
+    0x00000103: state.SmppCommand.DATA_SM,
+    0x00000015: state.SmppCommand.ENQUIRE_LINK,
+}
+_RESPONSES = {
+    0x80000005: state.SmppCommand.DELIVER_SM_RESP,
+    0x80000015: state.SmppCommand.ENQUIRE_LINK_RESP,
+    0x80000006: state.SmppCommand.UNBIND_RESP,
+    0x80000000: state.SmppCommand.GENERIC_NACK,
+}
+
+
+class ReceivedPDU(typing.NamedTuple):
+    """
+    A PDU that :class:`MockSMSC <MockSMSC>` received from a client.
+    """
+
+    # eg; submit_sm. It is empty if MockSMSC does not know the command_id.
+    smpp_command: str
+    command_id: int
+    command_status: int
+    sequence_number: int
+    body: bytes
+
+
+class MockSMSC:
+    """
+    An SMSC that runs in the same process, for testing applications that are built on naz without a real SMSC.
+    It listens on a local socket, accepts binds, answers `enquire_link`'s and answers `submit_sm`'s and `data_sm`'s with
+    the responses that a test scripted; and it can send `deliver_sm`'s to the clients that are bound to it.
+    The PDUs that it receives are recorded, so that a test can assert on them.
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        import naz
+
+        smsc = naz.testing.MockSMSC()
+        await smsc.start()
+        cli = naz.Client(
+            smsc_host=smsc.host,
+            smsc_port=smsc.port,
+            ...
+        )
+        smsc.respond_to_submit_sm(message_id="smsc-message-id")
+        ...
+        await smsc.wait_for(naz.SmppCommand.SUBMIT_SM)
+        await smsc.deliver_receipt(message_id="smsc-message-id")
+        await smsc.stop()
+    """
+
+    def __init__(
+        self,
+        host: str = "127.0.0.1",
+        port: int = 0,
+        system_id: str = "MockSMSC",
+        bind_status: state.CommandStatus = state.SmppCommandStatus.ESME_ROK,
+    ) -> None:
+        """
+        Parameters:
+            host: the host to listen on.
+            port: the port to listen on. If it is 0, a free port is picked; see :attr:`port <MockSMSC.port>`
+            system_id: the system_id that is sent in the bind responses.
+            bind_status: the command_status of the bind responses. eg; `ESME_RINVPASWD` to test a failed bind.
+        """
+        if not isinstance(host, str):
+            raise ValueError("`host` should be of type:: `str` You entered: {0}".format(type(host)))
+        if not isinstance(port, int) or isinstance(port, bool):
+            raise ValueError("`port` should be of type:: `int` You entered: {0}".format(type(port)))
+        if not isinstance(system_id, str):
+            raise ValueError(
+                "`system_id` should be of type:: `str` You entered: {0}".format(type(system_id))
+            )
+        if not isinstance(bind_status, state.CommandStatus):
+            raise ValueError(
+                "`bind_status` should be of type:: `naz.CommandStatus` You entered: {0}".format(
+                    type(bind_status)
+                )
+            )
+
+        self.host = host
+        self.port = port
+        self.system_id = system_id
+        self.bind_status = bind_status
+
+        self._server: typing.Union[None, asyncio.AbstractServer] = None
+        self._writers: typing.List[asyncio.StreamWriter] = []
+        self._received: typing.List[ReceivedPDU] = []
+        self._received_event: typing.Union[None, asyncio.Event] = None
+        self._submit_sm_responses: typing.Deque[
+            typing.Tuple[state.CommandStatus, typing.Union[None, str]]
+        ] = collections.deque()
+        self._message_ids = 0
+        self._sequence_number = 0
+
+    async def start(self) -> None:
+        """
+        starts listening. If the :attr:`port <MockSMSC.port>` was 0, it is set to the port that was picked.
+        """
+        self._received_event = asyncio.Event()
+        self._server = await asyncio.start_server(self._handle_conn, self.host, self.port)
+        self.port = self._server.sockets[0].getsockname()[1]
+
+    async def stop(self) -> None:
+        """
+        closes the connections to the clients and stops listening.
+        """
+        for writer in self._writers:
+            writer.close()
+        self._writers = []
+        if self._server is not None:
+            self._server.close()
+            await self._server.wait_closed()
+            self._server = None
+
+    def respond_to_submit_sm(
+        self,
+        command_status: state.CommandStatus = state.SmppCommandStatus.ESME_ROK,
+        message_id: typing.Union[None, str] = None,
+    ) -> None:
+        """
+        scripts the response to the next `submit_sm`(or `data_sm`) that is received. Each call scripts one more response, in order.
+        The requests that there is no scripted response for are answered with `ESME_ROK` and a generated message_id.
+
+        Parameters:
+            command_status: the command_status of the response.
+            message_id: the message_id of the response. If it is None, one is generated.
+        """
+        if not isinstance(command_status, state.CommandStatus):
+            raise ValueError(
+                "`command_status` should be of type:: `naz.CommandStatus` You entered: {0}".format(
+                    type(command_status)
+                )
+            )
+        if not isinstance(message_id, (type(None), str)):
+            raise ValueError(
+                "`message_id` should be of type:: `None` or `str` You entered: {0}".format(
+                    type(message_id)
+                )
+            )
+        self._submit_sm_responses.append((command_status, message_id))
+
+    def received(self, smpp_command: typing.Union[None, str] = None) -> typing.List[ReceivedPDU]:
+        """
+        returns the PDUs that have been received from clients, in the order that they were received.
+
+        Parameters:
+            smpp_command: if it is not None, only the PDUs of this command are returned. eg; submit_sm
+        """
+        return [pdu for pdu in self._received if smpp_command in (None, pdu.smpp_command)]
+
+    async def wait_for(
+        self, smpp_command: str, count: int = 1, timeout: float = 5.00
+    ) -> typing.List[ReceivedPDU]:
+        """
+        waits until at least `count` PDUs of the command `smpp_command` have been received, and returns them.
+
+        Parameters:
+            smpp_command: the command to wait for. eg; submit_sm
+            count: the number of PDUs to wait for.
+            timeout: the duration in seconds to wait for.
+
+        Raises:
+            asyncio.TimeoutError: raised if they have not been received within `timeout`
+        """
+
+        async def wait() -> typing.List[ReceivedPDU]:
+            if typing.TYPE_CHECKING:
+                # make mypy happy; https://github.com/python/mypy/issues/4805
+                assert isinstance(self._received_event, asyncio.Event)
+            while len(self.received(smpp_command)) < count:
+                self._received_event.clear()
+                await self._received_event.wait()
+            return self.received(smpp_command)
+
+        return await asyncio.wait_for(wait(), timeout=timeout)
+
+    async def deliver_sm(
+        self,
+        short_message: bytes,
+        source_addr: str = "254711999999",
+        destination_addr: str = "40404",
+        esm_class: int = 0,
+        data_coding: int = 0,
+        optional_params: typing.Union[None, typing.List[state.TLV]] = None,
+    ) -> int:
+        """
+        sends a `deliver_sm` to the clients that are connected.
+
+        Parameters:
+            short_message: the already encoded short_message.
+            source_addr: the source_addr of the message.
+            destination_addr: the destination_addr of the message.
+            esm_class: the esm_class of the message; eg 0b00000100 for a delivery receipt.
+            data_coding: the data_coding of the message.
+            optional_params: the optional parameters of the message.
+
+        Returns:
+            the sequence_number of the `deliver_sm`
+        """
+        body = (
+            b"\x00"  # service_type
+            + struct.pack(">BB", 0x01, 0x01)
+            + source_addr.encode("ascii")
+            + b"\x00"
+            + struct.pack(">BB", 0x00, 0x00)
+            + destination_addr.encode("ascii")
+            + b"\x00"
+            + struct.pack(">BBB", esm_class, 0, 0)  # esm_class, protocol_id, priority_flag
+            + b"\x00\x00"  # schedule_delivery_time, validity_period
+            # registered_delivery, replace_if_present_flag, data_coding, sm_default_msg_id, sm_length
+            + struct.pack(">BBBBB", 0, 0, data_coding, 0, len(short_message))
+            + short_message
+        )
+        for tlv in optional_params or []:
+            body = body + tlv.tlv
+        self._sequence_number += 1
+        await self._write(0x00000005, 0, self._sequence_number, body)
+        return self._sequence_number
+
+    async def deliver_receipt(
+        self, message_id: str, stat: str = "DELIVRD", destination_addr: str = "40404"
+    ) -> int:
+        """
+        sends a delivery receipt, for the message whose SMSC message_id is `message_id`, to the clients that are connected.
+        The receipt is in the format described in Appendix B of smpp ver 3.4 spec document.
+
+        Parameters:
+            message_id: the SMSC message_id of the message that the receipt is for.
+            stat: the final state of the message. eg; DELIVRD or UNDELIV
+            destination_addr: the destination_addr of the receipt; ie the source_addr of the message.
+
+        Returns:
+            the sequence_number of the `deliver_sm`
+        """
+        short_message = "id:{0} sub:001 dlvrd:{1} stat:{2} err:000 text:".format(
+            message_id, "001" if stat == "DELIVRD" else "000", stat
+        )
+        return await self.deliver_sm(
+            short_message=short_message.encode("ascii"),
+            destination_addr=destination_addr,
+            esm_class=0b00000100,
+        )
+
+    async def _write(
+        self, command_id: int, command_status: int, sequence_number: int, body: bytes
+    ) -> None:
+        header = struct.pack(">IIII", 16 + len(body), command_id, command_status, sequence_number)
+        for writer in self._writers:
+            writer.write(header + body)
+            await writer.drain()
+
+    async def _handle_conn(
+        self, reader: asyncio.StreamReader, writer: asyncio.StreamWriter
+    ) -> None:
+        self._writers.append(writer)
+        try:
+            while True:
+                header = await reader.readexactly(16)
+                command_length, command_id, command_status, sequence_number = struct.unpack(
+                    ">IIII", header
+                )
+                body = await reader.readexactly(command_length - 16)
+                smpp_command = _REQUESTS.get(command_id) or _RESPONSES.get(command_id, "")
+                self._received.append(
+                    ReceivedPDU(
+                        smpp_command=smpp_command,
+                        command_id=command_id,
+                        command_status=command_status,
+                        sequence_number=sequence_number,
+                        body=body,
+                    )
+                )
+                if self._received_event is not None:
+                    self._received_event.set()
+                await self._respond(writer, command_id, sequence_number)
+        except (asyncio.IncompleteReadError, ConnectionError):
+            # the client disconnected.
+            pass
+        finally:
+            if writer in self._writers:
+                self._writers.remove(writer)
+            writer.close()
+
+    async def _respond(
+        self, writer: asyncio.StreamWriter, command_id: int, sequence_number: int
+    ) -> None:
+        response_body = b""
+        command_status = state.SmppCommandStatus.ESME_ROK.value
+        if command_id in _RESPONSES:
+            return None
+        elif command_id in (0x00000009, 0x00000002, 0x00000001):
+            response_body = self.system_id.encode("ascii") + b"\x00"
+            command_status = self.bind_status.value
+        elif command_id in (0x00000004, 0x00000103):
+            status, message_id = state.SmppCommandStatus.ESME_ROK, None
+            if self._submit_sm_responses:
+                status, message_id = self._submit_sm_responses.popleft()
+            if message_id is None:
+                self._message_ids += 1
+                message_id = "mock-{0}".format(self._message_ids)
+            command_status = status.value
+            response_body = message_id.encode("ascii") + b"\x00"
+        elif command_id not in _REQUESTS:
+            # see section 3.3.4 of smpp ver 3.4 spec document
+            writer.write(
+                struct.pack(
+                    ">IIII",
+                    16,
+                    0x80000000,
+                    state.SmppCommandStatus.ESME_RINVCMDID.value,
+                    sequence_number,
+                )
+            )
+            await writer.drain()
+            return None
+        header = struct.pack(
+            ">IIII",
+            16 + len(response_body),
+            command_id | 0x80000000,
+            command_status,
+            sequence_number,
+        )
+        writer.write(header + response_body)
+        await writer.drain()
@@ -4,6 +4,7 @@ import typing
 import logging
 
 from . import log
+from . import ratelimiter
 
 
 class BaseThrottleHandler(abc.ABC):
@@ -194,3 +195,170 @@ class SimpleThrottleHandler(BaseThrottleHandler):
     async def throttle_delay(self) -> float:
         # todo: sleep in an exponetial manner upto a maximum then wrap around.
         return self.throttle_wait
+
+
+class AdaptiveThrottleHandler(BaseThrottleHandler):
+    """
+    This is an implementation of BaseThrottleHandler.
+
+    Instead of denying requests, it slows down the rate at which naz sends messages. It works by:
+
+    - reducing the send rate of :attr:`rate_limiter <AdaptiveThrottleHandler.rate_limiter>` by :attr:`decrease_factor <AdaptiveThrottleHandler.decrease_factor>` \
+    everytime we get a throttling response from SMSC.
+    - once there has been no throttling response for :attr:`cool_down <AdaptiveThrottleHandler.cool_down>` seconds, \
+    increasing the send rate by :attr:`increase_factor <AdaptiveThrottleHandler.increase_factor>` every :attr:`cool_down <AdaptiveThrottleHandler.cool_down>` seconds \
+    until it is back to the original send rate.
+
+    The same rate limiter should also be passed to :class:`naz.Client <naz.Client>`
+
+    example usage:
+
+    .. highlight:: python
+    .. code-block:: python
+
+        rate_limiter = naz.ratelimiter.SimpleRateLimiter(send_rate=100.00)
+        throttle_handler = naz.throttle.AdaptiveThrottleHandler(rate_limiter=rate_limiter)
+        client = naz.Client(..., rate_limiter=rate_limiter, throttle_handler=throttle_handler)
+    """
+
+    def __init__(
+        self,
+        rate_limiter: typing.Union[
+            ratelimiter.SimpleRateLimiter, ratelimiter.TokenBucketRateLimiter
+        ],
+        decrease_factor: float = 0.50,
+        increase_factor: float = 1.50,
+        cool_down: float = 30.00,
+        min_send_rate: float = 1.00,
+        on_throttle: typing.Union[None, typing.Callable[[float], None]] = None,
+        logger: typing.Union[None, logging.Logger] = None,
+    ) -> None:
+        """
+        Parameters:
+            rate_limiter: the rate limiter whose send rate will be adjusted.
+            decrease_factor: the send rate is multiplied by this factor everytime we get a throttling response.
+            increase_factor: the send rate is multiplied by this factor when recovering from throttling.
+            cool_down: the duration in seconds, without any throttling responses, before the send rate is increased.
+            min_send_rate: the send rate, in messages/second, is never reduced below this. It should not be greater than the send rate of `rate_limiter`.
+            on_throttle: a function that is called, with the new send rate, everytime we get a throttling response.
+        """
+        if not isinstance(
+            rate_limiter, (ratelimiter.SimpleRateLimiter, ratelimiter.TokenBucketRateLimiter)
+        ):
+            raise ValueError(
+                "`rate_limiter` should be of type:: `naz.ratelimiter.SimpleRateLimiter` or `naz.ratelimiter.TokenBucketRateLimiter` You entered: {0}".format(
+                    type(rate_limiter)
+                )
+            )
+        if not isinstance(decrease_factor, float) or not (0 < decrease_factor < 1):
+            raise ValueError(
+                "`decrease_factor` should be a `float` between 0 and 1. You entered: {0}".format(
+                    decrease_factor
+                )
+            )
+        if not isinstance(increase_factor, float) or not (increase_factor > 1):
+            raise ValueError(
+                "`increase_factor` should be a `float` greater than 1. You entered: {0}".format(
+                    increase_factor
+                )
+            )
+        if not isinstance(cool_down, float):
+            raise ValueError(
+                "`cool_down` should be of type:: `float` You entered: {0}".format(type(cool_down))
+            )
+        if cool_down <= 0:
+            raise ValueError(
+                "`cool_down` should be greater than zero. You entered: {0}".format(cool_down)
+            )
+        if not isinstance(min_send_rate, float):
+            raise ValueError(
+                "`min_send_rate` should be of type:: `float` You entered: {0}".format(
+                    type(min_send_rate)
+                )
+            )
+        if min_send_rate <= 0:
+            raise ValueError(
+                "`min_send_rate` should be greater than zero. You entered: {0}".format(
+                    min_send_rate
+                )
+            )
+        if min_send_rate > rate_limiter.send_rate:
+            raise ValueError(
+                "`min_send_rate` should not be greater than the send_rate({0}) of `rate_limiter`. You entered: {1}".format(
+                    rate_limiter.send_rate, min_send_rate
+                )
+            )
+        if not isinstance(on_throttle, type(None)) and not callable(on_throttle):
+            raise ValueError(
+                "`on_throttle` should be of type:: `None` or a callable. You entered: {0}".format(
+                    type(on_throttle)
+                )
+            )
+        if not isinstance(logger, (type(None), logging.Logger)):
+            raise ValueError(
+                "`logger` should be of type:: `None` or `logging.Logger` You entered: {0}".format(
+                    type(logger)
+                )
+            )
+
+        self.rate_limiter = rate_limiter
+        self.decrease_factor: float = decrease_factor
+        self.increase_factor: float = increase_factor
+        self.cool_down: float = cool_down
+        self.min_send_rate: float = min_send_rate
+        self.on_throttle = on_throttle
+
+        # the send rate that we recover to.
+        self.max_send_rate: float = rate_limiter.send_rate
+        self.throttle_responses: int = 0
+        self.updated_at: float = time.monotonic()
+
+        if logger is not None:
+            self.logger = logger
+        else:
+            self.logger = log.SimpleLogger("naz.AdaptiveThrottleHandler")
+
+    async def throttled(self) -> None:
+        self.throttle_responses += 1
+        self.updated_at = time.monotonic()
+        send_rate = max(self.min_send_rate, self.rate_limiter.send_rate * self.decrease_factor)
+        self.rate_limiter.set_send_rate(send_rate)
+        self.logger.log(
+            logging.WARNING,
+            {
+                "event": "naz.AdaptiveThrottleHandler.throttled",
+                "stage": "end",
+                "state": "reducing send rate",
+                "send_rate": send_rate,
+                "throttle_responses": self.throttle_responses,
+            },
+        )
+        if self.on_throttle is not None:
+            self.on_throttle(send_rate)
+
+    async def not_throttled(self) -> None:
+        if self.rate_limiter.send_rate >= self.max_send_rate:
+            return None
+        now: float = time.monotonic()
+        if now - self.updated_at < self.cool_down:
+            return None
+
+        self.updated_at = now
+        send_rate = min(self.max_send_rate, self.rate_limiter.send_rate * self.increase_factor)
+        self.rate_limiter.set_send_rate(send_rate)
+        self.logger.log(
+            logging.INFO,
+            {
+                "event": "naz.AdaptiveThrottleHandler.not_throttled",
+                "stage": "end",
+                "state": "increasing send rate",
+                "send_rate": send_rate,
+            },
+        )
+
+    async def allow_request(self) -> bool:
+        # requests are never denied, they are instead slowed down by the rate limiter.
+        return True
+
+    async def throttle_delay(self) -> float:
+        return 0.00
@@ -0,0 +1,304 @@
+# do not to pollute the global namespace.
+# see: https://python-packaging.readthedocs.io/en/latest/testing.html
+
+import os
+import json
+import asyncio
+import tempfile
+import threading
+from unittest import TestCase, mock
+
+import naz
+
+
+class MockRedis:
+    """
+    an in-memory stand-in for a redis client.
+    """
+
+    def __init__(self):
+        self.lists = {}
+
+    async def lpush(self, key, value):
+        self.lists.setdefault(key, []).insert(0, value.encode())
+        return len(self.lists[key])
+
+    async def brpop(self, key, timeout=0):
+        if self.lists.get(key):
+            return (key.encode(), self.lists[key].pop())
+        await asyncio.sleep(0.01)
+        return None
+
+
+class TestSimpleBroker(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_broker.TestSimpleBroker.test_something
+    """
+
+    @staticmethod
+    def _run(coro):
+        loop = asyncio.get_event_loop()
+        return loop.run_until_complete(coro)
+
+    @staticmethod
+    def _submit_sm(i):
+        return naz.protocol.SubmitSM(
+            short_message="Hello World-{0}".format(i),
+            log_id="log_id-{0}".format(i),
+            source_addr="254722111111",
+            destination_addr="254722999999",
+        )
+
+    def test_bad_args(self):
+        self.assertRaises(ValueError, naz.broker.SimpleBroker, maxsize="2")
+        self.assertRaises(ValueError, naz.broker.SimpleBroker, block_on_full="yes")
+
+    def test_size(self):
+        broker = naz.broker.SimpleBroker(maxsize=5)
+        self.assertEqual(broker.size(), 0)
+        for i in range(0, 3):
+            self._run(broker.enqueue(self._submit_sm(i)))
+        self.assertEqual(broker.size(), 3)
+        self._run(broker.dequeue())
+        self.assertEqual(broker.size(), 2)
+
+    def test_fail_fast_when_full(self):
+        broker = naz.broker.SimpleBroker(maxsize=2)
+        for i in range(0, 2):
+            self._run(broker.enqueue(self._submit_sm(i)))
+        with self.assertRaises(asyncio.QueueFull):
+            self._run(broker.enqueue(self._submit_sm(3)))
+        self.assertEqual(broker.size(), 2)
+
+    def test_block_when_full(self):
+        broker = naz.broker.SimpleBroker(maxsize=2, block_on_full=True)
+        for i in range(0, 2):
+            self._run(broker.enqueue(self._submit_sm(i)))
+
+        # enqueue waits until there is space, and can be cancelled.
+        with self.assertRaises(asyncio.TimeoutError):
+            self._run(asyncio.wait_for(broker.enqueue(self._submit_sm(3)), timeout=0.05))
+        self.assertEqual(broker.size(), 2)
+
+        async def dequeue_later():
+            await asyncio.sleep(0.05)
+            return await broker.dequeue()
+
+        async def run():
+            return await asyncio.gather(broker.enqueue(self._submit_sm(4)), dequeue_later())
+
+        _, message = self._run(run())
+        self.assertEqual(message.log_id, "log_id-0")
+        self.assertEqual(broker.size(), 2)
+
+
+class TestRedisBroker(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_broker.TestRedisBroker.test_something
+    """
+
+    def setUp(self):
+        self.redis = MockRedis()
+        self.broker = naz.broker.RedisBroker(redis=self.redis, queue_name="myqueue")
+
+    @staticmethod
+    def _run(coro):
+        loop = asyncio.get_event_loop()
+        return loop.run_until_complete(coro)
+
+    @staticmethod
+    def _submit_sm(i):
+        return naz.protocol.SubmitSM(
+            short_message="Hello World-{0}".format(i),
+            log_id="log_id-{0}".format(i),
+            source_addr="254722111111",
+            destination_addr="254722999999",
+            hook_metadata=json.dumps({"customer_id": i}),
+            encoding="ucs2",
+        )
+
+    def test_bad_args(self):
+        self.assertRaises(ValueError, naz.broker.RedisBroker, redis=self.redis, queue_name=9)
+        self.assertRaises(ValueError, naz.broker.RedisBroker, redis=self.redis, timeout=2.5)
+
+    def test_enqueue_dequeue_order(self):
+        for i in range(0, 4):
+            self._run(self.broker.enqueue(self._submit_sm(i)))
+        self.assertEqual(len(self.redis.lists["myqueue"]), 4)
+
+        for i in range(0, 4):
+            message = self._run(self.broker.dequeue())
+            self.assertIsInstance(message, naz.protocol.SubmitSM)
+            self.assertEqual(message.log_id, "log_id-{0}".format(i))
+            self.assertEqual(message.short_message, "Hello World-{0}".format(i))
+        self.assertEqual(len(self.redis.lists["myqueue"]), 0)
+
+    def test_serializes_full_message(self):
+        self._run(self.broker.enqueue(self._submit_sm(1)))
+        message = self._run(self.broker.dequeue())
+        self.assertEqual(message.to_json(), self._submit_sm(1).to_json())
+        self.assertEqual(message.encoding, "ucs2")
+        self.assertEqual(message.hook_metadata, json.dumps({"customer_id": 1}))
+
+    def test_dequeue_waits_for_item(self):
+        async def enqueue_later():
+            await asyncio.sleep(0.05)
+            await self.broker.enqueue(self._submit_sm(5))
+
+        async def run():
+            message, _ = await asyncio.gather(self.broker.dequeue(), enqueue_later())
+            return message
+
+        message = self._run(run())
+        self.assertEqual(message.log_id, "log_id-5")
+
+    def test_recovery_after_restart(self):
+        for i in range(0, 3):
+            self._run(self.broker.enqueue(self._submit_sm(i)))
+        message = self._run(self.broker.dequeue())
+        self.assertEqual(message.log_id, "log_id-0")
+
+        # simulate a restart; a new broker reads from the same redis key.
+        del self.broker
+        broker = naz.broker.RedisBroker(redis=self.redis, queue_name="myqueue")
+        for i in range(1, 3):
+            message = self._run(broker.dequeue())
+            self.assertEqual(message.log_id, "log_id-{0}".format(i))
+
+
+class TestFileBroker(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_broker.TestFileBroker.test_something
+    """
+
+    def setUp(self):
+        self.tmp_dir = tempfile.TemporaryDirectory()
+        self.path = os.path.join(self.tmp_dir.name, "queue.log")
+        self.broker = naz.broker.FileBroker(path=self.path)
+
+    def tearDown(self):
+        self.broker.close()
+        self.tmp_dir.cleanup()
+
+    @staticmethod
+    def _run(coro):
+        loop = asyncio.get_event_loop()
+        return loop.run_until_complete(coro)
+
+    @staticmethod
+    def _submit_sm(i):
+        return naz.protocol.SubmitSM(
+            short_message="Hello World-{0}".format(i),
+            log_id="log_id-{0}".format(i),
+            source_addr="254722111111",
+            destination_addr="254722999999",
+            hook_metadata=json.dumps({"customer_id": i}),
+        )
+
+    def test_bad_args(self):
+        self.assertRaises(ValueError, naz.broker.FileBroker, path=9)
+        self.assertRaises(ValueError, naz.broker.FileBroker, path=self.path, fsync="yes")
+        self.assertRaises(ValueError, naz.broker.FileBroker, path=self.path, max_segment_size=0)
+
+    def test_enqueue_dequeue_order(self):
+        for i in range(0, 4):
+            self._run(self.broker.enqueue(self._submit_sm(i)))
+        self.assertEqual(self.broker.size(), 4)
+
+        for i in range(0, 4):
+            message = self._run(self.broker.dequeue())
+            self.assertEqual(message.to_json(), self._submit_sm(i).to_json())
+        self.assertEqual(self.broker.size(), 0)
+
+    def test_replay_after_crash(self):
+        for i in range(0, 4):
+            self._run(self.broker.enqueue(self._submit_sm(i)))
+        for i in range(0, 2):
+            self._run(self.broker.dequeue())
+        # SMSC responded to the first message, but not to the second.
+        self._run(self.broker.acknowledge("log_id-0"))
+        # a message that was not sent through this broker.
+        self._run(self.broker.acknowledge("unknown-log-id"))
+
+        # simulate a crash in the middle of writing a record; the file is never closed.
+        self.broker._file.write(b"1f2e3d4c {\"op\": \"enq")
+        self.broker._file.flush()
+        crashed_file = self.broker._file
+
+        self.broker = naz.broker.FileBroker(path=self.path)
+        crashed_file.close()
+        self.assertEqual(self.broker.size(), 3)
+        for i in range(1, 4):
+            message = self._run(self.broker.dequeue())
+            self.assertEqual(message.log_id, "log_id-{0}".format(i))
+        # the partially written record was discarded.
+        with open(self.path, "rb") as f:
+            self.assertTrue(f.read().endswith(b"\n"))
+
+        # records written after recovery are not lost.
+        self._run(self.broker.enqueue(self._submit_sm(4)))
+        self._run(self.broker.acknowledge("log_id-1"))
+        self.broker.close()
+        self.broker = naz.broker.FileBroker(path=self.path)
+        self.assertEqual(self.broker.size(), 3)
+        self.assertEqual(
+            [self._run(self.broker.dequeue()).log_id for _ in range(0, 3)],
+            ["log_id-2", "log_id-3", "log_id-4"],
+        )
+
+    def test_corrupt_record(self):
+        for i in range(0, 3):
+            self._run(self.broker.enqueue(self._submit_sm(i)))
+        self.broker.close()
+
+        with open(self.path, "rb") as f:
+            lines = f.read().split(b"\n")
+        # flip a byte in the second record; it and the records after it can no longer be trusted.
+        lines[1] = lines[1].replace(b"World-1", b"World-X")
+        with open(self.path, "wb") as f:
+            f.write(b"\n".join(lines))
+
+        self.broker = naz.broker.FileBroker(path=self.path)
+        self.assertEqual(self.broker.size(), 1)
+        self.assertEqual(self._run(self.broker.dequeue()).log_id, "log_id-0")
+
+    def test_compaction(self):
+        self.broker.close()
+        self.broker = naz.broker.FileBroker(path=self.path, fsync=False, max_segment_size=2048)
+        for i in range(0, 20):
+            self._run(self.broker.enqueue(self._submit_sm(i)))
+            self._run(self.broker.dequeue())
+            if i != 7:
+                self._run(self.broker.acknowledge("log_id-{0}".format(i)))
+        self.assertLess(os.path.getsize(self.path), 2048)
+
+        self.broker.close()
+        self.broker = naz.broker.FileBroker(path=self.path)
+        self.assertEqual(self.broker.size(), 1)
+        self.assertEqual(self._run(self.broker.dequeue()).log_id, "log_id-7")
+
+    def test_fsync_off_the_event_loop(self):
+        fsync_threads = []
+        fsync = os.fsync
+
+        def recording_fsync(fd):
+            fsync_threads.append(threading.get_ident())
+            fsync(fd)
+
+        with mock.patch("naz.broker.os.fsync", new=recording_fsync):
+            # many concurrent writers.
+            self._run(
+                asyncio.gather(*[self.broker.enqueue(self._submit_sm(i)) for i in range(0, 5)])
+            )
+        self.assertEqual(len(fsync_threads), 5)
+        self.assertNotIn(threading.get_ident(), fsync_threads)
+        self.assertEqual(self.broker.size(), 5)
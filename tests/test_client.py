@@ -2,11 +2,18 @@
 # see: https://python-packaging.readthedocs.io/en/latest/testing.html
 
 import os
+import ssl
 import time
+import io
 import json
+import base64
 import codecs
 import struct
+import hashlib
+import socket
+import logging
 import asyncio
+import datetime
 from unittest import TestCase, mock
 
 import naz
@@ -114,6 +121,49 @@ class TestClient(TestCase):
             "correlation_handler": DummyClientArg,
             "drain_duration": DummyClientArg,
             "socket_timeout": DummyClientArg,
+            "split_long_messages": DummyClientArg,
+            "bind_mode": DummyClientArg,
+            "auto_reconnect": DummyClientArg,
+            "reconnect_initial_interval": DummyClientArg,
+            "reconnect_max_interval": DummyClientArg,
+            "reconnect_jitter": DummyClientArg,
+            "reconnect_fail_fast": DummyClientArg,
+            "enquire_link_response_timeout": DummyClientArg,
+            "ssl_context": DummyClientArg,
+            "metrics": DummyClientArg,
+            "window_size": DummyClientArg,
+            "window_timeout": DummyClientArg,
+            "registered_delivery": DummyClientArg,
+            "max_pdu_length": DummyClientArg,
+            "source_addr_ton": DummyClientArg,
+            "source_addr_npi": DummyClientArg,
+            "dest_addr_ton": DummyClientArg,
+            "dest_addr_npi": DummyClientArg,
+            "redact_system_id": DummyClientArg,
+            "on_write": DummyClientArg,
+            "on_read": DummyClientArg,
+            "on_outbind": DummyClientArg,
+            "read_timeout": DummyClientArg,
+            "write_timeout": DummyClientArg,
+            "use_message_payload": DummyClientArg,
+            "on_enquire_link_latency": DummyClientArg,
+            "dialer": DummyClientArg,
+            "response_timeout": DummyClientArg,
+            "priority_flag": DummyClientArg,
+            "bind_timeout": DummyClientArg,
+            "reassembly_timeout": DummyClientArg,
+            "retry_policy": DummyClientArg,
+            "smsc_endpoints": DummyClientArg,
+            "validity_period": DummyClientArg,
+            "bind_retries": DummyClientArg,
+            "message_payload_fallback": DummyClientArg,
+            "e164_addresses": DummyClientArg,
+            "log_pdu_contents": DummyClientArg,
+            "message_payload_threshold": DummyClientArg,
+            "dedup_window": DummyClientArg,
+            "byte_rate_limiter": DummyClientArg,
+            "unexpected_responses": DummyClientArg,
+            "window_maintenance_interval": DummyClientArg,
         }
 
         def mock_create_client():
@@ -328,7 +378,7 @@ class TestClient(TestCase):
             )
 
     def test_command_handlers_deliver_sm(self):
-        with mock.patch("naz.broker.SimpleBroker.enqueue", new=AsyncMock()) as mock_naz_enqueue:
+        with mock.patch("naz.Client.send_data", new=AsyncMock()) as mock_naz_send_data:
             sequence_number = 7
             self._run(
                 self.cli.command_handlers(
@@ -341,9 +391,10 @@ class TestClient(TestCase):
                     hook_metadata="hook_metadata",
                 )
             )
-            self.assertTrue(mock_naz_enqueue.mock.called)
+            self.assertTrue(mock_naz_send_data.mock.called)
             self.assertEqual(
-                mock_naz_enqueue.mock.call_args[0][1].smpp_command, naz.SmppCommand.DELIVER_SM_RESP
+                mock_naz_send_data.mock.call_args[1]["smpp_command"],
+                naz.SmppCommand.DELIVER_SM_RESP,
             )
 
     def test_unbind(self):
@@ -527,8 +578,111 @@ class TestClient(TestCase):
             self.assertEqual(received_pdu, b"")
             self.assertTrue(mock_naz_unbind_and_disconnect.mock.called)
 
+    def test_fragmented_reads(self):
+        """
+        test that a PDU that arrives one byte at a time is read whole.
+        """
+        submit_sm_resp_pdu = (
+            b"\x00\x00\x00\x12\x80\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00\x030\x00"
+        )
+
+        async def run():
+            reader = asyncio.StreamReader()
+
+            async def feed():
+                for i in range(0, len(submit_sm_resp_pdu)):
+                    reader.feed_data(submit_sm_resp_pdu[i : i + 1])
+                    await asyncio.sleep(0.001)
+
+            self.cli.reader = reader
+            self.cli.writer = MockStreamWriter()
+            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+            feeder = asyncio.ensure_future(feed())
+            with mock.patch("naz.Client._parse_response_pdu", new=AsyncMock()) as mock_parse:
+                received_pdu = await self.cli.receive_data(TESTING=True)
+            await feeder
+            return received_pdu, mock_parse
+
+        received_pdu, mock_parse = self._run(run())
+        self.assertEqual(received_pdu, submit_sm_resp_pdu)
+        self.assertEqual(mock_parse.mock.call_args[0][1], submit_sm_resp_pdu)
+
+    def test_partial_body_reads_disconnect(self):
+        """
+        test that if the connection breaks in the middle of a PDU's body,
+        then we should close the connection.
+        """
+        with mock.patch("asyncio.open_connection", new=AsyncMock()) as mock_naz_connect, mock.patch(
+            "naz.Client._unbind_and_disconnect", new=AsyncMock()
+        ) as mock_naz_unbind_and_disconnect, mock.patch(
+            "naz.Client._parse_response_pdu", new=AsyncMock()
+        ) as mock_parse:
+            # the header says that the PDU is 18bytes long, but only 17 arrive.
+            submit_sm_resp_pdu = (
+                b"\x00\x00\x00\x12\x80\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00\x030"
+            )
+            mock_naz_connect.mock.return_value = (
+                MockStreamReader(pdu=submit_sm_resp_pdu),
+                MockStreamWriter(),
+            )
+
+            self._run(self.cli.connect())
+            received_pdu = self._run(self.cli.receive_data(TESTING=True))
+            self.assertEqual(received_pdu, submit_sm_resp_pdu[:16])
+            self.assertTrue(mock_naz_unbind_and_disconnect.mock.called)
+            self.assertFalse(mock_parse.mock.called)
+
+    def test_max_pdu_length(self):
+        self.assertEqual(self.cli.max_pdu_length, 1024 * 1024)
+        # command_length's that are less than the header, or more than the max
+        for command_length in [0, 12, 15, 1024 * 1024 + 1, 0xFFFFFFFF]:
+            with mock.patch(
+                "asyncio.open_connection", new=AsyncMock()
+            ) as mock_naz_connect, mock.patch(
+                "naz.Client._unbind_and_disconnect", new=AsyncMock()
+            ) as mock_naz_unbind_and_disconnect, mock.patch(
+                "naz.Client._parse_response_pdu", new=AsyncMock()
+            ) as mock_parse:
+                header = struct.pack(">IIII", command_length, 0x80000004, 0x00000000, 3)
+                mock_naz_connect.mock.return_value = (
+                    MockStreamReader(pdu=header + b"\x00" * 64),
+                    MockStreamWriter(),
+                )
+
+                self._run(self.cli.connect())
+                received_pdu = self._run(self.cli.receive_data(TESTING=True))
+                self.assertEqual(received_pdu, header)
+                self.assertTrue(mock_naz_unbind_and_disconnect.mock.called, command_length)
+                self.assertFalse(mock_parse.mock.called, command_length)
+
+        # a PDU with just the header is fine.
+        with mock.patch("asyncio.open_connection", new=AsyncMock()) as mock_naz_connect, mock.patch(
+            "naz.Client._unbind_and_disconnect", new=AsyncMock()
+        ) as mock_naz_unbind_and_disconnect, mock.patch(
+            "naz.Client._parse_response_pdu", new=AsyncMock()
+        ) as mock_parse:
+            header = struct.pack(">IIII", 16, 0x80000015, 0x00000000, 3)
+            mock_naz_connect.mock.return_value = (MockStreamReader(pdu=header), MockStreamWriter())
+            self._run(self.cli.connect())
+            received_pdu = self._run(self.cli.receive_data(TESTING=True))
+            self.assertEqual(received_pdu, header)
+            self.assertFalse(mock_naz_unbind_and_disconnect.mock.called)
+            self.assertTrue(mock_parse.mock.called)
+
+    def test_bad_max_pdu_length(self):
+        for max_pdu_length in ["1024", 15]:
+            with self.assertRaises(naz.client.NazClientError):
+                naz.Client(
+                    smsc_host="127.0.0.1",
+                    smsc_port=TestClient.smsc_port,
+                    system_id="smppclient1",
+                    password=os.getenv("password", "password"),
+                    broker=self.broker,
+                    max_pdu_length=max_pdu_length,
+                )
+
     def test_enquire_link_resp(self):
-        with mock.patch("naz.broker.SimpleBroker.enqueue", new=AsyncMock()) as mock_naz_enqueue:
+        with mock.patch("naz.Client.send_data", new=AsyncMock()) as mock_naz_send_data:
             sequence_number = 7
             self._run(
                 self.cli.command_handlers(
@@ -541,168 +695,2389 @@ class TestClient(TestCase):
                     hook_metadata="hook_metadata",
                 )
             )
-            self.assertTrue(mock_naz_enqueue.mock.called)
+            # it is sent right away, rather than via the broker.
+            self.assertTrue(mock_naz_send_data.mock.called)
             self.assertEqual(
-                mock_naz_enqueue.mock.call_args[0][1].smpp_command,
+                mock_naz_send_data.mock.call_args[1]["smpp_command"],
                 naz.SmppCommand.ENQUIRE_LINK_RESP,
             )
+            self.assertEqual(
+                mock_naz_send_data.mock.call_args[1]["msg"],
+                struct.pack(">IIII", 16, 0x80000015, 0, sequence_number),
+            )
 
-    def test_retry_after(self):
-        self.assertEqual(self.cli._retry_after(current_retries=-23) / 60, 1)
-        self.assertEqual(self.cli._retry_after(current_retries=0) / 60, 1)
-        self.assertEqual(self.cli._retry_after(current_retries=1) / 60, 2)
-        self.assertEqual(self.cli._retry_after(current_retries=2) / 60, 4)
-        self.assertEqual(self.cli._retry_after(current_retries=3) / 60, 8)
-        self.assertEqual(self.cli._retry_after(current_retries=4) / 60, 16)
-        self.assertEqual(self.cli._retry_after(current_retries=5) / 60, 32)
-        self.assertEqual(self.cli._retry_after(current_retries=7) / 60, 16)
-        self.assertEqual(self.cli._retry_after(current_retries=5432) / 60, 16)
+    def test_unsolicited_enquire_link(self):
+        replies = []
 
-    def test_session_state_ok(self):
+        async def handle_conn(reader, writer):
+            header = await reader.readexactly(16)
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            await reader.readexactly(command_length - 16)
+            body = b"SMSC\x00"
+            writer.write(
+                struct.pack(">IIII", 16 + len(body), 0x80000009, 0, sequence_number) + body
+            )
+            # SMSC checks whether we are still there.
+            writer.write(struct.pack(">IIII", 16, 0x00000015, 0, 77))
+            await writer.drain()
+            replies.append(await reader.readexactly(16))
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                logger=naz.log.SimpleLogger("test_unsolicited_enquire_link", level="WARNING"),
+            )
+            await cli.connect()
+            await cli.bind()
+            # only the read loop is running; neither `dequeue_messages` nor `enquire_link` are.
+            receiver = asyncio.ensure_future(cli.receive_data())
+            for _ in range(0, 100):
+                if replies:
+                    break
+                await asyncio.sleep(0.01)
+            receiver.cancel()
+            server.close()
+            await server.wait_closed()
+
+        self._run(run())
+        self.assertEqual(replies, [struct.pack(">IIII", 16, 0x80000015, 0, 77)])
+
+    def test_enquire_link_resp_with_body(self):
+        class CapturingHandler(logging.Handler):
+            def __init__(self):
+                super(CapturingHandler, self).__init__()
+                self.records = []
+
+            def emit(self, record):
+                self.records.append(record)
+
+        enquire_links = []
+
+        async def handle_conn(reader, writer):
+            header = await reader.readexactly(16)
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            await reader.readexactly(command_length - 16)
+            body = b"SMSC\x00"
+            writer.write(
+                struct.pack(">IIII", 16 + len(body), 0x80000009, 0, sequence_number) + body
+            )
+            await writer.drain()
+            header = await reader.readexactly(16)
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            enquire_links.append((header, await reader.readexactly(command_length - 16)))
+            # a non-compliant SMSC; with a stray octet after the header.
+            writer.write(struct.pack(">IIII", 17, 0x80000015, 0, sequence_number) + b"\x00")
+            await writer.drain()
+            await reader.read()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            handler = CapturingHandler()
+            logger = logging.Logger("test_enquire_link_resp_with_body", level=logging.WARNING)
+            logger.addHandler(handler)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                enquire_link_response_timeout=2.0,
+                logger=logger,
+            )
+            await cli.connect()
+            await cli.bind()
+            await cli.receive_data(TESTING=True)
+            receiver = asyncio.ensure_future(cli.receive_data())
+            await cli.enquire_link(TESTING=True)
+            receiver.cancel()
+            state = cli.current_session_state
+            await cli._unbind_and_disconnect()
+            server.close()
+            await server.wait_closed()
+            return cli, state, handler.records
+
+        cli, state, records = self._run(run())
+        # naz sends an enquire_link that is only a header.
+        self.assertEqual(len(enquire_links), 1)
+        self.assertEqual(enquire_links[0][0][:8], struct.pack(">II", 16, 0x00000015))
+        self.assertEqual(enquire_links[0][1], b"")
+        # the response is accepted, but the stray body is logged.
+        self.assertEqual(state, naz.SmppSessionState.BOUND_TRX)
+        self.assertNotEqual(cli._latest_enquire_link_resp_at, 0.00)
+        self.assertIn(
+            "ignoring the 1 octets body of enquire_link_resp; it should have no body",
+            [record.msg.get("state") for record in records if record.levelno == logging.WARNING],
+        )
+
+    def _outbind(self, accept):
         """
-        send a `submit_sm` request when session state is `BOUND_TRX`
+        a mock SMSC connects to naz and sends it an outbind.
         """
-        with mock.patch(
-            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
-        ) as mock_naz_dequeue, mock.patch("asyncio.streams.StreamWriter.write") as mock_naz_writer:
-            log_id = "12345"
-            short_message = "hello smpp"
-            mock_naz_dequeue.mock.return_value = naz.protocol.SubmitSM(
-                version=1,
-                log_id=log_id,
-                short_message=short_message,
-                smpp_command=naz.SmppCommand.SUBMIT_SM,
-                source_addr="2547000000",
-                destination_addr="254711999999",
-                ms_validity=1,  # optional smpp param for submit_sm
+        outbinds = []
+
+        def on_outbind(system_id, password):
+            outbinds.append((system_id, password))
+            return accept
+
+        async def run():
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=2775,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                bind_mode=naz.BindMode.RECEIVER,
+                on_outbind=on_outbind,
+                logger=naz.log.SimpleLogger("test_outbind", level="CRITICAL"),
+            )
+            server = await asyncio.start_server(cli.accept_outbind, "127.0.0.1", 0)
+            reader, writer = await asyncio.open_connection(
+                "127.0.0.1", server.sockets[0].getsockname()[1]
             )
+            body = b"SMSC\x00smsc-pw\x00"
+            writer.write(struct.pack(">IIII", 16 + len(body), 0x0000000B, 0, 1) + body)
+            await writer.drain()
+            request = await reader.read(1024)
+            if request:
+                sequence_number = struct.unpack(">I", request[12:16])[0]
+                writer.write(struct.pack(">IIII", 21, 0x80000001, 0, sequence_number) + b"SMSC\x00")
+                await writer.drain()
+                await cli.receive_data(TESTING=True)
+            writer.close()
+            server.close()
+            await server.wait_closed()
+            return cli, request
 
-            self._run(self.cli.connect())
-            self._run(self.cli.tranceiver_bind())
-            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
-            self._run(self.cli.dequeue_messages(TESTING=True))
+        cli, request = self._run(run())
+        return cli, request, outbinds
 
-            self.assertTrue(mock_naz_writer.called)
-            self.assertEqual(mock_naz_writer.call_count, 2)
-            self.assertIn(short_message, mock_naz_writer.call_args[0][0].decode())
+    def test_outbind(self):
+        cli, request, outbinds = self._outbind(accept=True)
+        self.assertEqual(outbinds, [("SMSC", "smsc-pw")])
+        # naz responded with a bind_receiver
+        self.assertEqual(struct.unpack(">I", request[4:8])[0], 0x00000001)
+        self.assertTrue(request[16:].startswith(b"smppclient1\x00"))
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.BOUND_RX)
 
-    def test_broken_broker(self):
-        with mock.patch(
-            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
-        ) as mock_naz_dequeue, mock.patch("asyncio.streams.StreamWriter.write") as mock_naz_writer:
-            mock_naz_dequeue.mock.side_effect = ValueError("This test broker has 99 Problems")
-            self._run(self.cli.connect())
-            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
-            res = self._run(self.cli.dequeue_messages(TESTING=True))
-            self.assertEqual(res, {"broker_error": "broker_error"})
-            self.assertFalse(mock_naz_writer.called)
+    def test_outbind_rejected(self):
+        cli, request, outbinds = self._outbind(accept=False)
+        self.assertEqual(outbinds, [("SMSC", "smsc-pw")])
+        # naz closed the connection without binding.
+        self.assertEqual(request, b"")
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.CLOSED)
 
-    def test_session_state(self):
+    def test_outbind_bind_mode(self):
+        with self.assertRaises(ValueError):
+            self._run(self.cli.accept_outbind(MockStreamReader(pdu=b""), MockStreamWriter()))
+
+    def _smsc_unbind(self, auto_reconnect):
         """
-        try sending a `submit_sm` request when session state is `OPEN`
+        runs a client against a mock SMSC that unbinds the first connection right after the bind.
         """
-        with mock.patch(
-            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
-        ) as mock_naz_dequeue, mock.patch("asyncio.streams.StreamWriter.write") as mock_naz_writer:
-            log_id = "12345"
-            short_message = "hello smpp"
-            mock_naz_dequeue.mock.return_value = naz.protocol.SubmitSM(
-                version=1,
-                log_id=log_id,
-                short_message=short_message,
-                smpp_command=naz.SmppCommand.SUBMIT_SM,
-                source_addr="2547000000",
-                destination_addr="254711999999",
+        connections, replies = [], []
+
+        async def handle_conn(reader, writer):
+            connections.append(writer)
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                if command_id == 0x80000006:
+                    replies.append(header)
+                    writer.close()
+                    return
+                body = b"SMSC\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number
+                    )
+                    + body
+                )
+                if command_id == 0x00000009 and len(connections) == 1:
+                    # SMSC is going down for maintenance
+                    writer.write(struct.pack(">IIII", 16, 0x00000006, 0, 55))
+                await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=0.1,
+                auto_reconnect=auto_reconnect,
+                logger=naz.log.SimpleLogger("test_smsc_unbind", level="CRITICAL"),
             )
+            await cli.connect()
+            await cli.bind()
+            receiver = asyncio.ensure_future(cli.receive_data())
+            for _ in range(0, 200):
+                if receiver.done() or (len(connections) > 1 and cli._connection_is_alive()):
+                    break
+                await asyncio.sleep(0.01)
+            receiver_done = receiver.done()
+            receiver.cancel()
+            server.close()
+            await server.wait_closed()
+            return cli, receiver_done
 
-            self._run(self.cli.connect())
-            self.cli.current_session_state = naz.SmppSessionState.OPEN
-            self._run(self.cli.dequeue_messages(TESTING=True))
-            self.assertFalse(mock_naz_writer.called)
+        cli, receiver_done = self._run(run())
+        return cli, receiver_done, connections, replies
 
-    def test_submit_with_session_state_closed(self):
+    def test_smsc_unbind(self):
+        cli, receiver_done, connections, replies = self._smsc_unbind(auto_reconnect=False)
+        # naz acknowledged the unbind, and then stopped cleanly.
+        self.assertEqual(replies, [struct.pack(">IIII", 16, 0x80000006, 0, 55)])
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.CLOSED)
+        self.assertTrue(receiver_done)
+        self.assertEqual(len(connections), 1)
+        # shutting down after SMSC unbound is fine.
+        self._run(cli.shutdown())
+
+    def test_smsc_unbind_auto_reconnect(self):
+        cli, receiver_done, connections, replies = self._smsc_unbind(auto_reconnect=True)
+        self.assertEqual(replies, [struct.pack(">IIII", 16, 0x80000006, 0, 55)])
+        # naz re-connected and re-bound.
+        self.assertFalse(receiver_done)
+        self.assertEqual(len(connections), 2)
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.BOUND_TRX)
+
+    def test_healthy(self):
+        # not bound
+        self.assertFalse(self.cli.healthy())
+
+        self.cli.writer = MockStreamWriter()
+        self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+        # no enquire_link has been sent yet
+        self.assertTrue(self.cli.healthy())
+
+        now = time.monotonic()
+        # SMSC responded to the latest enquire_link
+        self.cli._latest_enquire_link = (7, now - 3.0)
+        self.cli._latest_enquire_link_resp_at = now - 2.9
+        self.assertTrue(self.cli.healthy())
+
+        # the latest enquire_link is awaiting a response, but it is not yet overdue.
+        self.cli._latest_enquire_link = (8, now)
+        self.assertTrue(self.cli.healthy())
+
+        # SMSC has not responded to the latest enquire_link in time.
+        self.cli._latest_enquire_link = (8, now - 1.0)
+        self.assertFalse(self.cli.healthy())
+
+        # enquire_link has stopped being sent.
+        self.cli._latest_enquire_link = (7, now - self.cli.enquire_link_interval - 1.0)
+        self.cli._latest_enquire_link_resp_at = now - self.cli.enquire_link_interval - 0.9
+        self.assertFalse(self.cli.healthy())
+
+    def _ping(self, respond):
         """
-        try sending a `submit_sm` request when session state is `CLOSED`
+        pings a mock SMSC that either responds to enquire_link or ignores it.
         """
-        with mock.patch(
-            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
-        ) as mock_naz_dequeue, mock.patch(
-            "naz.client.asyncio.sleep", new=AsyncMock()
-        ) as mock_sleep:
-            log_id = "12345"
-            short_message = "hello smpp"
-            mock_naz_dequeue.mock.return_value = naz.protocol.SubmitSM(
-                version=1,
-                log_id=log_id,
-                short_message=short_message,
-                smpp_command=naz.SmppCommand.SUBMIT_SM,
-                source_addr="2547000000",
-                destination_addr="254711999999",
-            )
-            self._run(self.cli.dequeue_messages(TESTING=True))
-            self.assertTrue(mock_sleep.mock.called)
-            self.assertEqual(mock_sleep.mock.call_args[0][0], self.cli.socket_timeout)
 
-    def test_correlater_put_called(self):
-        with mock.patch(
-            "naz.correlater.SimpleCorrelater.put", new=AsyncMock()
-        ) as mock_correlater_put, mock.patch(
-            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
-        ) as mock_naz_dequeue:
-            log_id = "12345"
-            short_message = "hello smpp"
-            _hook_metadata = {"telco": "Verizon", "customer_id": "909090123"}
-            hook_metadata = json.dumps(_hook_metadata)
-            mock_naz_dequeue.mock.return_value = naz.protocol.SubmitSM(
-                version=1,
-                log_id=log_id,
-                short_message=short_message,
-                smpp_command=naz.SmppCommand.SUBMIT_SM,
-                source_addr="2547000000",
-                destination_addr="254711999999",
-                hook_metadata=hook_metadata,
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                if command_id == 0x00000015 and not respond:
+                    continue
+                body = b"" if command_id == 0x00000015 else b"SMSC\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number
+                    )
+                    + body
+                )
+                await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                logger=naz.log.SimpleLogger("test_ping", level="CRITICAL"),
             )
+            await cli.connect()
+            await cli.bind()
+            receiver = asyncio.ensure_future(cli.receive_data())
+            try:
+                return await cli.ping(timeout=0.2)
+            finally:
+                receiver.cancel()
+                cli.writer.close()
+                server.close()
+                await server.wait_closed()
 
-            self._run(self.cli.connect())
-            # hack to allow sending submit_sm even when state is wrong
-            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
-            self._run(self.cli.dequeue_messages(TESTING=True))
-            self.assertTrue(mock_correlater_put.mock.called)
+        return self._run(run())
 
-            self.assertEqual(mock_correlater_put.mock.call_args[1]["log_id"], log_id)
-            self.assertEqual(mock_correlater_put.mock.call_args[1]["hook_metadata"], hook_metadata)
-            self.assertEqual(
-                json.loads(mock_correlater_put.mock.call_args[1]["hook_metadata"]), _hook_metadata
-            )
+    def test_ping(self):
+        round_trip = self._ping(respond=True)
+        self.assertTrue(0 <= round_trip < 0.2)
 
-    def test_correlater_get_called(self):
-        with mock.patch(
-            "naz.correlater.SimpleCorrelater.get", new=AsyncMock()
-        ) as mock_correlater_get:
-            mock_correlater_get.mock.return_value = "log_id", "hook_metadata"
-            self._run(
-                self.cli._parse_response_pdu(
-                    pdu=b"\x00\x00\x00\x18\x80\x00\x00\t\x00\x00\x00\x00\x00\x00\x00\x06SMPPSim\x00"
+    def test_ping_unresponsive(self):
+        with self.assertRaises(asyncio.TimeoutError):
+            self._ping(respond=False)
+        with self.assertRaises(ValueError):
+            self._run(self.cli.ping(timeout=1))
+
+    def test_enquire_link_latency(self):
+        delay = 0.1
+
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                body = b"SMSC\x00"
+                if command_id == 0x00000015:
+                    # a slow link
+                    await asyncio.sleep(delay)
+                    body = b""
+                writer.write(
+                    struct.pack(
+                        ">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number
+                    )
+                    + body
                 )
+                await writer.drain()
+
+        latencies = []
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                enquire_link_interval=0.2,
+                on_enquire_link_latency=latencies.append,
+                logger=naz.log.SimpleLogger("test_enquire_link_latency", level="CRITICAL"),
             )
-            self.assertTrue(mock_correlater_get.mock.called)
-            self.assertTrue(mock_correlater_get.mock.call_args[1]["sequence_number"])
+            before = cli.enquire_link_latency()
+            await cli.connect()
+            await cli.bind()
+            tasks = [
+                asyncio.ensure_future(cli.receive_data()),
+                asyncio.ensure_future(cli.enquire_link()),
+            ]
+            for _ in range(0, 200):
+                if len(latencies) >= 2:
+                    break
+                await asyncio.sleep(0.01)
+            after = cli.enquire_link_latency()
+            for task in tasks:
+                task.cancel()
+            cli.writer.close()
+            server.close()
+            await server.wait_closed()
+            return before, after
 
-    def test_logger_called(self):
-        with mock.patch("naz.log.SimpleLogger.log") as mock_logger_log:
-            mock_logger_log.return_value = None
-            self._run(
-                self.cli._parse_response_pdu(
-                    pdu=b"\x00\x00\x00\x18\x80\x00\x00\t\x00\x00\x00\x00\x00\x00\x00\x06SMPPSim\x00"
-                )
+        before, after = self._run(run())
+        self.assertEqual(before, (None, None))
+        self.assertTrue(len(latencies) >= 2)
+        latest, average = after
+        self.assertEqual(latest, latencies[-1])
+        self.assertAlmostEqual(average, sum(latencies) / len(latencies))
+        for latency in latencies:
+            self.assertTrue(delay <= latency < delay + 0.3, latency)
+
+    def test_enquire_link_latency_callback_error(self):
+        def on_enquire_link_latency(latency):
+            raise ValueError("bad callback")
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            on_enquire_link_latency=on_enquire_link_latency,
+            logger=naz.log.SimpleLogger("test_enquire_link_latency", level="CRITICAL"),
+        )
+        for latency in [0.1] + [0.3] * 10:
+            cli._record_enquire_link_latency(latency)
+        # only the last 10 are averaged.
+        latest, average = cli.enquire_link_latency()
+        self.assertEqual(latest, 0.3)
+        self.assertAlmostEqual(average, 0.3)
+
+    def test_dialer(self):
+        received = []
+
+        async def handle_conn(reader, writer):
+            header = await reader.readexactly(16)
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            await reader.readexactly(command_length - 16)
+            received.append(command_id)
+            body = b"SMSC\x00"
+            writer.write(
+                struct.pack(">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number)
+                + body
             )
-            self.assertTrue(mock_logger_log.called)
-            self.assertEqual(
-                mock_logger_log.call_args[0][1]["event"], "naz.Client._parse_response_pdu"
+            await writer.drain()
+
+        async def run():
+            client_sock, smsc_sock = socket.socketpair()
+            smsc_reader, smsc_writer = await asyncio.open_connection(sock=smsc_sock)
+            smsc = asyncio.ensure_future(handle_conn(smsc_reader, smsc_writer))
+            dialer = naz.dialer.ConnectionDialer(*await asyncio.open_connection(sock=client_sock))
+            cli = naz.Client(
+                # the connection has already been made, so there is nothing listening here.
+                smsc_host="127.0.0.1",
+                smsc_port=1,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                dialer=dialer,
+                logger=naz.log.SimpleLogger("test_dialer", level="CRITICAL"),
             )
+            await cli.connect()
+            await cli.bind()
+            await cli.receive_data(TESTING=True)
+            await smsc
+            with self.assertRaises(ConnectionError):
+                await dialer.dial(cli.smsc_host, cli.smsc_port, None)
+            cli.writer.close()
+            smsc_writer.close()
+            return cli
 
-    def test_parse_deliver_sm(self):
-        with mock.patch(
+        cli = self._run(run())
+        self.assertEqual(received, [0x00000009])
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.BOUND_TRX)
+
+        self.assertIsInstance(self.cli.dialer, naz.dialer.SimpleDialer)
+
+    def test_happy_eyeballs_dialer(self):
+        async def handle_conn(reader, writer):
+            writer.close()
+
+        async def dial(port, unreachable_ipv6):
+            open_connection = asyncio.open_connection
+            attempted = []
+
+            async def getaddrinfo(host, port, type):
+                return [
+                    (socket.AF_INET6, socket.SOCK_STREAM, 6, "", ("2001:db8::1", port, 0, 0)),
+                    (socket.AF_INET, socket.SOCK_STREAM, 6, "", ("127.0.0.1", port)),
+                ]
+
+            async def mock_open_connection(host, port, **kwargs):
+                attempted.append(host)
+                if host == "2001:db8::1":
+                    if unreachable_ipv6 == "hangs":
+                        await asyncio.sleep(60)
+                    raise OSError("Network is unreachable")
+                return await open_connection(host, port, **kwargs)
+
+            dialer = naz.dialer.HappyEyeballsDialer(delay=0.1)
+            loop = asyncio.get_event_loop()
+            with mock.patch.object(loop, "getaddrinfo", new=getaddrinfo), mock.patch(
+                "asyncio.open_connection", new=mock_open_connection
+            ):
+                started = time.monotonic()
+                _, writer = await dialer.dial("smsc.example.com", port, None)
+                duration = time.monotonic() - started
+            writer.close()
+            return attempted, duration
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            port = server.sockets[0].getsockname()[1]
+            results = [await dial(port, "fails"), await dial(port, "hangs")]
+            server.close()
+            await server.wait_closed()
+            return results
+
+        (failed_attempts, failed_duration), (hung_attempts, hung_duration) = self._run(run())
+        # IPv6 is tried first; IPv4 is tried as soon as it fails, or after the delay if it hangs.
+        self.assertEqual(failed_attempts, ["2001:db8::1", "127.0.0.1"])
+        self.assertTrue(failed_duration < 0.1, failed_duration)
+        self.assertEqual(hung_attempts, ["2001:db8::1", "127.0.0.1"])
+        self.assertTrue(0.1 <= hung_duration < 1.0, hung_duration)
+
+        dialer = naz.dialer.HappyEyeballsDialer(prefer_ipv6=False)
+        self.assertEqual(
+            dialer._order(
+                [
+                    (socket.AF_INET6, "::1", 2775),
+                    (socket.AF_INET6, "::2", 2775),
+                    (socket.AF_INET, "127.0.0.1", 2775),
+                ]
+            ),
+            [
+                (socket.AF_INET, "127.0.0.1", 2775),
+                (socket.AF_INET6, "::1", 2775),
+                (socket.AF_INET6, "::2", 2775),
+            ],
+        )
+        with self.assertRaises(ValueError):
+            naz.dialer.HappyEyeballsDialer(delay=-1.0)
+
+    def test_websocket_dialer(self):
+        requests = []
+        messages = []
+        smsc_done = asyncio.Event()
+
+        async def read_frame(reader):
+            first, second = await reader.readexactly(2)
+            length = second & 0x7F
+            if length == 126:
+                length = struct.unpack(">H", await reader.readexactly(2))[0]
+            mask = await reader.readexactly(4)
+            payload = await reader.readexactly(length)
+            # frames from a client are always masked
+            self.assertTrue(second & 0x80)
+            return first, bytes(octet ^ mask[i % 4] for i, octet in enumerate(payload))
+
+        async def handle_conn(reader, writer):
+            request = (await reader.readuntil(b"\r\n\r\n")).decode("latin_1")
+            requests.append(request)
+            key = [
+                line.split(": ")[1]
+                for line in request.split("\r\n")
+                if line.startswith("Sec-WebSocket-Key")
+            ][0]
+            accept = base64.b64encode(
+                hashlib.sha1(key.encode() + b"258EAFA5-E914-47DA-95CA-C5AB0DC85B11").digest()
+            )
+            writer.write(
+                b"HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"
+                + b"Sec-WebSocket-Accept: "
+                + accept
+                + b"\r\n\r\n"
+            )
+            # a ping, that should be answered with a pong
+            writer.write(b"\x89\x04ping")
+            messages.append(await read_frame(reader))
+
+            first, pdu = await read_frame(reader)
+            messages.append((first, pdu))
+            _, command_id, _, sequence_number = struct.unpack(">IIII", pdu[:16])
+            body = b"SMSC\x00"
+            bind_resp = (
+                struct.pack(">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number)
+                + body
+            )
+            # the bind response is fragmented into two frames; a binary frame and a final continuation frame.
+            writer.write(b"\x02\x0a" + bind_resp[:10] + b"\x80" + bytes([len(bind_resp) - 10]))
+            writer.write(bind_resp[10:])
+            await writer.drain()
+            # the close frame that naz sends when it closes the connection.
+            messages.append(await read_frame(reader))
+            writer.close()
+            smsc_done.set()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                bind_timeout=1.0,
+                dialer=naz.dialer.WebSocketDialer(
+                    path="/smpp?account=1", headers={"Authorization": "Bearer some-token"}
+                ),
+                logger=naz.log.SimpleLogger("test_websocket_dialer", level="CRITICAL"),
+            )
+            await cli.connect()
+            await cli.bind()
+            state = cli.current_session_state
+            cli.writer.close()
+            await asyncio.wait_for(smsc_done.wait(), timeout=1.0)
+            server.close()
+            await server.wait_closed()
+            return cli, state
+
+        cli, state = self._run(run())
+        self.assertEqual(state, naz.SmppSessionState.BOUND_TRX)
+        self.assertEqual(cli.smsc_system_id(), "SMSC")
+        self.assertTrue(requests[0].startswith("GET /smpp?account=1 HTTP/1.1\r\n"))
+        self.assertIn("Upgrade: websocket\r\n", requests[0])
+        self.assertIn("Authorization: Bearer some-token\r\n", requests[0])
+
+        # a final pong with the payload of the ping
+        self.assertEqual(messages[0], (0x8A, b"ping"))
+        # the bind_transceiver is sent whole, in one final binary frame.
+        first, pdu = messages[1]
+        self.assertEqual(first, 0x82)
+        self.assertEqual(struct.unpack(">II", pdu[:8]), (len(pdu), 0x00000009))
+        # a close frame with the `normal closure` status code
+        self.assertEqual(messages[2], (0x88, b"\x03\xe8"))
+
+    def test_websocket_dialer_rejected(self):
+        async def handle_conn(reader, writer):
+            await reader.readuntil(b"\r\n\r\n")
+            writer.write(b"HTTP/1.1 403 Forbidden\r\nContent-Length: 0\r\n\r\n")
+            await writer.drain()
+            writer.close()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            dialer = naz.dialer.WebSocketDialer()
+            with self.assertRaises(ConnectionError) as raised_exception:
+                await dialer.dial("127.0.0.1", server.sockets[0].getsockname()[1], None)
+            server.close()
+            await server.wait_closed()
+            return raised_exception.exception
+
+        error = self._run(run())
+        self.assertIn("HTTP/1.1 403 Forbidden", str(error))
+
+        with self.assertRaises(ValueError):
+            naz.dialer.WebSocketDialer(path="smpp")
+
+    def test_response_timeout(self):
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                body = await reader.readexactly(command_length - 16)
+                if b"ignore-me" in body:
+                    # a non-responsive SMSC
+                    continue
+                body = b"" if command_id in [0x00000006, 0x00000015] else b"SMSC\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number
+                    )
+                    + body
+                )
+                await writer.drain()
+
+        def submit_sm(short_message):
+            return naz.protocol.SubmitSM(
+                short_message=short_message,
+                log_id=short_message,
+                source_addr="254722111111",
+                destination_addr="254722999999",
+            )
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=5.0,
+                response_timeout=0.2,
+                window_size=2,
+                logger=naz.log.SimpleLogger("test_response_timeout", level="CRITICAL"),
+            )
+            await cli.connect()
+            await cli.bind()
+            receiver = asyncio.ensure_future(cli.receive_data())
+            started = time.monotonic()
+            results = await asyncio.gather(
+                cli.submit_message(submit_sm("ignore-me")),
+                cli.submit_message(submit_sm("hello")),
+                return_exceptions=True,
+            )
+            duration = time.monotonic() - started
+            window = dict(cli._window)
+            receiver.cancel()
+            cli.writer.close()
+            server.close()
+            await server.wait_closed()
+            return results, duration, window
+
+        results, duration, window = self._run(run())
+        self.assertIsInstance(results[0], asyncio.TimeoutError)
+        self.assertEqual(results[1], ["SMSC"])
+        self.assertTrue(duration < 1.0, duration)
+        # the request that timed out no longer takes up space in the window.
+        self.assertEqual(window, {})
+
+    def test_events(self):
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                body = b"" if command_id in [0x00000006, 0x00000015] else b"SMSC\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number
+                    )
+                    + body
+                )
+                await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                drain_duration=1.0,
+                logger=naz.log.SimpleLogger("test_events", level="CRITICAL"),
+            )
+            events = cli.events()
+            self.assertIs(cli.events(), events)
+            await cli.connect()
+            await cli.bind()
+            # the bind_transceiver_resp
+            await cli.receive_data(TESTING=True)
+            receiver = asyncio.ensure_future(cli.receive_data())
+            await cli.submit_message(
+                naz.protocol.SubmitSM(
+                    short_message="hello",
+                    log_id="submit-log-id",
+                    source_addr="254722111111",
+                    destination_addr="254722999999",
+                )
+            )
+            await cli.shutdown()
+            receiver.cancel()
+            server.close()
+            await server.wait_closed()
+            received = []
+            while not events.empty():
+                received.append(events.get_nowait())
+            return received
+
+        received = self._run(run())
+        self.assertEqual(
+            [(event.kind, getattr(event, "smpp_command", "")) for event in received],
+            [
+                ("connected", ""),
+                ("pdu_sent", naz.SmppCommand.BIND_TRANSCEIVER),
+                ("bound", ""),
+                ("pdu_received", naz.SmppCommand.BIND_TRANSCEIVER_RESP),
+                ("pdu_sent", naz.SmppCommand.SUBMIT_SM),
+                ("pdu_received", naz.SmppCommand.SUBMIT_SM_RESP),
+                ("pdu_sent", naz.SmppCommand.UNBIND),
+                ("pdu_received", naz.SmppCommand.UNBIND_RESP),
+                ("disconnected", ""),
+            ],
+        )
+        self.assertEqual(
+            received[2], naz.events.Bound(session_state=naz.SmppSessionState.BOUND_TRX)
+        )
+        self.assertEqual(
+            (received[4].log_id, received[5].log_id), ("submit-log-id", "submit-log-id")
+        )
+        self.assertEqual(received[5].command_status, "ESME_ROK")
+
+    def test_events_are_dropped(self):
+        # events are not emitted until someone asks for them.
+        self.cli._emit_event(naz.events.Disconnected())
+        self.assertEqual(self.cli.dropped_events(), 0)
+
+        events = self.cli.events(maxsize=2)
+        for _ in range(0, 5):
+            self.cli._emit_event(naz.events.Disconnected())
+        self.assertEqual(events.qsize(), 2)
+        self.assertEqual(self.cli.dropped_events(), 3)
+        with self.assertRaises(ValueError):
+            self.cli.events(maxsize=0)
+
+    def test_unexpected_responses(self):
+        class CapturingHandler(logging.Handler):
+            def __init__(self):
+                super(CapturingHandler, self).__init__()
+                self.records = []
+
+            def emit(self, record):
+                self.records.append(record)
+
+        unexpected = []
+
+        class Hook(naz.hooks.SimpleHook):
+            async def unexpected_response(self, smpp_command, sequence_number, status, pdu):
+                unexpected.append((smpp_command, sequence_number, status.code))
+
+        def client(unexpected_responses):
+            handler = CapturingHandler()
+            logger = logging.Logger("test_unexpected_responses", level=logging.WARNING)
+            logger.addHandler(handler)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=2775,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                hook=Hook(),
+                logger=logger,
+                unexpected_responses=unexpected_responses,
+            )
+            # a late submit_sm_resp; naz did not send a submit_sm with this sequence_number.
+            self._run(
+                cli._parse_response_pdu(
+                    b"\x00\x00\x00\x15\x80\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00\x2aabcd\x00"
+                )
+            )
+            return cli, [
+                record.msg
+                for record in handler.records
+                if record.msg.get("state")
+                == "the response does not match any request that is awaiting a response"
+            ]
+
+        cli, logged = client(naz.UnexpectedResponse.IGNORE)
+        self.assertEqual(cli.stats().unexpected_responses, 1)
+        self.assertEqual((logged, unexpected), ([], []))
+
+        cli, logged = client(naz.UnexpectedResponse.LOG)
+        self.assertEqual(cli.stats().unexpected_responses, 1)
+        self.assertEqual(
+            [(msg["sequence_number"], msg["command_status"]) for msg in logged], [(42, "ESME_ROK")]
+        )
+        self.assertEqual(unexpected, [])
+
+        cli, logged = client(naz.UnexpectedResponse.HOOK)
+        self.assertEqual(cli.stats().unexpected_responses, 1)
+        self.assertEqual(logged, [])
+        self.assertEqual(unexpected, [(naz.SmppCommand.SUBMIT_SM_RESP, 42, "ESME_ROK")])
+
+        # the response to a request that is awaiting one is not unexpected.
+        cli._window[42] = time.monotonic()
+        self._run(
+            cli._parse_response_pdu(
+                b"\x00\x00\x00\x15\x80\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00\x2aabcd\x00"
+            )
+        )
+        self.assertEqual(cli.stats().unexpected_responses, 1)
+        self.assertEqual(len(unexpected), 1)
+
+        with self.assertRaises(naz.client.NazClientError):
+            client("DROP")
+
+    def test_errors(self):
+        # errors are not emitted until someone asks for them.
+        self._run(self.cli._parse_response_pdu(pdu=b"\x00\x00\x00\x10\x80\x00"))
+        self.assertEqual(self.cli.dropped_errors(), 0)
+
+        errors = self.cli.errors()
+        # a malformed PDU, a PDU whose command_id is unknown and an uncorrelated submit_sm_resp
+        self._run(self.cli._parse_response_pdu(pdu=b"\x00\x00\x00\x10\x80\x00"))
+        self._run(
+            self.cli._parse_response_pdu(
+                pdu=b"\x00\x00\x00\x10\x00\x00\x00\x99\x00\x00\x00\x00\x00\x00\x00\x03"
+            )
+        )
+        self._run(
+            self.cli._parse_response_pdu(
+                pdu=b"\x00\x00\x00\x15\x80\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00\x09abcd\x00"
+            )
+        )
+        received = []
+        while not errors.empty():
+            received.append(errors.get_nowait())
+        self.assertEqual(
+            [(error.kind, error.sequence_number) for error in received],
+            [
+                (naz.client.NazReadError.MALFORMED_PDU, None),
+                (naz.client.NazReadError.UNKNOWN_COMMAND_ID, 3),
+                (naz.client.NazReadError.CORRELATION_MISS, 9),
+            ],
+        )
+        self.assertIsInstance(received[0], naz.client.NazReadError)
+        self.assertIn("`reserved_list_e` has not been implemented", str(received[1]))
+
+        # errors that are not consumed are dropped.
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password="password",
+            broker=naz.broker.SimpleBroker(maxsize=100),
+            logger=naz.log.SimpleLogger("test_errors", level="CRITICAL"),
+        )
+        errors = cli.errors(maxsize=1)
+        for _ in range(0, 3):
+            self._run(cli._parse_response_pdu(pdu=b"\x00\x00\x00\x10\x80\x00"))
+        self.assertEqual(errors.qsize(), 1)
+        self.assertEqual(cli.dropped_errors(), 2)
+        with self.assertRaises(ValueError):
+            cli.errors(maxsize=0)
+
+    def test_interface_version(self):
+        binds = []
+
+        async def handle_conn(reader, writer):
+            header = await reader.readexactly(16)
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            binds.append(await reader.readexactly(command_length - 16))
+            # system_id followed by the sc_interface_version optional parameter
+            body = b"SMSC\x00" + naz.TLV(tag=0x0210, value=b"\x50").tlv
+            writer.write(
+                struct.pack(">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number)
+                + body
+            )
+            await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password="password",
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                interface_version=naz.InterfaceVersion.V50,
+                logger=naz.log.SimpleLogger("test_interface_version", level="CRITICAL"),
+            )
+            before = (cli.smsc_system_id(), cli.smsc_interface_version())
+            await cli.connect()
+            await cli.bind()
+            await cli.receive_data(TESTING=True)
+            cli.writer.close()
+            server.close()
+            await server.wait_closed()
+            return before, (cli.smsc_system_id(), cli.smsc_interface_version())
+
+        before, after = self._run(run())
+        self.assertEqual(before, ("", None))
+        self.assertEqual(after, ("SMSC", 0x50))
+        # interface_version follows system_id, password and system_type
+        self.assertEqual(binds[0][len(b"smppclient1\x00password\x00\x00")], 0x50)
+        self.assertEqual(self.cli.interface_version, naz.InterfaceVersion.V34)
+
+    def test_submit_batch(self):
+        received = []
+
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                command_status = 0
+                body = b"" if command_id in [0x00000006, 0x00000015] else b"SMSC\x00"
+                if command_id == 0x00000004:
+                    received.append(sequence_number)
+                    body = "id-{0}\x00".format(len(received)).encode("ascii")
+                    if len(received) == 7:
+                        # ESME_RINVDSTADR
+                        command_status, body = 0x0000000B, b""
+                writer.write(
+                    struct.pack(
+                        ">IIII",
+                        16 + len(body),
+                        0x80000000 | command_id,
+                        command_status,
+                        sequence_number,
+                    )
+                    + body
+                )
+                await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                window_size=10,
+                logger=naz.log.SimpleLogger("test_submit_batch", level="CRITICAL"),
+            )
+            await cli.connect()
+            await cli.bind()
+            receiver = asyncio.ensure_future(cli.receive_data())
+            results = await cli.submit_batch(
+                [
+                    naz.protocol.SubmitSM(
+                        short_message="Hello World-{0}".format(i),
+                        log_id="log_id-{0}".format(i),
+                        source_addr="254722111111",
+                        destination_addr="254722999999",
+                    )
+                    for i in range(0, 100)
+                ]
+            )
+            receiver.cancel()
+            cli.writer.close()
+            server.close()
+            await server.wait_closed()
+            return results
+
+        results = self._run(run())
+        self.assertEqual(len(received), 100)
+        self.assertEqual(
+            [result.log_id for result in results], ["log_id-{0}".format(i) for i in range(0, 100)]
+        )
+        failed = [result for result in results if result.error is not None]
+        self.assertEqual(len(failed), 1)
+        self.assertIsInstance(failed[0].error, naz.client.NazCommandStatusError)
+        self.assertEqual(failed[0].message_ids, [])
+        message_ids = [result.message_ids[0] for result in results if result.error is None]
+        self.assertEqual(len(set(message_ids)), 99)
+
+    def test_submit_batch_cancelled(self):
+        async def run():
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=TestClient.smsc_port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                logger=naz.log.SimpleLogger("test_submit_batch_cancelled", level="CRITICAL"),
+            )
+            submitted, cancelled = [], []
+
+            async def submit_message(proto_msg):
+                submitted.append(proto_msg)
+                try:
+                    # SMSC never responds
+                    await asyncio.sleep(10)
+                except asyncio.CancelledError:
+                    cancelled.append(proto_msg)
+                    raise
+
+            cli.submit_message = submit_message
+            batch = asyncio.ensure_future(
+                cli.submit_batch(
+                    [
+                        naz.protocol.SubmitSM(
+                            short_message="hello",
+                            log_id="log_id-{0}".format(i),
+                            source_addr="254722111111",
+                            destination_addr="254722999999",
+                        )
+                        for i in range(0, 3)
+                    ]
+                )
+            )
+            await asyncio.sleep(0.05)
+            batch.cancel()
+            with self.assertRaises(asyncio.CancelledError):
+                await batch
+            # give the cancelled submissions a chance to run.
+            await asyncio.sleep(0)
+            return submitted, cancelled
+
+        submitted, cancelled = self._run(run())
+        self.assertEqual(len(submitted), 3)
+        self.assertEqual(cancelled, submitted)
+
+    def _bind_with_timeout(self, bind_resp_status):
+        """
+        binds, with a bind_timeout, to a mock SMSC that responds to the bind with `bind_resp_status` or, if it is None, does not respond.
+        """
+
+        async def handle_conn(reader, writer):
+            header = await reader.readexactly(16)
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            await reader.readexactly(command_length - 16)
+            if bind_resp_status is not None:
+                body = b"SMSC\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII",
+                        16 + len(body),
+                        0x80000000 | command_id,
+                        bind_resp_status,
+                        sequence_number,
+                    )
+                    + body
+                )
+                await writer.drain()
+            await asyncio.sleep(2)
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=5.0,
+                bind_timeout=0.2,
+                logger=naz.log.SimpleLogger("test_bind_timeout", level="CRITICAL"),
+            )
+            await cli.connect()
+            started = time.monotonic()
+            error = None
+            try:
+                await cli.bind()
+            except Exception as e:
+                error = e
+            duration = time.monotonic() - started
+            if cli.writer is not None:
+                cli.writer.close()
+            server.close()
+            await server.wait_closed()
+            return cli, error, duration
+
+        return self._run(run())
+
+    def test_bind_timeout(self):
+        cli, error, duration = self._bind_with_timeout(bind_resp_status=None)
+        self.assertIsInstance(error, naz.client.NazBindTimeoutError)
+        self.assertIsInstance(error, naz.client.NazConnectionError)
+        self.assertIn("bind timed out", str(error))
+        self.assertTrue(0.2 <= duration < 1.0, duration)
+        # the connection is closed
+        self.assertIsNone(cli.writer)
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.CLOSED)
+
+        cli, error, _ = self._bind_with_timeout(bind_resp_status=0x00000000)
+        self.assertIsNone(error)
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.BOUND_TRX)
+
+        # ESME_RINVPASWD
+        cli, error, _ = self._bind_with_timeout(bind_resp_status=0x0000000E)
+        self.assertIsInstance(error, naz.client.NazCommandStatusError)
+        self.assertTrue(error.is_status(naz.SmppCommandStatus.ESME_RINVPASWD))
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.CLOSED)
+
+    def test_bind_retries(self):
+        def bind(bind_resp_statuses):
+            """
+            binds to a mock SMSC that responds to the bind on each connection with the next of `bind_resp_statuses`
+            """
+            binds = []
+
+            async def handle_conn(reader, writer):
+                header = await reader.readexactly(16)
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                binds.append(command_id)
+                body = b"SMSC\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII",
+                        16 + len(body),
+                        0x80000000 | command_id,
+                        bind_resp_statuses[len(binds) - 1],
+                        sequence_number,
+                    )
+                    + body
+                )
+                await writer.drain()
+                await asyncio.sleep(2)
+
+            async def run():
+                server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+                cli = naz.Client(
+                    smsc_host="127.0.0.1",
+                    smsc_port=server.sockets[0].getsockname()[1],
+                    system_id="smppclient1",
+                    password=os.getenv("password", "password"),
+                    broker=naz.broker.SimpleBroker(maxsize=100),
+                    socket_timeout=5.0,
+                    bind_timeout=0.5,
+                    bind_retries=2,
+                    reconnect_initial_interval=0.01,
+                    logger=naz.log.SimpleLogger("test_bind_retries", level="CRITICAL"),
+                )
+                await cli.connect()
+                error = None
+                try:
+                    await cli.bind()
+                except Exception as e:
+                    error = e
+                if cli.writer is not None:
+                    cli.writer.close()
+                server.close()
+                await server.wait_closed()
+                return cli, error, binds
+
+            return self._run(run())
+
+        # ESME_RALYBND, then success
+        cli, error, binds = bind([0x00000005, 0x00000000])
+        self.assertIsNone(error)
+        self.assertEqual(binds, [0x00000009, 0x00000009])
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.BOUND_TRX)
+
+        # ESME_RBINDFAIL on each attempt; the bind is attempted 1 + bind_retries times.
+        cli, error, binds = bind([0x0000000D, 0x0000000D, 0x0000000D])
+        self.assertIsInstance(error, naz.client.NazCommandStatusError)
+        self.assertTrue(error.is_status(naz.SmppCommandStatus.ESME_RBINDFAIL))
+        self.assertEqual(len(binds), 3)
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.CLOSED)
+
+        # ESME_RINVPASWD is not retried
+        cli, error, binds = bind([0x0000000E, 0x00000000])
+        self.assertIsInstance(error, naz.client.NazCommandStatusError)
+        self.assertTrue(error.is_status(naz.SmppCommandStatus.ESME_RINVPASWD))
+        self.assertEqual(len(binds), 1)
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.CLOSED)
+
+        with self.assertRaises(naz.client.NazClientError) as raised:
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=2775,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                bind_retries=2,
+            )
+        self.assertIn("`bind_retries` requires `bind_timeout`", str(raised.exception))
+
+    def test_reassembly(self):
+        received = []
+
+        async def handler(message):
+            received.append(message)
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            pass
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=naz.broker.SimpleBroker(maxsize=100),
+            logger=naz.log.SimpleLogger("TestClient.test_reassembly", level="CRITICAL"),
+            reassembly_timeout=5.0,
+        )
+        cli.on_deliver_sm(handler)
+        parts = ["hello ", "world, ", "how are you?"]
+
+        async def receive_out_of_order():
+            for part_number in [3, 1, 2]:
+                # UDHL, IEI(8-bit reference number), IEDL, reference number, total parts, part number
+                udh = struct.pack(">BBBBBB", 0x05, 0x00, 0x03, 0x2A, 3, part_number)
+                await cli._parse_response_pdu(
+                    self._deliver_sm_pdu(
+                        udh + parts[part_number - 1].encode(),
+                        esm_class=0b01000000,
+                        sequence_number=part_number,
+                    )
+                )
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            self._run(receive_out_of_order())
+
+        self.assertEqual(len(received), 1)
+        self.assertEqual(received[0].short_message, "hello world, how are you?")
+        self.assertEqual(received[0].raw_short_message, b"hello world, how are you?")
+        self.assertEqual(received[0].esm_class, 0)
+        self.assertFalse(received[0].incomplete)
+        self.assertEqual(cli._fragments, {})
+        self.assertEqual(cli._fragment_timers, {})
+
+    def test_reassembly_ucs2(self):
+        received = []
+
+        async def handler(message):
+            received.append(message)
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            pass
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=naz.broker.SimpleBroker(maxsize=100),
+            logger=naz.log.SimpleLogger("TestClient.test_reassembly_ucs2", level="CRITICAL"),
+            reassembly_timeout=5.0,
+        )
+        cli.on_deliver_sm(handler)
+        parts = ["Привет, ", "мир 😀"]
+        part_pdus = []
+        for part_number in [1, 2]:
+            # UDHL, IEI(16-bit reference number), IEDL, reference number, total parts, part number
+            udh = struct.pack(">BBBHBB", 0x06, 0x08, 0x04, 0x1234, 2, part_number)
+            part_pdus.append(
+                self._deliver_sm_pdu(
+                    udh + parts[part_number - 1].encode("utf_16_be"),
+                    esm_class=0b01000000,
+                    data_coding=0x08,
+                    sequence_number=part_number,
+                )
+            )
+
+        # each part decodes on its own, without its UDH.
+        for part_number, pdu in enumerate(part_pdus, start=1):
+            part = naz.protocol.DeliverSM._from_pdu(pdu, log_id="")
+            self.assertEqual(part.short_message, parts[part_number - 1])
+
+        async def receive():
+            for pdu in part_pdus:
+                await cli._parse_response_pdu(pdu)
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            self._run(receive())
+
+        self.assertEqual(len(received), 1)
+        self.assertEqual(received[0].short_message, "Привет, мир 😀")
+        self.assertEqual(received[0].raw_short_message, "Привет, мир 😀".encode("utf_16_be"))
+        self.assertEqual(received[0].esm_class, 0)
+        self.assertEqual(cli._fragments, {})
+
+    def test_reassembly_timeout(self):
+        received = []
+
+        async def handler(message):
+            received.append(message)
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            pass
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=naz.broker.SimpleBroker(maxsize=100),
+            logger=naz.log.SimpleLogger("TestClient.test_reassembly_timeout", level="CRITICAL"),
+            reassembly_timeout=0.1,
+        )
+        cli.on_deliver_sm(handler)
+
+        def sar_part(reference_number, part_number, short_message):
+            pdu = self._deliver_sm_pdu(short_message, sequence_number=part_number)
+            sar_tlvs = (
+                naz.TLV(tag=0x020C, value=struct.pack(">H", reference_number)).tlv
+                + naz.TLV(tag=0x020E, value=b"\x03").tlv
+                + naz.TLV(tag=0x020F, value=struct.pack(">B", part_number)).tlv
+            )
+            return struct.pack(">I", len(pdu) + len(sar_tlvs)) + pdu[4:] + sar_tlvs
+
+        async def receive_with_missing_part():
+            await cli._parse_response_pdu(sar_part(500, 3, b"you?"))
+            await cli._parse_response_pdu(sar_part(500, 1, b"hello "))
+            # a message that is not concatenated is not buffered.
+            await cli._parse_response_pdu(self._deliver_sm_pdu(b"hi"))
+            self.assertEqual([m.short_message for m in received], ["hi"])
+            await asyncio.sleep(0.3)
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            self._run(receive_with_missing_part())
+
+        self.assertEqual(len(received), 2)
+        self.assertEqual(received[1].short_message, "hello you?")
+        self.assertTrue(received[1].incomplete)
+        self.assertEqual(received[1].optional_params, [naz.TLV(tag=0x1400, value=b"vendor")])
+        self.assertEqual(cli._fragments, {})
+
+    def test_address_range(self):
+        for bind_mode in [naz.BindMode.RECEIVER, naz.BindMode.TRANSCEIVER]:
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=2775,
+                system_id="smppclient1",
+                password="password",
+                system_type="VMA",
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                logger=naz.log.SimpleLogger("TestClient.test_address_range", level="CRITICAL"),
+                bind_mode=bind_mode,
+                addr_ton=0x03,
+                addr_npi=0x00,
+                address_range="40404",
+            )
+            with mock.patch("naz.Client.send_data", new=AsyncMock()) as mock_send_data:
+                self._run(cli.bind())
+                pdu = mock_send_data.mock.call_args[1]["msg"]
+                # system_id, password, system_type, interface_version, addr_ton, addr_npi, address_range
+                self.assertEqual(
+                    pdu[16:], b"smppclient1\x00password\x00VMA\x00\x34\x03\x0040404\x00"
+                )
+
+        for address_range in ["4" * 41, "4040é"]:
+            with self.assertRaises(naz.client.NazClientError) as raised_exception:
+                naz.Client(
+                    smsc_host="127.0.0.1",
+                    smsc_port=2775,
+                    system_id="smppclient1",
+                    password="password",
+                    broker=naz.broker.SimpleBroker(maxsize=100),
+                    address_range=address_range,
+                )
+            self.assertIn("should be at most 40 ascii characters", str(raised_exception.exception))
+
+    def test_system_type(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password="password",
+            system_type="SMPP",
+            broker=naz.broker.SimpleBroker(maxsize=100),
+            logger=naz.log.SimpleLogger("TestClient.test_system_type", level="CRITICAL"),
+        )
+        with mock.patch("naz.Client.send_data", new=AsyncMock()) as mock_send_data:
+            self._run(cli.bind())
+            pdu = mock_send_data.mock.call_args[1]["msg"]
+            self.assertEqual(pdu[16:], b"smppclient1\x00password\x00SMPP\x00\x34\x00\x00\x00")
+
+        # the longest system_type is 12 characters; 13 octets with its NULL terminator.
+        for system_type, ok in [("S" * 12, True), ("S" * 13, False), ("SMPPé", False)]:
+            kwargs = dict(
+                smsc_host="127.0.0.1",
+                smsc_port=2775,
+                system_id="smppclient1",
+                password="password",
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                system_type=system_type,
+            )
+            if ok:
+                naz.Client(**kwargs)
+                continue
+            with self.assertRaises(naz.client.NazClientError) as raised_exception:
+                naz.Client(**kwargs)
+            self.assertIn("should be at most 12 ascii characters", str(raised_exception.exception))
+
+    def test_concurrent_submits(self):
+        received = []
+        written = []
+
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                pdu_body = await reader.readexactly(command_length - 16)
+                body = b"" if command_id in [0x00000006, 0x00000015] else b"SMSC\x00"
+                if command_id == 0x00000004:
+                    received.append(header + pdu_body)
+                    body = "id-{0}\x00".format(sequence_number).encode("ascii")
+                writer.write(
+                    struct.pack(
+                        ">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number
+                    )
+                    + body
+                )
+                await writer.drain()
+
+        def on_write(pdu):
+            if struct.unpack(">I", pdu[4:8])[0] == 0x00000004:
+                written.append(pdu)
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=5.0,
+                on_write=on_write,
+                logger=naz.log.SimpleLogger("test_concurrent_submits", level="CRITICAL"),
+            )
+            await cli.connect()
+            await cli.bind()
+            receiver = asyncio.ensure_future(cli.receive_data())
+            results = await asyncio.gather(
+                *[
+                    cli.submit_message(
+                        naz.protocol.SubmitSM(
+                            short_message="Hello World-{0}".format(i),
+                            log_id="log_id-{0}".format(i),
+                            source_addr="254722111111",
+                            destination_addr="254722999999",
+                        )
+                    )
+                    for i in range(0, 200)
+                ]
+            )
+            receiver.cancel()
+            cli.writer.close()
+            server.close()
+            await server.wait_closed()
+            return results
+
+        results = self._run(run())
+        self.assertEqual(len(received), 200)
+        # every PDU arrived whole, and in the order that it was written.
+        self.assertEqual(received, written)
+        sequence_numbers = [struct.unpack(">I", pdu[12:16])[0] for pdu in received]
+        self.assertEqual(len(set(sequence_numbers)), 200)
+        # each submit got the response to its own sequence_number.
+        self.assertEqual(
+            sorted(message_ids[0] for message_ids in results),
+            sorted("id-{0}".format(sequence_number) for sequence_number in sequence_numbers),
+        )
+        for pdu in received:
+            sequence_number = struct.unpack(">I", pdu[12:16])[0]
+            index = int(pdu.split(b"Hello World-")[1])
+            self.assertEqual(results[index], ["id-{0}".format(sequence_number)])
+
+    def test_broker_acknowledge(self):
+        acknowledged = []
+
+        class Broker(naz.broker.SimpleBroker):
+            async def acknowledge(self, log_id):
+                acknowledged.append(log_id)
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=Broker(),
+            logger=naz.log.SimpleLogger("TestClient.test_broker_acknowledge", level="CRITICAL"),
+            split_long_messages=True,
+        )
+        sent_pdus = []
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+            return True
+
+        def send(proto_msg):
+            sent_pdus.clear()
+            with mock.patch("naz.Client.send_data", new=mock_send_data):
+                self._run(cli.broker.enqueue(proto_msg))
+                cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+                self._run(cli.dequeue_messages(TESTING=True))
+            return [struct.unpack(">I", pdu[12:16])[0] for pdu in sent_pdus]
+
+        def respond(sequence_number, command_id=0x80000004, command_status=0x00000000):
+            body = b"msg-id\x00" if command_status == 0 else b""
+            header = struct.pack(
+                ">IIII", 16 + len(body), command_id, command_status, sequence_number
+            )
+            self._run(cli._parse_response_pdu(header + body))
+
+        def msg(log_id, short_message="hello"):
+            return naz.protocol.SubmitSM(
+                short_message=short_message,
+                log_id=log_id,
+                source_addr="2547000000",
+                destination_addr="254711999999",
+            )
+
+        respond(*send(msg("log_id-1")))
+        # a response with an error is acknowledged too; SMSC has seen the message.
+        respond(*send(msg("log_id-2")), command_id=0x80000103, command_status=0x0000000B)
+        self.assertEqual(acknowledged, ["log_id-1", "log_id-2"])
+
+        # a message that is split into parts is acknowledged once SMSC has responded to all of them.
+        parts = send(msg("log_id-3", short_message="a" * 300))
+        self.assertEqual(len(parts), 2)
+        respond(parts[1])
+        self.assertEqual(acknowledged, ["log_id-1", "log_id-2"])
+        respond(parts[0])
+        self.assertEqual(acknowledged, ["log_id-1", "log_id-2", "log_id-3"])
+
+        # SMSC does not respond to a deliver_sm_resp; it is acknowledged once it is written.
+        send(naz.protocol.DeliverSmResp(log_id="log_id-4", message_id="", sequence_number=7))
+        self.assertEqual(acknowledged[-1], "log_id-4")
+
+        # a response that is not for a message dequeued from the broker is not acknowledged.
+        respond(99)
+        self.assertEqual(len(acknowledged), 4)
+
+    def test_retry_policy(self):
+        received = []
+        delivered = asyncio.Event()
+        retries = []
+
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                pdu_body = await reader.readexactly(command_length - 16)
+                body, command_status = b"SMSC\x00", 0x00000000
+                if command_id == 0x00000004:
+                    received.append(pdu_body)
+                    body = b""
+                    if b"permanent" in pdu_body:
+                        command_status = naz.SmppCommandStatus.ESME_RINVDSTADR.value
+                    elif len(received) <= 3:
+                        command_status = naz.SmppCommandStatus.ESME_RMSGQFUL.value
+                    else:
+                        body = b"msg-id\x00"
+                        delivered.set()
+                writer.write(
+                    struct.pack(
+                        ">IIII",
+                        16 + len(body),
+                        0x80000000 | command_id,
+                        command_status,
+                        sequence_number,
+                    )
+                    + body
+                )
+                await writer.drain()
+
+        class Hook(naz.hooks.SimpleHook):
+            async def retrying(self, smpp_command, log_id, hook_metadata, status, attempt):
+                retries.append((smpp_command, log_id, hook_metadata, status.code, attempt))
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=5.0,
+                hook=Hook(),
+                retry_policy=naz.retry.SimpleRetryPolicy(
+                    max_attempts=3, initial_interval=0.01, jitter=0.0
+                ),
+                logger=naz.log.SimpleLogger("test_retry_policy", level="CRITICAL"),
+            )
+            await cli.connect()
+            await cli.tranceiver_bind()
+            tasks = [
+                asyncio.ensure_future(cli.receive_data()),
+                asyncio.ensure_future(cli.dequeue_messages()),
+            ]
+            await cli.send_message(
+                naz.protocol.SubmitSM(
+                    short_message="permanent",
+                    log_id="log_id-1",
+                    source_addr="254722111111",
+                    destination_addr="254722999999",
+                )
+            )
+            await cli.send_message(
+                naz.protocol.SubmitSM(
+                    short_message="temporary",
+                    log_id="log_id-2",
+                    hook_metadata="meta",
+                    source_addr="254722111111",
+                    destination_addr="254722999999",
+                )
+            )
+            await asyncio.wait_for(delivered.wait(), 5)
+            await asyncio.sleep(0.05)
+            for task in tasks:
+                task.cancel()
+            cli.writer.close()
+            server.close()
+            await server.wait_closed()
+            return cli
+
+        cli = self._run(run())
+        # the permanent failure is not re-submitted, the temporary one is; until it is delivered.
+        self.assertEqual(
+            [b"permanent" in pdu_body for pdu_body in received], [True, False, False, False]
+        )
+        self.assertEqual(len(set(received[1:])), 1)
+        self.assertEqual(
+            retries,
+            [
+                ("submit_sm", "log_id-2", "meta", "ESME_RMSGQFUL", 2),
+                ("submit_sm", "log_id-2", "meta", "ESME_RMSGQFUL", 3),
+            ],
+        )
+        self.assertEqual(cli._retryable, {})
+        self.assertEqual(cli.broker.size(), 0)
+
+    def test_retry_policy_split_message(self):
+        acknowledged = []
+
+        class Broker(naz.broker.SimpleBroker):
+            async def acknowledge(self, log_id):
+                acknowledged.append(log_id)
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=Broker(maxsize=100),
+            split_long_messages=True,
+            retry_policy=naz.retry.SimpleRetryPolicy(
+                max_attempts=3, initial_interval=0.01, jitter=0.0
+            ),
+            window_size=10,
+            window_timeout=0.5,
+            logger=naz.log.SimpleLogger("test_retry_policy_split_message", level="CRITICAL"),
+        )
+        sent_pdus = []
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+            return True
+
+        def respond(pdu, command_status):
+            body = b"msg-id\x00" if command_status == 0 else b""
+            # the response echoes the sequence_number of the request.
+            header = struct.pack(">III", 16 + len(body), 0x80000004, command_status) + pdu[12:16]
+            self._run(cli._parse_response_pdu(header + body))
+
+        async def retried():
+            # the failed part is re-enqueued, after the retry_policy's interval.
+            await asyncio.sleep(0.1)
+            await cli.dequeue_messages(TESTING=True)
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            self._run(
+                cli.send_message(
+                    naz.protocol.SubmitSM(
+                        short_message="a" * 300,
+                        log_id="log_id-1",
+                        source_addr="254722111111",
+                        destination_addr="254722999999",
+                    )
+                )
+            )
+            cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+            self._run(cli.dequeue_messages(TESTING=True))
+            first, second = sent_pdus
+            respond(first, naz.SmppCommandStatus.ESME_ROK.value)
+            respond(second, naz.SmppCommandStatus.ESME_RMSGQFUL.value)
+            self._run(retried())
+            self.assertEqual(len(sent_pdus), 3)
+            # only the second part is re-submitted; with the same UDH, and reference number.
+            self.assertEqual(sent_pdus[2][16:], second[16:])
+            self.assertNotEqual(sent_pdus[2][12:16], second[12:16])
+            # the message is acknowledged, now that each part was responded to or re-enqueued.
+            self.assertEqual(acknowledged, ["log_id-1"])
+            respond(sent_pdus[2], naz.SmppCommandStatus.ESME_ROK.value)
+            self.assertEqual(acknowledged, ["log_id-1", "log_id-1"])
+            self.assertEqual(cli._retryable, {})
+
+            # a request that SMSC does not respond to within window_timeout is not kept.
+            self._run(
+                cli.send_message(
+                    naz.protocol.SubmitSM(
+                        short_message="hello",
+                        log_id="log_id-2",
+                        source_addr="254722111111",
+                        destination_addr="254722999999",
+                    )
+                )
+            )
+            self._run(cli.dequeue_messages(TESTING=True))
+            self.assertEqual(len(cli._retryable), 1)
+            # `send_data`, which is mocked, would have taken up a slot in the window.
+            sequence_number = struct.unpack(">I", sent_pdus[-1][12:16])[0]
+            cli._window[sequence_number] = time.monotonic() - 1.0
+            cli._reclaim_window_slots(log_id="")
+            self.assertEqual(cli._retryable, {})
+
+    def test_simple_retry_policy(self):
+        policy = naz.retry.SimpleRetryPolicy(
+            max_attempts=4, initial_interval=1.0, max_interval=3.0, jitter=0.0
+        )
+        for command_status, attempt, expected in [
+            (naz.SmppCommandStatus.ESME_RTHROTTLED, 1, 1.0),
+            (naz.SmppCommandStatus.ESME_RMSGQFUL, 2, 2.0),
+            (naz.SmppCommandStatus.ESME_RSYSERR, 3, 3.0),
+            (naz.SmppCommandStatus.ESME_RSYSERR, 4, None),
+            (naz.SmppCommandStatus.ESME_RINVDSTADR, 1, None),
+        ]:
+            self.assertEqual(
+                policy.retry_after(
+                    smpp_command="submit_sm",
+                    command_status_value=command_status.value,
+                    attempt=attempt,
+                ),
+                expected,
+            )
+
+        policy = naz.retry.SimpleRetryPolicy(
+            retryable_statuses=[naz.SmppCommandStatus.RESERVED_LIST_I]
+        )
+        self.assertIsNotNone(policy.retry_after("submit_sm", 0x00000420, 1))
+        self.assertIsNone(policy.retry_after("submit_sm", 0x00000014, 1))
+
+        for kwargs in [dict(max_attempts=0), dict(initial_interval=2), dict(jitter=1.5)]:
+            with self.assertRaises(ValueError):
+                naz.retry.SimpleRetryPolicy(**kwargs)
+
+    def test_smsc_endpoints(self):
+        connections = {"rejecting": 0, "accepting": 0}
+
+        def smsc(name, command_status):
+            async def handle_conn(reader, writer):
+                connections[name] += 1
+                header = await reader.readexactly(16)
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                body = b"SMSC\x00" if command_status == 0 else b""
+                writer.write(
+                    struct.pack(
+                        ">IIII",
+                        16 + len(body),
+                        0x80000000 | command_id,
+                        command_status,
+                        sequence_number,
+                    )
+                    + body
+                )
+                await writer.drain()
+                await reader.read()
+
+            return handle_conn
+
+        async def run():
+            # nothing listens on the first endpoint; so it refuses connections.
+            sock = socket.socket()
+            sock.bind(("127.0.0.1", 0))
+            refusing_port = sock.getsockname()[1]
+            sock.close()
+            rejecting = await asyncio.start_server(
+                smsc("rejecting", naz.SmppCommandStatus.ESME_RBINDFAIL.value), "127.0.0.1", 0
+            )
+            accepting = await asyncio.start_server(smsc("accepting", 0), "127.0.0.1", 0)
+            rejecting_port = rejecting.sockets[0].getsockname()[1]
+            accepting_port = accepting.sockets[0].getsockname()[1]
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=refusing_port,
+                smsc_endpoints=[
+                    "127.0.0.1:{0}".format(rejecting_port),
+                    "127.0.0.1:{0}".format(accepting_port),
+                ],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=5.0,
+                bind_timeout=5.0,
+                reconnect_initial_interval=0.001,
+                logger=naz.log.SimpleLogger("test_smsc_endpoints", level="CRITICAL"),
+            )
+            results = []
+            # the first endpoint refuses the connection; so naz fails over to the second.
+            await cli.connect()
+            results.append((cli.current_session_state, cli.active_endpoint))
+            # the second endpoint rejects the bind; so naz fails over to the third.
+            with self.assertRaises(naz.client.NazCommandStatusError):
+                await cli.bind()
+            results.append((cli.current_session_state, cli.active_endpoint))
+            await cli._reconnect_until_bound(log_id="log_id")
+            results.append((cli.current_session_state, cli.active_endpoint))
+            cli.writer.close()
+            for server in [rejecting, accepting]:
+                server.close()
+                await server.wait_closed()
+            return results, refusing_port, rejecting_port, accepting_port
+
+        results, refusing_port, rejecting_port, accepting_port = self._run(run())
+        self.assertEqual(
+            results,
+            [
+                (naz.SmppSessionState.OPEN, ("127.0.0.1", rejecting_port)),
+                (naz.SmppSessionState.CLOSED, ("127.0.0.1", accepting_port)),
+                (naz.SmppSessionState.BOUND_TRX, ("127.0.0.1", accepting_port)),
+            ],
+        )
+        self.assertEqual(connections, {"rejecting": 1, "accepting": 1})
+
+    def test_smsc_endpoints_fail_over_on_disconnect(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            smsc_endpoints=["smsc-b:2776", "[::1]:2777"],
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=naz.broker.SimpleBroker(maxsize=100),
+            logger=naz.log.SimpleLogger("test_smsc_endpoints", level="CRITICAL"),
+        )
+        self.assertEqual(cli.active_endpoint, ("127.0.0.1", 2775))
+        # stop right after failing over, rather than trying to re-connect.
+        cli.SHOULD_SHUT_DOWN = True
+        for expected in [("smsc-b", 2776), ("::1", 2777), ("127.0.0.1", 2775)]:
+            # the connection to the endpoint that naz was bound to is lost; so it fails over to the next one.
+            cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+            self._run(cli._reconnect_until_bound(log_id="log_id"))
+            self.assertEqual(cli.active_endpoint, expected)
+        # it does not fail over again, since it did not bind to that endpoint.
+        self._run(cli._reconnect_until_bound(log_id="log_id"))
+        self.assertEqual(cli.active_endpoint, ("127.0.0.1", 2775))
+
+        for endpoints in [["smsc-b"], ["smsc-b:0"], [":2775"], ["smsc-b:port"], [2775]]:
+            with self.assertRaises(naz.client.NazClientError):
+                naz.Client(
+                    smsc_host="127.0.0.1",
+                    smsc_port=2775,
+                    smsc_endpoints=endpoints,
+                    system_id="smppclient1",
+                    password=os.getenv("password", "password"),
+                    broker=naz.broker.SimpleBroker(maxsize=100),
+                )
+
+    def _silent_smsc(self, **kwargs):
+        """
+        returns a coroutine function that binds a client to a mock SMSC, which goes silent right after the bind; it neither reads nor writes.
+        """
+        connections = []
+
+        async def handle_conn(reader, writer):
+            connections.append(writer)
+            header = await reader.readexactly(16)
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            await reader.readexactly(command_length - 16)
+            body = b"SMSC\x00"
+            writer.write(
+                struct.pack(">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number)
+                + body
+            )
+            await writer.drain()
+            await asyncio.sleep(10)
+
+        async def start():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=5.0,
+                logger=naz.log.SimpleLogger("test_silent_smsc", level="CRITICAL"),
+                **kwargs
+            )
+            await cli.connect()
+            await cli.bind()
+            return server, cli
+
+        return start, connections
+
+    def test_read_timeout(self):
+        start, connections = self._silent_smsc(read_timeout=0.2)
+
+        async def run():
+            server, cli = await start()
+            receiver = asyncio.ensure_future(cli.receive_data())
+            # an idle connection is not timed out.
+            await asyncio.sleep(0.5)
+            idle_alive = cli._connection_is_alive()
+
+            sent_at = time.monotonic()
+            await cli.send_data(
+                smpp_command=naz.SmppCommand.ENQUIRE_LINK,
+                msg=struct.pack(">IIII", 16, 0x00000015, 0, 7),
+                log_id="log_id",
+            )
+            while cli._connection_is_alive() and time.monotonic() - sent_at < 3.0:
+                await asyncio.sleep(0.01)
+            stalled_after = time.monotonic() - sent_at
+            receiver.cancel()
+            server.close()
+            return cli, idle_alive, stalled_after
+
+        cli, idle_alive, stalled_after = self._run(run())
+        self.assertTrue(idle_alive)
+        self.assertTrue(0.2 <= stalled_after < 1.0)
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.CLOSED)
+        self.assertEqual(len(connections), 1)
+
+    def test_write_timeout(self):
+        start, _ = self._silent_smsc(write_timeout=0.2)
+
+        async def run():
+            server, cli = await start()
+            sent_at = time.monotonic()
+            # bigger than the socket buffers; so the write can only complete if SMSC reads it.
+            # it is not ascii, so that it is not logged.
+            await cli.send_data(
+                smpp_command=naz.SmppCommand.SUBMIT_SM, msg=b"\xff" * (32 * 1024 * 1024), log_id=""
+            )
+            server.close()
+            return cli, time.monotonic() - sent_at
+
+        cli, elapsed = self._run(run())
+        self.assertTrue(elapsed < 1.0)
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.CLOSED)
+        self.assertTrue(cli.writer.transport.is_closing())
+
+        with self.assertRaises(naz.client.NazClientError):
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=2775,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                write_timeout=0.0,
+            )
+
+    def test_short_writes(self):
+        class ShortStreamWriter(MockStreamWriter):
+            """
+            a writer that writes atmost 5 octets at a time, and returns the number that it wrote.
+            """
+
+            def __init__(self, fail_after=None):
+                super(ShortStreamWriter, self).__init__()
+                self.written = b""
+                self.fail_after = fail_after
+                self.aborted = False
+
+            def write(self, data):
+                if self.fail_after is not None and len(self.written) >= self.fail_after:
+                    raise ConnectionResetError("connection reset by peer")
+                self.written = self.written + data[:5]
+                return len(data[:5])
+
+            def _create_transport(self, _is_closing):
+                transport = super(ShortStreamWriter, self)._create_transport(_is_closing)
+                writer = self
+
+                def abort():
+                    writer.aborted = True
+
+                transport.abort = abort
+                return transport
+
+        pdu = struct.pack(">IIII", 23, 0x00000004, 0, 1) + b"abcdefg"
+        self.cli.writer = ShortStreamWriter()
+        self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+        self._run(self.cli.send_data(smpp_command=naz.SmppCommand.SUBMIT_SM, msg=pdu, log_id="1"))
+        # the whole PDU was written.
+        self.assertEqual(self.cli.writer.written, pdu)
+        self.assertFalse(self.cli.writer.aborted)
+        self.assertEqual(self.cli.current_session_state, naz.SmppSessionState.BOUND_TRX)
+
+        # the connection fails midway through the PDU.
+        self.cli.writer = ShortStreamWriter(fail_after=10)
+        self._run(self.cli.send_data(smpp_command=naz.SmppCommand.SUBMIT_SM, msg=pdu, log_id="2"))
+        self.assertEqual(self.cli.writer.written, pdu[:10])
+        # the half-written PDU is not followed by any other; the connection is closed.
+        self.assertTrue(self.cli.writer.aborted)
+        self.assertEqual(self.cli.current_session_state, naz.SmppSessionState.CLOSED)
+
+    def test_retry_after(self):
+        self.assertEqual(self.cli._retry_after(current_retries=-23) / 60, 1)
+        self.assertEqual(self.cli._retry_after(current_retries=0) / 60, 1)
+        self.assertEqual(self.cli._retry_after(current_retries=1) / 60, 2)
+        self.assertEqual(self.cli._retry_after(current_retries=2) / 60, 4)
+        self.assertEqual(self.cli._retry_after(current_retries=3) / 60, 8)
+        self.assertEqual(self.cli._retry_after(current_retries=4) / 60, 16)
+        self.assertEqual(self.cli._retry_after(current_retries=5) / 60, 32)
+        self.assertEqual(self.cli._retry_after(current_retries=7) / 60, 16)
+        self.assertEqual(self.cli._retry_after(current_retries=5432) / 60, 16)
+
+    def test_session_state_ok(self):
+        """
+        send a `submit_sm` request when session state is `BOUND_TRX`
+        """
+        with mock.patch(
+            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
+        ) as mock_naz_dequeue, mock.patch("asyncio.streams.StreamWriter.write") as mock_naz_writer:
+            log_id = "12345"
+            short_message = "hello smpp"
+            mock_naz_dequeue.mock.return_value = naz.protocol.SubmitSM(
+                version=1,
+                log_id=log_id,
+                short_message=short_message,
+                smpp_command=naz.SmppCommand.SUBMIT_SM,
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                ms_validity=1,  # optional smpp param for submit_sm
+            )
+
+            self._run(self.cli.connect())
+            self._run(self.cli.tranceiver_bind())
+            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+            self._run(self.cli.dequeue_messages(TESTING=True))
+
+            self.assertTrue(mock_naz_writer.called)
+            self.assertEqual(mock_naz_writer.call_count, 2)
+            self.assertIn(short_message, mock_naz_writer.call_args[0][0].decode())
+
+    def test_broken_broker(self):
+        with mock.patch(
+            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
+        ) as mock_naz_dequeue, mock.patch("asyncio.streams.StreamWriter.write") as mock_naz_writer:
+            mock_naz_dequeue.mock.side_effect = ValueError("This test broker has 99 Problems")
+            self._run(self.cli.connect())
+            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+            res = self._run(self.cli.dequeue_messages(TESTING=True))
+            self.assertEqual(res, {"broker_error": "broker_error"})
+            self.assertFalse(mock_naz_writer.called)
+
+    def test_session_state(self):
+        """
+        try sending a `submit_sm` request when session state is `OPEN`
+        """
+        with mock.patch(
+            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
+        ) as mock_naz_dequeue, mock.patch("asyncio.streams.StreamWriter.write") as mock_naz_writer:
+            log_id = "12345"
+            short_message = "hello smpp"
+            mock_naz_dequeue.mock.return_value = naz.protocol.SubmitSM(
+                version=1,
+                log_id=log_id,
+                short_message=short_message,
+                smpp_command=naz.SmppCommand.SUBMIT_SM,
+                source_addr="2547000000",
+                destination_addr="254711999999",
+            )
+
+            self._run(self.cli.connect())
+            self.cli.current_session_state = naz.SmppSessionState.OPEN
+            self._run(self.cli.dequeue_messages(TESTING=True))
+            self.assertFalse(mock_naz_writer.called)
+
+    def test_submit_with_session_state_closed(self):
+        """
+        try sending a `submit_sm` request when session state is `CLOSED`
+        """
+        with mock.patch(
+            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
+        ) as mock_naz_dequeue, mock.patch(
+            "naz.client.asyncio.sleep", new=AsyncMock()
+        ) as mock_sleep:
+            log_id = "12345"
+            short_message = "hello smpp"
+            mock_naz_dequeue.mock.return_value = naz.protocol.SubmitSM(
+                version=1,
+                log_id=log_id,
+                short_message=short_message,
+                smpp_command=naz.SmppCommand.SUBMIT_SM,
+                source_addr="2547000000",
+                destination_addr="254711999999",
+            )
+            self._run(self.cli.dequeue_messages(TESTING=True))
+            self.assertTrue(mock_sleep.mock.called)
+            self.assertEqual(mock_sleep.mock.call_args[0][0], self.cli.socket_timeout)
+
+    def test_correlater_put_called(self):
+        with mock.patch(
+            "naz.correlater.SimpleCorrelater.put", new=AsyncMock()
+        ) as mock_correlater_put, mock.patch(
+            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
+        ) as mock_naz_dequeue:
+            log_id = "12345"
+            short_message = "hello smpp"
+            _hook_metadata = {"telco": "Verizon", "customer_id": "909090123"}
+            hook_metadata = json.dumps(_hook_metadata)
+            mock_naz_dequeue.mock.return_value = naz.protocol.SubmitSM(
+                version=1,
+                log_id=log_id,
+                short_message=short_message,
+                smpp_command=naz.SmppCommand.SUBMIT_SM,
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                hook_metadata=hook_metadata,
+            )
+
+            self._run(self.cli.connect())
+            # hack to allow sending submit_sm even when state is wrong
+            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+            self._run(self.cli.dequeue_messages(TESTING=True))
+            self.assertTrue(mock_correlater_put.mock.called)
+
+            self.assertEqual(mock_correlater_put.mock.call_args[1]["log_id"], log_id)
+            self.assertEqual(mock_correlater_put.mock.call_args[1]["hook_metadata"], hook_metadata)
+            self.assertEqual(
+                json.loads(mock_correlater_put.mock.call_args[1]["hook_metadata"]), _hook_metadata
+            )
+
+    def test_correlater_get_called(self):
+        with mock.patch(
+            "naz.correlater.SimpleCorrelater.get", new=AsyncMock()
+        ) as mock_correlater_get:
+            mock_correlater_get.mock.return_value = "log_id", "hook_metadata"
+            self._run(
+                self.cli._parse_response_pdu(
+                    pdu=b"\x00\x00\x00\x18\x80\x00\x00\t\x00\x00\x00\x00\x00\x00\x00\x06SMPPSim\x00"
+                )
+            )
+            self.assertTrue(mock_correlater_get.mock.called)
+            self.assertTrue(mock_correlater_get.mock.call_args[1]["sequence_number"])
+
+    def test_logger_called(self):
+        with mock.patch("naz.log.SimpleLogger.log") as mock_logger_log:
+            mock_logger_log.return_value = None
+            self._run(
+                self.cli._parse_response_pdu(
+                    pdu=b"\x00\x00\x00\x18\x80\x00\x00\t\x00\x00\x00\x00\x00\x00\x00\x06SMPPSim\x00"
+                )
+            )
+            self.assertTrue(mock_logger_log.called)
+            self.assertEqual(
+                mock_logger_log.call_args[0][1]["event"], "naz.Client._parse_response_pdu"
+            )
+
+    def test_decoded_hook(self):
+        class DecodingHook(naz.hooks.SimpleHook):
+            def __init__(self):
+                super(DecodingHook, self).__init__()
+                self.decoded = []
+
+            async def decoded_from_smsc(self, log_id, hook_metadata, decoded):
+                self.decoded.append(decoded)
+
+        hook = DecodingHook()
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            hook=hook,
+            logger=naz.log.SimpleLogger("test_decoded_hook", level="CRITICAL"),
+        )
+        self._run(
+            cli._parse_response_pdu(
+                pdu=b"\x00\x00\x00\x18\x80\x00\x00\t\x00\x00\x00\x00\x00\x00\x00\x06SMPPSim\x00"
+            )
+        )
+        # a PDU whose command_id is unknown is not dropped.
+        self._run(
+            cli._parse_response_pdu(
+                pdu=b"\x00\x00\x00\x12\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x07hi"
+            )
+        )
+        self.assertEqual(len(hook.decoded), 2)
+        self.assertIsInstance(hook.decoded[0], naz.pdu.BindResp)
+        self.assertEqual(hook.decoded[0].system_id, "SMPPSim")
+        self.assertEqual(hook.decoded[0].sequence_number, 6)
+        self.assertEqual(
+            hook.decoded[1],
+            naz.pdu.RawPDU(
+                command_id=0,
+                command_status=0,
+                sequence_number=7,
+                header=b"\x00\x00\x00\x12\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x07",
+                body=b"hi",
+            ),
+        )
+
+    def test_structured_logging(self):
+        class CapturingHandler(logging.Handler):
+            def __init__(self):
+                super(CapturingHandler, self).__init__()
+                self.records = []
+
+            def emit(self, record):
+                self.records.append(record)
+
+        handler = CapturingHandler()
+        logger = logging.Logger("test_structured_logging", level=logging.DEBUG)
+        logger.addHandler(handler)
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password="s3cr3t-pass",
+            broker=self.broker,
+            logger=logger,
+        )
+        # a bind_transceiver_resp with sequence_number 6 whose body happens to contain the password.
+        self._run(
+            cli._parse_response_pdu(
+                pdu=b"\x00\x00\x00\x1c\x80\x00\x00\t\x00\x00\x00\x00\x00\x00\x00\x06s3cr3t-pass\x00"
+            )
+        )
+
+        # records logged by naz.Client carry the structured log event.
+        records = [record for record in handler.records if hasattr(record, "log_data")]
+        self.assertTrue(records)
+        events = [
+            record.log_data
+            for record in records
+            if record.log_data["event"] == "naz.Client._parse_response_pdu"
+            and record.log_data["stage"] == "end"
+        ]
+        self.assertEqual(len(events), 1)
+        self.assertEqual(events[0]["smpp_command"], naz.SmppCommand.BIND_TRANSCEIVER_RESP)
+        self.assertEqual(events[0]["sequence_number"], 6)
+        self.assertEqual(events[0]["command_status"], 0)
+        self.assertIn("log_id", events[0])
+        for record in records:
+            self.assertEqual(record.client_id, cli.client_id)
+            self.assertEqual(record.smsc_host, "127.0.0.1")
+            self.assertNotIn("s3cr3t-pass", str(record.log_data))
+
+    def test_log_pdu_contents(self):
+        class CapturingHandler(logging.Handler):
+            def __init__(self):
+                super(CapturingHandler, self).__init__()
+                self.records = []
+
+            def emit(self, record):
+                self.records.append(record)
+
+        def pdu_contents(level):
+            handler = CapturingHandler()
+            logger = logging.Logger("test_log_pdu_contents", level=level)
+            logger.addHandler(handler)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=2775,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                logger=logger,
+                log_pdu_contents=5,
+            )
+            self._run(
+                self.broker.enqueue(
+                    naz.protocol.SubmitSM(
+                        log_id="log_id",
+                        short_message="my one time pin is 1234",
+                        source_addr="2547000000",
+                        destination_addr="254711999999",
+                    )
+                )
+            )
+            with mock.patch("naz.Client.send_data", new=AsyncMock()):
+                self._run(cli._parse_response_pdu(self._deliver_sm_pdu(b"hello world")))
+                cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+                self._run(cli.dequeue_messages(TESTING=True))
+            return [
+                record.log_data
+                for record in handler.records
+                if hasattr(record, "log_data") and record.log_data.get("state") == "pdu contents"
+            ]
+
+        received, sent = pdu_contents(logging.DEBUG)
+        self.assertEqual(received["smpp_command"], naz.SmppCommand.DELIVER_SM)
+        self.assertEqual(received["short_message"], "hello...(6 more characters)")
+        self.assertEqual(received["source_addr"], "********9999")
+        self.assertEqual(received["destination_addr"], "*0404")
+        self.assertEqual(sent["smpp_command"], naz.SmppCommand.SUBMIT_SM)
+        self.assertEqual(sent["short_message"], "my on...(18 more characters)")
+        self.assertEqual(sent["source_addr"], "******0000")
+        self.assertEqual(sent["destination_addr"], "********9999")
+
+        # the contents are only logged at the DEBUG level.
+        self.assertEqual(pdu_contents(logging.INFO), [])
+
+    def test_parse_deliver_sm(self):
+        with mock.patch(
             "naz.Client.command_handlers", new=AsyncMock()
         ) as mock_naz_command_handlers:
             # see: https://github.com/mozes/smpp.pdu
@@ -713,409 +3088,3832 @@ class TestClient(TestCase):
                 b"\x00\x00\x00\x00\x00\x00\x00\x00\x03\x00"
                 b"\x11id:123456 sub:SSS dlvrd:DDD blah blah"
             )
-            self._run(self.cli._parse_response_pdu(pdu=deliver_sm_pdu))
+            self._run(self.cli._parse_response_pdu(pdu=deliver_sm_pdu))
+
+            self.assertTrue(mock_naz_command_handlers.mock.called)
+            self.assertEqual(mock_naz_command_handlers.mock.call_count, 1)
+            self.assertEqual(
+                mock_naz_command_handlers.mock.call_args[1]["smpp_command"],
+                naz.SmppCommand.DELIVER_SM,
+            )
+
+    def test_submit_sm_AND_deliver_sm_correlation(self):
+        with mock.patch(
+            "naz.sequence.SimpleSequenceGenerator.next_sequence"
+        ) as mock_sequence, mock.patch(
+            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
+        ) as mock_naz_dequeue:
+            mock_sequence_number = 909_012
+            mock_sequence.return_value = mock_sequence_number
+
+            log_id = "MyLog_id123456"
+            short_message = "hello smpp"
+            _hook_metadata = {"telco": "Verizon", "customer_id": "909090123"}
+            hook_metadata = json.dumps(_hook_metadata)
+            mock_naz_dequeue.mock.return_value = naz.protocol.SubmitSM(
+                version=1,
+                log_id=log_id,
+                short_message=short_message,
+                smpp_command=naz.SmppCommand.SUBMIT_SM,
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                hook_metadata=hook_metadata,
+            )
+
+            # 1. SEND SUBMIT_SM
+            self._run(self.cli.connect())
+            # hack to allow sending submit_sm even when state is wrong
+            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+            self._run(self.cli.dequeue_messages(TESTING=True))
+            self.assertTrue(self.cli.correlation_handler.store[mock_sequence_number])
+            self.assertEqual(
+                self.cli.correlation_handler.store[mock_sequence_number]["log_id"], log_id
+            )
+            self.assertEqual(
+                self.cli.correlation_handler.store[mock_sequence_number]["hook_metadata"],
+                hook_metadata,
+            )
+
+            # 2. RECEIVE SUBMIT_SM_RESP
+            submit_sm_resp_smsc_message_id = "1618Z-0102G-2333M-25FJF"
+            body = b""
+            body = body + submit_sm_resp_smsc_message_id.encode("ascii") + chr(0).encode("ascii")
+            command_length = 16 + len(body)  # 16 is for headers
+            command_id = 0x80000004  # submit_sm_resp
+            command_status = 0x00000000  # success
+            header = struct.pack(
+                ">IIII", command_length, command_id, command_status, mock_sequence_number
+            )  # SUBMIT_SM_RESP should have same sequence_number as SUBMIT_SM
+            submit_sm_resp_full_pdu = header + body
+            self._run(self.cli._parse_response_pdu(pdu=submit_sm_resp_full_pdu))
+
+            # assert message_id  was stored
+            self.assertTrue(self.cli.correlation_handler.store[submit_sm_resp_smsc_message_id])
+            self.assertEqual(
+                self.cli.correlation_handler.store[submit_sm_resp_smsc_message_id]["log_id"], log_id
+            )
+            self.assertEqual(
+                self.cli.correlation_handler.store[submit_sm_resp_smsc_message_id]["hook_metadata"],
+                hook_metadata,
+            )
+
+            # 3. RECEIVE DELIVER_SM
+            with mock.patch(
+                "naz.hooks.SimpleHook.from_smsc", new=AsyncMock()
+            ) as mock_hook_from_smsc:
+                tag = naz.OptionalTag.NAME_to_TAG["receipted_message_id"]
+                length = 0x0018  # 24 in length
+                tag_n_len = struct.pack(">HH", tag, length)
+                # DELIVER_SM has same message_id as SUBMIT_SM_RESP but DIFFERENT sequence_number
+                value = submit_sm_resp_smsc_message_id  # 23 in length
+                value = value.encode("ascii") + chr(0).encode("ascii")  # 24 in length
+                short_message = b"id:1618Z-0102G-2333M-25FJF sub:SSS dlvrd:DDD blah blah"
+                body = (
+                    b"AWSBD\x00\x01\x0116505551234"
+                    b"\x00\x01\x0117735554070\x00\x00\x00\x00\x00\x00"
+                    b"\x00\x00\x03\x00"
+                    + bytes([len(short_message)])
+                    + short_message
+                    + tag_n_len
+                    + value
+                )
+                deliver_sm_pdu = (
+                    struct.pack(">IIII", 16 + len(body), 0x00000005, 0, 2676551972) + body
+                )
+                self._run(self.cli._parse_response_pdu(pdu=deliver_sm_pdu))
+
+                self.assertTrue(mock_hook_from_smsc.mock.called)
+                self.assertEqual(
+                    mock_hook_from_smsc.mock.call_args[1]["smpp_command"],
+                    naz.SmppCommand.DELIVER_SM,
+                )
+                self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["log_id"], log_id)
+                self.assertEqual(
+                    mock_hook_from_smsc.mock.call_args[1]["hook_metadata"], hook_metadata
+                )
+
+    def test_receipted_message_id_correlation(self):
+        smsc_message_id = "1618Z-0102G-2333M-25FJF"
+
+        def receipt(short_message, optional_params=b""):
+            body = (
+                b"\x00\x01\x0116505551234\x00\x01\x0117735554070\x00"
+                # esm_class is that of an SMSC delivery receipt.
+                b"\x04\x00\x00\x00\x00\x00\x00\x00\x00"
+                + bytes([len(short_message)])
+                + short_message
+                + optional_params
+            )
+            return struct.pack(">IIII", 16 + len(body), 0x00000005, 0, 7) + body
+
+        async def run(pdu):
+            await self.cli.correlation_handler.put(
+                smpp_command=naz.SmppCommand.SUBMIT_SM_RESP,
+                sequence_number=3,
+                log_id="MyLog_id123456",
+                hook_metadata="some-metadata",
+                smsc_message_id=smsc_message_id,
+            )
+            with mock.patch("naz.hooks.SimpleHook.from_smsc", new=AsyncMock()) as mock_from_smsc:
+                await self.cli._parse_response_pdu(pdu=pdu)
+            return mock_from_smsc.mock.call_args[1]
+
+        # the message_id is only in the receipted_message_id optional parameter.
+        tlv = naz.TLV(tag=0x001E, value=smsc_message_id.encode("ascii") + b"\x00").tlv
+        pdu = receipt(b"sub:001 dlvrd:001 stat:DELIVRD err:000", optional_params=tlv)
+        self.assertEqual(
+            naz.pdu.decode(pdu).message.receipted_message_id, "1618Z-0102G-2333M-25FJF"
+        )
+        call_args = self._run(run(pdu))
+        self.assertEqual(call_args["smpp_command"], naz.SmppCommand.DELIVER_SM)
+        self.assertEqual(
+            (call_args["log_id"], call_args["hook_metadata"]), ("MyLog_id123456", "some-metadata")
+        )
+
+        # it takes precedence over the id field of the short_message.
+        pdu = receipt(b"id:some-other-id stat:DELIVRD", optional_params=tlv)
+        self.assertEqual(self._run(run(pdu))["log_id"], "MyLog_id123456")
+
+        # without it, the id field of the short_message is used.
+        pdu = receipt(b"id:1618Z-0102G-2333M-25FJF stat:DELIVRD")
+        self.assertIsNone(naz.pdu.decode(pdu).message.receipted_message_id)
+        self.assertEqual(self._run(run(pdu))["log_id"], "MyLog_id123456")
+
+    def test_re_establish_conn_bind(self):
+        """
+        test that `Client.re_establish_conn_bind` calls `Client.connect` & `Client.bind`
+        """
+        with mock.patch("asyncio.open_connection", new=AsyncMock()) as mock_naz_connect, mock.patch(
+            "naz.Client.bind", new=AsyncMock()
+        ) as mock_naz_tranceiver_bind:
+            submit_sm_resp_pdu = (
+                b"\x00\x00\x00\x12\x80\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00\x030\x00"
+            )
+            mock_naz_connect.mock.return_value = (
+                MockStreamReader(pdu=submit_sm_resp_pdu),
+                MockStreamWriter(),
+            )
+
+            self._run(
+                self.cli.re_establish_conn_bind(
+                    smpp_command=naz.SmppCommand.SUBMIT_SM, log_id="log_id", TESTING=True
+                )
+            )
+            self.assertTrue(mock_naz_connect.mock.called)
+            self.assertTrue(mock_naz_tranceiver_bind.mock.called)
+
+    def test_send_data_under_disconnection(self):
+        """
+        test that if sockect is disconnected, `naz` will try to re-connect & re-bind
+        """
+        with mock.patch("naz.Client.bind", new=AsyncMock()) as mock_naz_tranceiver_bind:
+            # do not connect or bind
+            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+            self._run(
+                self.cli.send_data(
+                    smpp_command=naz.SmppCommand.SUBMIT_SM, msg=b"someMessage", log_id="log_id"
+                )
+            )
+            self.assertTrue(mock_naz_tranceiver_bind.mock.called)
+
+    def test_issues_67(self):
+        """
+        test to prove we have fixed: https://github.com/komuw/naz/issues/67
+        1. start broker
+        2. start naz and run a naz operation like `Client..enquire_link`
+        3. kill broker
+        4. run a naz operation like `Client..enquire_link`
+        """
+        with mock.patch("naz.Client.bind", new=AsyncMock()) as mock_naz_tranceiver_bind:
+            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+            self.cli.writer = None  # simulate a connection loss
+            self._run(
+                self.cli.send_data(
+                    smpp_command=naz.SmppCommand.SUBMIT_SM, msg=b"someMessage", log_id="log_id"
+                )
+            )
+            self.assertTrue(mock_naz_tranceiver_bind.mock.called)
+
+    def test_issues_112(self):
+        """
+        test to prove we have fixed: https://github.com/komuw/naz/issues/112
+
+        Run `Client.enquire_link`.
+        Check if `StreamWriter.write` was called twice(one for `tranceiver_bind` and another for `enquire_link`)
+        """
+        with mock.patch("asyncio.streams.StreamWriter.write") as mock_naz_writer:
+            self._run(self.cli.connect())
+            self._run(self.cli.tranceiver_bind())
+            for item in (self.cli.system_id, self.cli.password):
+                # system_id & password are only sent in `tranceiver_bind`
+                self.assertIn(item.encode("ascii"), mock_naz_writer.call_args[0][0])
+
+            self._run(self.cli.enquire_link(TESTING=True))
+            self.assertTrue(mock_naz_writer.called)
+            self.assertEqual(mock_naz_writer.call_count, 2)
+            # assert `enquire_link` command_id is in the sent data
+            _command_id = struct.pack(">I", self.cli.command_ids["enquire_link"])
+            self.assertIn(_command_id, mock_naz_writer.call_args[0][0])
+
+    def test_command_id_lookup(self):
+        command_id = self.cli._search_by_command_id_code(
+            command_id_code=self.cli.command_ids[naz.SmppCommand.BIND_TRANSCEIVER]
+        )
+        self.assertEqual(command_id, "bind_transceiver")
+
+        command_id = self.cli._search_by_command_id_code(command_id_code=0x00000102)
+        self.assertEqual(command_id, "alert_notification")
+
+        command_id = self.cli._search_by_command_id_code(command_id_code=0x00000016)
+        self.assertEqual(command_id, "reserved_list_c")
+
+        command_id = self.cli._search_by_command_id_code(command_id_code=0x00000104)
+        self.assertEqual(command_id, "reserved_for_smpp_extension_a")
+
+    def test_command_handlers_unkown_command_ids(self):
+        """
+        test that `Client.command_handlers` behaves okay for unkown command_ids
+        """
+        with mock.patch("naz.hooks.SimpleHook.from_smsc", new=AsyncMock()) as mock_hook_from_smsc:
+            sequence_number = 3
+            alert_notification = naz.SmppCommand.ALERT_NOTIFICATION
+            self._run(
+                self.cli.command_handlers(
+                    pdu=b"pdu",
+                    body_data=b"body_data",
+                    smpp_command=alert_notification,
+                    command_status_value=0,
+                    sequence_number=sequence_number,
+                    log_id="log_id",
+                    hook_metadata="hook_metadata",
+                )
+            )
+            self.assertTrue(mock_hook_from_smsc.mock.called)
+            self.assertEqual(
+                mock_hook_from_smsc.mock.call_args[1]["smpp_command"], alert_notification
+            )
+            self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["log_id"], "log_id")
+
+            # reserved command_id's
+            sequence_number = 4
+            reserved_for_smpp_extension_a = naz.SmppCommand.RESERVED_FOR_SMPP_EXTENSION_A
+            self._run(
+                self.cli.command_handlers(
+                    pdu=b"pdu",
+                    body_data=b"body_data",
+                    smpp_command=reserved_for_smpp_extension_a,
+                    command_status_value=0,
+                    sequence_number=sequence_number,
+                    log_id="log_id",
+                    hook_metadata="hook_metadata",
+                )
+            )
+            self.assertTrue(mock_hook_from_smsc.mock.called)
+            self.assertEqual(
+                mock_hook_from_smsc.mock.call_args[1]["smpp_command"], reserved_for_smpp_extension_a
+            )
+            self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["log_id"], "log_id")
+
+            # known command_id
+            sequence_number = 5
+            bind_transceiver = naz.SmppCommand.BIND_TRANSCEIVER
+            self._run(
+                self.cli.command_handlers(
+                    pdu=b"pdu",
+                    body_data=b"body_data",
+                    smpp_command=bind_transceiver,
+                    command_status_value=0,
+                    sequence_number=sequence_number,
+                    log_id="log_id",
+                    hook_metadata="hook_metadata",
+                )
+            )
+            self.assertTrue(mock_hook_from_smsc.mock.called)
+            self.assertEqual(
+                mock_hook_from_smsc.mock.call_args[1]["smpp_command"], bind_transceiver
+            )
+            self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["log_id"], "log_id")
+
+    def test_command_status_lookup(self):
+        command_status = 411_041_792
+        command_status = self.cli._search_by_command_status_value(
+            command_status_value=command_status
+        )
+        self.assertEqual(command_status.code, "Reserved")
+
+        command_status = 0x00000000
+        command_status = self.cli._search_by_command_status_value(
+            command_status_value=command_status
+        )
+        self.assertEqual(command_status.code, "ESME_ROK")
+
+        command_status = 0x00000014
+        command_status = self.cli._search_by_command_status_value(
+            command_status_value=command_status
+        )
+        self.assertEqual(command_status.code, "ESME_RMSGQFUL")
+
+        command_status = 0x00000400
+        command_status = self.cli._search_by_command_status_value(
+            command_status_value=command_status
+        )
+        self.assertEqual(command_status.code, "Reserved")
+
+    def test_SmppCommandStatus(self):
+        """
+        tests of bugs gotten via benchmarks
+        """
+        with mock.patch("naz.hooks.SimpleHook.from_smsc", new=AsyncMock()) as mock_hook_from_smsc:
+            sequence_number = 1
+            bind_transceiver = naz.SmppCommand.BIND_TRANSCEIVER
+            command_status = 411_041_792
+            full_pdu = b"pdu"
+            self._run(
+                self.cli.command_handlers(
+                    pdu=full_pdu,
+                    body_data=b"body_data",
+                    smpp_command=bind_transceiver,
+                    command_status_value=command_status,
+                    sequence_number=sequence_number,
+                    log_id="log_id",
+                    hook_metadata="hook_metadata",
+                )
+            )
+            self.assertTrue(mock_hook_from_smsc.mock.called)
+            self.assertEqual(
+                mock_hook_from_smsc.mock.call_args[1]["smpp_command"], bind_transceiver
+            )
+            self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["log_id"], "log_id")
+            self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["pdu"], full_pdu)
+
+    def test_protocol_error(self):
+        """
+        tests that if we read bytes from connection and naz is unable to parse them,
+        it should close connection.
+        https://github.com/komuw/naz/issues/135
+        """
+        with mock.patch(
+            "naz.Client._unbind_and_disconnect", new=AsyncMock()
+        ) as mock_naz_unbind_and_disconnect:
+            self._run(self.cli._parse_response_pdu(pdu=b"\x00\x00\x00\x15\x80"))
+            self.assertTrue(mock_naz_unbind_and_disconnect.mock.called)
+
+    def test_enquire_link_resp_sending(self):
+        with mock.patch("naz.broker.SimpleBroker.dequeue", new=AsyncMock()) as mock_naz_dequeue:
+            mock_naz_dequeue.mock.return_value = naz.protocol.EnquireLinkResp(
+                log_id="log_id", sequence_number=34,
+            )
+
+            self._run(self.cli.connect())
+            # hack to allow sending submit_sm even when state is wrong
+            self.cli.current_session_state = "BOUND_TRX"
+            self._run(self.cli.dequeue_messages(TESTING=True))
+            self.assertTrue(mock_naz_dequeue.mock.called)
+
+    def test_deliver_sm_resp_sending(self):
+        with mock.patch("naz.broker.SimpleBroker.dequeue", new=AsyncMock()) as mock_naz_dequeue:
+            mock_naz_dequeue.mock.return_value = naz.protocol.DeliverSmResp(
+                log_id="mock-id", message_id="message_id", sequence_number=90,
+            )
+
+            self._run(self.cli.connect())
+            # hack to allow sending submit_sm even when state is wrong
+            self.cli.current_session_state = "BOUND_TRX"
+            self._run(self.cli.dequeue_messages(TESTING=True))
+            self.assertTrue(mock_naz_dequeue.mock.called)
+
+    def test_send_message_with_more_args(self):
+        log_id = "12345"
+        short_message = "hello"
+        msg = naz.protocol.SubmitSM(
+            short_message=short_message,
+            source_addr="2492",
+            destination_addr="8930302",
+            log_id=log_id,
+            source_addr_ton=0x00000010,  # National
+            dest_addr_npi=0b00001110,  # Internet
+        )
+        with mock.patch("naz.broker.SimpleBroker.enqueue", new=AsyncMock()) as mock_naz_enqueue:
+            self._run(self.cli.connect())
+            self._run(self.cli.tranceiver_bind())
+
+            self._run(self.cli.send_message(msg))
+            self.assertTrue(mock_naz_enqueue.mock.called)
+            self.assertEqual(mock_naz_enqueue.mock.call_args[0][1].log_id, log_id)
+            self.assertEqual(
+                mock_naz_enqueue.mock.call_args[0][1].smpp_command, naz.SmppCommand.SUBMIT_SM
+            )
+            self.assertEqual(mock_naz_enqueue.mock.call_args[0][1].short_message, short_message)
+
+    def test_issues_197(self):
+        """
+        test to prove we have fixed: https://github.com/komuw/naz/issues/197
+        ie;
+        During shutdown, close the writer & not reader
+
+        1. connect to smsc
+        2. bind
+        3. unbind & disconnect
+        """
+        self._run(self.cli.connect())
+        self._run(self.cli.tranceiver_bind())
+        self.assertIsNotNone(self.cli.writer)
+
+        self._run(self.cli._unbind_and_disconnect())
+        self.assertIsNone(self.cli.writer)
+        self.assertIsNotNone(self.cli.reader)
+
+    def test_issues_203(self):
+        """
+        test to prove we have fixed: https://github.com/komuw/naz/issues/203
+        ie;
+        If we close connection, we should thereafter still be able to send_data.
+
+        1. connect to smsc
+        2. bind
+        3. unbind & disconnect
+        4. send `submit_sm`
+        """
+        self._run(self.cli.connect())
+        self._run(self.cli.tranceiver_bind())
+        self.assertIsNotNone(self.cli.writer)
+
+        self._run(self.cli._unbind_and_disconnect())
+        self.assertIsNone(self.cli.writer)
+
+        self._run(
+            self.cli.send_data(
+                smpp_command=naz.SmppCommand.SUBMIT_SM, msg=b"someMessage", log_id="log_id"
+            )
+        )
+        self.assertIsNotNone(self.cli.writer)
+
+    def test_split_long_messages(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            socket_timeout=self.socket_timeout,
+            split_long_messages=True,
+        )
+        short_message = "a" * 400
+        proto_msg = naz.protocol.SubmitSM(
+            version=1,
+            log_id="log_id",
+            short_message=short_message,
+            smpp_command=naz.SmppCommand.SUBMIT_SM,
+            source_addr="2547000000",
+            destination_addr="254711999999",
+        )
+        pdus = self._run(cli._build_submit_sm_pdus(proto_msg))
+        self.assertEqual(len(pdus), 3)
+
+        reassembled = ""
+        part_lengths = []
+        reference_numbers = set()
+        for part_number, pdu in enumerate(pdus, start=1):
+            # service_type, source_addr_ton, source_addr_npi, source_addr, dest_addr_ton, dest_addr_npi, destination_addr
+            body = pdu[16:]
+            body = body[body.index(b"\x00") + 1 :][2:]
+            body = body[body.index(b"\x00") + 1 :][2:]
+            body = body[body.index(b"\x00") + 1 :]
+            esm_class = body[0]
+            self.assertEqual(esm_class & 0x40, 0x40)
+            # protocol_id, priority_flag, schedule_delivery_time, validity_period
+            body = body[3:]
+            body = body[body.index(b"\x00") + 1 :]
+            body = body[body.index(b"\x00") + 1 :]
+            # registered_delivery, replace_if_present_flag, data_coding, sm_default_msg_id
+            sm_length = body[4]
+            short_message_part = body[5 : 5 + sm_length]
+            udh = short_message_part[:6]
+            self.assertEqual(udh[:3], b"\x05\x00\x03")
+            reference_numbers.add(udh[3])
+            self.assertEqual(udh[4], 3)  # total parts
+            self.assertEqual(udh[5], part_number)
+            part_lengths.append(len(short_message_part[6:]))
+            reassembled = reassembled + codecs.decode(short_message_part[6:], "gsm0338")
+
+        self.assertEqual(len(reference_numbers), 1)
+        self.assertEqual(reassembled, short_message)
+        self.assertEqual(part_lengths, [153, 153, 94])
+
+    def test_message_payload(self):
+        def submit_sm(short_message):
+            return naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message=short_message,
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                encoding="latin_1",
+            )
+
+        # a message that is longer than the 254 octets that fit in short_message.
+        pdu = self._run(self.cli._build_submit_sm_pdu(submit_sm("a" * 300)))
+        # data_coding, sm_default_msg_id, sm_length of zero, then the message_payload TLV
+        self.assertTrue(
+            pdu.endswith(b"\x03\x00\x00" + struct.pack(">HH", 0x0424, 300) + b"a" * 300)
+        )
+        self.assertEqual(struct.unpack(">I", pdu[:4])[0], len(pdu))
+
+        pdu = self._run(self.cli._build_submit_sm_pdu(submit_sm("a" * 254)))
+        self.assertTrue(pdu.endswith(b"\x03\x00\xfe" + b"a" * 254))
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            use_message_payload=True,
+        )
+        pdu = self._run(cli._build_submit_sm_pdu(submit_sm("hello")))
+        self.assertTrue(pdu.endswith(b"\x03\x00\x00" + struct.pack(">HH", 0x0424, 5) + b"hello"))
+
+        with self.assertRaises(naz.client.NazClientError) as raised_exception:
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=TestClient.smsc_port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                use_message_payload=True,
+                split_long_messages=True,
+            )
+        self.assertIn(
+            "`use_message_payload` and `split_long_messages` cannot both be True",
+            str(raised_exception.exception),
+        )
+
+    def test_sm_default_msg_id(self):
+        proto_msg = naz.protocol.SubmitSM(
+            log_id="log_id",
+            short_message="",
+            source_addr="2547000000",
+            destination_addr="254711999999",
+            encoding="latin_1",
+            sm_default_msg_id=7,
+        )
+        pdus = self._run(self.cli._build_submit_sm_pdus(proto_msg))
+        self.assertEqual(len(pdus), 1)
+        # data_coding, sm_default_msg_id, then an sm_length of zero and no short_message.
+        self.assertTrue(pdus[0].endswith(b"\x03\x07\x00"))
+        self.assertEqual(struct.unpack(">I", pdus[0][:4])[0], len(pdus[0]))
+
+        # the short_message is not sent along with a canned message.
+        proto_msg = naz.protocol.SubmitSM(
+            log_id="log_id",
+            short_message="a" * 400,
+            source_addr="2547000000",
+            destination_addr="254711999999",
+            encoding="latin_1",
+            sm_default_msg_id=255,
+        )
+        pdus = self._run(self.cli._build_submit_sm_pdus(proto_msg))
+        self.assertEqual(len(pdus), 1)
+        self.assertTrue(pdus[0].endswith(b"\x03\xff\x00"))
+
+        for sm_default_msg_id in [-1, 256]:
+            with self.assertRaises(ValueError):
+                naz.protocol.SubmitSM(
+                    log_id="log_id",
+                    short_message="",
+                    source_addr="2547000000",
+                    destination_addr="254711999999",
+                    sm_default_msg_id=sm_default_msg_id,
+                )
+
+    def test_binary_short_message(self):
+        def submit_sm(short_message):
+            return naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message=short_message,
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                encoding="octet_unspecified_II",
+            )
+
+        # a WAP push; the UDH for port addressing, followed by the WSP payload.
+        payload = b"\x06\x05\x04\x0b\x84\x23\xf0\x00\x06\x03\xae\x81\xea\xff\x1b"
+        pdus = self._run(self.cli._build_submit_sm_pdus(submit_sm(payload)))
+        self.assertEqual(len(pdus), 1)
+        # data_coding, sm_default_msg_id, sm_length and then the payload as it is.
+        self.assertTrue(pdus[0].endswith(b"\x04\x00" + bytes([len(payload)]) + payload))
+
+        # a payload that does not fit in the short_message is sent in the message_payload optional parameter.
+        payload = bytes(range(0, 256)) * 2
+        pdu = self._run(self.cli._build_submit_sm_pdu(submit_sm(payload)))
+        self.assertTrue(pdu.endswith(b"\x04\x00\x00" + struct.pack(">HH", 0x0424, 512) + payload))
+
+        # binary messages are not split.
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            split_long_messages=True,
+        )
+        pdus = self._run(cli._build_submit_sm_pdus(submit_sm(payload)))
+        self.assertEqual(len(pdus), 1)
+        self.assertTrue(pdus[0].endswith(payload))
+
+    def test_message_too_long(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            message_payload_fallback=False,
+        )
+        proto_msg = naz.protocol.SubmitSM(
+            log_id="log_id",
+            short_message="a" * 300,
+            source_addr="2547000000",
+            destination_addr="254711999999",
+        )
+        with self.assertRaises(naz.client.NazMessageTooLongError) as raised:
+            self._run(cli._build_submit_sm_pdus(proto_msg))
+        self.assertEqual((raised.exception.length, raised.exception.limit), (300, 254))
+        self.assertIn("300 octets", str(raised.exception))
+        self.assertIn("254 octets", str(raised.exception))
+
+        # send_message raises it too, rather than enqueuing a message that cannot be sent.
+        with self.assertRaises(naz.client.NazMessageTooLongError):
+            self._run(cli.send_message(proto_msg))
+        self.assertEqual(cli.broker.size(), 0)
+        # unless the message is split into parts.
+        cli.split_long_messages = True
+        self._run(cli.send_message(proto_msg))
+        self.assertEqual(cli.broker.size(), 1)
+        self._run(cli.broker.dequeue())
+        cli.split_long_messages = False
+
+        # a message that fits is sent as usual.
+        proto_msg.short_message = "a" * 254
+        pdus = self._run(cli._build_submit_sm_pdus(proto_msg))
+        self.assertTrue(pdus[0].endswith(b"\xfe" + b"a" * 254))
+
+        # by default, it is sent in the message_payload optional parameter.
+        proto_msg.short_message = "a" * 300
+        pdu = self._run(self.cli._build_submit_sm_pdu(proto_msg))
+        self.assertTrue(pdu.endswith(b"\x00" + struct.pack(">HH", 0x0424, 300) + b"a" * 300))
+
+    def test_message_payload_threshold(self):
+        def submit_sm_pdu(length, **kwargs):
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=TestClient.smsc_port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                message_payload_threshold=100,
+                **kwargs
+            )
+            proto_msg = naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message="a" * length,
+                source_addr="2547000000",
+                destination_addr="254711999999",
+            )
+            return self._run(cli._build_submit_sm_pdus(proto_msg))
+
+        # at the threshold, the message is sent inline.
+        pdus = submit_sm_pdu(100)
+        self.assertTrue(pdus[0].endswith(b"\x00" + bytes([100]) + b"a" * 100))
+        # just above it, it is sent in the message_payload optional parameter.
+        pdus = submit_sm_pdu(101)
+        self.assertTrue(pdus[0].endswith(b"\x00" + struct.pack(">HH", 0x0424, 101) + b"a" * 101))
+        # or split, if it does not fit in one SMS.
+        pdus = submit_sm_pdu(161, split_long_messages=True)
+        self.assertEqual(len(pdus), 2)
+        # or it is sent inline, if SMSC does not support message_payload.
+        pdus = submit_sm_pdu(101, message_payload_fallback=False)
+        self.assertTrue(pdus[0].endswith(b"\x00" + bytes([101]) + b"a" * 101))
+
+        with self.assertRaises(naz.client.NazClientError):
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=TestClient.smsc_port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                message_payload_threshold=255,
+            )
+
+    def test_e164_addresses(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            e164_addresses=["source_addr", "destination_addr"],
+        )
+        for source_addr, destination_addr in [
+            ("+254700111222", "+254711999999"),
+            ("00254700111222", "00254711999999"),
+        ]:
+            proto_msg = naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message="hello",
+                source_addr=source_addr,
+                destination_addr=destination_addr,
+            )
+            pdu = self._run(cli._build_submit_sm_pdu(proto_msg))
+            self.assertIn(b"\x01\x01254700111222\x00\x01\x01254711999999\x00", pdu)
+
+        # a malformed number fails before the message is enqueued.
+        proto_msg = naz.protocol.SubmitSM(
+            log_id="log_id",
+            short_message="hello",
+            source_addr="+254700111222",
+            destination_addr="+2547-11",
+        )
+        with self.assertRaises(ValueError):
+            self._run(cli.send_message(proto_msg))
+        self.assertEqual(self.broker.size(), 0)
+
+        # addresses are sent as they are by default.
+        proto_msg.destination_addr = "+254711999999"
+        pdu = self._run(self.cli._build_submit_sm_pdu(proto_msg))
+        self.assertIn(b"+254700111222\x00", pdu)
+
+    def test_byte_rate_limiter(self):
+        # the burst is larger than any of the PDUs, so that none of them overdraws the bucket.
+        byte_rate, burst = 2_000.0, 400.0
+
+        async def run():
+            smsc = naz.testing.MockSMSC()
+            await smsc.start()
+            cli = naz.Client(
+                smsc_host=smsc.host,
+                smsc_port=smsc.port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                byte_rate_limiter=naz.ratelimiter.ByteRateLimiter(byte_rate=byte_rate, burst=burst),
+                logger=naz.log.SimpleLogger("test_byte_rate_limiter", level="CRITICAL"),
+            )
+            await cli.connect()
+            await cli.bind()
+            # a mix of small and large messages
+            for i, short_message in enumerate(["hi", "a" * 250, "hello", "b" * 200, "c" * 250]):
+                await cli.send_message(
+                    naz.protocol.SubmitSM(
+                        short_message=short_message,
+                        log_id="log_id-{0}".format(i),
+                        source_addr="254722111111",
+                        destination_addr="254722999999",
+                    )
+                )
+            bytes_written = cli.stats().bytes_written
+            now = time.monotonic()
+            for _ in range(5):
+                await cli.dequeue_messages(TESTING=True)
+            elapsed = time.monotonic() - now
+            bytes_written = cli.stats().bytes_written - bytes_written
+            await smsc.wait_for(naz.SmppCommand.SUBMIT_SM, count=5)
+            await cli._unbind_and_disconnect()
+            await smsc.stop()
+            return bytes_written, elapsed
+
+        bytes_written, elapsed = self._run(run())
+        self.assertGreater(bytes_written, 1_000)
+        # upto `burst` bytes are sent straight away, the rest no faster than the byte_rate.
+        self.assertLessEqual((bytes_written - burst) / elapsed, byte_rate)
+
+    def test_dedup_window(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            dedup_window=0.2,
+            logger=naz.log.SimpleLogger("test_dedup_window", level="CRITICAL"),
+        )
+
+        def proto_msg(log_id):
+            return naz.protocol.SubmitSM(
+                log_id=log_id,
+                short_message="hello",
+                source_addr="254722111111",
+                destination_addr="254722999999",
+            )
+
+        self._run(cli.send_message(proto_msg("log_id-1")))
+        self._run(cli.send_message(proto_msg("log_id-2")))
+        with self.assertRaises(naz.client.NazDuplicateMessageError) as raised_exception:
+            self._run(cli.send_message(proto_msg("log_id-1")))
+        self.assertEqual(raised_exception.exception.log_id, "log_id-1")
+        self.assertEqual(self.broker.size(), 2)
+
+        # once the window has passed, the log_id's are forgotten.
+        self._run(asyncio.sleep(0.25))
+        self._run(cli.send_message(proto_msg("log_id-1")))
+        self.assertEqual(self.broker.size(), 3)
+        self.assertEqual(list(cli._recent_log_ids.keys()), ["log_id-1"])
+
+        # messages are not deduplicated by default.
+        self._run(self.cli.send_message(proto_msg("log_id-1")))
+        self.assertEqual(self.broker.size(), 4)
+
+    def test_address_kind(self):
+        for kind, ton_npi in [
+            (naz.AddressKind.INTERNATIONAL, b"\x01\x01"),
+            (naz.AddressKind.NATIONAL, b"\x02\x01"),
+            (naz.AddressKind.SHORT_CODE, b"\x03\x00"),
+            (naz.AddressKind.ALPHANUMERIC, b"\x05\x00"),
+        ]:
+            proto_msg = naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message="hello",
+                source_addr="40404",
+                destination_addr="254711999999",
+                source_addr_kind=kind,
+                dest_addr_kind=kind,
+            )
+            pdu = self._run(self.cli._build_submit_sm_pdu(proto_msg))
+            self.assertIn(ton_npi + b"40404\x00" + ton_npi + b"254711999999\x00", pdu)
+
+            proto_msg = naz.protocol.DataSM(
+                log_id="log_id",
+                message_payload="hello",
+                source_addr="40404",
+                destination_addr="254711999999",
+                dest_addr_kind=kind,
+            )
+            pdu = self._run(self.cli._build_data_sm_pdu(proto_msg))
+            self.assertIn(b"40404\x00" + ton_npi + b"254711999999\x00", pdu)
+
+        # the numeric TON/NPI win over the kind.
+        proto_msg = naz.protocol.SubmitSM(
+            log_id="log_id",
+            short_message="hello",
+            source_addr="40404",
+            destination_addr="254711999999",
+            source_addr_kind=naz.AddressKind.SHORT_CODE,
+            source_addr_npi=0x09,
+            dest_addr_kind=naz.AddressKind.INTERNATIONAL,
+            dest_addr_ton=0x00,
+        )
+        pdu = self._run(self.cli._build_submit_sm_pdu(proto_msg))
+        self.assertIn(b"\x03\x0940404\x00\x00\x01254711999999\x00", pdu)
+
+        with self.assertRaises(ValueError):
+            naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message="hello",
+                source_addr="40404",
+                destination_addr="254711999999",
+                dest_addr_kind="MSISDN",
+            )
+
+    def test_data_coding_override(self):
+        def submit_sm(data_coding):
+            return naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message="hello",
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                encoding="ucs2",
+                data_coding=data_coding,
+            )
+
+        encoded, _ = naz.codec.UCS2Codec().encode("hello")
+        # data_coding, sm_default_msg_id, sm_length and then the short_message.
+        pdu = self._run(self.cli._build_submit_sm_pdu(submit_sm(None)))
+        self.assertTrue(pdu.endswith(b"\x08\x00" + bytes([len(encoded)]) + encoded))
+
+        # the overridden data_coding is sent, but the short_message is still encoded in ucs2.
+        pdu = self._run(self.cli._build_submit_sm_pdu(submit_sm(0x04)))
+        self.assertTrue(pdu.endswith(b"\x04\x00" + bytes([len(encoded)]) + encoded))
+
+    def test_service_type(self):
+        proto_msg = naz.protocol.SubmitSM(
+            log_id="log_id",
+            short_message="hello",
+            source_addr="2547000000",
+            destination_addr="254711999999",
+            service_type="WAP",
+        )
+        pdu = self._run(self.cli._build_submit_sm_pdu(proto_msg))
+        # service_type is the first field of the body, and it is followed by source_addr_ton
+        self.assertEqual(pdu[16:21], b"WAP\x00" + bytes([self.cli.source_addr_ton]))
+
+        proto_msg = naz.protocol.SubmitSM(
+            log_id="log_id",
+            short_message="hello",
+            source_addr="2547000000",
+            destination_addr="254711999999",
+            service_type="",
+        )
+        pdu = self._run(self.cli._build_submit_sm_pdu(proto_msg))
+        self.assertEqual(pdu[16:18], b"\x00" + bytes([self.cli.source_addr_ton]))
+
+    def test_priority_flag(self):
+        def priority_flag_of(cli, priority_flag):
+            proto_msg = naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message="hello",
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                service_type="",
+                priority_flag=priority_flag,
+            )
+            pdu = self._run(cli._build_submit_sm_pdu(proto_msg))
+            # the header, service_type, source_addr_ton, source_addr_npi, source_addr, dest_addr_ton,
+            # dest_addr_npi, destination_addr, esm_class and protocol_id come before priority_flag
+            return pdu[16 + 1 + 2 + len("2547000000") + 1 + 2 + len("254711999999") + 1 + 2]
+
+        self.assertEqual(self.cli.priority_flag, 0)
+        self.assertEqual(priority_flag_of(self.cli, None), 0)
+        self.assertEqual(priority_flag_of(self.cli, 3), 3)
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            priority_flag=2,
+        )
+        self.assertEqual(priority_flag_of(cli, None), 2)
+        self.assertEqual(priority_flag_of(cli, 1), 1)
+        with self.assertRaises(naz.client.NazClientError):
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=TestClient.smsc_port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                priority_flag=4,
+            )
+
+    def test_validity_period(self):
+        def validity_period_of(cli, validity_period):
+            proto_msg = naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message="hello",
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                service_type="",
+                validity_period=validity_period,
+            )
+            pdu = self._run(cli._build_submit_sm_pdu(proto_msg))
+            # the header, service_type, source_addr_ton, source_addr_npi, source_addr, dest_addr_ton, dest_addr_npi,
+            # destination_addr, esm_class, protocol_id, priority_flag and schedule_delivery_time come before validity_period
+            body = pdu[16 + 1 + 2 + len("2547000000") + 1 + 2 + len("254711999999") + 1 + 3 + 1 :]
+            return body[: body.index(b"\x00")].decode("ascii")
+
+        self.assertEqual(validity_period_of(self.cli, ""), "")
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            validity_period=datetime.timedelta(hours=24),
+        )
+        self.assertEqual(validity_period_of(cli, ""), "000001000000000R")
+        # the validity_period of a message takes precedence.
+        self.assertEqual(
+            validity_period_of(cli, datetime.timedelta(minutes=30)), "000000003000000R"
+        )
+        self.assertEqual(validity_period_of(cli, "200101000000000+"), "200101000000000+")
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            validity_period="000002000000000R",
+        )
+        self.assertEqual(validity_period_of(cli, ""), "000002000000000R")
+
+        for validity_period in [datetime.timedelta(hours=-1), 24]:
+            with self.assertRaises(naz.client.NazClientError):
+                naz.Client(
+                    smsc_host="127.0.0.1",
+                    smsc_port=TestClient.smsc_port,
+                    system_id="smppclient1",
+                    password=os.getenv("password", "password"),
+                    broker=self.broker,
+                    validity_period=validity_period,
+                )
+
+    def test_registered_data_coding(self):
+        naz.codec.register(
+            0xF5,
+            codecs.CodecInfo(
+                name="vendor_x",
+                encode=lambda text, errors="strict": (text[::-1].encode("ascii"), len(text)),
+                decode=lambda data, errors="strict": (data.decode("ascii")[::-1], len(data)),
+            ),
+        )
+        try:
+            proto_msg = naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message="hello",
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                encoding="vendor_x",
+            )
+            pdu = self._run(self.cli._build_submit_sm_pdu(proto_msg))
+        finally:
+            naz.codec._DATA_CODING_CODECS.pop(0xF5)
+        # data_coding, sm_default_msg_id, sm_length and the short_message encoded using the registered codec.
+        self.assertTrue(pdu.endswith(b"\xf5\x00\x05olleh"))
+
+    def test_registered_delivery(self):
+        def registered_delivery_of(pdu):
+            # service_type, source_addr_ton, source_addr_npi, source_addr, dest_addr_ton, dest_addr_npi, destination_addr
+            body = pdu[16:]
+            body = body[body.index(b"\x00") + 1 :][2:]
+            body = body[body.index(b"\x00") + 1 :][2:]
+            body = body[body.index(b"\x00") + 1 :]
+            # esm_class, protocol_id, priority_flag, schedule_delivery_time, validity_period
+            body = body[3:]
+            body = body[body.index(b"\x00") + 1 :]
+            body = body[body.index(b"\x00") + 1 :]
+            return body[0]
+
+        def submit_sm(registered_delivery=None):
+            return naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message="hello",
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                registered_delivery=registered_delivery,
+            )
+
+        # the client's default
+        pdu = self._run(self.cli._build_submit_sm_pdu(submit_sm()))
+        self.assertEqual(
+            registered_delivery_of(pdu), naz.RegisteredDelivery.RECEIPT_ON_SUCCESS_OR_FAILURE
+        )
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            registered_delivery=naz.RegisteredDelivery.NO_RECEIPT,
+        )
+        pdu = self._run(cli._build_submit_sm_pdu(submit_sm()))
+        self.assertEqual(registered_delivery_of(pdu), 0b00000000)
+
+        # the message's registered_delivery overrides the client's
+        pdu = self._run(
+            cli._build_submit_sm_pdu(
+                submit_sm(
+                    naz.RegisteredDelivery.RECEIPT_ON_FAILURE
+                    | naz.RegisteredDelivery.SME_DELIVERY_ACK
+                )
+            )
+        )
+        self.assertEqual(registered_delivery_of(pdu), 0b00000110)
+        pdu = self._run(
+            cli._build_submit_sm_pdu(
+                submit_sm(
+                    naz.RegisteredDelivery.RECEIPT_ON_SUCCESS_OR_FAILURE
+                    | naz.RegisteredDelivery.INTERMEDIATE_NOTIFICATION
+                )
+            )
+        )
+        self.assertEqual(registered_delivery_of(pdu), 0b00010001)
+
+        # data_sm
+        data_sm = naz.protocol.DataSM(
+            log_id="log_id",
+            message_payload="hello",
+            source_addr="2547000000",
+            destination_addr="254711999999",
+            registered_delivery=naz.RegisteredDelivery.SME_MANUAL_ACK,
+        )
+        pdu = self._run(cli._build_data_sm_pdu(data_sm))
+        # service_type, source_addr_ton, source_addr_npi, source_addr, dest_addr_ton, dest_addr_npi, destination_addr
+        body = pdu[16:]
+        body = body[body.index(b"\x00") + 1 :][2:]
+        body = body[body.index(b"\x00") + 1 :][2:]
+        body = body[body.index(b"\x00") + 1 :]
+        # esm_class, registered_delivery
+        self.assertEqual(body[1], 0b00001000)
+
+    def test_bad_registered_delivery(self):
+        with self.assertRaises(ValueError):
+            naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message="hello",
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                registered_delivery=256,
+            )
+        with self.assertRaises(naz.client.NazClientError):
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=TestClient.smsc_port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                registered_delivery=256,
+            )
+
+    def test_addresses_ton_npi(self):
+        def addresses_of(pdu):
+            # service_type, source_addr_ton, source_addr_npi, source_addr, dest_addr_ton, dest_addr_npi
+            body = pdu[16:]
+            body = body[body.index(b"\x00") + 1 :]
+            source_addr_ton, source_addr_npi = body[0], body[1]
+            body = body[2:]
+            source_addr, body = body[: body.index(b"\x00")], body[body.index(b"\x00") + 1 :]
+            return source_addr_ton, source_addr_npi, source_addr, body[0], body[1]
+
+        # international(TON=1, NPI=1); the client's default
+        submit_sm = naz.protocol.SubmitSM(
+            log_id="log_id",
+            short_message="hello",
+            source_addr="254700000000",
+            destination_addr="254711999999",
+        )
+        pdu = self._run(self.cli._build_submit_sm_pdu(submit_sm))
+        self.assertEqual(addresses_of(pdu), (1, 1, b"254700000000", 1, 1))
+
+        # alphanumeric sender(TON=5, NPI=0)
+        submit_sm = naz.protocol.SubmitSM(
+            log_id="log_id",
+            short_message="hello",
+            source_addr="MyShop",
+            destination_addr="254711999999",
+            source_addr_ton=0x05,
+            source_addr_npi=0x00,
+        )
+        pdu = self._run(self.cli._build_submit_sm_pdu(submit_sm))
+        self.assertEqual(addresses_of(pdu), (5, 0, b"MyShop", 1, 1))
+
+        # the message's values override the client's defaults
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            source_addr_ton=0x05,
+            source_addr_npi=0x00,
+            dest_addr_ton=0x02,
+            dest_addr_npi=0x00,
+        )
+        data_sm = naz.protocol.DataSM(
+            log_id="log_id",
+            message_payload="hello",
+            source_addr="MyShop",
+            destination_addr="254711999999",
+            dest_addr_ton=0x01,
+        )
+        pdu = self._run(cli._build_data_sm_pdu(data_sm))
+        self.assertEqual(addresses_of(pdu), (5, 0, b"MyShop", 1, 0))
+
+    def test_bad_addresses_ton_npi(self):
+        for name in ["source_addr_ton", "source_addr_npi", "dest_addr_ton", "dest_addr_npi"]:
+            with self.assertRaises(ValueError):
+                naz.protocol.SubmitSM(
+                    log_id="log_id",
+                    short_message="hello",
+                    source_addr="2547000000",
+                    destination_addr="254711999999",
+                    **{name: 0x100}
+                )
+            with self.assertRaises(naz.client.NazClientError):
+                naz.Client(
+                    smsc_host="127.0.0.1",
+                    smsc_port=TestClient.smsc_port,
+                    system_id="smppclient1",
+                    password=os.getenv("password", "password"),
+                    broker=self.broker,
+                    **{name: -1}
+                )
+
+    def test_split_long_messages_reference_number(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            split_long_messages=True,
+        )
+        # the reference number increments per multipart message and wraps at 255
+        cli._concat_reference_number = 254
+        self.assertEqual(cli._next_concat_reference_number(), 255)
+        self.assertEqual(cli._next_concat_reference_number(), 0)
+        self.assertEqual(cli._next_concat_reference_number(), 1)
+
+    def test_split_long_messages_16bit_reference(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            split_long_messages=True,
+            concat_mode=naz.ConcatMode.UDH_16BIT,
+        )
+        cli._concat_reference_number = 0x1233
+
+        def split(short_message, encoding):
+            proto_msg = naz.protocol.SubmitSM(
+                log_id="log_id",
+                short_message=short_message,
+                source_addr="2547000000",
+                destination_addr="254711999999",
+                encoding=encoding,
+            )
+            parts = []
+            for pdu in self._run(cli._build_submit_sm_pdus(proto_msg)):
+                udh_start = pdu.index(b"\x06\x08\x04")
+                sm_length = pdu[udh_start - 1]
+                udh = pdu[udh_start : udh_start + 7]
+                parts.append((udh, pdu[udh_start + 7 : udh_start + sm_length]))
+            return parts
+
+        # UDHL, IEI(16-bit reference number), IEDL, reference number(2 octets), total parts, part number
+        parts = split("a" * 400, "gsm0338")
+        self.assertEqual(
+            [udh for udh, _ in parts],
+            [
+                struct.pack(">BBBHBB", 6, 0x08, 4, 0x1234, 3, part_number)
+                for part_number in [1, 2, 3]
+            ],
+        )
+        self.assertEqual([len(user_data) for _, user_data in parts], [152, 152, 96])
+        self.assertEqual(b"".join(user_data for _, user_data in parts), b"a" * 400)
+
+        parts = split("a" * 200, "ucs2")
+        self.assertEqual([len(user_data) for _, user_data in parts], [132, 132, 132, 4])
+        self.assertEqual(parts[0][0][3:5], struct.pack(">H", 0x1235))
+
+        parts = split("a" * 300, "latin_1")
+        self.assertEqual([len(user_data) for _, user_data in parts], [133, 133, 34])
+
+        self.assertEqual(
+            naz.codec._split_message("a" * 160, "gsm0338", reference_16bit=True), ["a" * 160]
+        )
+        # the reference number wraps at 65535
+        cli._concat_reference_number = 65535
+        self.assertEqual(cli._next_concat_reference_number(reference_16bit=True), 0)
+
+    def test_caller_supplied_udh(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            split_long_messages=True,
+        )
+        # UDHL(4), IEI(0x04: application port addressing, 8-bit), IEDL(2), destination port, originator port
+        udh = b"\x04\x04\x02\xe2\xe3"
+        proto_msg = naz.protocol.SubmitSM(
+            log_id="log_id",
+            short_message="a" * 200,
+            source_addr="2547000000",
+            destination_addr="254711999999",
+            esm_class=0b00000011,
+            udh=udh,
+        )
+        pdus = self._run(cli._build_submit_sm_pdus(proto_msg))
+        # naz neither splits the message nor adds a UDH of its own.
+        self.assertEqual(len(pdus), 1)
+        self.assertTrue(pdus[0].endswith(b"\x00" + bytes([205]) + udh + b"a" * 200))
+        esm_class = pdus[0][pdus[0].index(b"254711999999\x00") + 13]
+        self.assertEqual(esm_class, 0b01000011)
+
+        self.assertEqual(naz.protocol.SubmitSM.from_json(proto_msg.to_json()).udh, udh)
+        for bad_udh in [b"\x05\x04\x02\xe2\xe3", b"\x00", "\x04\x04\x02\xe2\xe3"]:
+            with self.assertRaises(ValueError):
+                naz.protocol.SubmitSM(
+                    log_id="log_id",
+                    short_message="hello",
+                    source_addr="2547000000",
+                    destination_addr="254711999999",
+                    udh=bad_udh,
+                )
+
+    def test_split_long_messages_off(self):
+        proto_msg = naz.protocol.SubmitSM(
+            version=1,
+            log_id="log_id",
+            short_message="a" * 200,
+            smpp_command=naz.SmppCommand.SUBMIT_SM,
+            source_addr="2547000000",
+            destination_addr="254711999999",
+        )
+        pdus = self._run(self.cli._build_submit_sm_pdus(proto_msg))
+        self.assertEqual(len(pdus), 1)
+
+        with mock.patch("naz.broker.SimpleBroker.dequeue", new=AsyncMock()) as mock_naz_dequeue:
+            mock_naz_dequeue.mock.return_value = proto_msg
+            with mock.patch("naz.Client.send_data", new=AsyncMock()) as mock_send_data:
+                self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+                self._run(self.cli.dequeue_messages(TESTING=True))
+                self.assertEqual(mock_send_data.mock.call_count, 1)
+
+    def test_split_long_messages_sar(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            split_long_messages=True,
+            concat_mode=naz.ConcatMode.SAR,
+        )
+        short_message = "a" * 400
+        proto_msg = naz.protocol.SubmitSM(
+            version=1,
+            log_id="log_id",
+            short_message=short_message,
+            smpp_command=naz.SmppCommand.SUBMIT_SM,
+            source_addr="2547000000",
+            destination_addr="254711999999",
+        )
+        pdus = self._run(cli._build_submit_sm_pdus(proto_msg))
+        self.assertEqual(len(pdus), 3)
+
+        reassembled = ""
+        for part_number, pdu in enumerate(pdus, start=1):
+            body = pdu[16:]
+            body = body[body.index(b"\x00") + 1 :][2:]
+            body = body[body.index(b"\x00") + 1 :][2:]
+            body = body[body.index(b"\x00") + 1 :]
+            esm_class = body[0]
+            # the UDHI bit should NOT be set
+            self.assertEqual(esm_class & 0x40, 0)
+            body = body[3:]
+            body = body[body.index(b"\x00") + 1 :]
+            body = body[body.index(b"\x00") + 1 :]
+            sm_length = body[4]
+            short_message_part = body[5 : 5 + sm_length]
+            # the message body is left clean, and since there is no UDH each part uses the whole segment.
+            self.assertEqual(sm_length, [160, 160, 80][part_number - 1])
+            reassembled = reassembled + codecs.decode(short_message_part, "gsm0338")
+
+            tlvs = body[5 + sm_length :]
+            self.assertEqual(
+                tlvs,
+                struct.pack(">HHH", 0x020C, 2, 1)
+                + struct.pack(">HHB", 0x020E, 1, 3)
+                + struct.pack(">HHB", 0x020F, 1, part_number),
+            )
+        self.assertEqual(reassembled, short_message)
+
+        # 70 ucs2 characters per part
+        proto_msg.short_message, proto_msg.encoding = "ë" * 141, "ucs2"
+        self.assertEqual(
+            [len(part.short_message) for part in cli._submit_sm_parts(proto_msg)], [70, 70, 1]
+        )
+
+    def test_split_long_messages_sar_reference_wraps(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            split_long_messages=True,
+            concat_mode=naz.ConcatMode.SAR,
+        )
+        proto_msg = naz.protocol.SubmitSM(
+            version=1,
+            log_id="log_id",
+            short_message="a" * 200,
+            smpp_command=naz.SmppCommand.SUBMIT_SM,
+            source_addr="2547000000",
+            destination_addr="254711999999",
+        )
+        # sar_msg_ref_num is two octets, so it only wraps after 65535
+        cli._concat_reference_number = 255
+        parts = cli._submit_sm_parts(proto_msg)
+        self.assertEqual(parts[0].optional_tags_dict["sar_msg_ref_num"], 256)
+
+        cli._concat_reference_number = 65535
+        for pdu in self._run(cli._build_submit_sm_pdus(proto_msg)):
+            # a reference number of 0 is still sent
+            self.assertIn(struct.pack(">HHH", 0x020C, 2, 0), pdu)
+
+    def test_bad_concat_mode(self):
+        with self.assertRaises(naz.client.NazClientError) as raised_exception:
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=TestClient.smsc_port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                concat_mode="unknown-mode",
+            )
+        self.assertIn("`concat_mode` should be one of", str(raised_exception.exception.args[0][0]))
+
+    def test_query_message(self):
+        message_id = "some-smsc-message-id"
+        sent_pdus = []
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+
+        async def mock_smsc():
+            # wait for the client to send its query_sm, then respond to it.
+            for _ in range(100):
+                if self.cli._pending_responses:
+                    break
+                await asyncio.sleep(0.001)
+            sequence_number = list(self.cli._pending_responses.keys())[0]
+            body = (
+                message_id.encode("ascii")
+                + b"\x00"
+                + b"2001011200000004R"
+                + b"\x00"
+                + struct.pack(">B", naz.SmppMessageState.DELIVERED.value)
+                + struct.pack(">B", 0)
+            )
+            header = struct.pack(">IIII", 16 + len(body), 0x80000003, 0x00000000, sequence_number)
+            await self.cli._parse_response_pdu(header + body)
+
+        async def query():
+            return await asyncio.gather(
+                self.cli.query_message(message_id=message_id, source_addr="2547000000"),
+                mock_smsc(),
+            )
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            result, _ = self._run(query())
+
+        self.assertEqual(result.message_id, message_id)
+        self.assertEqual(result.final_date, "2001011200000004R")
+        self.assertEqual(result.message_state, naz.SmppMessageState.DELIVERED)
+        self.assertEqual(result.error_code, 0)
+        self.assertEqual(self.cli._pending_responses, {})
+
+        # the query_sm pdu
+        self.assertEqual(struct.unpack(">I", sent_pdus[0][4:8])[0], 0x00000003)
+        self.assertEqual(
+            sent_pdus[0][16:], message_id.encode("ascii") + b"\x00\x01\x012547000000\x00"
+        )
+
+    def test_query_message_unrecognised_state(self):
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            pass
+
+        async def mock_smsc():
+            for _ in range(100):
+                if self.cli._pending_responses:
+                    break
+                await asyncio.sleep(0.001)
+            sequence_number = list(self.cli._pending_responses.keys())[0]
+            # message_state 42 is not in the spec
+            body = b"some-smsc-message-id\x00\x00" + struct.pack(">BB", 42, 0)
+            header = struct.pack(">IIII", 16 + len(body), 0x80000003, 0x00000000, sequence_number)
+            await self.cli._parse_response_pdu(header + body)
+
+        async def query():
+            return await asyncio.gather(
+                self.cli.query_message(message_id="some-smsc-message-id", source_addr="2547000000"),
+                mock_smsc(),
+            )
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            result, _ = self._run(query())
+
+        self.assertEqual(result.message_state.code, "UNKNOWN")
+        self.assertEqual(result.message_state.value, 42)
+        self.assertEqual(naz.SmppMessageState._find_message_state(7), naz.SmppMessageState.UNKNOWN)
+
+    def test_send_raw(self):
+        sent_pdus = []
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append((smpp_command, msg))
+            command_id, sequence_number = struct.unpack(">I4xI", msg[4:16])
+            if command_id == 0x00000015:
+                response = struct.pack(">IIII", 16, 0x80000015, 0, sequence_number)
+            elif command_id == 0x00010201:
+                # a vendor specific request, that SMSC fails.
+                body = b"vendor-error\x00"
+                response = (
+                    struct.pack(">IIII", 16 + len(body), 0x80010201, 0x00000401, sequence_number)
+                    + body
+                )
+            else:
+                return
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(response))
+
+        self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            command_status, body = self._run(self.cli.send_raw(0x00000015))
+            self.assertEqual((command_status, body), (0, b""))
+            smpp_command, pdu = sent_pdus[0]
+            self.assertEqual(smpp_command, naz.SmppCommand.ENQUIRE_LINK)
+            self.assertEqual(struct.unpack(">III", pdu[:12]), (16, 0x00000015, 0))
+
+            command_status, body = self._run(
+                self.cli.send_raw(0x00010201, body=b"vendor-request\x00")
+            )
+            self.assertEqual((command_status, body), (0x00000401, b"vendor-error\x00"))
+            _, pdu = sent_pdus[1]
+            self.assertEqual(pdu[16:], b"vendor-request\x00")
+            self.assertEqual(struct.unpack(">I", pdu[:4])[0], len(pdu))
+            # each PDU gets its own sequence_number
+            self.assertNotEqual(sent_pdus[0][1][12:16], pdu[12:16])
+
+            # a response is not waited upon.
+            self.assertIsNone(self._run(self.cli.send_raw(0x80000015)))
+            self.assertEqual(len(sent_pdus), 3)
+        self.assertEqual(self.cli._raw_responses, {})
+
+        with self.assertRaises(ValueError):
+            self._run(self.cli.send_raw(0x100000000))
+        with self.assertRaises(ValueError):
+            self._run(self.cli.send_raw(0x00000015, body="abc"))
+
+    def test_send_raw_timeout(self):
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            pass
+
+        self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+        self.cli.response_timeout = 0.05
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            with self.assertRaises(asyncio.TimeoutError):
+                self._run(self.cli.send_raw(0x00000015))
+        self.assertEqual(self.cli._raw_responses, {})
+
+    def test_query_message_error(self):
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            header = struct.pack(
+                ">IIII",
+                16,
+                0x80000003,
+                naz.SmppCommandStatus.ESME_RQUERYFAIL.value,
+                sequence_number,
+            )
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(header))
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            with self.assertRaises(naz.client.NazCommandStatusError) as raised_exception:
+                self._run(self.cli.query_message(message_id="some-id", source_addr="2547000000"))
+        self.assertEqual(
+            raised_exception.exception.command_status, naz.SmppCommandStatus.ESME_RQUERYFAIL
+        )
+        self.assertIn("ESME_RQUERYFAIL", str(raised_exception.exception))
+        self.assertTrue(raised_exception.exception.is_status(naz.SmppCommandStatus.ESME_RQUERYFAIL))
+
+    def test_response_mismatch(self):
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            # SMSC responds to the query_sm with a cancel_sm_resp
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            header = struct.pack(">IIII", 16, 0x80000008, 0x00000000, sequence_number)
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(header))
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            with self.assertRaises(naz.client.NazResponseMismatchError) as raised_exception:
+                self._run(self.cli.query_message(message_id="some-id", source_addr="2547000000"))
+        err = raised_exception.exception
+        self.assertEqual(
+            (err.expected_smpp_command, err.expected_command_id),
+            (naz.SmppCommand.QUERY_SM_RESP, 0x80000003),
+        )
+        self.assertEqual(
+            (err.smpp_command, err.command_id), (naz.SmppCommand.CANCEL_SM_RESP, 0x80000008)
+        )
+        self.assertEqual(err.command_length, 16)
+        self.assertIn("query_sm_resp(command_id:0x80000003)", str(err))
+        self.assertIn("cancel_sm_resp(command_id:0x80000008, command_length:16)", str(err))
+        self.assertIn("sequence_number:{0}".format(err.sequence_number), str(err))
+        self.assertEqual(self.cli._pending_responses, {})
+        self.assertEqual(self.cli._expected_responses, {})
+
+    def test_command_status_error(self):
+        for command_status, expected in [
+            (
+                naz.SmppCommandStatus.ESME_RINVDSTADR,
+                "smpp_command `submit_sm_resp` failed with command_status `ESME_RINVDSTADR`(0x0000000B): Invalid Dest Addr",
+            ),
+            (
+                naz.SmppCommandStatus.ESME_RMSGQFUL,
+                "smpp_command `submit_sm_resp` failed with command_status `ESME_RMSGQFUL`(0x00000014): Message Broker Full",
+            ),
+            (
+                naz.SmppCommandStatus.ESME_RINVDLNAME,
+                "smpp_command `submit_sm_resp` failed with command_status `ESME_RINVDLNAME`(0x00000034): Invalid Distribution List name",
+            ),
+        ]:
+            err = naz.client.NazCommandStatusError(
+                smpp_command="submit_sm_resp", command_status=command_status
+            )
+            self.assertEqual(str(err), expected)
+            self.assertTrue(err.is_status(command_status))
+            self.assertFalse(err.is_status(naz.SmppCommandStatus.ESME_RSYSERR))
+
+    def test_command_status_error_reserved(self):
+        command_status = self.cli._search_by_command_status_value(0x00000420)
+        err = naz.client.NazCommandStatusError(
+            smpp_command="submit_sm_resp",
+            command_status=command_status,
+            command_status_value=0x00000420,
+        )
+        self.assertIn("`Reserved`(0x00000420)", str(err))
+        self.assertTrue(err.is_status(naz.SmppCommandStatus.RESERVED_LIST_I))
+        self.assertFalse(err.is_status(naz.SmppCommandStatus.RESERVED_LIST_J))
+
+    def test_command_status_table(self):
+        # every command_status value maps onto an SMPP v3.4 command status.
+        for value in range(0x00000000, 0x00000600):
+            self.assertIsNotNone(self.cli._search_by_command_status_value(value))
+        for key, val in naz.SmppCommandStatus.__dict__.items():
+            if key.startswith("ESME_"):
+                self.assertEqual(key, val.code)
+
+    def test_status_name_and_is_temporary(self):
+        for value, name, temporary in [
+            (0x00000000, "ESME_ROK", False),
+            (0x00000008, "ESME_RSYSERR", True),
+            (0x00000014, "ESME_RMSGQFUL", True),
+            (0x00000058, "ESME_RTHROTTLED", True),
+            (0x00000064, "ESME_RX_T_APPN", True),
+            (0x0000000B, "ESME_RINVDSTADR", False),
+            (0x00000065, "ESME_RX_P_APPN", False),
+            (0x00000045, "ESME_RSUBMITFAIL", False),
+            (0x000000FF, "ESME_RUNKNOWNERR", False),
+            (0x00000420, "Reserved", False),
+            (0xFFFFFFFF, "Reserved", False),
+        ]:
+            self.assertEqual(naz.status_name(value), name)
+            self.assertEqual(naz.is_temporary(value), temporary)
+        for value in [-1, 0x100000000, "0x14"]:
+            with self.assertRaises(ValueError):
+                naz.status_name(value)
+
+    def test_query_message_timeout(self):
+        with mock.patch("naz.Client.send_data", new=AsyncMock()):
+            with self.assertRaises(asyncio.TimeoutError):
+                self._run(self.cli.query_message(message_id="some-id", source_addr="2547000000"))
+        self.assertEqual(self.cli._pending_responses, {})
+
+    def test_cancel_message(self):
+        sent_pdus = []
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            header = struct.pack(">IIII", 16, 0x80000008, 0x00000000, sequence_number)
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(header))
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            res = self._run(
+                self.cli.cancel_message(
+                    message_id="some-smsc-message-id",
+                    source_addr="2547000000",
+                    destination_addr="254711999999",
+                    service_type="CMT",
+                )
+            )
+        self.assertIsNone(res)
+        self.assertEqual(self.cli._pending_responses, {})
+
+        # the wire bytes of the cancel_sm pdu
+        body = (
+            b"CMT\x00"
+            + b"some-smsc-message-id\x00"
+            + b"\x01\x01"
+            + b"2547000000\x00"
+            + b"\x01\x01"
+            + b"254711999999\x00"
+        )
+        sequence_number = struct.unpack(">I", sent_pdus[0][12:16])[0]
+        self.assertEqual(
+            sent_pdus[0],
+            struct.pack(">IIII", 16 + len(body), 0x00000008, 0x00000000, sequence_number) + body,
+        )
+
+    def test_submit_multi(self):
+        sent_pdus = []
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            # the message was not delivered to two of the destinations.
+            body = (
+                b"smsc-multi-id\x00"
+                + b"\x02"
+                + b"\x01\x01254711999999\x00"
+                + struct.pack(">I", naz.SmppCommandStatus.ESME_RINVDSTADR.value)
+                + b"\x00\x00friends\x00"
+                + struct.pack(">I", naz.SmppCommandStatus.ESME_RINVDLNAME.value)
+            )
+            header = struct.pack(">IIII", 16 + len(body), 0x80000021, 0x00000000, sequence_number)
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(header + body))
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            result = self._run(
+                self.cli.submit_multi(
+                    short_message="hello",
+                    source_addr="2547000000",
+                    destination_addrs=["254711111111", "254711999999"],
+                    distribution_lists=["friends"],
+                )
+            )
+        self.assertEqual(
+            result,
+            naz.SubmitMultiResult(
+                message_id="smsc-multi-id",
+                unsuccess_smes=[
+                    naz.UnsuccessSME(
+                        dest_addr_ton=1,
+                        dest_addr_npi=1,
+                        destination_addr="254711999999",
+                        error_status_code=naz.SmppCommandStatus.ESME_RINVDSTADR.value,
+                    ),
+                    naz.UnsuccessSME(
+                        dest_addr_ton=0,
+                        dest_addr_npi=0,
+                        destination_addr="friends",
+                        error_status_code=naz.SmppCommandStatus.ESME_RINVDLNAME.value,
+                    ),
+                ],
+            ),
+        )
+        self.assertEqual(self.cli._pending_responses, {})
+
+        # the wire bytes of the submit_multi pdu
+        body = (
+            b"CMT\x00"
+            + b"\x01\x01"
+            + b"2547000000\x00"
+            # number_of_dests and the dest_address array
+            + b"\x03"
+            + b"\x01\x01\x01254711111111\x00"
+            + b"\x01\x01\x01254711999999\x00"
+            + b"\x02friends\x00"
+            # esm_class, protocol_id, priority_flag, schedule_delivery_time, validity_period
+            + b"\x03\x00\x00\x00\x00"
+            # registered_delivery, replace_if_present_flag, data_coding, sm_default_msg_id
+            + b"\x01\x00\x00\x00"
+            + b"\x05hello"
+        )
+        sequence_number = struct.unpack(">I", sent_pdus[0][12:16])[0]
+        self.assertEqual(
+            sent_pdus[0],
+            struct.pack(">IIII", 16 + len(body), 0x00000021, 0x00000000, sequence_number) + body,
+        )
+
+        # the message was accepted for all the destinations.
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            body = b"smsc-multi-id\x00\x00"
+            header = struct.pack(">IIII", 16 + len(body), 0x80000021, 0x00000000, sequence_number)
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(header + body))
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            result = self._run(
+                self.cli.submit_multi(
+                    short_message="hello", source_addr="2547000000", destination_addrs=["2547"]
+                )
+            )
+        self.assertEqual(result.unsuccess_smes, [])
+
+    def test_submit_multi_bad_args(self):
+        for destination_addrs, distribution_lists in [
+            ([], None),
+            ("254711111111", None),
+            ([""], None),
+            (["254711111111"], [1]),
+            (["2547{0}".format(i) for i in range(0, 255)], None),
+        ]:
+            with self.assertRaises(ValueError):
+                self._run(
+                    self.cli.submit_multi(
+                        short_message="hello",
+                        source_addr="2547000000",
+                        destination_addrs=destination_addrs,
+                        distribution_lists=distribution_lists,
+                    )
+                )
+        with self.assertRaises(ValueError):
+            self._run(
+                self.cli.submit_multi(
+                    short_message="hello" * 40,
+                    source_addr="2547000000",
+                    destination_addrs=["254711111111"],
+                )
+            )
+
+    def test_cancel_message_error(self):
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            header = struct.pack(
+                ">IIII",
+                16,
+                0x80000008,
+                naz.SmppCommandStatus.ESME_RCANCELFAIL.value,
+                sequence_number,
+            )
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(header))
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            with self.assertRaises(naz.client.NazCommandStatusError) as raised_exception:
+                self._run(
+                    self.cli.cancel_message(
+                        message_id="some-id",
+                        source_addr="2547000000",
+                        destination_addr="254711999999",
+                    )
+                )
+        self.assertIn("ESME_RCANCELFAIL", str(raised_exception.exception))
+
+    def test_replace_message(self):
+        sent_pdus = []
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            header = struct.pack(">IIII", 16, 0x80000007, 0x00000000, sequence_number)
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(header))
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            self._run(
+                self.cli.replace_message(
+                    message_id="some-smsc-message-id",
+                    source_addr="2547000000",
+                    short_message="hello €",
+                    validity_period="000001000000000R",
+                )
+            )
+        self.assertEqual(self.cli._pending_responses, {})
+        body = (
+            b"some-smsc-message-id\x00"
+            + b"\x01\x01"
+            + b"2547000000\x00"
+            + b"\x00"
+            + b"000001000000000R\x00"
+            + b"\x01\x00"
+            + b"\x08"
+            + b"hello \x1b\x65"
+        )
+        self.assertEqual(struct.unpack(">I", sent_pdus[0][4:8])[0], 0x00000007)
+        self.assertEqual(sent_pdus[0][16:], body)
+
+    def test_replace_message_too_long(self):
+        with mock.patch("naz.Client.send_data", new=AsyncMock()) as mock_send_data:
+            with self.assertRaises(ValueError) as raised_exception:
+                self._run(
+                    self.cli.replace_message(
+                        message_id="some-smsc-message-id",
+                        source_addr="2547000000",
+                        short_message="a" * 161,
+                    )
+                )
+            self.assertFalse(mock_send_data.mock.called)
+        self.assertIn("does not support concatenation", str(raised_exception.exception))
+
+    def test_broadcast_message(self):
+        sent_pdus = []
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            body = b"bcast-1\x00"
+            header = struct.pack(">IIII", 16 + len(body), 0x80000111, 0x00000000, sequence_number)
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(header + body))
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            message_id = self._run(
+                self.cli.broadcast_message(
+                    message="flood",
+                    source_addr="112",
+                    broadcast_area_identifiers=[b"\x00Nairobi", b"\x00Mombasa"],
+                    broadcast_content_type=0x0001,
+                    broadcast_rep_num=3,
+                    broadcast_frequency_interval=datetime.timedelta(minutes=10),
+                    validity_period="000001000000000R",
+                    optional_params=[naz.TLV(tag=0x1400, value=b"vendor")],
+                )
+            )
+        self.assertEqual(message_id, "bcast-1")
+        self.assertEqual(self.cli._pending_responses, {})
+        body = (
+            b"\x00"  # service_type
+            + b"\x01\x01"
+            + b"112\x00"
+            + b"\x00"  # message_id
+            + b"\x00"  # priority_flag
+            + b"\x00"  # schedule_delivery_time
+            + b"000001000000000R\x00"
+            + b"\x00"  # replace_if_present_flag
+            + b"\x00"  # data_coding
+            + b"\x00"  # sm_default_msg_id
+            + b"\x06\x06\x00\x08\x00Nairobi"
+            + b"\x06\x06\x00\x08\x00Mombasa"
+            + b"\x06\x01\x00\x03\x01\x00\x01"
+            + b"\x06\x04\x00\x02\x00\x03"
+            + b"\x06\x05\x00\x03\x09\x00\x0a"
+            + b"\x04\x24\x00\x05flood"
+            + b"\x14\x00\x00\x06vendor"
+        )
+        self.assertEqual(struct.unpack(">I", sent_pdus[0][4:8])[0], 0x00000111)
+        self.assertEqual(struct.unpack(">I", sent_pdus[0][:4])[0], 16 + len(body))
+        self.assertEqual(sent_pdus[0][16:], body)
+
+    def test_broadcast_frequency_interval(self):
+        for interval, expected in [
+            (datetime.timedelta(0), b"\x00\x00\x00"),
+            (datetime.timedelta(seconds=90), b"\x08\x00\x5a"),
+            (datetime.timedelta(hours=2), b"\x0a\x00\x02"),
+            (datetime.timedelta(days=1), b"\x0b\x00\x01"),
+            (datetime.timedelta(weeks=3), b"\x0c\x00\x03"),
+            # not a whole number of hours, and too many seconds for 2 octets; so it is sent in minutes.
+            (datetime.timedelta(seconds=70020), b"\x09\x04\x8f"),
+        ]:
+            self.assertEqual(naz.Client._broadcast_frequency_interval(interval), expected)
+        for interval in [datetime.timedelta(seconds=-1), 10, datetime.timedelta(seconds=65536 + 1)]:
+            with self.assertRaises(ValueError):
+                naz.Client._broadcast_frequency_interval(interval)
+
+    def test_broadcast_message_validation(self):
+        kwargs = dict(
+            message="flood",
+            source_addr="112",
+            broadcast_area_identifiers=[b"\x00Nairobi"],
+            broadcast_content_type=0x0001,
+            broadcast_rep_num=3,
+            broadcast_frequency_interval=datetime.timedelta(minutes=10),
+        )
+        for bad in [
+            dict(broadcast_area_identifiers=[]),
+            dict(broadcast_area_identifiers=["Nairobi"]),
+            dict(broadcast_content_type=0x10000),
+            dict(broadcast_rep_num=-1),
+            dict(priority_flag=4),
+        ]:
+            with self.assertRaises(ValueError):
+                self._run(self.cli.broadcast_message(**{**kwargs, **bad}))
+
+    def test_query_broadcast_message(self):
+        sent_pdus = []
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            body = (
+                b"bcast-1\x00"
+                + b"\x04\x27\x00\x01\x02"
+                + b"\x06\x06\x00\x08\x00Nairobi"
+                + b"\x06\x08\x00\x01\x5a"
+                + b"\x06\x09\x00\x11000001000000000R\x00"
+            )
+            header = struct.pack(">IIII", 16 + len(body), 0x80000112, 0x00000000, sequence_number)
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(header + body))
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            result = self._run(
+                self.cli.query_broadcast_message(message_id="bcast-1", source_addr="112")
+            )
+        self.assertEqual(
+            result,
+            naz.BroadcastQueryResult(
+                message_id="bcast-1",
+                message_state=naz.SmppMessageState.DELIVERED,
+                broadcast_area_identifiers=[b"\x00Nairobi"],
+                broadcast_area_success=[90],
+                broadcast_end_time="000001000000000R",
+            ),
+        )
+        self.assertEqual(struct.unpack(">I", sent_pdus[0][4:8])[0], 0x00000112)
+        self.assertEqual(sent_pdus[0][16:], b"bcast-1\x00\x01\x01112\x00")
+
+    def test_cancel_broadcast_message(self):
+        sent_pdus = []
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            header = struct.pack(
+                ">IIII",
+                16,
+                0x80000113,
+                naz.SmppCommandStatus.ESME_RCANCELFAIL.value,
+                sequence_number,
+            )
+            asyncio.get_event_loop().create_task(self.cli._parse_response_pdu(header))
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            with self.assertRaises(naz.client.NazCommandStatusError) as raised_exception:
+                self._run(
+                    self.cli.cancel_broadcast_message(
+                        message_id="bcast-1", source_addr="112", service_type="CBS"
+                    )
+                )
+        self.assertEqual(
+            raised_exception.exception.command_status, naz.SmppCommandStatus.ESME_RCANCELFAIL
+        )
+        self.assertEqual(struct.unpack(">I", sent_pdus[0][4:8])[0], 0x00000113)
+        self.assertEqual(sent_pdus[0][16:], b"CBS\x00bcast-1\x00\x01\x01112\x00")
+
+    def test_submit_sm_generic_optional_params(self):
+        tlvs = [naz.TLV(tag=0x1400, value=b"vendor-value"), naz.TLV(tag=0x0005, value=b"\x02")]
+        proto_msg = naz.protocol.SubmitSM(
+            version=1,
+            log_id="log_id",
+            short_message="Hello",
+            source_addr="2547000000",
+            destination_addr="254711999999",
+            sar_msg_ref_num=7,
+            optional_params=tlvs,
+        )
+        pdu = self._run(self.cli._build_submit_sm_pdu(proto_msg))
+        self.assertEqual(struct.unpack(">I", pdu[:4])[0], len(pdu))
+        self.assertTrue(pdu.endswith(tlvs[0].tlv + tlvs[1].tlv))
+        self.assertEqual(
+            naz.protocol.read_optional_params(pdu),
+            [naz.TLV(tag=0x020C, value=b"\x00\x07")] + tlvs,
+        )
+
+    def test_build_data_sm_pdu(self):
+        proto_msg = naz.protocol.DataSM(
+            version=1,
+            log_id="log_id",
+            message_payload="hello €",
+            smpp_command=naz.SmppCommand.DATA_SM,
+            source_addr="2547000000",
+            destination_addr="254711999999",
+            service_type="WAP",
+            destination_port=9200,
+        )
+        pdu = self._run(self.cli._build_data_sm_pdu(proto_msg))
+        self.assertEqual(struct.unpack(">I", pdu[:4])[0], len(pdu))
+        self.assertEqual(struct.unpack(">I", pdu[4:8])[0], 0x00000103)
+        body = (
+            b"WAP\x00"
+            + b"\x01\x01"
+            + b"2547000000\x00"
+            + b"\x01\x01"
+            + b"254711999999\x00"
+            + b"\x03"  # esm_class
+            + b"\x01"  # registered_delivery
+            + b"\x00"  # data_coding
+            # there's no short_message field, the payload is in the message_payload TLV
+            + struct.pack(">HH", 0x0424, 8)
+            + b"hello \x1b\x65"
+            + struct.pack(">HHH", 0x020B, 2, 9200)
+        )
+        self.assertEqual(pdu[16:], body)
+
+    def test_data_sm_sending(self):
+        proto_msg = naz.protocol.DataSM(
+            log_id="log_id",
+            message_payload="hello",
+            source_addr="2547000000",
+            destination_addr="254711999999",
+        )
+        with mock.patch("naz.broker.SimpleBroker.dequeue", new=AsyncMock()) as mock_naz_dequeue:
+            mock_naz_dequeue.mock.return_value = proto_msg
+            with mock.patch("naz.Client.send_data", new=AsyncMock()) as mock_send_data:
+                self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+                self._run(self.cli.dequeue_messages(TESTING=True))
+                self.assertEqual(mock_send_data.mock.call_count, 1)
+                self.assertEqual(
+                    mock_send_data.mock.call_args[1]["smpp_command"], naz.SmppCommand.DATA_SM
+                )
+
+    def test_data_sm_resp_correlation(self):
+        with mock.patch(
+            "naz.correlater.SimpleCorrelater.put", new=AsyncMock()
+        ) as mock_correlater_put:
+            body = b"some-smsc-message-id\x00" + struct.pack(">HHB", 0x0420, 1, 0)
+            header = struct.pack(">IIII", 16 + len(body), 0x80000103, 0x00000000, 7)
+            self._run(self.cli._parse_response_pdu(header + body))
+            self.assertEqual(
+                mock_correlater_put.mock.call_args[1]["smsc_message_id"], "some-smsc-message-id"
+            )
+
+    @staticmethod
+    def _deliver_sm_pdu(
+        short_message, esm_class=0, data_coding=0, sequence_number=7, destination_addr=b"40404"
+    ):
+        body = (
+            b"\x00"  # service_type
+            + b"\x01\x01"
+            + b"254711999999\x00"
+            + b"\x00\x00"
+            + destination_addr
+            + b"\x00"
+            + struct.pack(">BBB", esm_class, 0, 0)
+            + b"\x00\x00"  # schedule_delivery_time, validity_period
+            + struct.pack(">BBBBB", 0, 0, data_coding, 0, len(short_message))
+            + short_message
+            + naz.TLV(tag=0x1400, value=b"vendor").tlv
+        )
+        header = struct.pack(">IIII", 16 + len(body), 0x00000005, 0x00000000, sequence_number)
+        return header + body
+
+    def test_on_deliver_sm(self):
+        received = []
+        sent_pdus = []
+
+        async def handler(message):
+            received.append(message)
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+
+        self.cli.on_deliver_sm(handler)
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            self._run(self.cli._parse_response_pdu(self._deliver_sm_pdu(b"hello \x1b\x65")))
+
+        self.assertEqual(len(received), 1)
+        self.assertEqual(received[0].short_message, "hello €")
+        self.assertEqual(received[0].source_addr, "254711999999")
+        self.assertEqual(received[0].destination_addr, "40404")
+        self.assertEqual(received[0].sequence_number, 7)
+        self.assertEqual(received[0].optional_params, [naz.TLV(tag=0x1400, value=b"vendor")])
+
+        # the deliver_sm_resp
+        self.assertEqual(
+            struct.unpack(">IIII", sent_pdus[0][:16]), (17, 0x80000005, 0x00000000, 7)
+        )
+
+    def test_on_deliver_sm_error(self):
+        sent_pdus = []
+
+        async def handler(message):
+            raise ValueError("database is down")
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+
+        self.cli.on_deliver_sm(handler)
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            self._run(self.cli._parse_response_pdu(self._deliver_sm_pdu(b"hello")))
+
+        self.assertEqual(
+            struct.unpack(">I", sent_pdus[0][8:12])[0], naz.SmppCommandStatus.ESME_RX_T_APPN.value
+        )
+
+    def test_on_deliver_sm_command_status(self):
+        sent_pdus = []
+
+        async def handler(message):
+            if message.short_message == "database is down":
+                return naz.SmppCommandStatus.ESME_RSYSERR
+            if message.short_message == "bad handler":
+                return 8
+            return None
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sent_pdus.append(msg)
+
+        self.cli.on_deliver_sm(handler)
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            for short_message in [b"database is down", b"hello", b"bad handler"]:
+                self._run(self.cli._parse_response_pdu(self._deliver_sm_pdu(short_message)))
+
+        self.assertEqual(
+            [struct.unpack(">I", pdu[8:12])[0] for pdu in sent_pdus],
+            [
+                naz.SmppCommandStatus.ESME_RSYSERR.value,
+                naz.SmppCommandStatus.ESME_ROK.value,
+                # the handler returned something other than a `naz.CommandStatus`
+                naz.SmppCommandStatus.ESME_RX_T_APPN.value,
+            ],
+        )
+
+    def test_on_deliver_sm_without_text_codec(self):
+        received = []
+
+        async def handler(message):
+            received.append(message)
+
+        self.cli.on_deliver_sm(handler)
+        # octet unspecified(binary), a message class coding with 8-bit data, and a reserved coding.
+        data_codings = [0x04, 0xF5, 0x10]
+        with mock.patch("naz.Client.deliver_sm_resp", new=AsyncMock()) as mock_deliver_sm_resp:
+            for data_coding in data_codings:
+                self._run(
+                    self.cli._parse_response_pdu(
+                        self._deliver_sm_pdu(b"\x00\xff\x01", data_coding=data_coding)
+                    )
+                )
+                self.assertEqual(
+                    mock_deliver_sm_resp.mock.call_args[1]["command_status"],
+                    naz.SmppCommandStatus.ESME_ROK.value,
+                )
+
+        self.assertEqual([m.data_coding for m in received], data_codings)
+        for message in received:
+            self.assertIsNone(message.short_message)
+            self.assertEqual(message.raw_short_message, b"\x00\xff\x01")
+            self.assertEqual(message.encoding, "")
+            self.assertIsNone(naz.protocol.DeliverSM.from_json(message.to_json()).short_message)
+
+    def test_on_deliver_sm_not_called_for_receipts(self):
+        received = []
+
+        async def handler(message):
+            received.append(message)
+
+        self.cli.on_deliver_sm(handler)
+        with mock.patch("naz.Client.deliver_sm_resp", new=AsyncMock()) as mock_deliver_sm_resp:
+            self._run(
+                self.cli._parse_response_pdu(
+                    self._deliver_sm_pdu(b"id:123 stat:DELIVRD", esm_class=0b00000100)
+                )
+            )
+            self.assertEqual(received, [])
+            self.assertEqual(
+                mock_deliver_sm_resp.mock.call_args[1]["command_status"],
+                naz.SmppCommandStatus.ESME_ROK.value,
+            )
+
+    def test_deliver_sm_resp_sent_hook(self):
+        sent = []
+
+        class Hook(naz.hooks.SimpleHook):
+            async def deliver_sm_resp_sent(
+                self, log_id, hook_metadata, sequence_number, command_status, message_id
+            ):
+                sent.append((sequence_number, command_status, message_id))
+
+        async def handler(message):
+            raise ValueError("database is down")
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            return True
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            hook=Hook(),
+        )
+        cli.on_deliver_sm(handler)
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            for pdu in [
+                self._deliver_sm_pdu(
+                    b"id:123 stat:DELIVRD", esm_class=0b00000100, sequence_number=8
+                ),
+                self._deliver_sm_pdu(b"hello", sequence_number=9),
+            ]:
+                self._run(cli._parse_response_pdu(pdu))
+
+        self.assertEqual(
+            sent,
+            [
+                (8, naz.SmppCommandStatus.ESME_ROK.value, "123"),
+                (9, naz.SmppCommandStatus.ESME_RX_T_APPN.value, ""),
+            ],
+        )
+
+        # it is not called if the deliver_sm_resp was not written.
+        async def mock_send_data_fails(_self, smpp_command, msg, log_id, hook_metadata=""):
+            return False
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data_fails):
+            self._run(cli._parse_response_pdu(self._deliver_sm_pdu(b"hello", sequence_number=10)))
+        self.assertEqual(len(sent), 2)
+
+    def test_on_deliver_sm_for(self):
+        received = []
+
+        def handler(name):
+            async def handle(message):
+                received.append((name, message.destination_addr))
+
+            return handle
+
+        self.cli.on_deliver_sm(handler("default"))
+        self.cli.on_deliver_sm_for("40404", handler("votes"))
+        self.cli.on_deliver_sm_for("3030", handler("promotions"), prefix=True)
+        self.cli.on_deliver_sm_for("30301", handler("competition"), prefix=True)
+        with mock.patch("naz.Client.deliver_sm_resp", new=AsyncMock()):
+            for destination_addr in [b"40404", b"404041", b"30305", b"303012", b"8080"]:
+                self._run(
+                    self.cli._parse_response_pdu(
+                        self._deliver_sm_pdu(b"hello", destination_addr=destination_addr)
+                    )
+                )
+
+        self.assertEqual(
+            received,
+            [
+                ("votes", "40404"),
+                # only an exact match is made for "40404"
+                ("default", "404041"),
+                ("promotions", "30305"),
+                # the longest prefix matches
+                ("competition", "303012"),
+                ("default", "8080"),
+            ],
+        )
+
+        with self.assertRaises(ValueError):
+            self.cli.on_deliver_sm_for("", handler("empty"))
+        with self.assertRaises(ValueError):
+            self.cli.on_deliver_sm_for("40404", lambda message: None)
+
+    def test_on_deliver_sm_bad_handler(self):
+        with self.assertRaises(ValueError):
+            self.cli.on_deliver_sm(lambda message: None)
+
+    def _client_with_bind_mode(self, bind_mode):
+        return naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            socket_timeout=self.socket_timeout,
+            bind_mode=bind_mode,
+        )
+
+    def test_bind_modes(self):
+        for bind_mode, command_id, bound_state in [
+            (naz.BindMode.TRANSMITTER, 0x00000002, naz.SmppSessionState.BOUND_TX),
+            (naz.BindMode.RECEIVER, 0x00000001, naz.SmppSessionState.BOUND_RX),
+            (naz.BindMode.TRANSCEIVER, 0x00000009, naz.SmppSessionState.BOUND_TRX),
+        ]:
+            cli = self._client_with_bind_mode(bind_mode)
+            with mock.patch("naz.Client.send_data", new=AsyncMock()) as mock_send_data:
+                self._run(cli.bind())
+                pdu = mock_send_data.mock.call_args[1]["msg"]
+                self.assertEqual(struct.unpack(">I", pdu[4:8])[0], command_id)
+                self.assertIn(b"smppclient1\x00", pdu)
+
+            # a successful bind response
+            body = b"SMSC\x00"
+            header = struct.pack(">IIII", 16 + len(body), 0x80000000 | command_id, 0, 1)
+            self._run(cli._parse_response_pdu(header + body))
+            self.assertEqual(cli.current_session_state, bound_state)
+
+    def test_smsc_system_id(self):
+        self.assertEqual(self.cli.smsc_system_id(), "")
+
+        # a failed bind
+        header = struct.pack(">IIII", 16, 0x80000009, 0x0000000D, 1)
+        self._run(self.cli._parse_response_pdu(header))
+        self.assertEqual(self.cli.smsc_system_id(), "")
+
+        body = b"acme-smsc-07\x00"
+        header = struct.pack(">IIII", 16 + len(body), 0x80000009, 0, 2)
+        self._run(self.cli._parse_response_pdu(header + body))
+        self.assertEqual(self.cli.smsc_system_id(), "acme-smsc-07")
+        self.assertEqual(self.cli.smsc_interface_version(), None)
+
+    def test_receiver_cannot_send(self):
+        cli = self._client_with_bind_mode(naz.BindMode.RECEIVER)
+        with self.assertRaises(ValueError) as raised_exception:
+            self._run(
+                cli.send_message(
+                    naz.protocol.SubmitSM(
+                        short_message="hello",
+                        log_id="log_id",
+                        source_addr="2547000000",
+                        destination_addr="254711999999",
+                    )
+                )
+            )
+        self.assertIn("`send_message` cannot be used", str(raised_exception.exception))
+        with self.assertRaises(ValueError):
+            self._run(cli.query_message(message_id="some-id", source_addr="2547000000"))
+        with self.assertRaises(ValueError) as raised_exception:
+            self._run(
+                cli.cancel_message(
+                    message_id="some-id", source_addr="2547000000", destination_addr="254711999999"
+                )
+            )
+        self.assertIn(
+            "`cancel_message` cannot be used when naz is bound as a `RECEIVER`",
+            str(raised_exception.exception),
+        )
+        with self.assertRaises(ValueError) as raised_exception:
+            self._run(cli.submit_batch([]))
+        self.assertIn("`submit_batch` cannot be used", str(raised_exception.exception))
+        with self.assertRaises(ValueError) as raised_exception:
+            self._run(
+                cli.submit_multi(
+                    short_message="hello", source_addr="2547000000", destination_addrs=["2547"]
+                )
+            )
+        self.assertIn("`submit_multi` cannot be used", str(raised_exception.exception))
+
+    def test_transmitter_cannot_receive(self):
+        async def handler(message):
+            pass
+
+        cli = self._client_with_bind_mode(naz.BindMode.TRANSMITTER)
+        with self.assertRaises(ValueError) as raised_exception:
+            cli.on_deliver_sm(handler)
+        self.assertIn("`on_deliver_sm` cannot be used", str(raised_exception.exception))
+        with self.assertRaises(ValueError) as raised_exception:
+            cli.on_deliver_sm_for("40404", handler)
+        self.assertIn(
+            "`on_deliver_sm_for` cannot be used when naz is bound as a `TRANSMITTER`",
+            str(raised_exception.exception),
+        )
+
+    def _auto_reconnect_client(self, **kwargs):
+        kwargs.setdefault("smsc_host", "127.0.0.1")
+        kwargs.setdefault("smsc_port", TestClient.smsc_port)
+        return naz.Client(
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            socket_timeout=self.socket_timeout,
+            auto_reconnect=True,
+            reconnect_initial_interval=0.001,
+            reconnect_max_interval=0.004,
+            **kwargs
+        )
+
+    def test_auto_reconnect(self):
+        smsc = naz.testing.MockSMSC()
+        self._run(smsc.start())
+        cli = self._auto_reconnect_client(smsc_host=smsc.host, smsc_port=smsc.port)
+        self._run(cli.connect())
+        self._run(cli.bind())
+        self.assertEqual(cli.state(), naz.SmppSessionState.BOUND_TRX)
+
+        # the connection to SMSC is lost, and the first two attempts to re-connect fail.
+        cli.writer.close()
+        open_connection = asyncio.open_connection
+        attempts = []
+
+        async def mock_open_connection(*args, **kwargs):
+            attempts.append(args)
+            if len(attempts) <= 2:
+                raise ConnectionRefusedError("smsc is down")
+            return await open_connection(*args, **kwargs)
+
+        with mock.patch("asyncio.open_connection", new=mock_open_connection), mock.patch(
+            "naz.Client.bind", wraps=cli.bind
+        ) as mock_bind:
+            self._run(cli.re_establish_conn_bind(smpp_command="", log_id="log_id"))
+        self._run(smsc.stop())
+
+        self.assertEqual(len(attempts), 3)
+        self.assertEqual(mock_bind.call_count, 1)
+        self.assertEqual(cli.state(), naz.SmppSessionState.BOUND_TRX)
+        self.assertFalse(cli.writer.transport.is_closing())
+        self.assertFalse(cli._reconnecting)
+
+    def test_reconnect_backoff(self):
+        cli = self._auto_reconnect_client(reconnect_jitter=0.0)
+        self.assertEqual(
+            [cli._reconnect_backoff(attempt) for attempt in range(5)],
+            [0.001, 0.002, 0.004, 0.004, 0.004],
+        )
+        cli = self._auto_reconnect_client(reconnect_jitter=0.5)
+        for _ in range(50):
+            self.assertTrue(0.0005 <= cli._reconnect_backoff(0) <= 0.0015)
+
+        # a long outage does not overflow the backoff.
+        cli = self._auto_reconnect_client(reconnect_jitter=0.0)
+        self.assertEqual(cli._reconnect_backoff(10_000), 0.004)
+
+    def test_send_while_reconnecting(self):
+        proto_msg = naz.protocol.SubmitSM(
+            short_message="hello",
+            log_id="log_id",
+            source_addr="2547000000",
+            destination_addr="254711999999",
+        )
+        cli = self._auto_reconnect_client(reconnect_fail_fast=True)
+        cli._reconnecting = True
+        with self.assertRaises(naz.client.NazConnectionError):
+            self._run(cli.send_message(proto_msg))
+
+        cli = self._auto_reconnect_client()
+        cli._reconnecting = True
+
+        async def reconnected():
+            await asyncio.sleep(0.01)
+            cli._reconnecting = False
+            cli._reconnected.set()
+
+        async def send():
+            await asyncio.gather(cli.send_message(proto_msg), reconnected())
+
+        with mock.patch("naz.broker.SimpleBroker.enqueue", new=AsyncMock()) as mock_enqueue:
+            self._run(send())
+            self.assertTrue(mock_enqueue.mock.called)
+
+    def test_bad_ssl_context(self):
+        with self.assertRaises(naz.client.NazClientError):
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=TestClient.smsc_port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                ssl_context=True,
+            )
+
+    def _tls_server_context(self):
+        here = os.path.dirname(os.path.abspath(__file__))
+        server_context = ssl.create_default_context(ssl.Purpose.CLIENT_AUTH)
+        server_context.load_cert_chain(
+            os.path.join(here, "tls_cert.pem"), os.path.join(here, "tls_key.pem")
+        )
+        return server_context
+
+    def _tls_client(self, port, ssl_context):
+        return naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            socket_timeout=1.0,
+            ssl_context=ssl_context,
+            logger=naz.log.SimpleLogger("test_tls", level="WARNING"),
+        )
+
+    def test_tls_bind(self):
+        received_command_ids = []
+
+        async def handle_conn(reader, writer):
+            header = await reader.readexactly(16)
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            await reader.readexactly(command_length - 16)
+            received_command_ids.append(command_id)
+            body = b"SMSC\x00"
+            writer.write(
+                struct.pack(">IIII", 16 + len(body), 0x80000009, 0, sequence_number) + body
+            )
+            await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(
+                handle_conn, "127.0.0.1", 0, ssl=self._tls_server_context()
+            )
+            port = server.sockets[0].getsockname()[1]
+
+            here = os.path.dirname(os.path.abspath(__file__))
+            client_context = ssl.create_default_context(cafile=os.path.join(here, "tls_cert.pem"))
+            cli = self._tls_client(port, client_context)
+            await cli.connect()
+            await cli.bind()
+            await cli.receive_data(TESTING=True)
+            server.close()
+            await server.wait_closed()
+            return cli
+
+        cli = self._run(run())
+        self.assertIsNotNone(cli.writer.get_extra_info("ssl_object"))
+        self.assertEqual(received_command_ids, [0x00000009])
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.BOUND_TRX)
+
+    def test_tls_certificate_verification(self):
+        async def handle_conn(reader, writer):
+            writer.close()
+
+        async def run():
+            server = await asyncio.start_server(
+                handle_conn, "127.0.0.1", 0, ssl=self._tls_server_context()
+            )
+            port = server.sockets[0].getsockname()[1]
+            # the self-signed certificate of the server is not trusted by the default context.
+            cli = self._tls_client(port, ssl.create_default_context())
+            await cli.connect()
+            server.close()
+            await server.wait_closed()
+            return cli
+
+        with mock.patch("naz.Client._log") as mock_log:
+            cli = self._run(run())
+            self.assertIsNone(cli.writer)
+            self.assertIn("CERTIFICATE_VERIFY_FAILED", str(mock_log.call_args_list))
+
+    def test_cancel_send_message(self):
+        broker = naz.broker.SimpleBroker(maxsize=1, block_on_full=True)
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=broker,
+            logger=naz.log.SimpleLogger("test_cancel_send_message", level="WARNING"),
+        )
+
+        def msg(i):
+            return naz.protocol.SubmitSM(
+                short_message="hello",
+                log_id="log_id-{0}".format(i),
+                source_addr="2547000000",
+                destination_addr="254711999999",
+            )
+
+        async def run():
+            await cli.send_message(msg(1))
+            # the broker is now full, thus this send blocks.
+            task = asyncio.ensure_future(cli.send_message(msg(2)))
+            await asyncio.sleep(0.02)
+            self.assertFalse(task.done())
+            task.cancel()
+            await asyncio.sleep(0.01)
+            return task
+
+        task = self._run(run())
+        self.assertTrue(task.cancelled())
+        self.assertEqual(broker.size(), 1)
+
+    def test_cancel_query_message(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            socket_timeout=5.0,
+            logger=naz.log.SimpleLogger("test_cancel_query_message", level="WARNING"),
+        )
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            # SMSC never responds
+            pass
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data):
+            start = time.monotonic()
+            with self.assertRaises(asyncio.TimeoutError):
+                self._run(
+                    asyncio.wait_for(
+                        cli.query_message(message_id="some-id", source_addr="2547000000"),
+                        timeout=0.05,
+                    )
+                )
+            self.assertLess(time.monotonic() - start, 1.0)
+        self.assertEqual(cli._pending_responses, {})
 
-            self.assertTrue(mock_naz_command_handlers.mock.called)
-            self.assertEqual(mock_naz_command_handlers.mock.call_count, 1)
-            self.assertEqual(
-                mock_naz_command_handlers.mock.call_args[1]["smpp_command"],
-                naz.SmppCommand.DELIVER_SM,
+    def test_cancel_dequeue_messages_while_rate_limited(self):
+        class SlowRateLimiter(naz.ratelimiter.BaseRateLimiter):
+            async def limit(self):
+                await asyncio.sleep(10)
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            rate_limiter=SlowRateLimiter(),
+            logger=naz.log.SimpleLogger("test_cancel_dequeue_messages", level="WARNING"),
+        )
+        cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+
+        async def run():
+            task = asyncio.ensure_future(cli.dequeue_messages())
+            await asyncio.sleep(0.02)
+            task.cancel()
+            await asyncio.sleep(0.01)
+            return task
+
+        task = self._run(run())
+        self.assertTrue(task.cancelled())
+
+    def _shutdown_client(self, port, broker, drain_duration):
+        return naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=broker,
+            socket_timeout=1.0,
+            drain_duration=drain_duration,
+            logger=naz.log.SimpleLogger("test_shutdown", level="WARNING"),
+        )
+
+    def test_shutdown_drains_queue(self):
+        received_command_ids = []
+
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                received_command_ids.append(command_id)
+                # respond to every request
+                body = b"" if command_id == 0x00000006 else b"SMSC\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number
+                    )
+                    + body
+                )
+                await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            port = server.sockets[0].getsockname()[1]
+            broker = naz.broker.SimpleBroker(maxsize=100)
+            cli = self._shutdown_client(port, broker, drain_duration=5.0)
+            await cli.connect()
+            await cli.bind()
+            for i in range(0, 3):
+                await cli.send_message(
+                    naz.protocol.SubmitSM(
+                        short_message="hello-{0}".format(i),
+                        log_id="log_id-{0}".format(i),
+                        source_addr="2547000000",
+                        destination_addr="254711999999",
+                    )
+                )
+            tasks = [
+                asyncio.ensure_future(cli.dequeue_messages()),
+                asyncio.ensure_future(cli.receive_data()),
+            ]
+            start = time.monotonic()
+            remaining = await cli.shutdown()
+            duration = time.monotonic() - start
+            for task in tasks:
+                task.cancel()
+            server.close()
+            await server.wait_closed()
+            return cli, remaining, duration
+
+        cli, remaining, duration = self._run(run())
+        self.assertEqual(remaining, 0)
+        # the queued messages were all sent before unbind
+        self.assertEqual(
+            received_command_ids, [0x00000009, 0x00000004, 0x00000004, 0x00000004, 0x00000006]
+        )
+        # we did not have to wait for the whole drain_duration
+        self.assertLess(duration, 2.0)
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.CLOSED)
+        self.assertEqual(cli._pending_responses, {})
+
+    def test_shutdown_releases_outstanding_requests(self):
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                body = await reader.readexactly(command_length - 16)
+                if command_id == 0x00000004 and b"slow" in body:
+                    # a slow SMSC; it responds after a while.
+                    await asyncio.sleep(0.2)
+                elif command_id == 0x00000004:
+                    # it never responds to this one.
+                    continue
+                body = b"" if command_id == 0x00000006 else b"SMSC\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number
+                    )
+                    + body
+                )
+                await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                drain_duration=0.5,
+                response_timeout=30.0,
+                logger=naz.log.SimpleLogger("test_shutdown", level="CRITICAL"),
             )
+            await cli.connect()
+            await cli.bind()
+            # the bind_transceiver_resp
+            await cli.receive_data(TESTING=True)
+            receiving = asyncio.ensure_future(cli.receive_data())
+            submits = [
+                asyncio.ensure_future(
+                    cli.submit_message(
+                        naz.protocol.SubmitSM(
+                            short_message=short_message,
+                            log_id="log_id-{0}".format(short_message),
+                            source_addr="2547000000",
+                            destination_addr="254711999999",
+                        )
+                    )
+                )
+                for short_message in ["slow", "never"]
+            ]
+            while cli.outstanding()[0] < 2:
+                await asyncio.sleep(0.01)
+            start = time.monotonic()
+            await cli.shutdown()
+            results = await asyncio.gather(*submits, return_exceptions=True)
+            duration = time.monotonic() - start
+            receiving.cancel()
+            server.close()
+            await server.wait_closed()
+            return cli, results, duration
 
-    def test_submit_sm_AND_deliver_sm_correlation(self):
-        with mock.patch(
-            "naz.sequence.SimpleSequenceGenerator.next_sequence"
-        ) as mock_sequence, mock.patch(
-            "naz.broker.SimpleBroker.dequeue", new=AsyncMock()
-        ) as mock_naz_dequeue:
-            mock_sequence_number = 909_012
-            mock_sequence.return_value = mock_sequence_number
+        cli, results, duration = self._run(run())
+        # SMSC responded to the slow request within the drain_duration.
+        self.assertEqual(results[0], ["SMSC"])
+        # the caller of the request that SMSC never responded to is released, rather than left waiting for the response_timeout.
+        self.assertIsInstance(results[1], naz.client.NazConnectionError)
+        self.assertIn("naz is shutting down", str(results[1]))
+        self.assertLess(duration, 1.5)
+        self.assertEqual(cli._pending_responses, {})
 
-            log_id = "MyLog_id123456"
-            short_message = "hello smpp"
-            _hook_metadata = {"telco": "Verizon", "customer_id": "909090123"}
-            hook_metadata = json.dumps(_hook_metadata)
-            mock_naz_dequeue.mock.return_value = naz.protocol.SubmitSM(
-                version=1,
-                log_id=log_id,
-                short_message=short_message,
-                smpp_command=naz.SmppCommand.SUBMIT_SM,
+    def test_shutdown_drain_duration_elapses(self):
+        broker = naz.broker.SimpleBroker(maxsize=100)
+        cli = self._shutdown_client(TestClient.smsc_port, broker, drain_duration=0.1)
+
+        def msg(i):
+            return naz.protocol.SubmitSM(
+                short_message="hello",
+                log_id="log_id-{0}".format(i),
                 source_addr="2547000000",
                 destination_addr="254711999999",
-                hook_metadata=hook_metadata,
             )
 
-            # 1. SEND SUBMIT_SM
-            self._run(self.cli.connect())
-            # hack to allow sending submit_sm even when state is wrong
-            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
-            self._run(self.cli.dequeue_messages(TESTING=True))
-            self.assertTrue(self.cli.correlation_handler.store[mock_sequence_number])
-            self.assertEqual(
-                self.cli.correlation_handler.store[mock_sequence_number]["log_id"], log_id
+        async def run():
+            await cli.connect()
+            await cli.bind()
+            for i in range(0, 3):
+                await cli.send_message(msg(i))
+            # nothing is dequeueing the messages, so they are never sent.
+            return await cli.shutdown()
+
+        self.assertEqual(self._run(run()), 3)
+        self.assertIsNone(cli.writer)
+        with self.assertRaises(naz.client.NazConnectionError):
+            self._run(cli.send_message(msg(4)))
+
+    def test_pause_and_resume(self):
+        received_command_ids = []
+        writers = []
+
+        async def handle_conn(reader, writer):
+            writers.append(writer)
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                received_command_ids.append(command_id)
+                if command_id & 0x80000000:
+                    # a response, eg deliver_sm_resp
+                    continue
+                body = b"" if command_id in [0x00000006, 0x00000015] else b"SMSC\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number
+                    )
+                    + body
+                )
+                await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            port = server.sockets[0].getsockname()[1]
+            broker = naz.broker.SimpleBroker(maxsize=100)
+            cli = self._shutdown_client(port, broker, drain_duration=5.0)
+            cli.enquire_link_interval = 0.1
+            await cli.connect()
+            await cli.bind()
+            tasks = [
+                asyncio.ensure_future(cli.dequeue_messages()),
+                asyncio.ensure_future(cli.receive_data()),
+                asyncio.ensure_future(cli.enquire_link()),
+            ]
+
+            cli.pause()
+            self.assertTrue(cli.paused)
+            for i in range(0, 3):
+                await cli.send_message(
+                    naz.protocol.SubmitSM(
+                        short_message="hello-{0}".format(i),
+                        log_id="log_id-{0}".format(i),
+                        source_addr="2547000000",
+                        destination_addr="254711999999",
+                    )
+                )
+            # a deliver_sm is still replied to.
+            writers[0].write(self._deliver_sm_pdu(b"hello"))
+            await asyncio.sleep(0.5)
+            sent_while_paused = list(received_command_ids)
+            queued_while_paused = broker.size()
+
+            cli.resume()
+            self.assertFalse(cli.paused)
+            for _ in range(0, 50):
+                if received_command_ids.count(0x00000004) == 3:
+                    break
+                await asyncio.sleep(0.05)
+            remaining = await cli.shutdown()
+            for task in tasks:
+                task.cancel()
+            server.close()
+            await server.wait_closed()
+            return sent_while_paused, queued_while_paused, remaining
+
+        sent_while_paused, queued_while_paused, remaining = self._run(run())
+        # nothing was sent while paused, but the bind was kept alive.
+        self.assertNotIn(0x00000004, sent_while_paused)
+        self.assertIn(0x00000015, sent_while_paused)
+        self.assertIn(0x80000005, sent_while_paused)
+        self.assertEqual(queued_while_paused, 3)
+        # the queued messages were sent once resumed
+        self.assertEqual(received_command_ids.count(0x00000004), 3)
+        self.assertEqual(remaining, 0)
+
+    def test_outstanding(self):
+        async def handle_conn(reader, writer):
+            # SMSC does not respond to anything.
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length = struct.unpack(">I", header[:4])[0]
+                await reader.readexactly(command_length - 16)
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = self._shutdown_client(
+                server.sockets[0].getsockname()[1],
+                naz.broker.SimpleBroker(maxsize=100),
+                drain_duration=0.1,
             )
-            self.assertEqual(
-                self.cli.correlation_handler.store[mock_sequence_number]["hook_metadata"],
-                hook_metadata,
+            await cli.connect()
+            await cli.bind()
+            outstanding = [cli.outstanding()]
+            sequence_numbers = []
+            for i in range(0, 2):
+                pdu = await cli._build_submit_sm_pdu(
+                    naz.protocol.SubmitSM(
+                        short_message="hello",
+                        log_id="log_id-{0}".format(i),
+                        source_addr="2547000000",
+                        destination_addr="254711999999",
+                    )
+                )
+                sequence_numbers.append(struct.unpack(">I", pdu[12:16])[0])
+                await cli.send_data(
+                    smpp_command=naz.SmppCommand.SUBMIT_SM, msg=pdu, log_id="log_id-{0}".format(i)
+                )
+                await asyncio.sleep(0.2)
+                outstanding.append(cli.outstanding())
+
+            # SMSC responds to the first one.
+            await cli._parse_response_pdu(
+                struct.pack(">IIII", 18, 0x80000004, 0, sequence_numbers[0]) + b"1\x00"
             )
+            outstanding.append(cli.outstanding())
+            cli.writer.close()
+            server.close()
+            await server.wait_closed()
+            return outstanding
 
-            # 2. RECEIVE SUBMIT_SM_RESP
-            submit_sm_resp_smsc_message_id = "1618Z-0102G-2333M-25FJF"
-            body = b""
-            body = body + submit_sm_resp_smsc_message_id.encode("ascii") + chr(0).encode("ascii")
-            command_length = 16 + len(body)  # 16 is for headers
-            command_id = 0x80000004  # submit_sm_resp
-            command_status = 0x00000000  # success
-            header = struct.pack(
-                ">IIII", command_length, command_id, command_status, mock_sequence_number
-            )  # SUBMIT_SM_RESP should have same sequence_number as SUBMIT_SM
-            submit_sm_resp_full_pdu = header + body
-            self._run(self.cli._parse_response_pdu(pdu=submit_sm_resp_full_pdu))
+        outstanding = self._run(run())
+        self.assertEqual(outstanding[0], (0, 0.0))
+        self.assertEqual(outstanding[1][0], 1)
+        self.assertEqual(outstanding[2][0], 2)
+        self.assertTrue(0.2 <= outstanding[1][1] < outstanding[2][1] < 1.0, outstanding)
+        # the oldest is now the second request.
+        self.assertEqual(outstanding[3][0], 1)
+        self.assertTrue(0.2 <= outstanding[3][1] < outstanding[2][1], outstanding)
 
-            # assert message_id  was stored
-            self.assertTrue(self.cli.correlation_handler.store[submit_sm_resp_smsc_message_id])
-            self.assertEqual(
-                self.cli.correlation_handler.store[submit_sm_resp_smsc_message_id]["log_id"], log_id
+    def test_enquire_link_interval_zero(self):
+        received_command_ids = []
+
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                received_command_ids.append(command_id)
+                if command_id == 0x00000009:
+                    body = b"SMSC\x00"
+                    writer.write(
+                        struct.pack(">IIII", 16 + len(body), 0x80000009, 0, sequence_number)
+                        + body
+                    )
+                    # the heartbeat of SMSC.
+                    writer.write(struct.pack(">IIII", 16, 0x00000015, 0, 1))
+                    await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                enquire_link_interval=0.0,
+                read_timeout=0.5,
+                logger=naz.log.SimpleLogger("test_enquire_link_interval_zero", level="CRITICAL"),
             )
+            await cli.connect()
+            await cli.bind()
+            tasks = [
+                asyncio.ensure_future(cli.dequeue_messages()),
+                asyncio.ensure_future(cli.enquire_link()),
+            ]
+            # read the bind_transceiver_resp, and then respond to the enquire_link of SMSC.
+            await cli.receive_data(TESTING=True)
+            await cli.receive_data(TESTING=True)
+            await asyncio.sleep(0.3)
+            for task in tasks:
+                task.cancel()
             self.assertEqual(
-                self.cli.correlation_handler.store[submit_sm_resp_smsc_message_id]["hook_metadata"],
-                hook_metadata,
+                await cli.enquire_link(TESTING=True), None, "no enquire_link is ever sent"
             )
 
-            # 3. RECEIVE DELIVER_SM
-            with mock.patch(
-                "naz.hooks.SimpleHook.from_smsc", new=AsyncMock()
-            ) as mock_hook_from_smsc:
-                tag = naz.OptionalTag.NAME_to_TAG["receipted_message_id"]
-                length = 0x0018  # 24 in length
-                tag_n_len = struct.pack(">HH", tag, length)
-                # DELIVER_SM has same message_id as SUBMIT_SM_RESP but DIFFERENT sequence_number
-                value = submit_sm_resp_smsc_message_id  # 23 in length
-                value = value.encode("ascii") + chr(0).encode("ascii")  # 24 in length
-                deliver_sm_pdu = (
-                    b"\x00\x00\x00M\x00\x00\x00\x05\x00\x00\x00"
-                    b"\x00\x9f\x88\xf1$AWSBD\x00\x01\x0116505551234"
-                    b"\x00\x01\x0117735554070\x00\x00\x00\x00\x00\x00"
-                    b"\x00\x00\x03\x00\x11id:1618Z-0102G-2333M-25FJF sub:SSS dlvrd:DDD blah blah"
-                )
-                deliver_sm_pdu = deliver_sm_pdu + tag_n_len + value
-                self._run(self.cli._parse_response_pdu(pdu=deliver_sm_pdu))
+            # SMSC has gone silent; the connection is dead after the read_timeout.
+            started = time.monotonic()
+            with self.assertRaises(asyncio.TimeoutError):
+                await cli._read_header()
+            duration = time.monotonic() - started
+            server.close()
+            await server.wait_closed()
+            return cli, duration
 
-                self.assertTrue(mock_hook_from_smsc.mock.called)
-                self.assertEqual(
-                    mock_hook_from_smsc.mock.call_args[1]["smpp_command"],
-                    naz.SmppCommand.DELIVER_SM,
-                )
-                self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["log_id"], log_id)
-                self.assertEqual(
-                    mock_hook_from_smsc.mock.call_args[1]["hook_metadata"], hook_metadata
-                )
+        cli, duration = self._run(run())
+        self.assertEqual(received_command_ids, [0x00000009, 0x80000015])
+        self.assertTrue(0.4 <= duration < 1.0, duration)
+        self.assertEqual(cli.current_session_state, naz.SmppSessionState.CLOSED)
 
-    def test_re_establish_conn_bind(self):
-        """
-        test that `Client.re_establish_conn_bind` calls `Client.connect` & `Client.tranceiver_bind`
-        """
-        with mock.patch("asyncio.open_connection", new=AsyncMock()) as mock_naz_connect, mock.patch(
-            "naz.Client.tranceiver_bind", new=AsyncMock()
-        ) as mock_naz_tranceiver_bind:
-            submit_sm_resp_pdu = (
-                b"\x00\x00\x00\x12\x80\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00\x030\x00"
+        with self.assertRaises(naz.client.NazClientError):
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=2775,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                enquire_link_interval=-1.0,
             )
-            mock_naz_connect.mock.return_value = (
-                MockStreamReader(pdu=submit_sm_resp_pdu),
-                MockStreamWriter(),
+
+    def test_stats(self):
+        self.assertEqual(
+            self.cli.stats(),
+            naz.Stats(
+                submitted=0,
+                delivered=0,
+                failed={},
+                queue_depth=0,
+                reconnects=0,
+                bytes_read=0,
+                bytes_written=0,
+                unexpected_responses=0,
+            ),
+        )
+
+        class RecordingStreamWriter(MockStreamWriter):
+            def __init__(self):
+                super(RecordingStreamWriter, self).__init__()
+                self.written = []
+
+            def write(self, data):
+                self.written.append(data)
+
+        def submit_sm_resp(command_status, sequence_number):
+            body = b"" if command_status else b"msg-id\x00"
+            header = struct.pack(
+                ">IIII", 16 + len(body), 0x80000004, command_status, sequence_number
             )
+            return header + body
 
-            self._run(
-                self.cli.re_establish_conn_bind(
-                    smpp_command=naz.SmppCommand.SUBMIT_SM, log_id="log_id", TESTING=True
-                )
+        def msg(i):
+            return naz.protocol.SubmitSM(
+                short_message="hello",
+                log_id="log_id-{0}".format(i),
+                source_addr="2547000000",
+                destination_addr="254711999999",
             )
-            self.assertTrue(mock_naz_connect.mock.called)
-            self.assertTrue(mock_naz_tranceiver_bind.mock.called)
 
-    def test_send_data_under_disconnection(self):
-        """
-        test that if sockect is disconnected, `naz` will try to re-connect & re-bind
-        """
-        with mock.patch("naz.Client.tranceiver_bind", new=AsyncMock()) as mock_naz_tranceiver_bind:
-            # do not connect or bind
-            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
-            self._run(
-                self.cli.send_data(
-                    smpp_command=naz.SmppCommand.SUBMIT_SM, msg=b"someMessage", log_id="log_id"
+        self.cli.writer = RecordingStreamWriter()
+        self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+        for i in range(0, 3):
+            self._run(self.cli.send_message(msg(i)))
+            self._run(self.cli.dequeue_messages(TESTING=True))
+        received_pdus = [
+            submit_sm_resp(0x00000000, 1),
+            submit_sm_resp(0x00000014, 2),
+            submit_sm_resp(0x00000014, 3),
+            self._deliver_sm_pdu(b"hello"),
+        ]
+        for pdu in received_pdus:
+            self._run(self.cli._parse_response_pdu(pdu))
+        # one more message is left in the broker.
+        self._run(self.cli.send_message(msg(3)))
+
+        stats = self.cli.stats()
+        self.assertEqual(stats.submitted, 3)
+        self.assertEqual(stats.delivered, 1)
+        self.assertEqual(stats.failed, {"ESME_RMSGQFUL": 2})
+        self.assertEqual(stats.queue_depth, 1)
+        self.assertEqual(stats.reconnects, 0)
+        self.assertEqual(stats.bytes_read, sum(len(pdu) for pdu in received_pdus))
+        # the three submit_sm's and the deliver_sm_resp
+        self.assertEqual(len(self.cli.writer.written), 4)
+        self.assertEqual(stats.bytes_written, sum(len(pdu) for pdu in self.cli.writer.written))
+        # the submit_sm's have the sequence_numbers 2, 3 & 4; so the submit_sm_resp with 1 does not match any of them.
+        self.assertEqual(stats.unexpected_responses, 1)
+
+        # the snapshot does not change with the traffic that comes after it.
+        self._run(self.cli._parse_response_pdu(submit_sm_resp(0x00000058, 4)))
+        self.assertEqual(stats.failed, {"ESME_RMSGQFUL": 2})
+        self.assertEqual(self.cli.stats().failed, {"ESME_RMSGQFUL": 2, "ESME_RTHROTTLED": 1})
+
+    def test_connection_bytes(self):
+        async def run():
+            smsc = naz.testing.MockSMSC()
+            await smsc.start()
+            cli = naz.Client(
+                smsc_host=smsc.host,
+                smsc_port=smsc.port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                logger=naz.log.SimpleLogger("test_connection_bytes", level="WARNING"),
+            )
+            self.assertEqual(cli.connection_bytes(), (0, 0))
+            await cli.connect()
+            await cli.bind()
+            await cli.enquire_link(TESTING=True)
+            await smsc.wait_for(naz.SmppCommand.ENQUIRE_LINK)
+            # the bind_transceiver_resp and the enquire_link_resp
+            await cli.receive_data(TESTING=True)
+            await cli.receive_data(TESTING=True)
+            first_bind = cli.connection_bytes()
+
+            # a re-connection starts a new count.
+            cli.writer.close()
+            await cli.connect()
+            reconnected = cli.connection_bytes()
+            await cli.bind()
+            second_bind = cli.connection_bytes()
+            stats = cli.stats()
+            await cli._unbind_and_disconnect()
+            await smsc.stop()
+            return smsc, first_bind, reconnected, second_bind, stats
+
+        smsc, first_bind, reconnected, second_bind, stats = self._run(run())
+        bind_transceiver, enquire_link = [16 + len(pdu.body) for pdu in smsc.received()[:2]]
+        bind_transceiver_resp = 16 + len(b"MockSMSC\x00")
+        enquire_link_resp = 16
+        self.assertEqual(enquire_link, 16)
+        self.assertEqual(
+            first_bind, (bind_transceiver_resp + enquire_link_resp, bind_transceiver + enquire_link)
+        )
+        self.assertEqual(reconnected, (0, 0))
+        self.assertEqual(second_bind, (0, bind_transceiver))
+        # the lifetime counters carry on across re-connections.
+        self.assertEqual(
+            (stats.bytes_read, stats.bytes_written),
+            (first_bind[0] + second_bind[0], first_bind[1] + second_bind[1]),
+        )
+
+    def test_bad_metrics(self):
+        with self.assertRaises(naz.client.NazClientError):
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=TestClient.smsc_port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                metrics=object(),
+            )
+
+    def test_metrics(self):
+        submit_sm_statuses = [
+            naz.SmppCommandStatus.ESME_ROK.value,
+            naz.SmppCommandStatus.ESME_RTHROTTLED.value,
+        ]
+
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                command_status = 0
+                if command_id == 0x00000004:
+                    command_status = submit_sm_statuses.pop(0)
+                body = b"" if command_id == 0x00000015 else b"SMSC\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII",
+                        16 + len(body),
+                        0x80000000 | command_id,
+                        command_status,
+                        sequence_number,
+                    )
+                    + body
                 )
+                await writer.drain()
+
+        metrics = naz.metrics.PrometheusMetrics()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            port = server.sockets[0].getsockname()[1]
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                metrics=metrics,
+                logger=naz.log.SimpleLogger("test_metrics", level="WARNING"),
             )
-            self.assertTrue(mock_naz_tranceiver_bind.mock.called)
+            await cli.connect()
+            await cli.bind()
+            await cli.receive_data(TESTING=True)
+            for i in range(0, 2):
+                await cli.send_message(
+                    naz.protocol.SubmitSM(
+                        short_message="hello-{0}".format(i),
+                        log_id="log_id-{0}".format(i),
+                        source_addr="2547000000",
+                        destination_addr="254711999999",
+                    )
+                )
+            self.assertEqual(metrics.current_queue_depth, 2)
+            for i in range(0, 2):
+                await cli.dequeue_messages(TESTING=True)
+                await cli.receive_data(TESTING=True)
+            await cli.enquire_link(TESTING=True)
+            await cli.receive_data(TESTING=True)
+            server.close()
+            await server.wait_closed()
 
-    def test_issues_67(self):
+        self._run(run())
+        self.assertEqual(
+            metrics.pdus_sent,
+            {
+                naz.SmppCommand.BIND_TRANSCEIVER: 1,
+                naz.SmppCommand.SUBMIT_SM: 2,
+                naz.SmppCommand.ENQUIRE_LINK: 1,
+            },
+        )
+        self.assertEqual(
+            metrics.pdus_received,
+            {
+                (naz.SmppCommand.BIND_TRANSCEIVER_RESP, "ESME_ROK"): 1,
+                (naz.SmppCommand.SUBMIT_SM_RESP, "ESME_ROK"): 1,
+                (naz.SmppCommand.SUBMIT_SM_RESP, "ESME_RTHROTTLED"): 1,
+                (naz.SmppCommand.ENQUIRE_LINK_RESP, "ESME_ROK"): 1,
+            },
+        )
+        self.assertEqual(metrics.current_queue_depth, 0)
+        self.assertEqual(metrics.throttles, 1)
+        self.assertEqual(metrics.reconnects, 0)
+        self.assertEqual(metrics.enquire_link_latency_count, 1)
+        self.assertTrue(0 < metrics.enquire_link_latency_sum < 1.0)
+
+    def test_metrics_reconnect(self):
+        metrics = naz.metrics.PrometheusMetrics()
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            metrics=metrics,
+            logger=naz.log.SimpleLogger("test_metrics_reconnect", level="WARNING"),
+        )
+        self._run(cli.re_establish_conn_bind(smpp_command="", log_id="", TESTING=True))
+        self.assertEqual(metrics.reconnects, 1)
+
+    def _submit_message(self, proto_msg, command_status=0x00000000, **client_args):
         """
-        test to prove we have fixed: https://github.com/komuw/naz/issues/67
-        1. start broker
-        2. start naz and run a naz operation like `Client..enquire_link`
-        3. kill broker
-        4. run a naz operation like `Client..enquire_link`
+        runs `Client.submit_message` against a mock SMSC that assigns incrementing message_id's.
         """
-        with mock.patch("naz.Client.tranceiver_bind", new=AsyncMock()) as mock_naz_tranceiver_bind:
-            self.cli.current_session_state = naz.SmppSessionState.BOUND_TRX
-            self.cli.writer = None  # simulate a connection loss
-            self._run(
-                self.cli.send_data(
-                    smpp_command=naz.SmppCommand.SUBMIT_SM, msg=b"someMessage", log_id="log_id"
+        message_ids = iter(range(1, 100))
+        received_sequence_numbers = []
+
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                status = 0x00000000
+                if command_id == 0x00000004:
+                    received_sequence_numbers.append(sequence_number)
+                    status = command_status
+                body = "smsc-id-{0}".format(next(message_ids)).encode() + b"\x00"
+                writer.write(
+                    struct.pack(
+                        ">IIII", 16 + len(body), 0x80000000 | command_id, status, sequence_number
+                    )
+                    + body
                 )
+                await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                logger=naz.log.SimpleLogger("test_submit_message", level="WARNING"),
+                **client_args
             )
-            self.assertTrue(mock_naz_tranceiver_bind.mock.called)
+            await cli.connect()
+            await cli.bind()
+            receiver = asyncio.ensure_future(cli.receive_data())
+            try:
+                return await cli.submit_message(proto_msg), cli
+            finally:
+                receiver.cancel()
+                server.close()
+                await server.wait_closed()
 
-    def test_issues_112(self):
-        """
-        test to prove we have fixed: https://github.com/komuw/naz/issues/112
+        return self._run(run()), received_sequence_numbers
 
-        Run `Client.enquire_link`.
-        Check if `StreamWriter.write` was called twice(one for `tranceiver_bind` and another for `enquire_link`)
-        """
-        with mock.patch("asyncio.streams.StreamWriter.write") as mock_naz_writer:
-            self._run(self.cli.connect())
-            self._run(self.cli.tranceiver_bind())
-            for item in (self.cli.system_id, self.cli.password):
-                # system_id & password are only sent in `tranceiver_bind`
-                self.assertIn(item.encode("ascii"), mock_naz_writer.call_args[0][0])
+    def test_submit_message(self):
+        proto_msg = naz.protocol.SubmitSM(
+            short_message="hello",
+            log_id="log_id",
+            source_addr="2547000000",
+            destination_addr="254711999999",
+        )
+        # the bind_transceiver_resp got `smsc-id-1`
+        (message_ids, cli), _ = self._submit_message(proto_msg)
+        self.assertEqual(message_ids, ["smsc-id-2"])
+        self.assertEqual(cli._pending_responses, {})
 
-            self._run(self.cli.enquire_link(TESTING=True))
-            self.assertTrue(mock_naz_writer.called)
-            self.assertEqual(mock_naz_writer.call_count, 2)
-            # assert `enquire_link` command_id is in the sent data
-            _command_id = struct.pack(">I", self.cli.command_ids["enquire_link"])
-            self.assertIn(_command_id, mock_naz_writer.call_args[0][0])
+    def test_submit_message_multipart(self):
+        proto_msg = naz.protocol.SubmitSM(
+            short_message="a" * 400,
+            log_id="log_id",
+            source_addr="2547000000",
+            destination_addr="254711999999",
+        )
+        (message_ids, cli), received_sequence_numbers = self._submit_message(
+            proto_msg, split_long_messages=True
+        )
+        # one message_id per part, in the order of the parts.
+        self.assertEqual(message_ids, ["smsc-id-2", "smsc-id-3", "smsc-id-4"])
+        self.assertEqual(received_sequence_numbers, sorted(received_sequence_numbers))
+        self.assertEqual(cli._pending_responses, {})
 
-    def test_command_id_lookup(self):
-        command_id = self.cli._search_by_command_id_code(
-            command_id_code=self.cli.command_ids[naz.SmppCommand.BIND_TRANSCEIVER]
+    def test_submit_message_error(self):
+        proto_msg = naz.protocol.SubmitSM(
+            short_message="hello",
+            log_id="log_id",
+            source_addr="2547000000",
+            destination_addr="254711999999",
         )
-        self.assertEqual(command_id, "bind_transceiver")
+        with self.assertRaises(naz.client.NazCommandStatusError) as raised_exception:
+            self._submit_message(
+                proto_msg, command_status=naz.SmppCommandStatus.ESME_RINVDSTADR.value
+            )
+        self.assertTrue(raised_exception.exception.is_status(naz.SmppCommandStatus.ESME_RINVDSTADR))
+
+    def test_submit_message_generic_nack(self):
+        async def handle_conn(reader, writer):
+            while True:
+                try:
+                    header = await reader.readexactly(16)
+                except asyncio.IncompleteReadError:
+                    return
+                command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+                await reader.readexactly(command_length - 16)
+                if command_id == 0x00000004:
+                    # SMSC could not make sense of the submit_sm
+                    writer.write(
+                        struct.pack(
+                            ">IIII",
+                            16,
+                            0x80000000,
+                            naz.SmppCommandStatus.ESME_RINVCMDLEN.value,
+                            sequence_number,
+                        )
+                    )
+                else:
+                    body = b"SMSC\x00"
+                    writer.write(
+                        struct.pack(
+                            ">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number
+                        )
+                        + body
+                    )
+                await writer.drain()
+
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=30.0,
+                logger=naz.log.SimpleLogger("test_submit_message_generic_nack", level="CRITICAL"),
+            )
+            await cli.connect()
+            await cli.bind()
+            receiver = asyncio.ensure_future(cli.receive_data())
+            try:
+                await cli.submit_message(
+                    naz.protocol.SubmitSM(
+                        short_message="hello",
+                        log_id="log_id",
+                        source_addr="2547000000",
+                        destination_addr="254711999999",
+                    )
+                )
+            finally:
+                receiver.cancel()
+                server.close()
+                await server.wait_closed()
+
+        started = time.monotonic()
+        with self.assertRaises(naz.client.NazCommandStatusError) as raised_exception:
+            self._run(run())
+        # it does not wait for the socket_timeout
+        self.assertLess(time.monotonic() - started, 10.0)
+        self.assertTrue(raised_exception.exception.is_status(naz.SmppCommandStatus.ESME_RINVCMDLEN))
+        self.assertIn("generic_nack", str(raised_exception.exception))
 
-        command_id = self.cli._search_by_command_id_code(command_id_code=0x00000102)
-        self.assertEqual(command_id, "alert_notification")
+    def test_submit_message_bad_args(self):
+        with self.assertRaises(ValueError):
+            self._run(self.cli.submit_message("hello"))
 
-        command_id = self.cli._search_by_command_id_code(command_id_code=0x00000016)
-        self.assertEqual(command_id, "reserved_list_c")
+    def test_wire_hooks(self):
+        received, sent, written, read = [], [], [], []
 
-        command_id = self.cli._search_by_command_id_code(command_id_code=0x00000104)
-        self.assertEqual(command_id, "reserved_for_smpp_extension_a")
+        async def handle_conn(reader, writer):
+            header = await reader.readexactly(16)
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            received.append(header + await reader.readexactly(command_length - 16))
+            body = b"SMSC\x00"
+            resp = struct.pack(">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number)
+            sent.append(resp + body)
+            writer.write(resp + body)
+            await writer.drain()
 
-    def test_command_handlers_unkown_command_ids(self):
-        """
-        test that `Client.command_handlers` behaves okay for unkown command_ids
-        """
-        with mock.patch("naz.hooks.SimpleHook.from_smsc", new=AsyncMock()) as mock_hook_from_smsc:
-            sequence_number = 3
-            alert_notification = naz.SmppCommand.ALERT_NOTIFICATION
-            self._run(
-                self.cli.command_handlers(
-                    pdu=b"pdu",
-                    body_data=b"body_data",
-                    smpp_command=alert_notification,
-                    command_status_value=0,
-                    sequence_number=sequence_number,
-                    log_id="log_id",
-                    hook_metadata="hook_metadata",
-                )
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                on_write=written.append,
+                on_read=read.append,
+                logger=naz.log.SimpleLogger("test_wire_hooks", level="WARNING"),
             )
-            self.assertTrue(mock_hook_from_smsc.mock.called)
-            self.assertEqual(
-                mock_hook_from_smsc.mock.call_args[1]["smpp_command"], alert_notification
+            await cli.connect()
+            await cli.bind()
+            await cli.receive_data(TESTING=True)
+            server.close()
+            await server.wait_closed()
+
+        self._run(run())
+        self.assertEqual(len(received), 1)
+        self.assertEqual(written, received)
+        self.assertEqual(struct.unpack(">I", written[0][4:8])[0], 0x00000009)
+        self.assertEqual(read, sent)
+        self.assertEqual(struct.unpack(">I", read[0][4:8])[0], 0x80000009)
+
+    def test_failing_wire_hook(self):
+        class RecordingStreamWriter(MockStreamWriter):
+            def __init__(self):
+                super(RecordingStreamWriter, self).__init__()
+                self.written = []
+
+            def write(self, data):
+                self.written.append(data)
+
+        def on_write(pdu):
+            raise ValueError("bad hook")
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            on_write=on_write,
+            logger=naz.log.SimpleLogger("test_failing_wire_hook", level="CRITICAL"),
+        )
+        cli.writer = RecordingStreamWriter()
+        cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+        self._run(cli.send_data(smpp_command=naz.SmppCommand.ENQUIRE_LINK, msg=b"pdu", log_id="1"))
+        # the write still goes ahead.
+        self.assertEqual(cli.writer.written, [b"pdu"])
+
+        with self.assertRaises(naz.client.NazClientError):
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=TestClient.smsc_port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=self.broker,
+                on_read="hexdump",
             )
-            self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["log_id"], "log_id")
 
-            # reserved command_id's
-            sequence_number = 4
-            reserved_for_smpp_extension_a = naz.SmppCommand.RESERVED_FOR_SMPP_EXTENSION_A
-            self._run(
-                self.cli.command_handlers(
-                    pdu=b"pdu",
-                    body_data=b"body_data",
-                    smpp_command=reserved_for_smpp_extension_a,
-                    command_status_value=0,
-                    sequence_number=sequence_number,
-                    log_id="log_id",
-                    hook_metadata="hook_metadata",
+    def test_redact_credentials(self):
+        password = "pw-Zk93xq"
+        system_id = "sysid-Qm27"
+        stream = io.StringIO()
+
+        async def handle_conn(reader, writer):
+            header = await reader.readexactly(16)
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            await reader.readexactly(command_length - 16)
+            # an SMSC that rejects the bind, and echoes the credentials back.
+            body = "{0}:{1}".format(system_id, password).encode() + b"\x00"
+            writer.write(
+                struct.pack(
+                    ">IIII",
+                    16 + len(body),
+                    0x80000000 | command_id,
+                    naz.SmppCommandStatus.ESME_RINVPASWD.value,
+                    sequence_number,
                 )
+                + body
             )
-            self.assertTrue(mock_hook_from_smsc.mock.called)
-            self.assertEqual(
-                mock_hook_from_smsc.mock.call_args[1]["smpp_command"], reserved_for_smpp_extension_a
-            )
-            self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["log_id"], "log_id")
+            await writer.drain()
 
-            # known command_id
-            sequence_number = 5
-            bind_transceiver = naz.SmppCommand.BIND_TRANSCEIVER
-            self._run(
-                self.cli.command_handlers(
-                    pdu=b"pdu",
-                    body_data=b"body_data",
-                    smpp_command=bind_transceiver,
-                    command_status_value=0,
-                    sequence_number=sequence_number,
-                    log_id="log_id",
-                    hook_metadata="hook_metadata",
-                )
+        async def run():
+            server = await asyncio.start_server(handle_conn, "127.0.0.1", 0)
+            cli = naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=server.sockets[0].getsockname()[1],
+                system_id=system_id,
+                password=password,
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                socket_timeout=1.0,
+                redact_system_id=True,
+                logger=naz.log.SimpleLogger(
+                    "test_redact_credentials", level="DEBUG", handler=logging.StreamHandler(stream)
+                ),
             )
-            self.assertTrue(mock_hook_from_smsc.mock.called)
-            self.assertEqual(
-                mock_hook_from_smsc.mock.call_args[1]["smpp_command"], bind_transceiver
+            await cli.connect()
+            await cli.bind()
+            await cli.receive_data(TESTING=True)
+            server.close()
+            await server.wait_closed()
+
+        self._run(run())
+        output = stream.getvalue()
+        self.assertIn("ESME_RINVPASWD", output)
+        self.assertIn("****", output)
+        self.assertNotIn(password, output)
+        self.assertNotIn(system_id, output)
+
+        with self.assertRaises(naz.client.NazClientError) as raised_exception:
+            naz.Client(
+                smsc_host="127.0.0.1",
+                smsc_port=2775,
+                system_id="smppclient1",
+                password="pässwörd",
+                broker=naz.broker.SimpleBroker(maxsize=100),
             )
-            self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["log_id"], "log_id")
+        self.assertIn("`password` should only contain ascii", str(raised_exception.exception))
+        self.assertNotIn("pässwörd", str(raised_exception.exception))
 
-    def test_command_status_lookup(self):
-        command_status = 411_041_792
-        command_status = self.cli._search_by_command_status_value(
-            command_status_value=command_status
-        )
-        self.assertEqual(command_status.code, "Reserved")
+    def _windowed_client(self, window_size, window_timeout=30.00, **kwargs):
+        class RecordingStreamWriter(MockStreamWriter):
+            def __init__(self):
+                super(RecordingStreamWriter, self).__init__()
+                self.written = []
 
-        command_status = 0x00000000
-        command_status = self.cli._search_by_command_status_value(
-            command_status_value=command_status
-        )
-        self.assertEqual(command_status.code, "ESME_ROK")
+            def write(self, data):
+                self.written.append(data)
 
-        command_status = 0x00000014
-        command_status = self.cli._search_by_command_status_value(
-            command_status_value=command_status
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=naz.broker.SimpleBroker(maxsize=100),
+            window_size=window_size,
+            window_timeout=window_timeout,
+            logger=naz.log.SimpleLogger("test_window", level="WARNING"),
+            **kwargs,
         )
-        self.assertEqual(command_status.code, "ESME_RMSGQFUL")
+        cli.writer = RecordingStreamWriter()
+        cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+        return cli
 
-        command_status = 0x00000400
-        command_status = self.cli._search_by_command_status_value(
-            command_status_value=command_status
+    @staticmethod
+    def _submit_sm(i):
+        return naz.protocol.SubmitSM(
+            short_message="hello-{0}".format(i),
+            log_id="log_id-{0}".format(i),
+            source_addr="2547000000",
+            destination_addr="254711999999",
         )
-        self.assertEqual(command_status.code, "Reserved")
 
-    def test_SmppCommandStatus(self):
-        """
-        tests of bugs gotten via benchmarks
-        """
-        with mock.patch("naz.hooks.SimpleHook.from_smsc", new=AsyncMock()) as mock_hook_from_smsc:
-            sequence_number = 1
-            bind_transceiver = naz.SmppCommand.BIND_TRANSCEIVER
-            command_status = 411_041_792
-            full_pdu = b"pdu"
-            self._run(
-                self.cli.command_handlers(
-                    pdu=full_pdu,
-                    body_data=b"body_data",
-                    smpp_command=bind_transceiver,
-                    command_status_value=command_status,
-                    sequence_number=sequence_number,
-                    log_id="log_id",
-                    hook_metadata="hook_metadata",
+    def test_bad_window_args(self):
+        for kwargs in [
+            {"window_size": 0},
+            {"window_size": "1"},
+            {"window_timeout": 1},
+            {"window_timeout": 0.0},
+            {"window_timeout": -1.0},
+            {"window_maintenance_interval": 0.0},
+            {"window_maintenance_interval": 1},
+        ]:
+            with self.assertRaises(naz.client.NazClientError):
+                naz.Client(
+                    smsc_host="127.0.0.1",
+                    smsc_port=TestClient.smsc_port,
+                    system_id="smppclient1",
+                    password=os.getenv("password", "password"),
+                    broker=self.broker,
+                    **kwargs
                 )
-            )
-            self.assertTrue(mock_hook_from_smsc.mock.called)
-            self.assertEqual(
-                mock_hook_from_smsc.mock.call_args[1]["smpp_command"], bind_transceiver
-            )
-            self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["log_id"], "log_id")
-            self.assertEqual(mock_hook_from_smsc.mock.call_args[1]["pdu"], full_pdu)
 
-    def test_protocol_error(self):
-        """
-        tests that if we read bytes from connection and naz is unable to parse them,
-        it should close connection.
-        https://github.com/komuw/naz/issues/135
-        """
-        with mock.patch(
-            "naz.Client._unbind_and_disconnect", new=AsyncMock()
-        ) as mock_naz_unbind_and_disconnect:
-            self._run(self.cli._parse_response_pdu(pdu=b"\x00\x00\x00\x15\x80"))
-            self.assertTrue(mock_naz_unbind_and_disconnect.mock.called)
+    def test_window_size(self):
+        cli = self._windowed_client(window_size=1)
 
-    def test_enquire_link_resp_sending(self):
-        with mock.patch("naz.broker.SimpleBroker.dequeue", new=AsyncMock()) as mock_naz_dequeue:
-            mock_naz_dequeue.mock.return_value = naz.protocol.EnquireLinkResp(
-                log_id="log_id", sequence_number=34,
-            )
+        async def run():
+            for i in range(0, 2):
+                await cli.send_message(self._submit_sm(i))
 
-            self._run(self.cli.connect())
-            # hack to allow sending submit_sm even when state is wrong
-            self.cli.current_session_state = "BOUND_TRX"
-            self._run(self.cli.dequeue_messages(TESTING=True))
-            self.assertTrue(mock_naz_dequeue.mock.called)
+            async def dequeue():
+                for _ in range(0, 2):
+                    await cli.dequeue_messages(TESTING=True)
 
-    def test_deliver_sm_resp_sending(self):
-        with mock.patch("naz.broker.SimpleBroker.dequeue", new=AsyncMock()) as mock_naz_dequeue:
-            mock_naz_dequeue.mock.return_value = naz.protocol.DeliverSmResp(
-                log_id="mock-id", message_id="message_id", sequence_number=90,
+            task = asyncio.ensure_future(dequeue())
+            await asyncio.sleep(0.05)
+            # the second submit_sm waits for the first one to be acked.
+            self.assertEqual(len(cli.writer.written), 1)
+            self.assertFalse(task.done())
+
+            sequence_number = struct.unpack(">I", cli.writer.written[0][12:16])[0]
+            body = b"SMSC\x00"
+            await cli._parse_response_pdu(
+                struct.pack(">IIII", 16 + len(body), 0x80000004, 0, sequence_number) + body
             )
+            await asyncio.wait_for(task, 1.0)
+            self.assertEqual(len(cli.writer.written), 2)
 
-            self._run(self.cli.connect())
-            # hack to allow sending submit_sm even when state is wrong
-            self.cli.current_session_state = "BOUND_TRX"
-            self._run(self.cli.dequeue_messages(TESTING=True))
-            self.assertTrue(mock_naz_dequeue.mock.called)
+        self._run(run())
+        self.assertEqual(len(cli._window), 1)
 
-    def test_send_message_with_more_args(self):
-        log_id = "12345"
-        short_message = "hello"
-        msg = naz.protocol.SubmitSM(
-            short_message=short_message,
-            source_addr="2492",
-            destination_addr="8930302",
-            log_id=log_id,
-            source_addr_ton=0x00000010,  # National
-            dest_addr_npi=0x00001110,  # Internet
-        )
-        with mock.patch("naz.broker.SimpleBroker.enqueue", new=AsyncMock()) as mock_naz_enqueue:
-            self._run(self.cli.connect())
-            self._run(self.cli.tranceiver_bind())
+    def test_window_timeout(self):
+        cli = self._windowed_client(window_size=1, window_timeout=0.05)
 
-            self._run(self.cli.send_message(msg))
-            self.assertTrue(mock_naz_enqueue.mock.called)
-            self.assertEqual(mock_naz_enqueue.mock.call_args[0][1].log_id, log_id)
-            self.assertEqual(
-                mock_naz_enqueue.mock.call_args[0][1].smpp_command, naz.SmppCommand.SUBMIT_SM
-            )
-            self.assertEqual(mock_naz_enqueue.mock.call_args[0][1].short_message, short_message)
+        async def run():
+            for i in range(0, 2):
+                await cli.send_message(self._submit_sm(i))
+            start = time.monotonic()
+            for _ in range(0, 2):
+                await asyncio.wait_for(cli.dequeue_messages(TESTING=True), 1.0)
+            return time.monotonic() - start
 
-    def test_issues_197(self):
-        """
-        test to prove we have fixed: https://github.com/komuw/naz/issues/197
-        ie;
-        During shutdown, close the writer & not reader
+        # SMSC never responds to the first submit_sm, so its slot is reclaimed after window_timeout
+        duration = self._run(run())
+        self.assertEqual(len(cli.writer.written), 2)
+        self.assertTrue(0.05 <= duration < 1.0)
 
-        1. connect to smsc
-        2. bind
-        3. unbind & disconnect
-        """
-        self._run(self.cli.connect())
-        self._run(self.cli.tranceiver_bind())
-        self.assertIsNotNone(self.cli.writer)
+    def test_window_maintenance_interval(self):
+        cli = self._windowed_client(
+            window_size=2,
+            window_timeout=0.1,
+            window_maintenance_interval=0.05,
+            response_timeout=5.0,
+        )
 
-        self._run(self.cli._unbind_and_disconnect())
-        self.assertIsNone(self.cli.writer)
-        self.assertIsNotNone(self.cli.reader)
+        async def run():
+            start = time.monotonic()
+            # SMSC never responds; and nothing else is sent after the window is full.
+            tasks = [
+                asyncio.ensure_future(cli.submit_message(self._submit_sm(i))) for i in range(0, 2)
+            ]
+            await asyncio.sleep(0.02)
+            self.assertEqual(len(cli._window), 2)
+            await asyncio.wait(tasks, timeout=1.0)
+            return tasks, time.monotonic() - start
 
-    def test_issues_203(self):
-        """
-        test to prove we have fixed: https://github.com/komuw/naz/issues/203
-        ie;
-        If we close connection, we should thereafter still be able to send_data.
+        tasks, duration = self._run(run())
+        self.assertEqual(len(cli.writer.written), 2)
+        # the slots are reclaimed, and the callers failed, on the tick; well before the response_timeout.
+        self.assertLess(duration, 1.0)
+        for task in tasks:
+            self.assertIsInstance(task.exception(), asyncio.TimeoutError)
+            self.assertIn("window_timeout", str(task.exception()))
+        self.assertEqual(cli._window, {})
+        self.assertEqual(cli.outstanding(), (0, 0.0))
+        self._run(asyncio.sleep(0.1))
+        self.assertTrue(cli._window_maintenance.done())
 
-        1. connect to smsc
-        2. bind
-        3. unbind & disconnect
-        4. send `submit_sm`
-        """
-        self._run(self.cli.connect())
-        self._run(self.cli.tranceiver_bind())
-        self.assertIsNotNone(self.cli.writer)
+    def test_enquire_link_response_timeout(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            socket_timeout=self.socket_timeout,
+            enquire_link_response_timeout=0.05,
+        )
+        cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+        with mock.patch("naz.Client.send_data", new=AsyncMock()), mock.patch(
+            "naz.Client.re_establish_conn_bind", new=AsyncMock()
+        ) as mock_re_establish_conn_bind:
+            # the SMSC does not answer the enquire_link
+            start = time.monotonic()
+            self._run(cli.enquire_link(TESTING=True))
+            self.assertLess(time.monotonic() - start, 1.0)
+            self.assertTrue(mock_re_establish_conn_bind.mock.called)
+            self.assertEqual(cli._pending_responses, {})
 
-        self._run(self.cli._unbind_and_disconnect())
-        self.assertIsNone(self.cli.writer)
+    def test_bad_enquire_link_response_timeout(self):
+        for enquire_link_response_timeout in [0.0, -1.0, 1]:
+            with self.assertRaises(naz.client.NazClientError):
+                naz.Client(
+                    smsc_host="127.0.0.1",
+                    smsc_port=TestClient.smsc_port,
+                    system_id="smppclient1",
+                    password=os.getenv("password", "password"),
+                    broker=self.broker,
+                    enquire_link_response_timeout=enquire_link_response_timeout,
+                )
 
-        self._run(
-            self.cli.send_data(
-                smpp_command=naz.SmppCommand.SUBMIT_SM, msg=b"someMessage", log_id="log_id"
+    def test_enquire_link_response_received(self):
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            socket_timeout=self.socket_timeout,
+            enquire_link_response_timeout=1.0,
+        )
+        cli.current_session_state = naz.SmppSessionState.BOUND_TRX
+
+        async def mock_send_data(_self, smpp_command, msg, log_id, hook_metadata=""):
+            sequence_number = struct.unpack(">I", msg[12:16])[0]
+            resp = struct.pack(">IIII", 16, 0x80000015, 0x00000000, sequence_number)
+            asyncio.get_event_loop().call_soon(
+                asyncio.ensure_future, cli._parse_response_pdu(resp)
             )
+
+        with mock.patch("naz.Client.send_data", new=mock_send_data), mock.patch(
+            "naz.Client.re_establish_conn_bind", new=AsyncMock()
+        ) as mock_re_establish_conn_bind:
+            self._run(cli.enquire_link(TESTING=True))
+            self.assertFalse(mock_re_establish_conn_bind.mock.called)
+            self.assertEqual(cli._pending_responses, {})
+
+    def test_adaptive_throttling(self):
+        rate_limiter = naz.ratelimiter.SimpleRateLimiter(send_rate=100.00)
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=TestClient.smsc_port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=self.broker,
+            socket_timeout=self.socket_timeout,
+            rate_limiter=rate_limiter,
+            throttle_handler=naz.throttle.AdaptiveThrottleHandler(rate_limiter=rate_limiter),
         )
-        self.assertIsNotNone(self.cli.writer)
+        # the SMSC throttles the first three submit_sm's
+        for sequence_number in range(1, 4):
+            body = b"\x00"
+            header = struct.pack(
+                ">IIII",
+                16 + len(body),
+                0x80000004,
+                naz.SmppCommandStatus.ESME_RTHROTTLED.value,
+                sequence_number,
+            )
+            self._run(cli._parse_response_pdu(header + body))
+        self.assertEqual(rate_limiter.send_rate, 12.50)
@@ -44,6 +44,55 @@ class TestCodec(TestCase):
         python -m unittest -v tests.test_codec.TestCodec.test_something
     """
 
+    def tearDown(self):
+        naz.codec._DATA_CODING_CODECS.clear()
+
+    @staticmethod
+    def _vendor_codec(name="vendor_x"):
+        # a trivial proprietary encoding; ascii, reversed.
+        return codecs.CodecInfo(
+            name=name,
+            encode=lambda text, errors="strict": (text[::-1].encode("ascii", errors), len(text)),
+            decode=lambda data, errors="strict": (data.decode("ascii", errors)[::-1], len(data)),
+        )
+
+    def test_register(self):
+        naz.codec.register(0xF5, self._vendor_codec())
+        self.assertEqual(naz.codec.lookup(0xF5).name, "vendor_x")
+        self.assertEqual(naz.codec.lookup(0xF5).encode("hello")[0], b"olleh")
+        # inbuilt codecs are used for the other data_coding's
+        self.assertEqual(naz.codec.lookup(0x03).encode("résumé")[0], b"r\xe9sum\xe9")
+        self.assertRaises(ValueError, naz.codec.lookup, 0xF6)
+
+        self.assertRaises(ValueError, naz.codec.register, "0xF5", self._vendor_codec())
+        self.assertRaises(ValueError, naz.codec.register, 256, self._vendor_codec())
+        self.assertRaises(ValueError, naz.codec.register, 0xF5, "vendor_x")
+
+    def test_register_overrides_inbuilt(self):
+        naz.codec.register(0x03, self._vendor_codec("latin_1_vendor"))
+        self.assertEqual(naz.codec.lookup(0x03).name, "latin_1_vendor")
+        msg = naz.protocol.SubmitSM(
+            short_message="hello",
+            log_id="log_id",
+            source_addr="2547000000",
+            destination_addr="254711999999",
+            encoding="latin_1_vendor",
+        )
+        self.assertEqual(msg.data_coding.value, 0x03)
+
+    def test_register_inbound(self):
+        naz.codec.register(0xF5, self._vendor_codec())
+        # a deliver_sm with data_coding 0xF5
+        deliver_sm_pdu = (
+            b"\x00\x00\x00\x3c\x00\x00\x00\x05\x00\x00\x00\x00\x00\x00\x00\x07"
+            b"\x00\x01\x0116505551234\x00\x01\x0117735554070\x00"
+            b"\x00\x00\x00\x00\x00\x00\x00\xf5\x00\x05olleh"
+        )
+        message = naz.protocol.DeliverSM._from_pdu(pdu=deliver_sm_pdu, log_id="log_id")
+        self.assertEqual(message.short_message, "hello")
+        self.assertEqual(message.encoding, "vendor_x")
+        self.assertEqual(message.data_coding, 0xF5)
+
     def test_byte_encode_guard(self):
         codec = codecs.lookup("utf-8")
         self.assertRaises(TypeError, codec.encode, b"some bytes")
@@ -84,6 +133,90 @@ class TestCodec(TestCase):
         codec = naz.codec.UCS2Codec()
         self.assertEqual(codec.decode(b"\x00Z\x00o\x00\xeb")[0], "Zoë")
 
+    def test_ucs2_surrogate_pairs(self):
+        codec = naz.codec.UCS2Codec()
+        # characters outside the Basic Multilingual Plane are encoded as a 4 octet surrogate pair.
+        self.assertEqual(codec.encode("hi 😀")[0], b"\x00h\x00i\x00 \xd8\x3d\xde\x00")
+        self.assertEqual(codec.decode(b"\x00h\x00i\x00 \xd8\x3d\xde\x00")[0], "hi 😀")
+        for message in ["😀", "Zoë 😀 𝄞 foo", "\U0010FFFF"]:
+            self.assertEqual(codec.decode(codec.encode(message)[0])[0], message)
+
+        # a surrogate pair that is held as two characters is encoded the same way.
+        self.assertEqual(codec.encode("hi \ud83d\ude00")[0], codec.encode("hi 😀")[0])
+        self.assertEqual(codec.encode("hi \ud83d\ude00")[1], 5)
+        # a lone surrogate cannot be encoded.
+        self.assertRaises(UnicodeEncodeError, codec.encode, "hi \ud83d", "strict")
+        self.assertRaises(UnicodeDecodeError, codec.decode, b"\x00h\xd8\x3d", "strict")
+
+    def test_ucs2_bom(self):
+        codec = naz.codec.UCS2BOMCodec()
+        self.assertEqual(codec.encode("hi ë")[0], b"\xfe\xff\x00h\x00i\x00 \x00\xeb")
+        for message in ["", "hi ë", "Zoë 😀 𝄞 foo"]:
+            self.assertEqual(codec.decode(codec.encode(message)[0])[0], message)
+            # the BOM is optional when decoding.
+            self.assertEqual(codec.decode(naz.codec.UCS2Codec.encode(message)[0])[0], message)
+        # the default ucs2 codec does not add a BOM.
+        self.assertEqual(naz.codec.UCS2Codec.encode("hi")[0], b"\x00h\x00i")
+        naz.codec.register_codecs()
+        self.assertEqual(codecs.lookup("ucs2_bom").encode("hi")[0], b"\xfe\xff\x00h\x00i")
+        self.assertEqual(naz.SmppDataCoding._find_data_coding("ucs2_bom").value, 8)
+
+        # the BOM's 2 octets take up the space of one character in each segment.
+        self.assertEqual(naz.codec.segment_count("ë" * 69, "ucs2_bom"), 1)
+        self.assertEqual(naz.codec.segment_count("ë" * 70, "ucs2_bom"), 2)
+        self.assertEqual(naz.codec.segment_count("ë" * 132, "ucs2_bom"), 2)
+        self.assertEqual(naz.codec.segment_count("ë" * 133, "ucs2_bom"), 3)
+        self.assertEqual(len(codec.encode("ë" * 69)[0]), 140)
+        for part in naz.codec._split_message("ë" * 133, "ucs2_bom"):
+            # each part, with its BOM, leaves room for the 6 octet concatenation UDH.
+            self.assertLessEqual(len(codec.encode(part)[0]), 134)
+
+    def test_codec_error(self):
+        message = "hello José 😀 bye"
+        for codec, name in [
+            (naz.codec.GSM7BitCodec(), "gsm0338"),
+            (naz.codec.GSM7BitPackedCodec(), "gsm0338_packed"),
+        ]:
+            with self.assertRaises(naz.codec.CodecError) as raised_exception:
+                codec.encode(message, "strict")
+            err = raised_exception.exception
+            self.assertEqual((err.char, err.index, err.codec), ("😀", 11, name))
+            self.assertEqual(
+                str(err), "character '😀' at position 11 is not representable in {0}".format(name)
+            )
+            # it is still a UnicodeEncodeError
+            self.assertIsInstance(err, UnicodeEncodeError)
+            self.assertEqual((err.object, err.start, err.end), (message, 11, 12))
+        # the other error handlers are unaffected.
+        self.assertEqual(
+            naz.codec.GSM7BitCodec.encode(message, "replace")[0],
+            naz.codec.GSM7BitCodec.encode("hello José ? bye")[0],
+        )
+
+        codec = naz.codec.Latin1Codec()
+        self.assertEqual(codec.encode("résumé")[0], b"r\xe9sum\xe9")
+        self.assertEqual(codec.decode(b"r\xe9sum\xe9")[0], "résumé")
+        self.assertEqual(codec.encode("ñü\u00ff")[0], b"\xf1\xfc\xff")
+        # it matches python's inbuilt codec, which is what naz uses for the `latin_1` encoding.
+        self.assertEqual(codec.encode("résumé"), codecs.lookup("latin_1").encode("résumé"))
+        self.assertEqual(naz.SmppDataCoding._find_data_coding("latin_1").value, 3)
+
+    def test_latin_1_is_inbuilt(self):
+        # the `latin_1` encoding and data_coding 3 use `Latin1Codec`
+        codec_info = naz.codec.lookup(3)
+        self.assertIs(codec_info, naz.codec._INBUILT_CODECS["latin_1"])
+        self.assertEqual(codec_info.name, "latin_1")
+        self.assertEqual(codec_info.encode("résumé")[0], b"r\xe9sum\xe9")
+        self.assertEqual(codec_info.decode(b"r\xe9sum\xe9")[0], "résumé")
+        self.assertIs(naz.codec._codec_info("latin_1"), codec_info)
+        self.assertRaises(UnicodeEncodeError, codec_info.encode, "résumé €")
+
+    def test_latin_1_out_of_range(self):
+        codec = naz.codec.Latin1Codec()
+        for char in ["€", "\u0100", "😀"]:
+            self.assertRaises(UnicodeEncodeError, codec.encode, "résumé " + char, "strict")
+        self.assertEqual(codec.encode("résumé €", "replace")[0], b"r\xe9sum\xe9 ?")
+
     def test_encode_gsm0338(self):
         codec = naz.codec.GSM7BitCodec()
         self.assertEqual(
@@ -104,6 +237,131 @@ class TestCodec(TestCase):
             "foo €",
         )
 
+    def test_encode_gsm0338_table(self):
+        codec = naz.codec.GSM7BitCodec()
+        table = [
+            # (input, expected_output)
+            ("SMPPtest", b"\x53\x4d\x50\x50\x74\x65\x73\x74"),
+            ("@£$¥_§¿", b"\x00\x01\x02\x03\x11\x5f\x60"),
+            (
+                "{}[]\\|~^€",
+                b"\x1b\x28\x1b\x29\x1b\x3c\x1b\x3e\x1b\x2f\x1b\x40\x1b\x3d\x1b\x14\x1b\x65",
+            ),
+            ("SMPP {test}", b"\x53\x4d\x50\x50\x20\x1b\x28\x74\x65\x73\x74\x1b\x29"),
+        ]
+        for _input, expected_output in table:
+            encoded, _ = codec.encode(_input)
+            self.assertEqual(encoded, expected_output)
+            # it should also round-trip
+            self.assertEqual(codec.decode(encoded)[0], _input)
+
+    def test_encode_gsm0338_not_in_alphabet(self):
+        codec = naz.codec.GSM7BitCodec()
+        # the backtick is used as a placeholder in the extension table, but it is not in the GSM alphabet.
+        for char in ["`", "Zoë", "😀"]:
+            self.assertRaises(UnicodeEncodeError, codec.encode, char, "strict")
+
+    def test_decode_gsm0338_trailing_escape(self):
+        codec = naz.codec.GSM7BitCodec()
+        self.assertRaises(UnicodeDecodeError, codec.decode, b"\x53\x1b", "strict")
+        self.assertEqual(codec.decode(b"\x53\x1b", "replace")[0], "S?")
+
+    def test_decode_gsm0338_unknown_extension(self):
+        codec = naz.codec.GSM7BitCodec()
+        # escape followed by a code that has no extension character falls back to the basic charset.
+        self.assertEqual(codec.decode(b"\x1b\x41")[0], "A")
+
+    def test_encode_gsm0338_packed(self):
+        codec = naz.codec.GSM7BitPackedCodec()
+        table = [
+            # (input, expected_output)
+            ("hellohello", bytes.fromhex("E8329BFD4697D9EC37")),
+            # 8n-1 characters; the spare bits are filled with a CR
+            ("1234567", bytes.fromhex("31D98C56B3DD1A")),
+            ("12345678", bytes.fromhex("31D98C56B3DD70")),
+            ("foo €", bytes.fromhex("E6F71BB42903")),
+        ]
+        for _input, expected_output in table:
+            encoded, _ = codec.encode(_input)
+            self.assertEqual(encoded, expected_output)
+            # it should also round-trip
+            self.assertEqual(codec.decode(encoded)[0], _input)
+
+    def test_encode_gsm0338_packed_max_length(self):
+        codec = naz.codec.GSM7BitPackedCodec()
+        msg = "a" * 160
+        encoded, length = codec.encode(msg)
+        self.assertEqual(length, 140)
+        self.assertEqual(codec.decode(encoded)[0], msg)
+
+    def test_encode_gsm0338_packed_trailing_cr(self):
+        codec = naz.codec.GSM7BitPackedCodec()
+        # a wanted CR at an octet boundary is doubled so that it is not mistaken for padding.
+        encoded, _ = codec.encode("1234567\r")
+        self.assertEqual(len(encoded), 8)
+        self.assertEqual(codec.decode(encoded)[0], "1234567\r\r")
+
+    def test_encode_gsm0338_packed_strict(self):
+        codec = naz.codec.GSM7BitPackedCodec()
+        self.assertRaises(UnicodeEncodeError, codec.encode, "Zoë", "strict")
+        self.assertEqual(codec.decode(codec.encode("Zoë", "replace")[0])[0], "Zo?")
+
+    def test_detect_encoding(self):
+        table = [
+            # (input, expected_output)
+            ("hello world", ("gsm0338", 0)),
+            ("", ("gsm0338", 0)),
+            # extension table characters are still representable in gsm0338
+            ("price: 5€ {ok}", ("gsm0338", 0)),
+            ("hello 😀", ("ucs2", 8)),
+            ("Zoë", ("ucs2", 8)),
+        ]
+        for _input, expected_output in table:
+            self.assertEqual(naz.codec.detect_encoding(_input), expected_output)
+
+    def test_segment_count(self):
+        table = [
+            # (message, encoding, expected_segments)
+            ("", "gsm0338", 1),
+            ("a" * 160, "gsm0338", 1),
+            ("a" * 161, "gsm0338", 2),
+            ("a" * 306, "gsm0338", 2),
+            ("a" * 307, "gsm0338", 3),
+            # extension characters take up two septets
+            ("€" * 80, "gsm0338", 1),
+            ("€" * 81, "gsm0338", 2),
+            ("a" * 160, "gsm0338_packed", 1),
+            ("ë" * 70, "ucs2", 1),
+            ("ë" * 71, "ucs2", 2),
+            ("ë" * 134, "ucs2", 2),
+            ("ë" * 135, "ucs2", 3),
+            # emoji are encoded as surrogate pairs
+            ("😀" * 35, "ucs2", 1),
+            ("😀" * 36, "ucs2", 2),
+            ("\ud83d\ude00" * 35, "ucs2", 1),
+            ("\ud83d\ude00" * 36, "ucs2", 2),
+            ("a" + "😀" * 35, "ucs2", 2),
+            ("a" * 140, "latin_1", 1),
+            ("a" * 141, "latin_1", 2),
+        ]
+        for message, encoding, expected_segments in table:
+            self.assertEqual(naz.codec.segment_count(message, encoding), expected_segments)
+
+    def test_split_message_keeps_escape_sequences(self):
+        # 152 normal characters followed by an extension character; the escape sequence should not be split.
+        message = "a" * 152 + "€" + "b" * 10
+        parts = naz.codec._split_message(message, "gsm0338")
+        self.assertEqual(parts, ["a" * 152, "€" + "b" * 10])
+        self.assertEqual("".join(parts), message)
+
+    def test_split_message_keeps_surrogate_pairs(self):
+        # 66 code units followed by an emoji; the surrogate pair should not be split.
+        message = "a" * 66 + "\ud83d\ude00" + "b" * 10
+        parts = naz.codec._split_message(message, "ucs2")
+        self.assertEqual(parts, ["a" * 66, "😀" + "b" * 10])
+        for part in parts:
+            naz.codec.UCS2Codec.encode(part)
+
     def test_encode_gsm0338_strict(self):
         codec = naz.codec.GSM7BitCodec()
         self.assertRaises(UnicodeEncodeError, codec.encode, "Zoë", "strict")
@@ -141,7 +399,11 @@ class TestCodecRegistration(TestCase):
         # register
         naz.codec.register_codecs()
 
-        for k, _ in naz.codec._INBUILT_CODECS.items():
+        for k, v in naz.codec._INBUILT_CODECS.items():
+            self.assertIs(naz.codec._codec_info(k), v)
+            if k == "latin_1":
+                # python's own `latin_1` codec is found first by `codecs.lookup`
+                continue
             codec = codecs.lookup(k)
             self.assertEqual(codec.name, k)
 
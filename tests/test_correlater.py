@@ -3,6 +3,7 @@
 
 import json
 import time
+import struct
 import asyncio
 from unittest import TestCase, mock
 
@@ -201,6 +202,63 @@ class TestCorrelater(TestCase):
         )
 
 
+    def test_bad_args(self):
+        self.assertRaises(ValueError, naz.correlater.SimpleCorrelater, max_size="10")
+        self.assertRaises(ValueError, naz.correlater.SimpleCorrelater, max_size=0)
+
+    def test_max_size(self):
+        max_size = 5
+        correlater = naz.correlater.SimpleCorrelater(max_ttl=self.max_ttl, max_size=max_size)
+        for i in range(0, max_size + 3):
+            self._run(
+                correlater.put(
+                    smpp_command=naz.SmppCommand.SUBMIT_SM,
+                    sequence_number=str(i),
+                    log_id="log_id-" + str(i),
+                    hook_metadata="hook_metadata-" + str(i),
+                )
+            )
+        self.assertEqual(correlater.size(), max_size)
+        # the oldest items are gone
+        for i in range(0, 3):
+            self.assertNotIn(str(i), correlater.store)
+            log_id, hook_metadata = self._run(
+                correlater.get(smpp_command=naz.SmppCommand.SUBMIT_SM, sequence_number=str(i))
+            )
+            self.assertEqual(log_id, "")
+            self.assertEqual(hook_metadata, "")
+        log_id, _ = self._run(
+            correlater.get(smpp_command=naz.SmppCommand.SUBMIT_SM, sequence_number="7")
+        )
+        self.assertEqual(log_id, "log_id-7")
+
+    def test_max_size_evicts_least_recently_used(self):
+        correlater = naz.correlater.SimpleCorrelater(max_ttl=self.max_ttl, max_size=2)
+        for i in ["1", "2"]:
+            self._run(
+                correlater.put(
+                    smpp_command=naz.SmppCommand.SUBMIT_SM,
+                    sequence_number=i,
+                    log_id="log_id-" + i,
+                    hook_metadata="hook_metadata-" + i,
+                )
+            )
+        # accessing "1" makes "2" the least recently used item
+        self._run(correlater.get(smpp_command=naz.SmppCommand.SUBMIT_SM, sequence_number="1"))
+        self._run(
+            correlater.put(
+                smpp_command=naz.SmppCommand.SUBMIT_SM,
+                sequence_number="3",
+                log_id="log_id-3",
+                hook_metadata="hook_metadata-3",
+            )
+        )
+        self.assertEqual(correlater.size(), 2)
+        self.assertIn("1", correlater.store)
+        self.assertNotIn("2", correlater.store)
+        self.assertIn("3", correlater.store)
+
+
 class TestBenchmarkCorrelater(TestCase):
     """
     run tests as:
@@ -295,3 +353,159 @@ class TestBenchmarkCorrelater(TestCase):
         )
         self.assertEqual(log_id, "log_id-99999")
         self.assertEqual(hook_metadata, "hook_metadata-99999")
+
+
+class MockRedis:
+    """
+    an in-memory stand-in for a redis client.
+    """
+
+    def __init__(self):
+        self.store = {}
+        self.available = True
+
+    async def set(self, key, value, pexpire=0):
+        if not self.available:
+            raise ConnectionError("redis is unavailable")
+        self.store[key] = (value.encode(), time.monotonic() + (pexpire / 1000))
+
+    async def get(self, key):
+        if not self.available:
+            raise ConnectionError("redis is unavailable")
+        if key not in self.store:
+            return None
+        value, expires_at = self.store[key]
+        if time.monotonic() > expires_at:
+            del self.store[key]
+            return None
+        return value
+
+
+class TestRedisCorrelater(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_correlater.TestRedisCorrelater.test_something
+    """
+
+    def setUp(self):
+        self.redis = MockRedis()
+        self.correlater = naz.correlater.RedisCorrelater(
+            redis=self.redis, max_ttl=0.2, client_id="client-1"
+        )
+
+    @staticmethod
+    def _run(coro):
+        loop = asyncio.get_event_loop()
+        return loop.run_until_complete(coro)
+
+    def test_submit_sm_deliver_sm_workflow(self):
+        self._run(
+            self.correlater.put(
+                smpp_command=naz.SmppCommand.SUBMIT_SM,
+                sequence_number=7,
+                log_id="log_id1",
+                hook_metadata="hook_metadata1",
+            )
+        )
+        self.assertEqual(
+            self._run(
+                self.correlater.get(smpp_command=naz.SmppCommand.SUBMIT_SM_RESP, sequence_number=7)
+            ),
+            ("log_id1", "hook_metadata1"),
+        )
+        self._run(
+            self.correlater.put(
+                smpp_command=naz.SmppCommand.SUBMIT_SM_RESP,
+                sequence_number=7,
+                log_id="log_id1",
+                hook_metadata="hook_metadata1",
+                smsc_message_id="smsc_message_id1",
+            )
+        )
+
+        # a different naz client, sharing the same redis, receives the deliver_sm
+        other_correlater = naz.correlater.RedisCorrelater(redis=self.redis)
+        self.assertEqual(
+            self._run(
+                other_correlater.get(
+                    smpp_command=naz.SmppCommand.DELIVER_SM,
+                    sequence_number=99,
+                    smsc_message_id="smsc_message_id1",
+                )
+            ),
+            ("log_id1", "hook_metadata1"),
+        )
+        self.assertEqual(
+            set(self.redis.store.keys()),
+            {
+                "naz:correlater:client-1:sequence_number:7",
+                "naz:correlater:smsc_message_id:smsc_message_id1",
+            },
+        )
+
+    def test_sequence_numbers_are_per_client(self):
+        self.assertRaises(ValueError, naz.correlater.RedisCorrelater, redis=self.redis, client_id=7)
+        other_correlater = naz.correlater.RedisCorrelater(redis=self.redis, client_id="client-2")
+        for correlater, log_id in [(self.correlater, "log_id1"), (other_correlater, "log_id2")]:
+            self._run(
+                correlater.put(
+                    smpp_command=naz.SmppCommand.SUBMIT_SM,
+                    sequence_number=7,
+                    log_id=log_id,
+                    hook_metadata="",
+                )
+            )
+        for correlater, log_id in [(self.correlater, "log_id1"), (other_correlater, "log_id2")]:
+            self.assertEqual(
+                self._run(
+                    correlater.get(smpp_command=naz.SmppCommand.SUBMIT_SM_RESP, sequence_number=7)
+                ),
+                (log_id, ""),
+            )
+        # a random client_id is generated if none is given.
+        self.assertNotEqual(
+            naz.correlater.RedisCorrelater(redis=self.redis).client_id,
+            naz.correlater.RedisCorrelater(redis=self.redis).client_id,
+        )
+
+    def test_ttl(self):
+        self._run(
+            self.correlater.put(
+                smpp_command=naz.SmppCommand.SUBMIT_SM,
+                sequence_number=7,
+                log_id="log_id1",
+                hook_metadata="hook_metadata1",
+            )
+        )
+        time.sleep(0.3)
+        self.assertEqual(
+            self._run(
+                self.correlater.get(smpp_command=naz.SmppCommand.SUBMIT_SM_RESP, sequence_number=7)
+            ),
+            ("", ""),
+        )
+
+    def test_redis_unavailable(self):
+        self.redis.available = False
+        with self.assertRaises(ConnectionError):
+            self._run(
+                self.correlater.get(smpp_command=naz.SmppCommand.SUBMIT_SM_RESP, sequence_number=7)
+            )
+
+        # naz logs the error and carries on.
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password="password",
+            broker=naz.broker.SimpleBroker(),
+            correlation_handler=self.correlater,
+        )
+        with mock.patch("naz.Client._log") as mock_log:
+            header = struct.pack(">IIII", 16 + 3, 0x80000004, 0x00000000, 7)
+            self._run(cli._parse_response_pdu(header + b"id\x00"))
+            self.assertIn(
+                "ConnectionError", str([call[0][1].get("error") for call in mock_log.call_args_list])
+            )
@@ -0,0 +1,63 @@
+# do not to pollute the global namespace.
+# see: https://python-packaging.readthedocs.io/en/latest/testing.html
+
+import unittest
+from unittest import TestCase
+
+import naz
+
+try:
+    import prometheus_client
+except ImportError:
+    prometheus_client = None
+
+
+class TestPrometheusMetrics(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_metrics.TestPrometheusMetrics.test_something
+    """
+
+    def setUp(self):
+        self.metrics = naz.metrics.PrometheusMetrics()
+
+    def _record(self):
+        self.metrics.pdu_sent(naz.SmppCommand.SUBMIT_SM)
+        self.metrics.pdu_sent(naz.SmppCommand.SUBMIT_SM)
+        self.metrics.pdu_received(
+            naz.SmppCommand.SUBMIT_SM_RESP, naz.SmppCommandStatus.ESME_RTHROTTLED
+        )
+        self.metrics.queue_depth(7)
+        self.metrics.enquire_link_latency(0.25)
+        self.metrics.enquire_link_latency(0.75)
+        self.metrics.reconnect()
+        self.metrics.throttled()
+
+    def test_bad_args(self):
+        self.assertRaises(ValueError, naz.metrics.PrometheusMetrics, namespace=1)
+
+    def test_record(self):
+        self._record()
+        self.assertEqual(self.metrics.pdus_sent, {naz.SmppCommand.SUBMIT_SM: 2})
+        self.assertEqual(
+            self.metrics.pdus_received, {(naz.SmppCommand.SUBMIT_SM_RESP, "ESME_RTHROTTLED"): 1}
+        )
+        self.assertEqual(self.metrics.current_queue_depth, 7)
+        self.assertEqual(self.metrics.enquire_link_latency_count, 2)
+        self.assertEqual(self.metrics.enquire_link_latency_sum, 1.00)
+        self.assertEqual(self.metrics.reconnects, 1)
+        self.assertEqual(self.metrics.throttles, 1)
+
+    @unittest.skipIf(prometheus_client is None, "prometheus_client is not installed")
+    def test_collect(self):
+        self._record()
+        registry = prometheus_client.CollectorRegistry()
+        registry.register(self.metrics)
+        self.assertEqual(
+            registry.get_sample_value("naz_pdus_sent_total", {"smpp_command": "submit_sm"}), 2
+        )
+        self.assertEqual(registry.get_sample_value("naz_queue_depth"), 7)
+        self.assertEqual(registry.get_sample_value("naz_enquire_link_latency_seconds_count"), 2)
+        self.assertEqual(registry.get_sample_value("naz_throttles_total"), 1)
@@ -0,0 +1,229 @@
+# do not to pollute the global namespace.
+# see: https://python-packaging.readthedocs.io/en/latest/testing.html
+
+import struct
+from unittest import TestCase
+
+import naz
+
+
+class TestDecode(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_pdu.TestDecode.test_something
+    """
+
+    def setUp(self):
+        # naz.Client registers the codecs that are used to decode deliver_sm's
+        naz.codec.register_codecs()
+
+    def test_deliver_sm(self):
+        deliver_sm_pdu = (
+            b"\x00\x00\x00a\x00\x00\x00\x05\x00\x00"
+            b"\x00\x00\x9f\x88\xf1$AWSBD\x00\x01"
+            b"\x0116505551234\x00\x01\x0117735554070"
+            b"\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00"
+            b"%id:123456 sub:SSS dlvrd:DDD blah blah"
+        )
+        decoded = naz.pdu.decode(deliver_sm_pdu, log_id="log_id")
+        self.assertIsInstance(decoded, naz.pdu.DeliverSM)
+        self.assertEqual(decoded.smpp_command, naz.SmppCommand.DELIVER_SM)
+        self.assertEqual(decoded.command_id, 0x00000005)
+        self.assertEqual(decoded.sequence_number, 2676551972)
+        self.assertEqual(decoded.message.service_type, "AWSBD")
+        self.assertEqual(decoded.message.source_addr, "16505551234")
+        self.assertEqual(decoded.message.destination_addr, "17735554070")
+        self.assertEqual(decoded.message.short_message, "id:123456 sub:SSS dlvrd:DDD blah blah")
+        self.assertEqual(decoded.message.log_id, "log_id")
+
+    def test_deliver_sm_esm_class(self):
+        def deliver_sm_pdu(esm_class, short_message):
+            body = (
+                b"\x00\x01\x01254711999999\x00\x00\x0040404\x00"
+                + struct.pack(">BBB", esm_class, 0, 0)
+                + b"\x00\x00"
+                + struct.pack(">BBBBB", 0, 0, 0, 0, len(short_message))
+                + short_message
+            )
+            return struct.pack(">IIII", 16 + len(body), 0x00000005, 0, 1) + body
+
+        receipt = naz.pdu.decode(
+            deliver_sm_pdu(0b00000100, b"id:123456 sub:001 dlvrd:001 stat:DELIVRD err:000")
+        ).message
+        self.assertTrue(receipt.is_smsc_delivery_receipt)
+        self.assertTrue(receipt.is_delivery_receipt)
+        self.assertFalse(receipt.is_mobile_originated)
+        self.assertFalse(receipt.is_sme_delivery_acknowledgement)
+        self.assertFalse(receipt.udh_indicator)
+        self.assertFalse(receipt.reply_path)
+        self.assertEqual(receipt.message_type, 0b00000100)
+
+        # a part of a concatenated mobile originated message, that asks for a reply path.
+        mobile_originated = naz.pdu.decode(
+            deliver_sm_pdu(0b11000000, b"\x05\x00\x03\x2a\x02\x01hello")
+        ).message
+        self.assertTrue(mobile_originated.is_mobile_originated)
+        self.assertFalse(mobile_originated.is_smsc_delivery_receipt)
+        self.assertFalse(mobile_originated.is_delivery_receipt)
+        self.assertTrue(mobile_originated.udh_indicator)
+        self.assertTrue(mobile_originated.reply_path)
+        self.assertEqual(mobile_originated.message_type, 0)
+
+        for esm_class, flag in [
+            (0b00001000, "is_sme_delivery_acknowledgement"),
+            (0b00010000, "is_sme_manual_acknowledgement"),
+            (0b00011000, "is_conversation_abort"),
+            (0b00100000, "is_intermediate_notification"),
+        ]:
+            message = naz.pdu.decode(deliver_sm_pdu(esm_class, b"hello")).message
+            self.assertTrue(getattr(message, flag))
+            self.assertFalse(message.is_mobile_originated)
+            self.assertFalse(message.is_smsc_delivery_receipt)
+
+    def test_generic_nack(self):
+        # a generic_nack with command_status ESME_RINVCMDLEN and sequence_number 7
+        generic_nack_pdu = b"\x00\x00\x00\x10\x80\x00\x00\x00\x00\x00\x00\x02\x00\x00\x00\x07"
+        decoded = naz.pdu.decode(generic_nack_pdu)
+        self.assertEqual(
+            decoded,
+            naz.pdu.GenericNack(
+                command_id=0x80000000,
+                command_status=naz.SmppCommandStatus.ESME_RINVCMDLEN.value,
+                sequence_number=7,
+            ),
+        )
+        self.assertEqual(decoded.smpp_command, naz.SmppCommand.GENERIC_NACK)
+
+    def test_responses(self):
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x18\x80\x00\x00\t\x00\x00\x00\x00\x00\x00\x00\x06SMPPSim\x00"
+        )
+        self.assertEqual(
+            decoded,
+            naz.pdu.BindResp(
+                command_id=0x80000009,
+                command_status=0,
+                sequence_number=6,
+                smpp_command=naz.SmppCommand.BIND_TRANSCEIVER_RESP,
+                system_id="SMPPSim",
+            ),
+        )
+
+        self.assertEqual(decoded.sc_interface_version, None)
+
+        # a bind_transmitter_resp with the sc_interface_version optional parameter
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x1a\x80\x00\x00\x02\x00\x00\x00\x00\x00\x00\x00\x06"
+            b"SMSC\x00\x02\x10\x00\x01\x34"
+        )
+        self.assertIsInstance(decoded, naz.pdu.BindResp)
+        self.assertEqual((decoded.system_id, decoded.sc_interface_version), ("SMSC", 0x34))
+
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x15\x80\x00\x00\x04\x00\x00\x00\x00\x00\x00\x00\x03abcd\x00"
+        )
+        self.assertIsInstance(decoded, naz.pdu.SubmitSMResp)
+        self.assertEqual(decoded.message_id, "abcd")
+
+        # a failed submit_sm_resp has no body.
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x10\x80\x00\x00\x04\x00\x00\x00\x0b\x00\x00\x00\x03"
+        )
+        self.assertIsInstance(decoded, naz.pdu.SubmitSMResp)
+        self.assertEqual(decoded.message_id, "")
+        self.assertEqual(decoded.command_status, 0x0000000B)
+
+        # a submit_multi_resp for a message that was not delivered to one of the destinations.
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x24\x80\x00\x00\x21\x00\x00\x00\x00\x00\x00\x00\x08abcd\x00\x01"
+            b"\x01\x012547111\x00\x00\x00\x00\x0b"
+        )
+        self.assertEqual(
+            decoded,
+            naz.pdu.SubmitMultiResp(
+                command_id=0x80000021,
+                command_status=0,
+                sequence_number=8,
+                message_id="abcd",
+                unsuccess_smes=[
+                    naz.UnsuccessSME(
+                        dest_addr_ton=1,
+                        dest_addr_npi=1,
+                        destination_addr="2547111",
+                        error_status_code=0x0000000B,
+                    )
+                ],
+            ),
+        )
+
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x10\x00\x00\x00\x15\x00\x00\x00\x00\x00\x00\x00\x09"
+        )
+        self.assertIsInstance(decoded, naz.pdu.EnquireLink)
+        self.assertEqual(decoded.sequence_number, 9)
+
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x17\x80\x00\x00\x03\x00\x00\x00\x00\x00\x00\x00\x04ab\x00\x00\x02\x05"
+        )
+        self.assertIsInstance(decoded, naz.pdu.QuerySMResp)
+        self.assertEqual(
+            (decoded.message_id, decoded.final_date, decoded.message_state, decoded.error_code),
+            ("ab", "", 2, 5),
+        )
+
+    def test_broadcast_responses(self):
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x1d\x80\x00\x01\x11\x00\x00\x00\x00\x00\x00\x00\x05"
+            b"bcast\x00\x06\x08\x00\x01\x5a"
+        )
+        self.assertEqual(
+            decoded,
+            naz.pdu.BroadcastSMResp(
+                command_id=0x80000111,
+                command_status=0,
+                sequence_number=5,
+                message_id="bcast",
+                optional_params=[naz.TLV(tag=0x0608, value=b"\x5a")],
+            ),
+        )
+        self.assertEqual(decoded.smpp_command, naz.SmppCommand.BROADCAST_SM_RESP)
+
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x16\x80\x00\x01\x12\x00\x00\x00\x00\x00\x00\x00\x06bcast\x00"
+        )
+        self.assertIsInstance(decoded, naz.pdu.QueryBroadcastSMResp)
+        self.assertEqual((decoded.message_id, decoded.optional_params), ("bcast", []))
+
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x10\x80\x00\x01\x13\x00\x00\x00\x00\x00\x00\x00\x07"
+        )
+        self.assertIsInstance(decoded, naz.pdu.CancelBroadcastSMResp)
+        self.assertEqual(decoded.smpp_command, naz.SmppCommand.CANCEL_BROADCAST_SM_RESP)
+
+    def test_outbind(self):
+        decoded = naz.pdu.decode(
+            b"\x00\x00\x00\x1c\x00\x00\x00\x0b\x00\x00\x00\x00\x00\x00\x00\x01SMSC\x00smsc-pw\x00"
+        )
+        self.assertIsInstance(decoded, naz.pdu.Outbind)
+        self.assertEqual((decoded.system_id, decoded.password), ("SMSC", "smsc-pw"))
+
+    def test_unknown_command_id(self):
+        pdu = b"\x00\x00\x00\x13\x00\x01\x02\x05\x00\x00\x00\x00\x00\x00\x00\x01abc"
+        decoded = naz.pdu.decode(pdu)
+        self.assertIsInstance(decoded, naz.pdu.RawPDU)
+        self.assertEqual(decoded.command_id, 0x00010205)
+        self.assertEqual(decoded.header, pdu[:16])
+        self.assertEqual(decoded.body, b"abc")
+
+    def test_malformed_body(self):
+        # a query_sm_resp that is missing its message_state and error_code
+        pdu = b"\x00\x00\x00\x13\x80\x00\x00\x03\x00\x00\x00\x00\x00\x00\x00\x04ab\x00"
+        decoded = naz.pdu.decode(pdu)
+        self.assertIsInstance(decoded, naz.pdu.RawPDU)
+        self.assertEqual(decoded.body, b"ab\x00")
+
+    def test_short_pdu(self):
+        with self.assertRaises(ValueError):
+            naz.pdu.decode(b"\x00\x00\x00\x10\x80\x00")
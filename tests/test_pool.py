@@ -0,0 +1,169 @@
+# do not to pollute the global namespace.
+# see: https://python-packaging.readthedocs.io/en/latest/testing.html
+
+import os
+import struct
+import asyncio
+from unittest import TestCase
+
+import naz
+
+
+class TestPool(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_pool.TestPool.test_something
+    """
+
+    def setUp(self):
+        # the submit_sm's received on each connection to the mock SMSC
+        self.connections = []
+        # whether the mock SMSC responds to a submit_sm with the short_message as the message_id
+        self.echo = False
+
+    @staticmethod
+    def _run(coro):
+        loop = asyncio.get_event_loop()
+        return loop.run_until_complete(coro)
+
+    @staticmethod
+    def _submit_sm(i):
+        return naz.protocol.SubmitSM(
+            short_message="Hello World-{0}".format(i),
+            log_id="log_id-{0}".format(i),
+            source_addr="254722111111",
+            destination_addr="254722999999",
+        )
+
+    async def _handle_conn(self, reader, writer):
+        received = []
+        self.connections.append(received)
+        while True:
+            try:
+                header = await reader.readexactly(16)
+            except asyncio.IncompleteReadError:
+                return
+            command_length, command_id, _, sequence_number = struct.unpack(">IIII", header)
+            body = await reader.readexactly(command_length - 16)
+            if command_id == 0x00000004:
+                received.append(sequence_number)
+            if command_id == 0x00000004 and self.echo:
+                body = body[body.rindex(b"Hello World-") :] + b"\x00"
+            else:
+                body = b"" if command_id in [0x00000006, 0x00000015] else b"SMSC\x00"
+            writer.write(
+                struct.pack(">IIII", 16 + len(body), 0x80000000 | command_id, 0, sequence_number)
+                + body
+            )
+            await writer.drain()
+
+    def _pool(self, port, **kwargs):
+        return naz.Pool(
+            smsc_host="127.0.0.1",
+            smsc_port=port,
+            system_id="smppclient1",
+            password=os.getenv("password", "password"),
+            broker=naz.broker.SimpleBroker(maxsize=100),
+            socket_timeout=1.0,
+            drain_duration=1.0,
+            logger=naz.log.SimpleLogger("test_pool", level="WARNING"),
+            **kwargs
+        )
+
+    def test_bad_args(self):
+        self.assertRaises(ValueError, self._pool, 2775, size="3")
+        self.assertRaises(ValueError, self._pool, 2775, size=0)
+        self.assertRaises(ValueError, self._pool, 2775, health_check_interval=3)
+
+    def test_shared_correlater(self):
+        pool = self._pool(2775, size=3)
+        self.assertEqual(len(pool.clients), 3)
+        for client in pool.clients:
+            self.assertIs(client.correlation_handler, pool.correlation_handler)
+            self.assertIs(client.sequence_generator, pool.sequence_generator)
+
+        correlation_handler = naz.correlater.SimpleCorrelater()
+        pool = self._pool(2775, size=2, correlation_handler=correlation_handler)
+        for client in pool.clients:
+            self.assertIs(client.correlation_handler, correlation_handler)
+
+    def test_responses_correlate_to_their_own_bind(self):
+        correlated = {}
+
+        class Hook(naz.hooks.SimpleHook):
+            async def from_smsc(self, smpp_command, log_id, hook_metadata, status, pdu):
+                if smpp_command == naz.SmppCommand.SUBMIT_SM_RESP:
+                    correlated[pdu[16:-1].decode()] = log_id
+
+        async def run():
+            server = await asyncio.start_server(self._handle_conn, "127.0.0.1", 0)
+            pool = self._pool(
+                server.sockets[0].getsockname()[1],
+                size=2,
+                hook=Hook(logger=naz.log.SimpleLogger("test_pool", level="WARNING")),
+            )
+            await pool.start()
+            await asyncio.gather(*[pool.submit_message(self._submit_sm(i)) for i in range(0, 6)])
+            await pool.shutdown()
+            server.close()
+            await server.wait_closed()
+
+        self.echo = True
+        self._run(run())
+        self.assertEqual([len(received) for received in self.connections], [3, 3])
+        # the binds do not re-use each other's sequence_numbers
+        self.assertEqual(set(self.connections[0]) & set(self.connections[1]), set())
+        self.assertEqual(
+            correlated,
+            {"Hello World-{0}".format(i): "log_id-{0}".format(i) for i in range(0, 6)},
+        )
+
+    def test_sends_are_distributed(self):
+        async def run():
+            server = await asyncio.start_server(self._handle_conn, "127.0.0.1", 0)
+            pool = self._pool(server.sockets[0].getsockname()[1], size=3)
+            await pool.start()
+            results = await asyncio.gather(
+                *[pool.submit_message(self._submit_sm(i)) for i in range(0, 9)]
+            )
+            await pool.shutdown()
+            server.close()
+            await server.wait_closed()
+            return results
+
+        results = self._run(run())
+        self.assertEqual(results, [["SMSC"]] * 9)
+        self.assertEqual(len(self.connections), 3)
+        for received in self.connections:
+            self.assertEqual(len(received), 3)
+
+    def test_dead_bind_is_replaced(self):
+        async def run():
+            server = await asyncio.start_server(self._handle_conn, "127.0.0.1", 0)
+            pool = self._pool(
+                server.sockets[0].getsockname()[1], size=2, health_check_interval=0.05
+            )
+            await pool.start()
+            dead_client = pool.clients[1]
+            # simulate a bind whose background tasks have crashed.
+            for task in pool._tasks[1]:
+                task.cancel()
+            await asyncio.sleep(0.2)
+            results = await asyncio.gather(
+                *[pool.submit_message(self._submit_sm(i)) for i in range(0, 4)]
+            )
+            clients = list(pool.clients)
+            await pool.shutdown()
+            server.close()
+            await server.wait_closed()
+            return dead_client, clients, results
+
+        dead_client, clients, results = self._run(run())
+        self.assertIsNot(clients[1], dead_client)
+        self.assertTrue(dead_client.SHOULD_SHUT_DOWN)
+        self.assertEqual(results, [["SMSC"]] * 4)
+        # the replacement bind is a new connection, and it is used.
+        self.assertEqual(len(self.connections), 3)
+        self.assertEqual(len(self.connections[2]), 2)
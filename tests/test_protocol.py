@@ -2,6 +2,8 @@
 # see: https://python-packaging.readthedocs.io/en/latest/testing.html
 
 import json
+import struct
+import datetime
 from unittest import TestCase
 
 import naz
@@ -62,6 +64,25 @@ class TestProtocol(TestCase):
         proto = naz.protocol.json_to_Message(_in_json)
 
         self.assertIsInstance(proto, naz.protocol.Message)
+        # messages serialized before `attempt` was added are on their first attempt.
+        self.assertEqual(proto.attempt, 1)
+
+    def test_attempt(self):
+        for message_class, message_kwarg in [
+            (naz.protocol.SubmitSM, "short_message"),
+            (naz.protocol.DataSM, "message_payload"),
+        ]:
+            kwargs = {
+                message_kwarg: "hello",
+                "log_id": "some-log-id",
+                "source_addr": "546464",
+                "destination_addr": "24292",
+            }
+            proto = message_class(attempt=3, **kwargs)
+            self.assertEqual(naz.protocol.json_to_Message(proto.to_json()).attempt, 3)
+            for attempt in [0, "2"]:
+                with self.assertRaises(ValueError):
+                    message_class(attempt=attempt, **kwargs)
 
     def test_serialize_n_deserialize(self):
         proto = naz.protocol.EnquireLinkResp(log_id="some-log-id", sequence_number=294)
@@ -234,6 +255,90 @@ class TestSubmitSMProtocol(TestCase):
         )
         self.assertIsNotNone(proto)
 
+    def test_service_type(self):
+        def make_submit_sm(service_type):
+            return naz.protocol.SubmitSM(
+                log_id="some-log-id",
+                short_message="Hello, thanks for shopping with us.",
+                source_addr="254722111111",
+                destination_addr="254722999999",
+                service_type=service_type,
+            )
+
+        self.assertEqual(make_submit_sm("WAP").service_type, "WAP")
+        self.assertEqual(make_submit_sm("").service_type, "")
+        self.assertEqual(make_submit_sm("12345").service_type, "12345")
+        with self.assertRaises(ValueError) as raised_exception:
+            make_submit_sm("123456")
+        self.assertIn(
+            "`service_type` should be at most 5 ascii characters", str(raised_exception.exception)
+        )
+        self.assertRaises(ValueError, make_submit_sm, "WÄP")
+        with self.assertRaises(ValueError):
+            naz.protocol.DataSM(
+                log_id="some-log-id",
+                message_payload="hello",
+                source_addr="546464",
+                destination_addr="24292",
+                service_type="123456",
+            )
+
+    def test_priority_flag(self):
+        def make_submit_sm(priority_flag):
+            return naz.protocol.SubmitSM(
+                log_id="some-log-id",
+                short_message="Hello, thanks for shopping with us.",
+                source_addr="254722111111",
+                destination_addr="254722999999",
+                priority_flag=priority_flag,
+            )
+
+        self.assertEqual(make_submit_sm(None).priority_flag, None)
+        self.assertEqual(make_submit_sm(3).priority_flag, 3)
+        for priority_flag in [-1, 4, "1"]:
+            self.assertRaises(ValueError, make_submit_sm, priority_flag)
+
+    def test_binary_short_message(self):
+        def make_submit_sm(short_message, encoding):
+            return naz.protocol.SubmitSM(
+                log_id="some-log-id",
+                short_message=short_message,
+                source_addr="254722111111",
+                destination_addr="254722999999",
+                encoding=encoding,
+            )
+
+        proto = make_submit_sm(b"\x06\x05\x04\x0b\x84\x23\xf0\x00\xff", "octet_unspecified_II")
+        self.assertEqual(proto.data_coding.value, 4)
+        self.assertEqual(
+            naz.protocol.SubmitSM.from_json(proto.to_json()).short_message, proto.short_message
+        )
+        self.assertEqual(make_submit_sm(b"\x00", "octet_unspecified_I").data_coding.value, 2)
+        # a text message in a binary encoding, and a binary one in a text encoding.
+        self.assertRaises(ValueError, make_submit_sm, "hello", "octet_unspecified_II")
+        self.assertRaises(ValueError, make_submit_sm, b"hello", "gsm0338")
+
+    def test_data_coding(self):
+        def make_submit_sm(data_coding):
+            return naz.protocol.SubmitSM(
+                log_id="some-log-id",
+                short_message="hello",
+                source_addr="254722111111",
+                destination_addr="254722999999",
+                encoding="ucs2",
+                data_coding=data_coding,
+            )
+
+        self.assertEqual(make_submit_sm(None).data_coding.value, 8)
+        proto = make_submit_sm(0x04)
+        self.assertEqual((proto.data_coding.value, proto.encoding), (0x04, "ucs2"))
+        self.assertEqual(naz.protocol.SubmitSM.from_json(proto.to_json()).data_coding.value, 0x04)
+        self.assertEqual(
+            naz.protocol.SubmitSM.from_json(make_submit_sm(None).to_json()).data_coding.value, 8
+        )
+        self.assertRaises(ValueError, make_submit_sm, 256)
+        self.assertRaises(ValueError, make_submit_sm, "4")
+
     def test_optional_params_are_validated(self):
         def make_submit_sm():
             naz.protocol.SubmitSM(
@@ -344,3 +449,357 @@ class TestSubmitSMProtocol(TestCase):
         for k, v in _args.items():
             with self.assertRaises(ValueError):
                 make_create_submitSm_message(k, v)
+
+    def test_generic_optional_params(self):
+        tlvs = [naz.TLV(tag=0x1400, value=b"vendor-value"), naz.TLV(tag=0x0005, value=b"\x02")]
+        proto = naz.protocol.SubmitSM(
+            version=1,
+            log_id="some-log-id",
+            short_message="Hello",
+            source_addr="254722111111",
+            destination_addr="254722999999",
+            optional_params=tlvs,
+        )
+        self.assertEqual(tlvs[0].length, 12)
+        self.assertEqual(tlvs[1].tlv, b"\x00\x05\x00\x01\x02")
+
+        new_proto = naz.protocol.json_to_Message(proto.to_json())
+        self.assertEqual(new_proto.optional_params, tlvs)
+
+    def test_bad_generic_optional_params(self):
+        for bad in [
+            "not-a-list",
+            [(0x1400, b"value")],
+            [naz.TLV(tag=0x10000, value=b"value")],
+            [naz.TLV(tag=0x1400, value="not-bytes")],
+        ]:
+            with self.assertRaises(ValueError):
+                naz.protocol.SubmitSM(
+                    version=1,
+                    log_id="some-log-id",
+                    short_message="Hello",
+                    source_addr="254722111111",
+                    destination_addr="254722999999",
+                    optional_params=bad,
+                )
+
+    def test_parse_tlvs(self):
+        data = naz.TLV(tag=0x1400, value=b"abc").tlv + naz.TLV(tag=0x0005, value=b"").tlv
+        self.assertEqual(
+            naz.TLV.parse(data), [naz.TLV(tag=0x1400, value=b"abc"), naz.TLV(tag=0x0005, value=b"")]
+        )
+        with self.assertRaises(ValueError):
+            naz.TLV.parse(data[:5])
+
+    def test_alphanumeric_sender(self):
+        def submit_sm(source_addr, **kwargs):
+            return naz.protocol.SubmitSM(
+                short_message="hello",
+                log_id="log_id",
+                source_addr=source_addr,
+                destination_addr="254722999999",
+                **kwargs
+            )
+
+        # an 11 character brand name
+        proto_msg = submit_sm("MyShopBrand")
+        self.assertEqual((proto_msg.source_addr_ton, proto_msg.source_addr_npi), (0x05, 0x00))
+        # explicitly set TON/NPI are left as they are
+        proto_msg = submit_sm("MyShop", source_addr_ton=0x02, source_addr_npi=0x01)
+        self.assertEqual((proto_msg.source_addr_ton, proto_msg.source_addr_npi), (0x02, 0x01))
+        # a numeric sender is left untouched; the client's defaults will be used
+        for source_addr in [
+            "254722111111",
+            "+254722111111",
+            # formatted numbers are not alphanumeric, even if they are longer than 11 characters
+            "254 700 111 222",
+            "+254700111222",
+            "+254-700-111-222",
+            "(254) 700111222",
+        ]:
+            proto_msg = submit_sm(source_addr)
+            self.assertEqual((proto_msg.source_addr_ton, proto_msg.source_addr_npi), (None, None))
+
+        with self.assertRaises(ValueError) as raised_exception:
+            submit_sm("MyShopBrand2")
+        self.assertIn("at most 11 characters", str(raised_exception.exception))
+        with self.assertRaises(ValueError) as raised_exception:
+            submit_sm("MyShop™")
+        self.assertIn("GSM alphabet", str(raised_exception.exception))
+        with self.assertRaises(ValueError):
+            naz.protocol.DataSM(
+                message_payload="hello",
+                log_id="log_id",
+                source_addr="MyShopBrand2",
+                destination_addr="254722999999",
+            )
+
+
+class TestSmppTime(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_protocol.TestSmppTime.test_something
+    """
+
+    @staticmethod
+    def _submit_sm(**kwargs):
+        return naz.protocol.SubmitSM(
+            log_id="some-log-id",
+            short_message="Hello",
+            source_addr="254722111111",
+            destination_addr="254722999999",
+            **kwargs
+        )
+
+    def test_absolute_time(self):
+        # UTC+2 is 8 quarter hours ahead of UTC
+        value = datetime.datetime(
+            2022,
+            10,
+            17,
+            9,
+            54,
+            7,
+            200_000,
+            tzinfo=datetime.timezone(datetime.timedelta(hours=2)),
+        )
+        self.assertEqual(naz.protocol.smpp_time(value), "221017095407208+")
+
+        value = datetime.datetime(
+            2022, 10, 17, 9, 54, 7, tzinfo=datetime.timezone(-datetime.timedelta(hours=5))
+        )
+        self.assertEqual(naz.protocol.smpp_time(value), "221017095407020-")
+        # naive datetimes are in UTC
+        value = datetime.datetime(2022, 10, 17, 9, 54, 7)
+        self.assertEqual(naz.protocol.smpp_time(value), "221017095407000+")
+
+    def test_relative_time(self):
+        self.assertEqual(naz.protocol.smpp_time(datetime.timedelta(hours=24)), "000001000000000R")
+        self.assertEqual(
+            naz.protocol.smpp_time(datetime.timedelta(days=396, hours=3, minutes=2, seconds=1)),
+            "010101030201000R",
+        )
+        self.assertRaises(ValueError, naz.protocol.smpp_time, datetime.timedelta(hours=-1))
+        self.assertRaises(ValueError, naz.protocol.smpp_time, "000001000000000R")
+
+    def test_submit_sm_fields(self):
+        proto = self._submit_sm(
+            schedule_delivery_time=datetime.datetime(2022, 10, 17, 9, 54, 7),
+            validity_period=datetime.timedelta(hours=24),
+        )
+        self.assertEqual(proto.schedule_delivery_time, "221017095407000+")
+        self.assertEqual(proto.validity_period, "000001000000000R")
+        # it is still serializable
+        self.assertEqual(
+            naz.protocol.json_to_Message(proto.to_json()).validity_period, "000001000000000R"
+        )
+
+    def test_immediate(self):
+        proto = self._submit_sm(schedule_delivery_time="", validity_period="")
+        self.assertEqual(proto.schedule_delivery_time, "")
+        self.assertEqual(proto.validity_period, "")
+
+    def test_malformed(self):
+        for value in [
+            "22101709540720+",  # too short
+            "221017095407208X",
+            "221317095407208+",  # month 13
+            "221017095407299+",  # more than 48 quarter hours
+            "tomorrow",
+        ]:
+            with self.assertRaises(ValueError):
+                self._submit_sm(validity_period=value)
+            with self.assertRaises(ValueError):
+                self._submit_sm(schedule_delivery_time=value)
+        # valid strings are accepted as is
+        proto = self._submit_sm(validity_period="221017095407208+")
+        self.assertEqual(proto.validity_period, "221017095407208+")
+
+
+class TestE164(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_protocol.TestE164.test_something
+    """
+
+    def test_normalize(self):
+        self.assertEqual(naz.protocol.e164("+254700111222"), "254700111222")
+        self.assertEqual(naz.protocol.e164("00254700111222"), "254700111222")
+        self.assertEqual(naz.protocol.e164("254700111222"), "254700111222")
+        for number in ["+2547-11", "0700111222", "+25470011122233344", "+123", "+"]:
+            with self.assertRaises(ValueError):
+                naz.protocol.e164(number)
+
+
+class TestDataSMProtocol(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_protocol.TestDataSMProtocol.test_something
+    """
+
+    def test_success_instantiation(self):
+        proto = naz.protocol.DataSM(
+            log_id="some-log-id",
+            message_payload="Hello, thanks for shopping with us.",
+            source_addr="254722111111",
+            destination_addr="254722999999",
+            payload_type=0,
+        )
+        self.assertEqual(proto.smpp_command, naz.SmppCommand.DATA_SM)
+        self.assertEqual(proto.optional_tags_dict, {"payload_type": 0})
+
+    def test_serialize_n_deserialize(self):
+        proto = naz.protocol.DataSM(
+            log_id="some-log-id",
+            message_payload="hello",
+            source_addr="546464",
+            destination_addr="24292",
+            encoding="ucs2",
+            destination_port=9200,
+        )
+        _msg = naz.protocol.json_to_Message(proto.to_json())
+        self.assertIsInstance(_msg, naz.protocol.DataSM)
+        self.assertEqual(_msg.message_payload, "hello")
+        self.assertEqual(_msg.encoding, "ucs2")
+        self.assertEqual(_msg.optional_tags_dict, {"destination_port": 9200})
+
+    def test_bad_args(self):
+        with self.assertRaises(ValueError) as raised_exception:
+            naz.protocol.DataSM(
+                log_id="some-log-id",
+                message_payload=b"hello",
+                source_addr="546464",
+                destination_addr="24292",
+            )
+        self.assertIn("`message_payload` should be of type", str(raised_exception.exception))
+        with self.assertRaises(ValueError):
+            naz.protocol.DataSM(
+                log_id="some-log-id",
+                message_payload="hello",
+                source_addr="546464",
+                destination_addr="24292",
+                smpp_command=naz.SmppCommand.SUBMIT_SM,
+            )
+
+
+class TestDeliveryReceipt(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_protocol.TestDeliveryReceipt.test_something
+    """
+
+    def test_parse(self):
+        receipt = naz.protocol.parse_delivery_receipt(
+            "id:abc123 sub:001 dlvrd:001 submit date:1910141523 done date:191014152530 "
+            "stat:DELIVRD err:000 text:Hello there: friend"
+        )
+        self.assertEqual(receipt.message_id, "abc123")
+        self.assertEqual(receipt.submitted, 1)
+        self.assertEqual(receipt.delivered, 1)
+        self.assertEqual(receipt.submit_date, datetime.datetime(2019, 10, 14, 15, 23))
+        self.assertEqual(receipt.done_date, datetime.datetime(2019, 10, 14, 15, 25, 30))
+        self.assertEqual(receipt.stat, "DELIVRD")
+        self.assertEqual(receipt.message_state, naz.SmppMessageState.DELIVERED)
+        self.assertEqual(receipt.err, "000")
+        self.assertEqual(receipt.text, "Hello there: friend")
+
+    def test_parse_missing_field(self):
+        receipt = naz.protocol.parse_delivery_receipt("id:abc123 sub:001 stat:UNDELIV err:044")
+        self.assertEqual(receipt.message_id, "abc123")
+        self.assertIsNone(receipt.delivered)
+        self.assertIsNone(receipt.submit_date)
+        self.assertIsNone(receipt.text)
+        self.assertEqual(receipt.message_state, naz.SmppMessageState.UNDELIVERABLE)
+
+        with self.assertRaises(ValueError) as raised_exception:
+            naz.protocol.parse_delivery_receipt("sub:001 dlvrd:001 stat:DELIVRD err:000")
+        self.assertIn("does not have a message id", str(raised_exception.exception))
+
+    def test_parse_with_tlvs(self):
+        receipt = naz.protocol.parse_delivery_receipt(
+            "sub:001 dlvrd:000 stat:EXPIRED",
+            optional_params=naz.protocol.read_optional_params(
+                struct.pack(">IIII", 0, 0x00000005, 0, 1)
+                + b"\x00\x01\x01\x00\x01\x01\x00\x04\x00\x00\x00\x00\x00\x00\x00\x00\x00"
+                + naz.TLV(tag=0x001E, value=b"abc123\x00").tlv
+                + naz.TLV(tag=0x0427, value=b"\x03").tlv
+            ),
+        )
+        self.assertEqual(receipt.message_id, "abc123")
+        self.assertEqual(receipt.stat, "EXPIRED")
+        self.assertEqual(receipt.message_state, naz.SmppMessageState.EXPIRED)
+
+
+class TestDeliverSMProtocol(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_protocol.TestDeliverSMProtocol.test_something
+    """
+
+    def test_serialize_n_deserialize(self):
+        proto = naz.protocol.DeliverSM(
+            log_id="some-log-id",
+            sequence_number=7,
+            short_message="hello",
+            source_addr="254711999999",
+            destination_addr="40404",
+            optional_params=[naz.TLV(tag=0x1400, value=b"vendor")],
+        )
+        new_proto = naz.protocol.json_to_Message(proto.to_json())
+        self.assertIsInstance(new_proto, naz.protocol.DeliverSM)
+        self.assertEqual(new_proto.short_message, "hello")
+        self.assertEqual(new_proto.optional_params, proto.optional_params)
+        self.assertFalse(new_proto.is_delivery_receipt)
+
+    def test_from_pdu_ucs2(self):
+        short_message = "hello 你好".encode("utf_16_be")
+        body = (
+            b"\x00\x01\x01254711999999\x00\x00\x0040404\x00\x04\x00\x00\x00\x00"
+            + struct.pack(">BBBBB", 0, 0, 8, 0, len(short_message))
+            + short_message
+        )
+        pdu = struct.pack(">IIII", 16 + len(body), 0x00000005, 0, 9) + body
+        proto = naz.protocol.DeliverSM._from_pdu(pdu, log_id="some-log-id")
+        self.assertEqual(proto.short_message, "hello 你好")
+        self.assertEqual(proto.sequence_number, 9)
+        self.assertTrue(proto.is_delivery_receipt)
+        self.assertEqual(proto.encoding, "utf_16_be")
+        self.assertEqual(proto.raw_short_message, short_message)
+
+        new_proto = naz.protocol.json_to_Message(proto.to_json())
+        self.assertEqual(new_proto.raw_short_message, short_message)
+
+    def test_from_pdu_latin_1(self):
+        short_message = "café".encode("latin_1")
+        body = (
+            b"\x00\x01\x01254711999999\x00\x00\x0040404\x00\x00\x00\x00\x00\x00"
+            + struct.pack(">BBBBB", 0, 0, 3, 0, len(short_message))
+            + short_message
+        )
+        pdu = struct.pack(">IIII", 16 + len(body), 0x00000005, 0, 9) + body
+        proto = naz.protocol.DeliverSM._from_pdu(pdu, log_id="some-log-id")
+        self.assertEqual(proto.short_message, "café")
+        self.assertEqual(proto.encoding, "latin_1")
+        self.assertEqual(proto.raw_short_message, b"caf\xe9")
+
+    def test_raw_short_message_validation(self):
+        with self.assertRaises(ValueError) as raised_exception:
+            naz.protocol.DeliverSM(
+                log_id="some-log-id",
+                sequence_number=7,
+                short_message="hello",
+                source_addr="254711999999",
+                destination_addr="40404",
+                raw_short_message="hello",
+            )
+        self.assertIn("`raw_short_message` should be of type", str(raised_exception.exception))
@@ -54,3 +54,95 @@ class TestRateLimit(TestCase):
         total_msgs_delivered = len(msgs_delivered)
         effective_message_rate = total_msgs_delivered / time_taken_to_deliver
         self.assertAlmostEqual(effective_message_rate, send_rate, 0)
+
+
+class TestTokenBucketRateLimit(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_ratelimit.TestTokenBucketRateLimit.test_something
+    """
+
+    @staticmethod
+    def _run(coro):
+        loop = asyncio.get_event_loop()
+        return loop.run_until_complete(coro)
+
+    def test_burst(self):
+        rate_limiter = naz.ratelimiter.TokenBucketRateLimiter(send_rate=1.0, burst=5.0)
+        with mock.patch("naz.ratelimiter.asyncio.sleep", new=AsyncMock()) as mock_sleep:
+            for _ in range(5):
+                self._run(rate_limiter.limit())
+            self.assertFalse(mock_sleep.mock.called)
+
+    def test_send_rate(self):
+        send_rate = 20.0
+        rate_limiter = naz.ratelimiter.TokenBucketRateLimiter(send_rate=send_rate, burst=5.0)
+        now = time.monotonic()
+        for _ in range(15):
+            self._run(rate_limiter.limit())
+        then = time.monotonic()
+        # the first 5 are sent in a burst, the other 10 have to wait for tokens.
+        self.assertGreaterEqual(then - now, 10 / send_rate * 0.95)
+        self.assertLess(then - now, 2.0)
+
+    def test_cancellation(self):
+        rate_limiter = naz.ratelimiter.TokenBucketRateLimiter(send_rate=0.1, burst=1.0)
+        self._run(rate_limiter.limit())
+        now = time.monotonic()
+        with self.assertRaises(asyncio.TimeoutError):
+            # the next token is only available after 10 seconds
+            self._run(asyncio.wait_for(rate_limiter.limit(), timeout=0.05))
+        self.assertLess(time.monotonic() - now, 1.0)
+
+    def test_bad_args(self):
+        with self.assertRaises(ValueError):
+            naz.ratelimiter.TokenBucketRateLimiter(send_rate=0.0)
+        with self.assertRaises(ValueError):
+            naz.ratelimiter.TokenBucketRateLimiter(send_rate=1.0, burst=0.5)
+
+    def test_with_adaptive_throttle_handler(self):
+        rate_limiter = naz.ratelimiter.TokenBucketRateLimiter(send_rate=10.0)
+        throttle_handler = naz.throttle.AdaptiveThrottleHandler(rate_limiter=rate_limiter)
+        self._run(throttle_handler.throttled())
+        self.assertEqual(rate_limiter.send_rate, 5.0)
+
+
+class TestByteRateLimit(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_ratelimit.TestByteRateLimit.test_something
+    """
+
+    @staticmethod
+    def _run(coro):
+        loop = asyncio.get_event_loop()
+        return loop.run_until_complete(coro)
+
+    def test_burst(self):
+        rate_limiter = naz.ratelimiter.ByteRateLimiter(byte_rate=100.0, burst=500.0)
+        with mock.patch("naz.ratelimiter.asyncio.sleep", new=AsyncMock()) as mock_sleep:
+            for size in [100, 300, 100]:
+                self._run(rate_limiter.limit(size))
+            self.assertFalse(mock_sleep.mock.called)
+            self._run(rate_limiter.limit(1))
+            self.assertTrue(mock_sleep.mock.called)
+
+    def test_larger_than_burst(self):
+        rate_limiter = naz.ratelimiter.ByteRateLimiter(byte_rate=1_000.0, burst=100.0)
+        now = time.monotonic()
+        # the first is sent on a full bucket, the second has to wait for the 400 bytes it overdrew plus 100 bytes.
+        self._run(rate_limiter.limit(500))
+        self._run(rate_limiter.limit(500))
+        self.assertGreaterEqual(time.monotonic() - now, 0.5 * 0.95)
+
+    def test_bad_args(self):
+        with self.assertRaises(ValueError):
+            naz.ratelimiter.ByteRateLimiter(byte_rate=0.0)
+        with self.assertRaises(ValueError):
+            naz.ratelimiter.ByteRateLimiter(byte_rate=100, burst=100.0)
+        with self.assertRaises(ValueError):
+            naz.ratelimiter.ByteRateLimiter(byte_rate=100.0, burst=0.5)
@@ -0,0 +1,73 @@
+# do not to pollute the global namespace.
+# see: https://python-packaging.readthedocs.io/en/latest/testing.html
+
+import threading
+from unittest import TestCase
+
+import naz
+
+
+class TestSequence(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_sequence.TestSequence.test_something
+    """
+
+    def setUp(self):
+        self.sequence_generator = naz.sequence.SimpleSequenceGenerator()
+
+    def test_increments(self):
+        first = self.sequence_generator.next_sequence()
+        second = self.sequence_generator.next_sequence()
+        self.assertEqual(second, first + 1)
+
+    def test_wraps_around(self):
+        max_sequence_number = naz.sequence.SimpleSequenceGenerator.max_sequence_number
+        self.assertEqual(max_sequence_number, 0x7FFFFFFF)
+        self.sequence_generator.sequence_number = max_sequence_number - 2
+
+        sequence_numbers = [self.sequence_generator.next_sequence() for _ in range(0, 4)]
+        # it wraps to 1 rather than going to 0 or going negative.
+        self.assertEqual(sequence_numbers, [max_sequence_number - 1, max_sequence_number, 1, 2])
+
+    def test_recovers_from_out_of_range(self):
+        self.sequence_generator.sequence_number = 0x7FFFFFFF + 10
+        self.assertEqual(self.sequence_generator.next_sequence(), 1)
+        self.sequence_generator.sequence_number = -5
+        self.assertEqual(self.sequence_generator.next_sequence(), 1)
+
+    def test_concurrent_use(self):
+        sequence_numbers = []
+
+        def generate():
+            for _ in range(0, 1000):
+                sequence_numbers.append(self.sequence_generator.next_sequence())
+
+        threads = [threading.Thread(target=generate) for _ in range(0, 8)]
+        for thread in threads:
+            thread.start()
+        for thread in threads:
+            thread.join()
+        # no sequence_number is handed out twice.
+        self.assertEqual(len(set(sequence_numbers)), 8000)
+
+    def test_custom_generator(self):
+        class ReplayGenerator(naz.sequence.BaseSequenceGenerator):
+            def __init__(self, sequence_numbers):
+                self.sequence_numbers = iter(sequence_numbers)
+
+            def next_sequence(self):
+                return next(self.sequence_numbers)
+
+        cli = naz.Client(
+            smsc_host="127.0.0.1",
+            smsc_port=2775,
+            system_id="smppclient1",
+            password="password",
+            broker=naz.broker.SimpleBroker(),
+            sequence_generator=ReplayGenerator([7, 9]),
+        )
+        self.assertEqual(cli.sequence_generator.next_sequence(), 7)
+        self.assertEqual(cli.sequence_generator.next_sequence(), 9)
@@ -0,0 +1,145 @@
+# do not to pollute the global namespace.
+# see: https://python-packaging.readthedocs.io/en/latest/testing.html
+
+import os
+import struct
+import asyncio
+from unittest import TestCase
+
+import naz
+
+
+class TestMockSMSC(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_testing.TestMockSMSC.test_something
+    """
+
+    @staticmethod
+    def _run(coro):
+        loop = asyncio.get_event_loop()
+        return loop.run_until_complete(coro)
+
+    def test_bind_submit_and_receipt(self):
+        from_smsc = []
+
+        class Hook(naz.hooks.SimpleHook):
+            async def from_smsc(self, smpp_command, log_id, hook_metadata, status, pdu):
+                from_smsc.append((smpp_command, log_id, status.code))
+
+        async def run():
+            smsc = naz.testing.MockSMSC(system_id="TestSMSC")
+            await smsc.start()
+            smsc.respond_to_submit_sm(message_id="smsc-message-id")
+            cli = naz.Client(
+                smsc_host=smsc.host,
+                smsc_port=smsc.port,
+                system_id="smppclient1",
+                password=os.getenv("password", "password"),
+                broker=naz.broker.SimpleBroker(maxsize=100),
+                hook=Hook(),
+                logger=naz.log.SimpleLogger("test_bind_submit_and_receipt", level="WARNING"),
+            )
+            await cli.connect()
+            await cli.bind()
+
+            await cli.send_message(
+                naz.protocol.SubmitSM(
+                    short_message="hello",
+                    log_id="log_id-1",
+                    source_addr="254722111111",
+                    destination_addr="254722999999",
+                )
+            )
+            await cli.dequeue_messages(TESTING=True)
+            submit_sms = await smsc.wait_for(naz.SmppCommand.SUBMIT_SM)
+            # the bind_transceiver_resp and the submit_sm_resp
+            await cli.receive_data(TESTING=True)
+            await cli.receive_data(TESTING=True)
+
+            await smsc.deliver_receipt(message_id="smsc-message-id")
+            await cli.receive_data(TESTING=True)
+            deliver_sm_resps = await smsc.wait_for(naz.SmppCommand.DELIVER_SM_RESP)
+
+            await cli._unbind_and_disconnect()
+            await smsc.stop()
+            return cli, smsc, submit_sms, deliver_sm_resps
+
+        cli, smsc, submit_sms, deliver_sm_resps = self._run(run())
+        self.assertEqual(cli.smsc_system_id(), "TestSMSC")
+        self.assertEqual(
+            [pdu.smpp_command for pdu in smsc.received()][:2],
+            [naz.SmppCommand.BIND_TRANSCEIVER, naz.SmppCommand.SUBMIT_SM],
+        )
+        self.assertEqual(len(submit_sms), 1)
+        self.assertIn(b"254722999999\x00", submit_sms[0].body)
+        self.assertTrue(submit_sms[0].body.endswith(b"hello"))
+        self.assertEqual(len(deliver_sm_resps), 1)
+        self.assertEqual(deliver_sm_resps[0].command_status, 0)
+        # the receipt is correlated with the message that it is for.
+        self.assertIn((naz.SmppCommand.SUBMIT_SM_RESP, "log_id-1", "ESME_ROK"), from_smsc)
+        self.assertIn((naz.SmppCommand.DELIVER_SM, "log_id-1", "ESME_ROK"), from_smsc)
+
+    def test_scripted_responses(self):
+        async def request(reader, writer, command_id, sequence_number, body=b""):
+            header = struct.pack(">IIII", 16 + len(body), command_id, 0, sequence_number)
+            writer.write(header + body)
+            await writer.drain()
+            header = await reader.readexactly(16)
+            command_length, command_id, command_status, sequence_number = struct.unpack(
+                ">IIII", header
+            )
+            body = await reader.readexactly(command_length - 16)
+            return command_id, command_status, sequence_number, body
+
+        async def run():
+            smsc = naz.testing.MockSMSC(bind_status=naz.SmppCommandStatus.ESME_RBINDFAIL)
+            await smsc.start()
+            smsc.respond_to_submit_sm(command_status=naz.SmppCommandStatus.ESME_RMSGQFUL)
+            reader, writer = await asyncio.open_connection(smsc.host, smsc.port)
+            bind_transmitter = b"sys\x00pw\x00\x00\x34\x00\x00\x00"
+            responses = [
+                await request(reader, writer, 0x00000002, 1, bind_transmitter),
+                await request(reader, writer, 0x00000015, 2),
+                await request(reader, writer, 0x00000004, 3, b"a-submit_sm"),
+                await request(reader, writer, 0x00000004, 4, b"a-submit_sm"),
+                # an unknown command
+                await request(reader, writer, 0x00000099, 5),
+            ]
+            writer.close()
+            await smsc.stop()
+            return smsc, responses
+
+        smsc, responses = self._run(run())
+        self.assertEqual(
+            responses,
+            [
+                (0x80000002, naz.SmppCommandStatus.ESME_RBINDFAIL.value, 1, b"MockSMSC\x00"),
+                (0x80000015, 0, 2, b""),
+                (0x80000004, naz.SmppCommandStatus.ESME_RMSGQFUL.value, 3, b"mock-1\x00"),
+                # no response was scripted for this one.
+                (0x80000004, 0, 4, b"mock-2\x00"),
+                (0x80000000, naz.SmppCommandStatus.ESME_RINVCMDID.value, 5, b""),
+            ],
+        )
+        self.assertEqual(
+            [pdu.smpp_command for pdu in smsc.received()],
+            [
+                naz.SmppCommand.BIND_TRANSMITTER,
+                naz.SmppCommand.ENQUIRE_LINK,
+                naz.SmppCommand.SUBMIT_SM,
+                naz.SmppCommand.SUBMIT_SM,
+                "",
+            ],
+        )
+        self.assertEqual(len(smsc.received(naz.SmppCommand.SUBMIT_SM)), 2)
+
+    def test_bad_args(self):
+        with self.assertRaises(ValueError):
+            naz.testing.MockSMSC(port="2775")
+        with self.assertRaises(ValueError):
+            naz.testing.MockSMSC(bind_status=0)
+        with self.assertRaises(ValueError):
+            naz.testing.MockSMSC().respond_to_submit_sm(command_status=0x00000014)
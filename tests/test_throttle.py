@@ -1,6 +1,7 @@
 # do not to pollute the global namespace.
 # see: https://python-packaging.readthedocs.io/en/latest/testing.html
 
+import time
 import asyncio
 from unittest import TestCase
 
@@ -69,3 +70,68 @@ class TestThrottle(TestCase):
         self._run(self.throttle_handler.allow_request())
         self.assertEqual(self.throttle_handler.throttle_responses, 0)
         self.assertEqual(self.throttle_handler.NON_throttle_responses, 0)
+
+
+class TestAdaptiveThrottle(TestCase):
+    """
+    run tests as:
+        python -m unittest discover -v -s .
+    run one testcase as:
+        python -m unittest -v tests.test_throttle.TestAdaptiveThrottle.test_something
+    """
+
+    def setUp(self):
+        self.throttle_events = []
+        self.rate_limiter = naz.ratelimiter.SimpleRateLimiter(send_rate=100.00)
+        self.throttle_handler = naz.throttle.AdaptiveThrottleHandler(
+            rate_limiter=self.rate_limiter,
+            cool_down=0.05,
+            on_throttle=self.throttle_events.append,
+        )
+
+    @staticmethod
+    def _run(coro):
+        loop = asyncio.get_event_loop()
+        return loop.run_until_complete(coro)
+
+    def test_throttling_slows_down(self):
+        send_intervals = [1 / self.rate_limiter.send_rate]
+        for _ in range(3):
+            self._run(self.throttle_handler.throttled())
+            send_intervals.append(1 / self.rate_limiter.send_rate)
+            self.assertTrue(self._run(self.throttle_handler.allow_request()))
+
+        self.assertEqual(send_intervals, sorted(send_intervals))
+        self.assertEqual(self.rate_limiter.send_rate, 12.50)
+        self.assertEqual(self.throttle_events, [50.00, 25.00, 12.50])
+
+    def test_min_send_rate(self):
+        for _ in range(20):
+            self._run(self.throttle_handler.throttled())
+        self.assertEqual(self.rate_limiter.send_rate, 1.00)
+        self.assertEqual(self.rate_limiter.max_tokens, 1.00)
+
+    def test_recovery(self):
+        self._run(self.throttle_handler.throttled())
+        self._run(self.throttle_handler.throttled())
+        # no recovery before the cool down
+        self._run(self.throttle_handler.not_throttled())
+        self.assertEqual(self.rate_limiter.send_rate, 25.00)
+
+        for expected_send_rate in [37.50, 56.25, 84.375, 100.00, 100.00]:
+            time.sleep(0.06)
+            self._run(self.throttle_handler.not_throttled())
+            self.assertEqual(self.rate_limiter.send_rate, expected_send_rate)
+
+    def test_bad_args(self):
+        for kwargs in [
+            {"cool_down": 0.0},
+            {"cool_down": -1.0},
+            {"min_send_rate": 0.0},
+            {"min_send_rate": -1.0},
+            # the rate limiter's send_rate is 100.00
+            {"min_send_rate": 101.00},
+        ]:
+            with self.assertRaises(ValueError):
+                naz.throttle.AdaptiveThrottleHandler(rate_limiter=self.rate_limiter, **kwargs)
+        naz.throttle.AdaptiveThrottleHandler(rate_limiter=self.rate_limiter, min_send_rate=100.00)